@@ -2,17 +2,27 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"fora/internal/api"
 	"fora/internal/db"
+	"fora/internal/importers"
+	"fora/internal/metrics"
+	"fora/internal/serverconfig"
+	"fora/internal/tracing"
 )
 
 const serverVersion = "0.1.15"
@@ -24,56 +34,128 @@ func main() {
 		}
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("migrate failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("config failed: %v", err)
+		}
+		return
+	}
 
-	var (
-		port        = flag.String("port", "8080", "HTTP listen port")
-		dbPath      = flag.String("db", "./fora.db", "path to SQLite database")
-		adminKeyOut = flag.String("admin-key-out", "", "write bootstrap admin API key to this file if no admin exists")
-	)
+	configPath := flag.String("config", os.Getenv("FORA_CONFIG"), "path to YAML config file (env FORA_CONFIG)")
+	// Legacy flags still work and win over the config file when set.
+	flag.String("port", "8080", "HTTP listen port (overrides listen)")
+	flag.String("db", "./fora.db", "path to SQLite database")
+	flag.String("admin-key-out", "", "write bootstrap admin API key to this file if no admin exists")
+	flag.Bool("access-log", false, "write JSON access logs to stdout")
+	flag.Bool("seed-boards", true, "create the default boards on first start")
+	flag.String("trace-exporter", "", "OpenTelemetry span exporter: stdout or otlp (default off)")
+	flag.String("trace-endpoint", "http://localhost:4318", "OTLP/HTTP collector base URL for --trace-exporter=otlp")
 	flag.Parse()
 
-	_, dbErr := os.Stat(*dbPath)
+	cfg, err := loadConfig(*configPath, flag.CommandLine)
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: "fora-server",
+		Writer:      os.Stdout,
+	})
+	if err != nil {
+		log.Fatalf("tracing: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = shutdownTracing(ctx)
+	}()
+
+	dbPath := cfg.DB.Path
+	_, dbErr := os.Stat(dbPath)
 	dbIsNew := os.IsNotExist(dbErr)
 
-	database, err := db.Open(*dbPath)
+	database, err := openDatabase(cfg)
 	if err != nil {
 		log.Fatalf("open database: %v", err)
 	}
 	defer database.Close()
+	registerDBMetrics(database)
 
 	if dbIsNew {
-		log.Printf("new database created at %s", *dbPath)
+		log.Printf("new database created at %s", dbPath)
 	} else {
-		log.Printf("opened existing database at %s", *dbPath)
+		log.Printf("opened existing database at %s", dbPath)
 	}
 
 	if err := db.ApplyMigrations(database); err != nil {
 		log.Fatalf("apply migrations: %v", err)
 	}
-	if err := db.SeedDefaultBoards(context.Background(), database); err != nil {
-		log.Fatalf("seed default boards: %v", err)
+	if cfg.Features.SeedBoards {
+		if err := db.SeedDefaultBoards(context.Background(), database); err != nil {
+			log.Fatalf("seed default boards: %v", err)
+		}
+	}
+	if err := db.IndexContentRefs(context.Background(), database); err != nil {
+		log.Fatalf("index content metadata: %v", err)
 	}
 
-	if *adminKeyOut != "" {
-		adminName, err := db.EnsureBootstrapAdmin(database, *adminKeyOut)
+	if cfg.AdminKeyOut != "" {
+		adminName, err := db.EnsureBootstrapAdmin(database, cfg.AdminKeyOut)
 		if err != nil {
 			log.Fatalf("bootstrap admin: %v", err)
 		}
 		if adminName != "" {
-			log.Printf("bootstrap admin %q created, key written to %s", adminName, *adminKeyOut)
+			log.Printf("bootstrap admin %q created, key written to %s", adminName, cfg.AdminKeyOut)
 		} else {
 			log.Printf("bootstrap admin already exists, key unchanged")
 		}
 	}
 
-	mux := api.NewRouter(database, serverVersion)
+	routerOpts := api.Options{
+		RateLimits: &api.RateLimits{
+			PostsPerHour:   cfg.RateLimits.PostsPerHour,
+			RepliesPerHour: cfg.RateLimits.RepliesPerHour,
+			TotalWritesDay: cfg.RateLimits.WritesPerDay,
+			ReadsPerMinute: cfg.RateLimits.ReadsPerMinute,
+			SearchPerMin:   cfg.RateLimits.SearchPerMinute,
+		},
+		CORSOrigins:    cfg.HTTP.CORSOrigins,
+		DisableMCP:     !cfg.Features.MCP,
+		DisableMetrics: !cfg.Features.Metrics,
+		Attachments: api.AttachmentOptions{
+			Dir:      cfg.Attachments.Dir,
+			MaxBytes: int64(cfg.Attachments.MaxBytes),
+		},
+	}
+	if cfg.Features.AccessLog {
+		routerOpts.AccessLog = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	}
+	mux := api.NewRouterWithOptions(database, serverVersion, routerOpts)
+
+	// Polls close lazily on read; the closer records each one and sends its
+	// poll.closed webhook within a tick of the deadline.
+	closerCtx, stopCloser := context.WithCancel(context.Background())
+	defer stopCloser()
+	go api.RunPollCloser(closerCtx, database, 15*time.Second)
+	go api.RunAttachmentSweeper(closerCtx, database, cfg.Attachments.Dir, time.Hour)
 
 	server := &http.Server{
-		Addr:         ":" + *port,
 		Handler:      mux,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 0,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  time.Duration(cfg.HTTP.ReadTimeout),
+		WriteTimeout: time.Duration(cfg.HTTP.WriteTimeout),
+		IdleTimeout:  time.Duration(cfg.HTTP.IdleTimeout),
+	}
+	listener, err := listen(cfg)
+	if err != nil {
+		log.Fatalf("listen: %v", err)
 	}
 
 	shutdownDone := make(chan struct{})
@@ -90,8 +172,12 @@ func main() {
 		}
 	}()
 
-	log.Printf("fora-server listening on %s", server.Addr)
-	err = server.ListenAndServe()
+	log.Printf("fora-server listening on %s", cfg.Listen)
+	if cfg.TLS.CertFile != "" {
+		err = server.ServeTLS(listener, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	} else {
+		err = server.Serve(listener)
+	}
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("server error: %v", err)
 	}
@@ -100,16 +186,31 @@ func main() {
 
 func runImport(args []string) error {
 	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("FORA_CONFIG"), "path to YAML config file (env FORA_CONFIG)")
 	fromPath := fs.String("from", "", "path to json export file or markdown export directory")
-	dbPath := fs.String("db", "./fora.db", "path to SQLite database")
+	source := fs.String("source", "fora", "import format: fora, "+strings.Join(importers.Kinds(), ", "))
+	fs.String("db", "./fora.db", "path to SQLite database (overrides db.path)")
+	fs.Bool("seed-boards", true, "create the default boards if they were never seeded (overrides features.seed_boards)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 	if *fromPath == "" {
 		return errors.New("missing --from")
 	}
+	var imp importers.Importer
+	if *source != "fora" {
+		var err error
+		if imp, err = importers.Lookup(*source); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := loadConfig(*configPath, fs)
+	if err != nil {
+		return err
+	}
 
-	database, err := db.Open(*dbPath)
+	database, err := openDatabase(cfg)
 	if err != nil {
 		return err
 	}
@@ -118,12 +219,227 @@ func runImport(args []string) error {
 	if err := db.ApplyMigrations(database); err != nil {
 		return err
 	}
-	if err := db.ImportFromPath(context.Background(), database, *fromPath); err != nil {
-		return err
+	if imp == nil {
+		if err := db.ImportFromPath(context.Background(), database, *fromPath); err != nil {
+			return err
+		}
+	} else {
+		payload, err := imp.Load(*fromPath)
+		if err != nil {
+			return err
+		}
+		if err := db.ImportPayload(context.Background(), database, payload); err != nil {
+			return err
+		}
 	}
-	if err := db.SeedDefaultBoards(context.Background(), database); err != nil {
+	if cfg.Features.SeedBoards {
+		if err := db.SeedDefaultBoards(context.Background(), database); err != nil {
+			return err
+		}
+	}
+	if err := db.IndexContentRefs(context.Background(), database); err != nil {
 		return err
 	}
-	log.Printf("import complete from %s into %s", *fromPath, *dbPath)
+	log.Printf("import complete from %s (%s) into %s", *fromPath, *source, cfg.DB.Path)
 	return nil
 }
+
+func runMigrate(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: fora-server migrate <status|up|down|plan> [--config path] [--db path] [--to version]")
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("FORA_CONFIG"), "path to YAML config file (env FORA_CONFIG)")
+	fs.String("db", "./fora.db", "path to SQLite database (overrides db.path)")
+	to := fs.Int("to", 0, "target schema version (default: latest for up/plan, previous for down)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	cfg, err := loadConfig(*configPath, fs)
+	if err != nil {
+		return err
+	}
+
+	database, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	switch action {
+	case "status":
+		states, err := db.MigrationStatus(database)
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt
+			}
+			var notes []string
+			if !s.Known {
+				notes = append(notes, "unknown to this binary")
+			}
+			if s.Drifted {
+				notes = append(notes, "checksum drift")
+			}
+			if s.Destructive {
+				notes = append(notes, "destructive")
+			}
+			if s.Known && !s.Reversible {
+				notes = append(notes, "irreversible")
+			}
+			line := fmt.Sprintf("%3d  %-24s %s", s.Version, s.Name, state)
+			if len(notes) > 0 {
+				line += "  [" + strings.Join(notes, ", ") + "]"
+			}
+			fmt.Fprintln(out, line)
+		}
+		return nil
+	case "plan":
+		steps, err := db.PlanMigrations(database, *to)
+		if err != nil {
+			return err
+		}
+		if len(steps) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+			return nil
+		}
+		for _, step := range steps {
+			header := fmt.Sprintf("-- %s %d (%s)", step.Direction, step.Version, step.Name)
+			if step.Destructive {
+				header += " [destructive: snapshot will be taken]"
+			}
+			fmt.Fprintln(out, header)
+			fmt.Fprintln(out, strings.TrimSpace(step.SQL))
+			fmt.Fprintln(out)
+		}
+		return nil
+	case "up", "down":
+		var result db.MigrateResult
+		if action == "up" {
+			result, err = db.MigrateUp(database, *to)
+		} else {
+			target := *to
+			if target == 0 {
+				states, err := db.MigrationStatus(database)
+				if err != nil {
+					return err
+				}
+				var applied []int
+				for _, s := range states {
+					if s.Applied {
+						applied = append(applied, s.Version)
+					}
+				}
+				if len(applied) < 2 {
+					return errors.New("no earlier schema version to roll back to")
+				}
+				target = applied[len(applied)-2]
+			}
+			result, err = db.MigrateDown(database, target)
+		}
+		if result.Snapshot != "" {
+			fmt.Fprintf(out, "snapshot written to %s\n", result.Snapshot)
+		}
+		for _, version := range result.Applied {
+			fmt.Fprintf(out, "%s %d\n", action, version)
+		}
+		if err != nil {
+			return err
+		}
+		if len(result.Applied) == 0 {
+			fmt.Fprintln(out, "nothing to do")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate action %q", action)
+	}
+}
+
+// loadConfig layers explicitly set command-line flags over the config file
+// and FORA_* environment; flags left at their defaults do not override.
+func loadConfig(path string, fs *flag.FlagSet) (serverconfig.Config, error) {
+	cfg, err := serverconfig.Load(path, nil)
+	if err != nil {
+		return cfg, err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		value := f.Value.String()
+		switch f.Name {
+		case "port":
+			cfg.Listen = ":" + value
+		case "db":
+			cfg.DB.Path = value
+		case "admin-key-out":
+			cfg.AdminKeyOut = value
+		case "access-log":
+			cfg.Features.AccessLog = value == "true"
+		case "seed-boards":
+			cfg.Features.SeedBoards = value == "true"
+		case "trace-exporter":
+			cfg.Tracing.Exporter = value
+		case "trace-endpoint":
+			cfg.Tracing.Endpoint = value
+		}
+	})
+	return cfg, cfg.Validate()
+}
+
+// openDatabase opens the configured database with its pool settings and
+// pragmas, so serve and migrate see the same journal mode and busy timeout.
+func openDatabase(cfg serverconfig.Config) (*sql.DB, error) {
+	return db.OpenWithOptions(cfg.DB.Path, db.Options{
+		MaxOpenConns:    cfg.DB.MaxOpenConns,
+		MaxIdleConns:    cfg.DB.MaxIdleConns,
+		ConnMaxIdleTime: time.Duration(cfg.DB.ConnMaxIdleTime),
+		Pragmas:         cfg.Pragmas(),
+	})
+}
+
+// registerDBMetrics exposes gauges for the served database. It is called
+// once per process, so the gauge always reports the database being served.
+func registerDBMetrics(database *sql.DB) {
+	if path, err := db.FilePath(database); err == nil && path != "" {
+		metrics.SetGaugeFunc("fora_sqlite_wal_bytes", "Size of the SQLite write-ahead log.", func() float64 {
+			return float64(db.WALSize(path))
+		})
+	}
+}
+
+func listen(cfg serverconfig.Config) (net.Listener, error) {
+	socket, ok := cfg.UnixSocket()
+	if !ok {
+		return net.Listen("tcp", cfg.Listen)
+	}
+	// A socket file left behind by an unclean exit would make bind fail.
+	if info, err := os.Stat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(socket); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", socket)
+}
+
+func runConfig(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("usage: fora-server config print [--config path]")
+	}
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("FORA_CONFIG"), "path to YAML config file (env FORA_CONFIG)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	cfg, err := serverconfig.Load(*configPath, nil)
+	if err != nil {
+		return err
+	}
+	b, err := cfg.YAML()
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(b)
+	return err
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 
 	"fora/internal/db"
+	"fora/internal/metrics"
 )
 
 func TestRunImportSeedsDefaultBoards(t *testing.T) {
@@ -53,3 +59,168 @@ func TestRunImportSeedsDefaultBoards(t *testing.T) {
 		t.Fatalf("unexpected board ids after import startup path: got %v want %v", ids, want)
 	}
 }
+
+func TestRunImportWithSource(t *testing.T) {
+	tempDir := t.TempDir()
+	exportPath := filepath.Join(tempDir, "discourse.json")
+	dbPath := filepath.Join(tempDir, "fora.db")
+
+	export := `{"topics": [{"id": 1, "title": "Hello", "post_stream": {"posts": [
+  {"post_number": 1, "username": "alice", "created_at": "2024-01-01T00:00:00Z", "raw": "first"}
+]}}]}`
+	if err := os.WriteFile(exportPath, []byte(export), 0o644); err != nil {
+		t.Fatalf("write export file: %v", err)
+	}
+	if err := runImport([]string{"--source", "discourse", "--from", exportPath, "--db", dbPath}); err != nil {
+		t.Fatalf("runImport: %v", err)
+	}
+	if err := runImport([]string{"--source", "nope", "--from", exportPath, "--db", dbPath}); err == nil {
+		t.Fatal("expected unknown source to fail")
+	}
+
+	database, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	if _, err := db.GetAgent(context.Background(), database, "alice"); err != nil {
+		t.Fatalf("expected imported agent: %v", err)
+	}
+}
+
+func TestRunImportUsesServeConfig(t *testing.T) {
+	dir := t.TempDir()
+	exportPath := filepath.Join(dir, "export.json")
+	dbPath := filepath.Join(dir, "configured.db")
+	configPath := filepath.Join(dir, "fora.yaml")
+	if err := os.WriteFile(exportPath, []byte("{}\n"), 0o644); err != nil {
+		t.Fatalf("write export file: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte("db:\n  path: "+dbPath+"\nfeatures:\n  seed_boards: false\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := runImport([]string{"--from", exportPath, "--config", configPath}); err != nil {
+		t.Fatalf("runImport: %v", err)
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		t.Fatalf("import did not write to db.path from the config: %v", err)
+	}
+	database, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	boards, err := db.ListBoards(context.Background(), database)
+	if err != nil {
+		t.Fatalf("list boards: %v", err)
+	}
+	for _, b := range boards {
+		if b.ID != "general" {
+			t.Fatalf("features.seed_boards: false still seeded %q", b.ID)
+		}
+	}
+}
+
+func TestRunMigrateStatusAndPlan(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "fora.db")
+
+	var out bytes.Buffer
+	if err := runMigrate([]string{"plan", "--db", dbPath}, &out); err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if !strings.Contains(out.String(), "-- up 7 (fix_archived_status) [destructive") {
+		t.Fatalf("expected destructive v7 in plan, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runMigrate([]string{"up", "--db", dbPath}, &out); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	out.Reset()
+	if err := runMigrate([]string{"status", "--db", dbPath}, &out); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if strings.Contains(out.String(), "pending") {
+		t.Fatalf("expected all migrations applied, got:\n%s", out.String())
+	}
+	if err := runMigrate([]string{"down", "--to", "7", "--db", dbPath}, &out); err != nil {
+		t.Fatalf("down to 7: %v", err)
+	}
+	if err := runMigrate([]string{"down", "--db", dbPath}, &out); err == nil {
+		t.Fatal("expected down past irreversible migration to fail")
+	}
+}
+
+func TestRunMigrateUsesServeConfig(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "configured.db")
+	configPath := filepath.Join(dir, "fora.yaml")
+	if err := os.WriteFile(configPath, []byte("db:\n  path: "+dbPath+"\n  journal_mode: DELETE\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runMigrate([]string{"up", "--config", configPath}, &out); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	header := make([]byte, 20)
+	f, err := os.Open(dbPath)
+	if err != nil {
+		t.Fatalf("migrate did not use db.path from the config: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Read(header); err != nil {
+		t.Fatalf("read header: %v", err)
+	}
+	// Bytes 18 and 19 of the SQLite header are 2 in WAL mode, 1 otherwise.
+	if header[18] != 1 {
+		t.Fatalf("expected the configured rollback journal, header write version = %d", header[18])
+	}
+}
+
+func TestConfigPrintAndFlagPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fora.yaml")
+	if err := os.WriteFile(path, []byte("listen: unix:/tmp/fora.sock\ndb:\n  path: /srv/fora.db\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("FORA_RATE_LIMITS_POSTS_PER_HOUR", "5")
+
+	var out bytes.Buffer
+	if err := runConfig([]string{"print", "--config", path}, &out); err != nil {
+		t.Fatalf("config print: %v", err)
+	}
+	for _, want := range []string{"listen: unix:/tmp/fora.sock", "path: /srv/fora.db", "posts_per_hour: 5", "read_timeout: 10s"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("config print missing %q:\n%s", want, out.String())
+		}
+	}
+
+	fs := flag.NewFlagSet("fora-server", flag.ContinueOnError)
+	fs.String("port", "8080", "")
+	fs.String("db", "./fora.db", "")
+	if err := fs.Parse([]string{"--port", "9090"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	cfg, err := loadConfig(path, fs)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.Listen != ":9090" || cfg.DB.Path != "/srv/fora.db" {
+		t.Fatalf("expected explicit --port to win and unset --db to defer to file, got listen=%q db=%q", cfg.Listen, cfg.DB.Path)
+	}
+}
+
+func TestRegisterDBMetricsExposesWALGauge(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fora.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	registerDBMetrics(database)
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "# TYPE fora_sqlite_wal_bytes gauge") {
+		t.Fatalf("metrics output missing the WAL gauge:\n%s", body)
+	}
+}
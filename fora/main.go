@@ -1,11 +1,16 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/fs"
+	"mime"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
@@ -15,8 +20,11 @@ import (
 	"time"
 
 	"fora/internal/cli/client"
+	"fora/internal/cli/completion"
 	"fora/internal/cli/config"
+	"fora/internal/cli/outbox"
 	"fora/internal/cli/output"
+	"fora/internal/cli/tui"
 	"fora/internal/skill"
 )
 
@@ -28,6 +36,11 @@ func main() {
 }
 
 func run(args []string) error {
+	args, profile, err := extractProfileFlag(args)
+	if err != nil {
+		return err
+	}
+	config.SetProfileOverride(profile)
 	if len(args) == 0 {
 		return usage()
 	}
@@ -50,10 +63,18 @@ func run(args []string) error {
 		return cmdBoards(args[1:])
 	case "posts":
 		return cmdPosts(args[1:])
+	case "tags":
+		return cmdTags(args[1:])
 	case "notifications":
 		return cmdNotifications(args[1:])
 	case "watch":
 		return cmdWatch(args[1:])
+	case "tui":
+		return cmdTUI(args[1:])
+	case "sync":
+		return cmdSync(args[1:])
+	case "cache":
+		return cmdCache(args[1:])
 	case "search":
 		return cmdSearch(args[1:])
 	case "activity":
@@ -62,15 +83,57 @@ func run(args []string) error {
 		return cmdHive(args[1:])
 	case "agent":
 		return cmdAgent(args[1:])
+	case "group":
+		return cmdGroup(args[1:])
 	case "admin":
 		return cmdAdmin(args[1:])
+	case "webhooks":
+		return cmdWebhooks(args[1:])
+	case "replies":
+		return cmdReplies(args[1:])
+	case "dm":
+		return cmdDM(args[1:])
+	case "attachments":
+		return cmdAttachments(args[1:])
 	case "skill":
 		return cmdSkill(args[1:])
+	case "profile":
+		return cmdProfile(args[1:])
+	case "completion":
+		return cmdCompletion(args[1:])
+	case "__complete":
+		return cmdComplete(args[1:])
 	default:
 		return usage()
 	}
 }
 
+// extractProfileFlag removes the global --profile flag, which may appear
+// anywhere on the command line, and returns its value.
+func extractProfileFlag(args []string) ([]string, string, error) {
+	rest := make([]string, 0, len(args))
+	profile := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			rest = append(rest, args[i:]...)
+			return rest, profile, nil
+		case arg == "--profile" || arg == "-profile":
+			if i+1 >= len(args) {
+				return nil, "", errors.New("flag needs an argument: --profile")
+			}
+			i++
+			profile = args[i]
+		case strings.HasPrefix(arg, "--profile="):
+			profile = strings.TrimPrefix(arg, "--profile=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, profile, nil
+}
+
 func cmdInstall(args []string) error {
 	fs := flag.NewFlagSet("install", flag.ContinueOnError)
 	image := fs.String("image", "ghcr.io/net-forge/fora-server:latest", "Server image")
@@ -265,27 +328,211 @@ func cmdDisconnect() error {
 }
 
 func cmdStatus() error {
-	cfg, err := config.Load()
+	cfgPath, local, err := config.Resolve()
 	if err != nil {
 		return err
 	}
-	srv, ok := cfg.Default()
-	if !ok {
-		return errors.New("not connected. run: fora connect <url> --api-key <key>")
+	cfg, err := config.LoadFromPath(cfgPath)
+	if err != nil {
+		return err
+	}
+	srv, err := activeServer(cfg)
+	if err != nil {
+		return err
 	}
 	cl := client.New(srv.URL, srv.APIKey)
 	var status map[string]any
 	if err := cl.Get("/api/v1/status", &status); err != nil {
 		return err
 	}
+	source := "home"
+	if local {
+		source = "local"
+	}
 	return printJSON(map[string]any{
-		"server":       srv.URL,
-		"agent":        srv.Agent,
-		"connected_at": srv.ConnectedAt,
-		"status":       status,
+		"profile":       cfg.ActiveProfile(),
+		"config_path":   cfgPath,
+		"config_source": source,
+		"server":        srv.URL,
+		"agent":         srv.Agent,
+		"connected_at":  srv.ConnectedAt,
+		"status":        status,
+	})
+}
+
+func cmdProfile(args []string) error {
+	const usage = "usage: fora profile <list|use|add|remove|rename|set>"
+	if len(args) == 0 {
+		return cmdProfileList(nil)
+	}
+	switch args[0] {
+	case "list":
+		return cmdProfileList(args[1:])
+	case "use":
+		return cmdProfileUse(args[1:])
+	case "add":
+		return cmdProfileAdd(args[1:])
+	case "remove":
+		return cmdProfileRemove(args[1:])
+	case "rename":
+		return cmdProfileRename(args[1:])
+	case "set":
+		return cmdProfileSet(args[1:])
+	default:
+		return errors.New(usage)
+	}
+}
+
+func cmdProfileList(args []string) error {
+	fs := flag.NewFlagSet("profile list", flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "Names only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: fora profile list [--format f] [--quiet]")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	active := cfg.ActiveProfile()
+	profiles := make([]any, 0, len(cfg.Servers))
+	for _, name := range cfg.ProfileNames() {
+		srv := cfg.Servers[name]
+		row := map[string]any{
+			"name":    name,
+			"url":     srv.URL,
+			"agent":   srv.Agent,
+			"active":  name == active,
+			"default": name == cfg.DefaultServer,
+		}
+		if len(srv.Preferences) > 0 {
+			prefs := map[string]any{}
+			for k, v := range srv.Preferences {
+				prefs[k] = v
+			}
+			row["preferences"] = prefs
+		}
+		profiles = append(profiles, row)
+	}
+	return outFlags.print(map[string]any{"profiles": profiles})
+}
+
+func cmdProfileUse(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: fora profile use <name>")
+	}
+	return updateConfig(func(cfg *config.Config) error {
+		if err := cfg.UseProfile(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("default profile is now %s\n", args[0])
+		return nil
+	})
+}
+
+func cmdProfileAdd(args []string) error {
+	const usage = "usage: fora profile add <name> --url <url> --api-key <key> [--agent name] [--board id] [--format f] [--use]"
+	fs := flag.NewFlagSet("profile add", flag.ContinueOnError)
+	rawURL := fs.String("url", "", "Server URL")
+	apiKey := fs.String("api-key", "", "API key")
+	agent := fs.String("agent", "", "Agent name for this key")
+	board := fs.String("board", "", "Default board for new posts")
+	format := fs.String("format", "", "Default output format")
+	use := fs.Bool("use", false, "Make this the default profile")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 || strings.TrimSpace(*rawURL) == "" || strings.TrimSpace(*apiKey) == "" {
+		return errors.New(usage)
+	}
+	if _, err := url.ParseRequestURI(strings.TrimSpace(*rawURL)); err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	name := strings.TrimSpace(positionals[0])
+	return updateConfig(func(cfg *config.Config) error {
+		if err := cfg.AddProfile(name, config.Server{
+			URL:    strings.TrimSpace(*rawURL),
+			APIKey: strings.TrimSpace(*apiKey),
+			Agent:  strings.TrimSpace(*agent),
+		}); err != nil {
+			return err
+		}
+		if err := cfg.SetPreference(name, config.PrefDefaultBoard, strings.TrimSpace(*board)); err != nil {
+			return err
+		}
+		if err := cfg.SetPreference(name, config.PrefDefaultFormat, strings.TrimSpace(*format)); err != nil {
+			return err
+		}
+		if *use {
+			if err := cfg.UseProfile(name); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("added profile %s\n", name)
+		return nil
+	})
+}
+
+func cmdProfileRemove(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: fora profile remove <name>")
+	}
+	return updateConfig(func(cfg *config.Config) error {
+		if err := cfg.RemoveProfile(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("removed profile %s\n", args[0])
+		return nil
+	})
+}
+
+func cmdProfileRename(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: fora profile rename <old> <new>")
+	}
+	return updateConfig(func(cfg *config.Config) error {
+		if err := cfg.RenameProfile(args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("renamed profile %s to %s\n", args[0], args[1])
+		return nil
+	})
+}
+
+func cmdProfileSet(args []string) error {
+	const usage = "usage: fora profile set <key> [value]   (keys: default_board, default_format, outbox; empty value clears)"
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New(usage)
+	}
+	value := ""
+	if len(args) == 2 {
+		value = strings.TrimSpace(args[1])
+	}
+	return updateConfig(func(cfg *config.Config) error {
+		name := cfg.ActiveProfile()
+		if err := cfg.SetPreference(name, args[0], value); err != nil {
+			return err
+		}
+		fmt.Printf("profile %s: %s=%s\n", name, args[0], value)
+		return nil
 	})
 }
 
+// updateConfig loads the resolved config file, applies fn and saves it.
+func updateConfig(fn func(cfg *config.Config) error) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := fn(cfg); err != nil {
+		return err
+	}
+	return config.Save(cfg)
+}
+
 func cmdWhoAmI() error {
 	cl, err := defaultClient()
 	if err != nil {
@@ -315,15 +562,28 @@ func cmdPrimer() error {
 
 func cmdBoards(args []string) error {
 	if len(args) == 0 || args[0] == "list" {
+		fs := flag.NewFlagSet("boards list", flag.ContinueOnError)
+		all := fs.Bool("all", false, "Include archived boards")
+		outFlags := addOutputFlags(fs, "IDs only")
+		if len(args) > 0 {
+			args = args[1:]
+		}
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
 		cl, err := defaultClient()
 		if err != nil {
 			return err
 		}
+		path := "/api/v1/boards"
+		if *all {
+			path += "?include_archived=true"
+		}
 		var resp map[string]any
-		if err := cl.Get("/api/v1/boards", &resp); err != nil {
+		if err := cl.Get(path, &resp); err != nil {
 			return err
 		}
-		return printJSON(resp)
+		return outFlags.print(resp)
 	}
 	if args[0] == "add" {
 		name, description, icon, tags, err := parseBoardsAddArgs(args[1:])
@@ -392,7 +652,273 @@ func cmdBoards(args []string) error {
 		fmt.Printf("unsubscribed from board %s\n", strings.TrimSpace(args[1]))
 		return nil
 	}
-	return errors.New("usage: fora boards <list|add|info|subscribe|unsubscribe>")
+	switch args[0] {
+	case "update":
+		return cmdBoardsUpdate(args[1:])
+	case "archive":
+		return cmdBoardsArchive(args[1:])
+	case "delete":
+		return deleteResource(args[1:], "boards delete", "/api/v1/boards/", "usage: fora boards delete <id> [--format f] [--quiet]")
+	case "suggest-tags":
+		return cmdBoardsSuggestTags(args[1:])
+	}
+	return errors.New("usage: fora boards <list|add|info|update|archive|delete|suggest-tags|subscribe|unsubscribe>")
+}
+
+func cmdBoardsSuggestTags(args []string) error {
+	const usage = "usage: fora boards suggest-tags <id> [text] [--from-file file] [--tags a,b] [--limit n] [--format f] [--quiet]"
+	fs := flag.NewFlagSet("boards suggest-tags", flag.ContinueOnError)
+	fromFile := fs.String("from-file", "", "Read the text to tag from file")
+	tags := fs.String("tags", "", "Tags already chosen, left out of the suggestions")
+	limit := fs.Int("limit", 10, "Maximum suggestions")
+	outFlags := addOutputFlags(fs, "Tags only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) < 1 || len(positionals) > 2 {
+		return errors.New(usage)
+	}
+	text := ""
+	if len(positionals) == 2 || strings.TrimSpace(*fromFile) != "" {
+		if text, err = resolveBodyInput(positionals[1:], *fromFile); err != nil {
+			return err
+		}
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(*limit))
+	if text != "" {
+		q.Set("text", text)
+	}
+	if parsed := parseTags(*tags); len(parsed) > 0 {
+		q.Set("tags", strings.Join(parsed, ","))
+	}
+	var resp map[string]any
+	if err := cl.Get("/api/v1/boards/"+url.PathEscape(strings.TrimSpace(positionals[0]))+"/suggest-tags?"+q.Encode(), &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdBoardsUpdate(args []string) error {
+	const usage = "usage: fora boards update <id> [--name n] [--description text] [--icon text] [--sort-order n] [--restrict-tags] [--add-tags a,b] [--remove-tags a,b] [--format f] [--quiet]"
+	fs := flag.NewFlagSet("boards update", flag.ContinueOnError)
+	name := fs.String("name", "", "New display name (the ID is unchanged)")
+	description := fs.String("description", "", "Description")
+	icon := fs.String("icon", "", "Icon")
+	sortOrder := fs.Int("sort-order", 0, "Position in board listings (lower first)")
+	restrictTags := fs.Bool("restrict-tags", false, "Only allow the board's own tags on its posts (--restrict-tags=false to lift)")
+	addTags := fs.String("add-tags", "", "Board tags to add")
+	removeTags := fs.String("remove-tags", "", "Board tags to remove")
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New(usage)
+	}
+	req := map[string]any{}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			req["name"] = *name
+		case "description":
+			req["description"] = *description
+		case "icon":
+			req["icon"] = *icon
+		case "sort-order":
+			req["sort_order"] = *sortOrder
+		case "restrict-tags":
+			req["restrict_tags"] = *restrictTags
+		case "add-tags":
+			req["add_tags"] = parseTags(*addTags)
+		case "remove-tags":
+			req["remove_tags"] = parseTags(*removeTags)
+		}
+	})
+	if len(req) == 0 {
+		return errors.New("nothing to update: pass --name, --description, --icon, --sort-order, --restrict-tags, --add-tags or --remove-tags")
+	}
+	return patchBoard(strings.TrimSpace(positionals[0]), req, outFlags)
+}
+
+func cmdBoardsArchive(args []string) error {
+	fs := flag.NewFlagSet("boards archive", flag.ContinueOnError)
+	undo := fs.Bool("undo", false, "Unarchive the board")
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New("usage: fora boards archive <id> [--undo] [--format f] [--quiet]")
+	}
+	return patchBoard(strings.TrimSpace(positionals[0]), map[string]any{"archived": !*undo}, outFlags)
+}
+
+func patchBoard(id string, req map[string]any, outFlags *outputFlags) error {
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Patch("/api/v1/boards/"+url.PathEscape(id), req, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdTags(args []string) error {
+	const usage = "usage: fora tags <list|rename|merge|alias>"
+	if len(args) == 0 {
+		return cmdTagsList(nil)
+	}
+	switch args[0] {
+	case "list":
+		return cmdTagsList(args[1:])
+	case "rename":
+		return cmdTagsRename(args[1:])
+	case "merge":
+		return cmdTagsMerge(args[1:])
+	case "alias":
+		return cmdTagsAlias(args[1:])
+	default:
+		return errors.New(usage)
+	}
+}
+
+func cmdTagsList(args []string) error {
+	fs := flag.NewFlagSet("tags list", flag.ContinueOnError)
+	board := fs.String("board", "", "Only count posts on this board")
+	prefix := fs.String("prefix", "", "Only tags starting with prefix")
+	limit := fs.Int("limit", 20, "Maximum tags")
+	offset := fs.Int("offset", 0, "Offset")
+	outFlags := addOutputFlags(fs, "Tags only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 0 {
+		return errors.New("usage: fora tags list [--board id] [--prefix p] [--limit n] [--offset n] [--format f] [--quiet]")
+	}
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(*limit))
+	q.Set("offset", strconv.Itoa(*offset))
+	if strings.TrimSpace(*board) != "" {
+		q.Set("board", strings.TrimSpace(*board))
+	}
+	if strings.TrimSpace(*prefix) != "" {
+		q.Set("prefix", strings.TrimSpace(*prefix))
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Get("/api/v1/tags?"+q.Encode(), &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdTagsRename(args []string) error {
+	fs := flag.NewFlagSet("tags rename", flag.ContinueOnError)
+	alias := fs.Bool("alias", false, "Keep the old name as an alias of the new one")
+	outFlags := addOutputFlags(fs, "New tag only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 2 {
+		return errors.New("usage: fora tags rename <from> <to> [--alias] [--format f] [--quiet]")
+	}
+	return postTags("/api/v1/tags/rename", map[string]any{
+		"from":  strings.TrimSpace(positionals[0]),
+		"to":    strings.TrimSpace(positionals[1]),
+		"alias": *alias,
+	}, outFlags)
+}
+
+func cmdTagsMerge(args []string) error {
+	const usage = "usage: fora tags merge <tag>... --into <tag> [--alias] [--format f] [--quiet]"
+	fs := flag.NewFlagSet("tags merge", flag.ContinueOnError)
+	into := fs.String("into", "", "Tag to merge into")
+	alias := fs.Bool("alias", false, "Keep the merged names as aliases")
+	outFlags := addOutputFlags(fs, "New tag only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) == 0 || strings.TrimSpace(*into) == "" {
+		return errors.New(usage)
+	}
+	return postTags("/api/v1/tags/merge", map[string]any{
+		"from":  parseTags(strings.Join(positionals, ",")),
+		"to":    strings.TrimSpace(*into),
+		"alias": *alias,
+	}, outFlags)
+}
+
+func cmdTagsAlias(args []string) error {
+	const usage = "usage: fora tags alias <list|add|remove>"
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "list":
+		fs := flag.NewFlagSet("tags alias list", flag.ContinueOnError)
+		outFlags := addOutputFlags(fs, "Aliases only")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 0 {
+			return errors.New("usage: fora tags alias list [--format f] [--quiet]")
+		}
+		cl, err := defaultClient()
+		if err != nil {
+			return err
+		}
+		var resp map[string]any
+		if err := cl.Get("/api/v1/tags/aliases", &resp); err != nil {
+			return err
+		}
+		return outFlags.print(resp)
+	case "add":
+		fs := flag.NewFlagSet("tags alias add", flag.ContinueOnError)
+		outFlags := addOutputFlags(fs, "Alias only")
+		positionals, err := parseInterspersedFlags(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positionals) != 2 {
+			return errors.New("usage: fora tags alias add <alias> <tag> [--format f] [--quiet]")
+		}
+		return postTags("/api/v1/tags/aliases", map[string]any{
+			"alias": strings.TrimSpace(positionals[0]),
+			"tag":   strings.TrimSpace(positionals[1]),
+		}, outFlags)
+	case "remove":
+		return deleteResource(args[1:], "tags alias remove", "/api/v1/tags/aliases/", "usage: fora tags alias remove <alias> [--format f] [--quiet]")
+	default:
+		return errors.New(usage)
+	}
+}
+
+func postTags(path string, req map[string]any, outFlags *outputFlags) error {
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Post(path, req, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
 }
 
 func parseBoardsAddArgs(args []string) (string, string, string, []string, error) {
@@ -445,7 +971,7 @@ func parseBoardsAddArgs(args []string) (string, string, string, []string, error)
 
 func cmdPosts(args []string) error {
 	if len(args) == 0 {
-		return errors.New("usage: fora posts <add|list|latest|read|thread|reply|edit|tag|close|reopen|pin>")
+		return errors.New("usage: fora posts <add|list|latest|read|thread|reply|edit|tag|close|reopen|pin|poll|vote|history|summary|metadata|delete>")
 	}
 	switch args[0] {
 	case "add":
@@ -470,8 +996,20 @@ func cmdPosts(args []string) error {
 		return cmdPostsStatus(args[1:], "open")
 	case "pin":
 		return cmdPostsStatus(args[1:], "pinned")
+	case "poll":
+		return cmdPostsPoll(args[1:])
+	case "vote":
+		return cmdPostsVote(args[1:])
+	case "history":
+		return cmdPostsGet(args[1:], "history")
+	case "summary":
+		return cmdPostsGet(args[1:], "summary")
+	case "metadata":
+		return cmdPostsGet(args[1:], "metadata")
+	case "delete":
+		return cmdPostsDelete(args[1:])
 	default:
-		return errors.New("usage: fora posts <add|list|latest|read|thread|reply|edit|tag|close|reopen|pin>")
+		return errors.New("usage: fora posts <add|list|latest|read|thread|reply|edit|tag|close|reopen|pin|poll|vote|history|summary|metadata|delete>")
 	}
 }
 
@@ -482,16 +1020,29 @@ func cmdPostsAdd(args []string) error {
 	fromFile := fs.String("from-file", "", "Read body from file")
 	tags := fs.String("tags", "", "Comma-separated tags")
 	board := fs.String("board", "", "Board ID")
-	var mentions multiStringFlag
+	queue := fs.Bool("outbox", false, "Queue locally if the server is unreachable")
+	strict := fs.Bool("strict-mentions", false, "Fail instead of posting when a mention matches no agent or group")
+	pollMultiple := fs.Bool("poll-multiple", false, "Let voters choose several poll options")
+	pollAnonymous := fs.Bool("poll-anonymous", false, "Hide who voted for which poll option")
+	pollDeadline := fs.String("poll-deadline", "", "Close the poll at a time or after a duration (e.g. 24h)")
+	var mentions, attach, pollOptions multiStringFlag
 	fs.Var(&mentions, "mention", "Mention agent (repeat or comma-separated)")
+	fs.Var(&attach, "attach", "Attach a file (repeatable)")
+	fs.Var(&pollOptions, "poll-option", "Add a poll option (repeatable)")
 	positionals, err := parseInterspersedFlags(fs, args)
 	if err != nil {
 		return err
 	}
+	if len(pollOptions.values) == 0 && (*pollMultiple || *pollAnonymous || strings.TrimSpace(*pollDeadline) != "") {
+		return errors.New("--poll-multiple, --poll-anonymous and --poll-deadline need --poll-option")
+	}
 	body, err := resolveBodyInput(positionals, *fromFile)
 	if err != nil {
 		return err
 	}
+	if err := checkAttachFiles(attach.values, *queue); err != nil {
+		return err
+	}
 	cl, err := defaultClient()
 	if err != nil {
 		return err
@@ -505,15 +1056,29 @@ func cmdPostsAdd(args []string) error {
 	}
 	if strings.TrimSpace(*board) != "" {
 		req["board_id"] = strings.TrimSpace(*board)
+	} else if pref := profilePreference(config.PrefDefaultBoard); pref != "" {
+		req["board_id"] = pref
 	}
 	if parsed := parseMentions(mentions.values); len(parsed) > 0 {
 		req["mentions"] = parsed
 	}
-	var resp map[string]any
-	if err := cl.Post("/api/v1/posts", req, &resp); err != nil {
-		return err
+	if *strict {
+		req["strict_mentions"] = true
 	}
-	return printJSON(resp)
+	if len(pollOptions.values) > 0 {
+		poll := map[string]any{"options": pollOptions.values, "multiple": *pollMultiple}
+		if *pollAnonymous {
+			poll["visibility"] = "anonymous"
+		}
+		if strings.TrimSpace(*pollDeadline) != "" {
+			poll["deadline"] = strings.TrimSpace(*pollDeadline)
+		}
+		req["poll"] = poll
+	}
+	if len(attach.values) > 0 {
+		return createWithAttachments(cl, "/api/v1/posts", req, attach.values)
+	}
+	return sendWrite(cl, *queue, "posts add", http.MethodPost, "/api/v1/posts", req)
 }
 
 func cmdPostsList(args []string) error {
@@ -528,8 +1093,10 @@ func cmdPostsList(args []string) error {
 	since := fs.String("since", "", "Filter by date/duration")
 	sort := fs.String("sort", "", "Sort by activity|created|replies")
 	order := fs.String("order", "", "Sort order asc|desc")
-	format := fs.String("format", "", "Output format: json|table|plain|md|quiet")
-	quiet := fs.Bool("quiet", false, "IDs only")
+	unread := fs.Bool("unread", false, "Only threads with replies you have not read")
+	linksTo := fs.String("links-to", "", "Only threads linking to a host or URL prefix")
+	references := fs.String("references", "", "Only threads referencing a thread ID")
+	outFlags := addOutputFlags(fs, "IDs only")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -538,6 +1105,9 @@ func cmdPostsList(args []string) error {
 		return err
 	}
 	path := "/api/v1/posts?limit=" + strconv.Itoa(*limit) + "&offset=" + strconv.Itoa(*offset)
+	if *unread {
+		path += "&unread=true"
+	}
 	if strings.TrimSpace(*author) != "" {
 		path += "&author=" + url.QueryEscape(strings.TrimSpace(*author))
 	}
@@ -553,6 +1123,12 @@ func cmdPostsList(args []string) error {
 	if strings.TrimSpace(*since) != "" {
 		path += "&since=" + url.QueryEscape(strings.TrimSpace(*since))
 	}
+	if strings.TrimSpace(*linksTo) != "" {
+		path += "&links_to=" + url.QueryEscape(strings.TrimSpace(*linksTo))
+	}
+	if strings.TrimSpace(*references) != "" {
+		path += "&references=" + url.QueryEscape(strings.TrimSpace(*references))
+	}
 	if strings.TrimSpace(*sort) != "" {
 		path += "&sort=" + url.QueryEscape(strings.TrimSpace(*sort))
 	}
@@ -563,7 +1139,7 @@ func cmdPostsList(args []string) error {
 	if err := cl.Get(path, &resp); err != nil {
 		return err
 	}
-	return output.Print(resp, *format, *quiet)
+	return outFlags.print(resp)
 }
 
 func cmdPostsRead(args []string) error {
@@ -598,24 +1174,31 @@ func cmdPostsThread(args []string) error {
 	depth := fs.Int("depth", 0, "Max reply depth (0 = unlimited)")
 	since := fs.String("since", "", "Filter replies by date/duration")
 	flat := fs.Bool("flat", false, "Request flat view when supported by server")
+	sinceLastRead := fs.Bool("since-last-read", false, "Only print posts and replies new or edited since the last read")
 	positionals, err := parseInterspersedFlags(fs, args)
 	if err != nil {
 		return err
 	}
 	if len(positionals) != 1 {
-		return errors.New("usage: fora posts thread <post-id> [--raw] [--depth n] [--since t] [--flat]")
+		return errors.New("usage: fora posts thread <post-id> [--raw] [--depth n] [--since t] [--flat] [--since-last-read]")
 	}
 	postID := strings.TrimSpace(positionals[0])
 	if postID == "" {
-		return errors.New("usage: fora posts thread <post-id> [--raw] [--depth n] [--since t] [--flat]")
+		return errors.New("usage: fora posts thread <post-id> [--raw] [--depth n] [--since t] [--flat] [--since-last-read]")
 	}
 	if *depth < 0 {
 		return errors.New("depth must be >= 0")
 	}
+	if *sinceLastRead && (*raw || *depth > 0 || strings.TrimSpace(*since) != "" || *flat) {
+		return errors.New("--since-last-read cannot be combined with --raw, --depth, --since or --flat")
+	}
 	cl, err := defaultClient()
 	if err != nil {
 		return err
 	}
+	if *sinceLastRead {
+		return printThreadChanges(cl, postID)
+	}
 
 	path := "/api/v1/posts/" + postID + "/thread"
 	params := make([]string, 0, 4)
@@ -651,41 +1234,264 @@ func cmdPostsThread(args []string) error {
 	return printJSON(resp)
 }
 
-func cmdPostsReply(args []string) error {
-	fs := flag.NewFlagSet("posts reply", flag.ContinueOnError)
-	fromFile := fs.String("from-file", "", "Read body from file")
-	var mentions multiStringFlag
-	fs.Var(&mentions, "mention", "Mention agent (repeat or comma-separated)")
-	positionals, err := parseInterspersedFlags(fs, args)
-	if err != nil {
+// printThreadChanges prints the thread items created or edited since the
+// agent last read the thread through any client, and moves the server's
+// read marker, so the web, MCP and CLI share one notion of "read".
+func printThreadChanges(cl *client.Client, postID string) error {
+	var resp map[string]any
+	if err := cl.Get("/api/v1/posts/"+url.PathEscape(postID)+"/thread?since_last_read=true", &resp); err != nil {
+		return err
+	}
+	return printJSON(resp)
+}
+
+func cmdCache(args []string) error {
+	if len(args) != 1 || args[0] != "clear" {
+		return errors.New("usage: fora cache clear")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	dir, err := stateDir(cfg, "cache")
+	if err != nil {
+		return err
+	}
+	removed, err := client.ClearCache(dir)
+	if err != nil {
+		return err
+	}
+	return printJSON(map[string]any{"profile": cfg.ActiveProfile(), "removed": removed})
+}
+
+func cmdPostsReply(args []string) error {
+	fs := flag.NewFlagSet("posts reply", flag.ContinueOnError)
+	fromFile := fs.String("from-file", "", "Read body from file")
+	queue := fs.Bool("outbox", false, "Queue locally if the server is unreachable")
+	strict := fs.Bool("strict-mentions", false, "Fail instead of replying when a mention matches no agent or group")
+	var mentions, attach multiStringFlag
+	fs.Var(&mentions, "mention", "Mention agent (repeat or comma-separated)")
+	fs.Var(&attach, "attach", "Attach a file (repeatable)")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
 		return err
 	}
 	if len(positionals) < 1 || len(positionals) > 2 {
-		return errors.New("usage: fora posts reply <post-or-reply-id> [content] [--from-file file] [--mention a,b]")
+		return errors.New("usage: fora posts reply <post-or-reply-id> [content] [--from-file file] [--mention a,b] [--strict-mentions] [--attach file]")
 	}
 	parentID := positionals[0]
 	body, err := resolveBodyInput(positionals[1:], *fromFile)
 	if err != nil {
 		return err
 	}
+	if err := checkAttachFiles(attach.values, *queue); err != nil {
+		return err
+	}
 	cl, err := defaultClient()
 	if err != nil {
 		return err
 	}
-	var resp map[string]any
 	req := map[string]any{"body": body}
 	if parsed := parseMentions(mentions.values); len(parsed) > 0 {
 		req["mentions"] = parsed
 	}
-	if err := cl.Post("/api/v1/posts/"+parentID+"/replies", req, &resp); err != nil {
+	if *strict {
+		req["strict_mentions"] = true
+	}
+	if len(attach.values) > 0 {
+		return createWithAttachments(cl, "/api/v1/posts/"+parentID+"/replies", req, attach.values)
+	}
+	return sendWrite(cl, *queue, "posts reply", http.MethodPost, "/api/v1/posts/"+parentID+"/replies", req)
+}
+
+// checkAttachFiles fails before anything is sent if a --attach file cannot be
+// read. Attachments are uploaded right after the content is created, so they
+// cannot wait in the outbox.
+func checkAttachFiles(files []string, queue bool) error {
+	if len(files) == 0 {
+		return nil
+	}
+	if queue {
+		return errors.New("--attach cannot be combined with --outbox")
+	}
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("--attach: %w", err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("--attach: %s is a directory", f)
+		}
+	}
+	return nil
+}
+
+// createWithAttachments POSTs body to path and uploads files to the content
+// it creates, printing the content with its attachments.
+func createWithAttachments(cl *client.Client, path string, body map[string]any, files []string) error {
+	var created map[string]any
+	if err := cl.WithIdempotencyKey(outbox.NewID()).Post(path, body, &created); err != nil {
 		return err
 	}
-	return printJSON(resp)
+	warnUnresolvedMentions(created)
+	id, _ := created["id"].(string)
+	attachments, err := uploadAttachments(cl, id, files)
+	created["attachments"] = attachments
+	if perr := printJSON(created); perr != nil {
+		return perr
+	}
+	if err != nil {
+		return fmt.Errorf("%s was created but not all files were attached: %w", id, err)
+	}
+	return nil
+}
+
+func uploadAttachments(cl *client.Client, contentID string, files []string) ([]any, error) {
+	out := make([]any, 0, len(files))
+	for _, name := range files {
+		a, err := uploadAttachment(cl, contentID, name)
+		if err != nil {
+			return out, fmt.Errorf("%s: %w", name, err)
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+func uploadAttachment(cl *client.Client, contentID, name string) (map[string]any, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	path := "/api/v1/posts/" + url.PathEscape(contentID) + "/attachments?filename=" + url.QueryEscape(filepath.Base(name))
+	var created map[string]any
+	if err := cl.Upload(path, "application/octet-stream", f, &created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func cmdAttachments(args []string) error {
+	const usage = "usage: fora attachments <list|add|get>"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "list":
+		return cmdAttachmentsList(args[1:])
+	case "add":
+		return cmdAttachmentsAdd(args[1:])
+	case "get":
+		return cmdAttachmentsGet(args[1:])
+	default:
+		return errors.New(usage)
+	}
+}
+
+func cmdAttachmentsList(args []string) error {
+	fs := flag.NewFlagSet("attachments list", flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New("usage: fora attachments list <post-or-reply-id> [--format f] [--quiet]")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Get("/api/v1/posts/"+url.PathEscape(positionals[0])+"/attachments", &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdAttachmentsAdd(args []string) error {
+	fs := flag.NewFlagSet("attachments add", flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) < 2 {
+		return errors.New("usage: fora attachments add <post-or-reply-id> <file>... [--format f] [--quiet]")
+	}
+	files := positionals[1:]
+	if err := checkAttachFiles(files, false); err != nil {
+		return err
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	attachments, err := uploadAttachments(cl, positionals[0], files)
+	if len(attachments) > 0 {
+		if perr := outFlags.print(map[string]any{"attachments": attachments}); perr != nil {
+			return perr
+		}
+	}
+	return err
+}
+
+func cmdAttachmentsGet(args []string) error {
+	fs := flag.NewFlagSet("attachments get", flag.ContinueOnError)
+	out := fs.String("out", "", "Write to this path (- for stdout; default: the attachment's filename)")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New("usage: fora attachments get <attachment-id> [--out path]")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	resp, err := cl.Download("/api/v1/attachments/" + url.PathEscape(positionals[0]))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if *out == "-" {
+		_, err := io.Copy(os.Stdout, resp.Body)
+		return err
+	}
+	dest := *out
+	if dest == "" {
+		dest = positionals[0]
+		if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+			if name := filepath.Base(params["filename"]); name != "." && name != "/" && name != "" {
+				dest = name
+			}
+		}
+	}
+	// Only an explicit --out may overwrite an existing file.
+	mode := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *out != "" {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(dest, mode, 0o644)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return printJSON(map[string]any{"id": positionals[0], "path": dest, "bytes": n})
 }
 
 func cmdPostsEdit(args []string) error {
 	fs := flag.NewFlagSet("posts edit", flag.ContinueOnError)
 	fromFile := fs.String("from-file", "", "Read body from file")
+	queue := fs.Bool("outbox", false, "Queue locally if the server is unreachable")
 	positionals, err := parseInterspersedFlags(fs, args)
 	if err != nil {
 		return err
@@ -708,20 +1514,17 @@ func cmdPostsEdit(args []string) error {
 		return err
 	}
 
+	req := map[string]any{"body": body}
 	var current map[string]any
 	if err := cl.Get("/api/v1/posts/"+postID, &current); err != nil {
-		return err
-	}
-	req := map[string]any{"body": body}
-	if title, ok := current["title"]; ok {
+		// Offline edits keep the title; fora sync fills it in before sending.
+		if !client.IsUnreachable(err) || !outboxEnabled(*queue) {
+			return err
+		}
+	} else if title, ok := current["title"]; ok {
 		req["title"] = title
 	}
-
-	var resp map[string]any
-	if err := cl.Put("/api/v1/posts/"+postID, req, &resp); err != nil {
-		return err
-	}
-	return printJSON(resp)
+	return sendWrite(cl, *queue, "posts edit", http.MethodPut, "/api/v1/posts/"+postID, req)
 }
 
 func cmdPostsTag(args []string) error {
@@ -766,6 +1569,177 @@ func cmdPostsStatus(args []string, status string) error {
 	return printJSON(resp)
 }
 
+func cmdPostsGet(args []string, view string) error {
+	fs := flag.NewFlagSet("posts "+view, flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return fmt.Errorf("usage: fora posts %s <post-id> [--format f] [--quiet]", view)
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Get("/api/v1/posts/"+url.PathEscape(positionals[0])+"/"+view, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdPostsPoll(args []string) error {
+	fs := flag.NewFlagSet("posts poll", flag.ContinueOnError)
+	closePoll := fs.Bool("close", false, "Close the poll now (post author or admin)")
+	outFlags := addOutputFlags(fs, "option IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New("usage: fora posts poll <post-id> [--close]")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	path := "/api/v1/posts/" + url.PathEscape(positionals[0]) + "/poll"
+	var resp map[string]any
+	if *closePoll {
+		err = cl.Post(path+"/close", map[string]any{}, &resp)
+	} else {
+		err = cl.Get(path, &resp)
+	}
+	if err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+// cmdPostsVote takes options by ID or by their exact text.
+func cmdPostsVote(args []string) error {
+	fs := flag.NewFlagSet("posts vote", flag.ContinueOnError)
+	retract := fs.Bool("retract", false, "Remove your vote")
+	outFlags := addOutputFlags(fs, "option IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) < 1 || (*retract) != (len(positionals) == 1) {
+		return errors.New("usage: fora posts vote <post-id> <option>... | fora posts vote <post-id> --retract")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	path := "/api/v1/posts/" + url.PathEscape(positionals[0]) + "/poll"
+	var resp map[string]any
+	if *retract {
+		if err := cl.Do(http.MethodDelete, path+"/vote", nil, &resp); err != nil {
+			return err
+		}
+		return outFlags.print(resp)
+	}
+	var poll struct {
+		Options []struct {
+			ID   int    `json:"id"`
+			Text string `json:"text"`
+		} `json:"options"`
+	}
+	if err := cl.Get(path, &poll); err != nil {
+		return err
+	}
+	ids := make([]int, 0, len(positionals)-1)
+	for _, choice := range positionals[1:] {
+		id, err := strconv.Atoi(choice)
+		if err != nil {
+			for _, o := range poll.Options {
+				if strings.EqualFold(o.Text, strings.TrimSpace(choice)) {
+					id = o.ID
+				}
+			}
+			if id == 0 {
+				return fmt.Errorf("no poll option %q", choice)
+			}
+		}
+		ids = append(ids, id)
+	}
+	if err := cl.Post(path+"/vote", map[string]any{"options": ids}, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdPostsDelete(args []string) error {
+	return deleteResource(args, "posts delete", "/api/v1/posts/", "usage: fora posts delete <post-id> [--format f] [--quiet]")
+}
+
+func cmdReplies(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: fora replies <edit|delete>")
+	}
+	switch args[0] {
+	case "edit":
+		return cmdRepliesEdit(args[1:])
+	case "delete":
+		return deleteResource(args[1:], "replies delete", "/api/v1/replies/", "usage: fora replies delete <reply-id> [--format f] [--quiet]")
+	default:
+		return errors.New("usage: fora replies <edit|delete>")
+	}
+}
+
+func cmdRepliesEdit(args []string) error {
+	const usage = "usage: fora replies edit <reply-id> [content] [--from-file file] [--format f] [--quiet]"
+	fs := flag.NewFlagSet("replies edit", flag.ContinueOnError)
+	fromFile := fs.String("from-file", "", "Read body from file")
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) < 1 || len(positionals) > 2 {
+		return errors.New(usage)
+	}
+	body, err := resolveBodyInput(positionals[1:], *fromFile)
+	if err != nil {
+		return err
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Put("/api/v1/replies/"+url.PathEscape(positionals[0]), map[string]any{"body": body}, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+// deleteResource issues a DELETE for prefix+id and reports the removed id in
+// the requested output format.
+func deleteResource(args []string, name, prefix, usage string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New(usage)
+	}
+	id := strings.TrimSpace(positionals[0])
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	if err := cl.Delete(prefix + url.PathEscape(id)); err != nil {
+		return err
+	}
+	return outFlags.print(map[string]any{"id": id, "deleted": true})
+}
+
 func cmdNotifications(args []string) error {
 	if len(args) == 0 {
 		return cmdNotificationsList(nil)
@@ -785,8 +1759,7 @@ func cmdNotifications(args []string) error {
 func cmdNotificationsList(args []string) error {
 	fs := flag.NewFlagSet("notifications", flag.ContinueOnError)
 	all := fs.Bool("all", false, "Include read notifications")
-	format := fs.String("format", "", "Output format: json|table|plain|md|quiet")
-	quiet := fs.Bool("quiet", false, "IDs only")
+	outFlags := addOutputFlags(fs, "IDs only")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -802,7 +1775,7 @@ func cmdNotificationsList(args []string) error {
 	if err := cl.Get(path, &resp); err != nil {
 		return err
 	}
-	return output.Print(resp, *format, *quiet)
+	return outFlags.print(resp)
 }
 
 func cmdNotificationsRead(args []string) error {
@@ -835,11 +1808,585 @@ func cmdNotificationsClear(args []string) error {
 	return printJSON(resp)
 }
 
-func cmdWatch(args []string) error {
-	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
-	intervalRaw := fs.String("interval", "10s", "Polling interval")
-	threadFilter := fs.String("thread", "", "Filter by thread ID")
-	tagFilter := fs.String("tag", "", "Filter by tag")
+func cmdDM(args []string) error {
+	if len(args) == 0 {
+		return cmdDMList(nil)
+	}
+	switch args[0] {
+	case "list":
+		return cmdDMList(args[1:])
+	case "send":
+		return cmdDMSend(args[1:])
+	case "read":
+		return cmdDMRead(args[1:])
+	case "reply":
+		return cmdDMReply(args[1:])
+	default:
+		return errors.New("usage: fora dm [list|send|read|reply]")
+	}
+}
+
+func cmdDMList(args []string) error {
+	fs := flag.NewFlagSet("dm list", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "Limit")
+	offset := fs.Int("offset", 0, "Offset")
+	outFlags := addOutputFlags(fs, "conversation IDs only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Get("/api/v1/conversations?limit="+strconv.Itoa(*limit)+"&offset="+strconv.Itoa(*offset), &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdDMSend(args []string) error {
+	fs := flag.NewFlagSet("dm send", flag.ContinueOnError)
+	fromFile := fs.String("from-file", "", "Read body from file")
+	outFlags := addOutputFlags(fs, "conversation ID only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) < 1 || len(positionals) > 2 {
+		return errors.New("usage: fora dm send <agent[,agent]> [message] [--from-file file]")
+	}
+	to := parseTags(positionals[0])
+	if len(to) == 0 {
+		return errors.New("at least one recipient is required")
+	}
+	body, err := resolveBodyInput(positionals[1:], *fromFile)
+	if err != nil {
+		return err
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Post("/api/v1/conversations", map[string]any{"to": to, "body": body}, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdDMRead(args []string) error {
+	fs := flag.NewFlagSet("dm read", flag.ContinueOnError)
+	limit := fs.Int("limit", 50, "Limit")
+	offset := fs.Int("offset", 0, "Offset")
+	outFlags := addOutputFlags(fs, "message IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New("usage: fora dm read <conversation-id> [--limit n] [--offset n]")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	path := "/api/v1/conversations/" + url.PathEscape(positionals[0]) +
+		"?limit=" + strconv.Itoa(*limit) + "&offset=" + strconv.Itoa(*offset)
+	if err := cl.Get(path, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdDMReply(args []string) error {
+	fs := flag.NewFlagSet("dm reply", flag.ContinueOnError)
+	fromFile := fs.String("from-file", "", "Read body from file")
+	outFlags := addOutputFlags(fs, "message ID only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) < 1 || len(positionals) > 2 {
+		return errors.New("usage: fora dm reply <conversation-id> [message] [--from-file file]")
+	}
+	body, err := resolveBodyInput(positionals[1:], *fromFile)
+	if err != nil {
+		return err
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Post("/api/v1/conversations/"+url.PathEscape(positionals[0])+"/messages", map[string]any{"body": body}, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdTUI(args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	board := fs.String("board", "", "Open this board directly")
+	intervalRaw := fs.String("interval", "10s", "Notification polling interval")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	interval, err := time.ParseDuration(*intervalRaw)
+	if err != nil || interval <= 0 {
+		return errors.New("invalid --interval")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	return tui.Run(cl, tui.Options{Board: strings.TrimSpace(*board), Interval: interval})
+}
+
+func cmdCompletion(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: fora completion bash|zsh|fish")
+	}
+	script, err := completion.Script(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// cmdComplete is called by the completion scripts with every word typed after
+// "fora"; the last one is the word being completed.
+func cmdComplete(args []string) error {
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	// The command line being completed may pick another profile.
+	for i, arg := range args[:max(len(args)-1, 0)] {
+		switch {
+		case arg == "--profile" && i+1 < len(args)-1:
+			config.SetProfileOverride(args[i+1])
+		case strings.HasPrefix(arg, "--profile="):
+			config.SetProfileOverride(strings.TrimPrefix(arg, "--profile="))
+		}
+	}
+	result := completion.Parse(usageText).Complete(args, completionSource())
+	return completion.Write(os.Stdout, result)
+}
+
+// completionSource serves profiles and outbox IDs from local files and
+// everything else from the server, cached for FORA_COMPLETION_TTL.
+func completionSource() completion.Source {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil
+	}
+	var remote completion.Source
+	if srv, err := activeServer(cfg); err == nil {
+		ttl := completion.DefaultTTL
+		if d, err := time.ParseDuration(os.Getenv("FORA_COMPLETION_TTL")); err == nil {
+			ttl = d
+		}
+		sum := sha256.Sum256([]byte(srv.URL + "\n" + srv.APIKey))
+		cache := &completion.Cache{Key: hex.EncodeToString(sum[:]), TTL: ttl}
+		if dir, err := stateDir(cfg, "completion"); err == nil {
+			cache.Dir = dir
+		}
+		remote = completion.Remote(client.New(srv.URL, srv.APIKey).WithTimeout(2*time.Second), cache)
+	}
+	return func(kind string) []completion.Candidate {
+		var out []completion.Candidate
+		switch kind {
+		case completion.KindProfiles:
+			for _, name := range cfg.ProfileNames() {
+				out = append(out, completion.Candidate{Value: name})
+			}
+		case completion.KindOutbox:
+			ob, err := openOutbox()
+			if err != nil {
+				return nil
+			}
+			entries, _ := ob.List()
+			for _, e := range entries {
+				out = append(out, completion.Candidate{Value: e.ID, Description: e.Command})
+			}
+		default:
+			if remote != nil {
+				out = remote(kind)
+			}
+		}
+		return out
+	}
+}
+
+func outboxEnabled(flagSet bool) bool {
+	if flagSet {
+		return true
+	}
+	switch strings.ToLower(profilePreference(config.PrefOutbox)) {
+	case "on", "true", "yes", "1":
+		return true
+	}
+	return false
+}
+
+func openOutbox() (*outbox.Outbox, error) {
+	p, err := config.Path()
+	if err != nil {
+		return nil, err
+	}
+	return outbox.Open(filepath.Join(filepath.Dir(p), "outbox.jsonl")), nil
+}
+
+// sendWrite sends a write with a fresh Idempotency-Key. If the server cannot
+// be reached and the outbox is enabled, the write is queued under that key
+// for `fora sync` instead of failing.
+func sendWrite(cl *client.Client, queue bool, command, method, path string, body map[string]any) error {
+	key := outbox.NewID()
+	var resp map[string]any
+	err := cl.WithIdempotencyKey(key).Do(method, path, body, &resp)
+	if err == nil {
+		warnUnresolvedMentions(resp)
+		return printJSON(resp)
+	}
+	if !client.IsUnreachable(err) || !outboxEnabled(queue) {
+		return err
+	}
+	cfg, cerr := config.Load()
+	if cerr != nil {
+		return cerr
+	}
+	ob, oerr := openOutbox()
+	if oerr != nil {
+		return oerr
+	}
+	entry, qerr := ob.Add(outbox.Entry{
+		ID:        key,
+		Profile:   cfg.ActiveProfile(),
+		Command:   command,
+		Method:    method,
+		Path:      path,
+		Body:      body,
+		Attempts:  1,
+		LastError: err.Error(),
+	})
+	if qerr != nil {
+		return fmt.Errorf("%v (queueing failed: %w)", err, qerr)
+	}
+	fmt.Fprintf(os.Stderr, "server unreachable (%v); queued %s. run: fora sync\n", err, entry.ID)
+	return printJSON(map[string]any{
+		"queued":    true,
+		"outbox_id": entry.ID,
+		"command":   entry.Command,
+		"path":      entry.Path,
+	})
+}
+
+func warnUnresolvedMentions(resp map[string]any) {
+	raw, _ := resp["unresolved_mentions"].([]any)
+	names := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if name, ok := v.(string); ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: nobody notified for unknown mentions: %s\n", strings.Join(names, ", "))
+	}
+}
+
+func cmdSync(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "list":
+			return cmdSyncList(args[1:])
+		case "drop":
+			return cmdSyncDrop(args[1:])
+		}
+	}
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "Show what would be sent")
+	force := fs.Bool("force", false, "Retry conflicted writes and skip conflict checks")
+	outFlags := addOutputFlags(fs, "IDs only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	ob, err := openOutbox()
+	if err != nil {
+		return err
+	}
+	entries, err := ob.List()
+	if err != nil {
+		return err
+	}
+	profile := cfg.ActiveProfile()
+
+	var (
+		kept                     []outbox.Entry
+		results                  []any
+		sent, conflicts, pending int
+		unreachable              error
+		sentIDs                  = map[string]bool{}
+	)
+	for _, e := range entries {
+		if e.Profile != profile {
+			kept = append(kept, e)
+			continue
+		}
+		row := map[string]any{"id": e.ID, "command": e.Command, "path": e.Path}
+		results = append(results, row)
+		switch {
+		case unreachable != nil:
+			row["result"], row["detail"] = "pending", "not attempted"
+		case e.Conflict != "" && !*force:
+			row["result"], row["detail"] = "conflict", e.Conflict
+		case *dryRun:
+			row["result"] = "would-send"
+		default:
+			created, conflict, err := syncEntry(cl, &e, *force)
+			switch {
+			case err != nil:
+				e.Attempts++
+				e.LastError = err.Error()
+				row["result"], row["detail"] = "pending", err.Error()
+				if client.IsUnreachable(err) {
+					unreachable = err
+				}
+			case conflict != "":
+				e.Conflict = conflict
+				row["result"], row["detail"] = "conflict", conflict
+			default:
+				sent++
+				sentIDs[e.ID] = true
+				row["result"], row["detail"] = "sent", created
+				continue
+			}
+		}
+		switch row["result"] {
+		case "conflict":
+			conflicts++
+		case "pending":
+			pending++
+		}
+		kept = append(kept, e)
+	}
+	if !*dryRun {
+		// Writes queued while this sync ran are not in entries; only the
+		// entries replayed here are removed or updated.
+		updated := make(map[string]outbox.Entry, len(kept))
+		for _, e := range kept {
+			updated[e.ID] = e
+		}
+		err := ob.Update(func(current []outbox.Entry) []outbox.Entry {
+			out := current[:0]
+			for _, e := range current {
+				if sentIDs[e.ID] {
+					continue
+				}
+				if u, ok := updated[e.ID]; ok {
+					e = u
+				}
+				out = append(out, e)
+			}
+			return out
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if results == nil {
+		results = []any{}
+	}
+	if err := outFlags.print(map[string]any{
+		"outbox":    results,
+		"sent":      sent,
+		"conflicts": conflicts,
+		"pending":   pending,
+	}); err != nil {
+		return err
+	}
+	switch {
+	case unreachable != nil:
+		return fmt.Errorf("server unreachable, %d write(s) still queued: %w", pending, unreachable)
+	case pending > 0:
+		return fmt.Errorf("%d write(s) failed and stay queued", pending)
+	case conflicts > 0:
+		return fmt.Errorf("%d conflicted write(s). inspect: fora sync list; retry: fora sync --force; discard: fora sync drop <id>", conflicts)
+	}
+	return nil
+}
+
+// syncEntry replays one queued write. It returns the created or updated
+// content ID, or a conflict when the target changed while the write was
+// queued or the server rejected it.
+func syncEntry(cl *client.Client, e *outbox.Entry, force bool) (string, string, error) {
+	if !force {
+		conflict, err := checkOutboxConflict(cl, e)
+		if err != nil || conflict != "" {
+			return "", conflict, err
+		}
+	}
+	var resp map[string]any
+	err := cl.WithIdempotencyKey(e.ID).Do(e.Method, e.Path, e.Body, &resp)
+	var httpErr *client.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode < 500 && httpErr.StatusCode != 429 {
+		return "", httpErr.Error(), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	id, _ := resp["id"].(string)
+	return id, "", nil
+}
+
+func checkOutboxConflict(cl *client.Client, e *outbox.Entry) (string, error) {
+	switch e.Command {
+	case "posts reply":
+		parent := strings.TrimSuffix(strings.TrimPrefix(e.Path, "/api/v1/posts/"), "/replies")
+		var resp struct {
+			Thread struct {
+				Status string `json:"status"`
+			} `json:"thread"`
+		}
+		if err := cl.Get("/api/v1/posts/"+parent+"/thread", &resp); err != nil {
+			return notFoundConflict(err, "reply target was deleted")
+		}
+		switch resp.Thread.Status {
+		case "closed", "archived":
+			return "thread is " + resp.Thread.Status, nil
+		}
+	case "posts edit":
+		var current map[string]any
+		if err := cl.Get(e.Path, &current); err != nil {
+			return notFoundConflict(err, "post was deleted")
+		}
+		updatedRaw, _ := current["updated"].(string)
+		updated, _ := time.Parse(time.RFC3339, updatedRaw)
+		queued, _ := time.Parse(time.RFC3339, e.QueuedAt)
+		if updated.After(queued) {
+			return "post was changed on the server after this edit was queued", nil
+		}
+		if _, ok := e.Body["title"]; !ok {
+			if title, ok := current["title"]; ok {
+				e.Body["title"] = title
+			}
+		}
+	}
+	return "", nil
+}
+
+func notFoundConflict(err error, conflict string) (string, error) {
+	var httpErr *client.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == 404 {
+		return conflict, nil
+	}
+	return "", err
+}
+
+func outboxRows(entries []outbox.Entry, profile string) []any {
+	rows := make([]any, 0, len(entries))
+	for _, e := range entries {
+		if e.Profile != profile {
+			continue
+		}
+		result, detail := "pending", e.LastError
+		if e.Conflict != "" {
+			result, detail = "conflict", e.Conflict
+		}
+		rows = append(rows, map[string]any{
+			"id":        e.ID,
+			"command":   e.Command,
+			"path":      e.Path,
+			"queued_at": e.QueuedAt,
+			"attempts":  e.Attempts,
+			"result":    result,
+			"detail":    detail,
+		})
+	}
+	return rows
+}
+
+func cmdSyncList(args []string) error {
+	fs := flag.NewFlagSet("sync list", flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "IDs only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	ob, err := openOutbox()
+	if err != nil {
+		return err
+	}
+	entries, err := ob.List()
+	if err != nil {
+		return err
+	}
+	return outFlags.print(map[string]any{"outbox": outboxRows(entries, cfg.ActiveProfile())})
+}
+
+func cmdSyncDrop(args []string) error {
+	fs := flag.NewFlagSet("sync drop", flag.ContinueOnError)
+	all := fs.Bool("all", false, "Drop every queued write of the active profile")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *all == (len(positionals) == 1) || len(positionals) > 1 {
+		return errors.New("usage: fora sync drop <outbox-id> | --all")
+	}
+	ob, err := openOutbox()
+	if err != nil {
+		return err
+	}
+	if !*all {
+		found, err := ob.Remove(positionals[0])
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("outbox entry %q not found", positionals[0])
+		}
+		return printJSON(map[string]any{"id": positionals[0], "dropped": true})
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	dropped := 0
+	err = ob.Update(func(entries []outbox.Entry) []outbox.Entry {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.Profile == cfg.ActiveProfile() {
+				dropped++
+				continue
+			}
+			kept = append(kept, e)
+		}
+		return kept
+	})
+	if err != nil {
+		return err
+	}
+	return printJSON(map[string]any{"dropped": dropped})
+}
+
+func cmdWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	intervalRaw := fs.String("interval", "10s", "Polling interval")
+	threadFilter := fs.String("thread", "", "Filter by thread ID")
+	tagFilter := fs.String("tag", "", "Filter by tag")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -930,108 +2477,525 @@ func postHasTag(cl *client.Client, postID, tag string, threadTagMatchCache map[s
 	return false, nil
 }
 
-func cmdSearch(args []string) error {
-	args = normalizeLegacyFlag(args, "channel", "board")
-	fs := flag.NewFlagSet("search", flag.ContinueOnError)
-	author := fs.String("author", "", "Filter by author")
-	tag := fs.String("tag", "", "Filter by tag")
-	board := fs.String("board", "", "Filter by board")
-	since := fs.String("since", "", "Filter by duration/date (e.g. 24h, 2026-02-01)")
-	threadsOnly := fs.Bool("threads-only", false, "Only search root posts")
-	limit := fs.Int("limit", 20, "Limit")
-	offset := fs.Int("offset", 0, "Offset")
-	format := fs.String("format", "", "Output format: json|table|plain|md|quiet")
-	quiet := fs.Bool("quiet", false, "IDs only")
+func cmdSearch(args []string) error {
+	args = normalizeLegacyFlag(args, "channel", "board")
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	author := fs.String("author", "", "Filter by author")
+	tag := fs.String("tag", "", "Filter by tag")
+	board := fs.String("board", "", "Filter by board")
+	since := fs.String("since", "", "Filter by duration/date (e.g. 24h, 2026-02-01)")
+	threadsOnly := fs.Bool("threads-only", false, "Only search root posts")
+	includeDMs := fs.Bool("include-direct-messages", false, "Also search direct messages (admin only)")
+	limit := fs.Int("limit", 20, "Limit")
+	offset := fs.Int("offset", 0, "Offset")
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New("usage: fora search <query> [--author x] [--tag x] [--board id] [--since t] [--threads-only] [--include-direct-messages]")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	path := "/api/v1/search?q=" + url.QueryEscape(positionals[0]) +
+		"&limit=" + strconv.Itoa(*limit) + "&offset=" + strconv.Itoa(*offset)
+	if strings.TrimSpace(*author) != "" {
+		path += "&author=" + url.QueryEscape(strings.TrimSpace(*author))
+	}
+	if strings.TrimSpace(*tag) != "" {
+		path += "&tag=" + url.QueryEscape(strings.TrimSpace(*tag))
+	}
+	if strings.TrimSpace(*board) != "" {
+		path += "&board=" + url.QueryEscape(strings.TrimSpace(*board))
+	}
+	if strings.TrimSpace(*since) != "" {
+		path += "&since=" + url.QueryEscape(strings.TrimSpace(*since))
+	}
+	if *threadsOnly {
+		path += "&threads_only=true"
+	}
+	if *includeDMs {
+		path += "&include_direct_messages=true"
+	}
+	var resp map[string]any
+	if err := cl.Get(path, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdActivity(args []string) error {
+	fs := flag.NewFlagSet("activity", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "Limit")
+	offset := fs.Int("offset", 0, "Offset")
+	author := fs.String("author", "", "Filter by author")
+	outFlags := addOutputFlags(fs, "IDs only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	path := "/api/v1/activity?limit=" + strconv.Itoa(*limit) + "&offset=" + strconv.Itoa(*offset)
+	if strings.TrimSpace(*author) != "" {
+		path += "&author=" + url.QueryEscape(strings.TrimSpace(*author))
+	}
+	var resp map[string]any
+	if err := cl.Get(path, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdAgent(args []string) error {
+	if len(args) == 0 {
+		return cmdAgentList(nil)
+	}
+	switch args[0] {
+	case "add":
+		return cmdAgentAdd(args[1:])
+	case "list":
+		return cmdAgentList(args[1:])
+	case "remove":
+		return cmdAgentRemove(args[1:])
+	case "info":
+		return cmdAgentInfo(args[1:])
+	case "inspect":
+		return cmdHiveAgent(args[1:])
+	case "profile":
+		return cmdAgentProfile(args[1:])
+	case "heartbeat":
+		return cmdAgentHeartbeat(args[1:])
+	case "presence":
+		return cmdAgentPresence(args[1:])
+	case "wait":
+		return cmdAgentWait(args[1:])
+	case "suspend":
+		return cmdAgentState(args[1:], "suspended")
+	case "deactivate":
+		return cmdAgentState(args[1:], "deactivated")
+	case "reactivate":
+		return cmdAgentState(args[1:], "active")
+	case "reassign":
+		return cmdAgentReassign(args[1:])
+	case "anonymize":
+		return cmdAgentAnonymize(args[1:])
+	default:
+		return errors.New("usage: fora agent <add|list|remove|info|inspect|profile|heartbeat|presence|wait|suspend|deactivate|reactivate|reassign|anonymize>")
+	}
+}
+
+func cmdAgentState(args []string, state string) error {
+	fs := flag.NewFlagSet("agent state", flag.ContinueOnError)
+	reason := fs.String("reason", "", "Why, shown to the agent when it is turned away")
+	until := fs.String("until", "", "End of the suspension: RFC3339 time or duration like 72h")
+	outFlags := addOutputFlags(fs, "Names only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 || (*until != "" && state != "suspended") {
+		switch state {
+		case "suspended":
+			return errors.New("usage: fora agent suspend <name> [--reason text] [--until 72h|time] [--format f] [--quiet]")
+		case "deactivated":
+			return errors.New("usage: fora agent deactivate <name> [--reason text] [--format f] [--quiet]")
+		default:
+			return errors.New("usage: fora agent reactivate <name> [--format f] [--quiet]")
+		}
+	}
+	req := map[string]any{"state": state}
+	if strings.TrimSpace(*reason) != "" {
+		req["reason"] = strings.TrimSpace(*reason)
+	}
+	if strings.TrimSpace(*until) != "" {
+		req["until"] = strings.TrimSpace(*until)
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Patch("/api/v1/agents/"+url.PathEscape(positionals[0])+"/state", req, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdAgentReassign(args []string) error {
+	fs := flag.NewFlagSet("agent reassign", flag.ContinueOnError)
+	to := fs.String("to", "", "Agent that takes over the content")
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 || strings.TrimSpace(*to) == "" {
+		return errors.New("usage: fora agent reassign <name> --to <agent> [--format f] [--quiet]")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Post("/api/v1/agents/"+url.PathEscape(positionals[0])+"/reassign", map[string]any{"to": strings.TrimSpace(*to)}, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdAgentAnonymize(args []string) error {
+	fs := flag.NewFlagSet("agent anonymize", flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New("usage: fora agent anonymize <name> [--format f] [--quiet]")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Post("/api/v1/agents/"+url.PathEscape(positionals[0])+"/anonymize", map[string]any{}, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdAgentHeartbeat(args []string) error {
+	fs := flag.NewFlagSet("agent heartbeat", flag.ContinueOnError)
+	status := fs.String("status", "online", "online, busy, away or offline")
+	message := fs.String("message", "", "What you are working on")
+	ttl := fs.Duration("ttl", 0, "How long the heartbeat holds (server default 5m)")
+	outFlags := addOutputFlags(fs, "Status only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: fora agent heartbeat [--status online|busy|away|offline] [--message text] [--ttl 10m] [--format f] [--quiet]")
+	}
+	req := map[string]any{"status": *status, "message": *message}
+	if *ttl > 0 {
+		req["ttl"] = ttl.String()
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Do(http.MethodPut, "/api/v1/me/presence", req, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdAgentPresence(args []string) error {
+	fs := flag.NewFlagSet("agent presence", flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "Status only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) > 1 {
+		return errors.New("usage: fora agent presence [name] [--format f] [--quiet]")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	path := "/api/v1/me/presence"
+	if len(positionals) == 1 {
+		path = "/api/v1/hive/agents/" + url.PathEscape(positionals[0]) + "/presence"
+	}
+	var resp map[string]any
+	if err := cl.Get(path, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+// cmdAgentWait long-polls an agent's presence, one server-side wait of up to
+// a minute at a time, until it reaches a wanted status or --timeout passes.
+func cmdAgentWait(args []string) error {
+	fs := flag.NewFlagSet("agent wait", flag.ContinueOnError)
+	status := fs.String("status", "online", "Statuses to wait for, comma-separated")
+	timeout := fs.Duration("timeout", 5*time.Minute, "Give up after this long")
+	outFlags := addOutputFlags(fs, "Status only")
 	positionals, err := parseInterspersedFlags(fs, args)
 	if err != nil {
 		return err
 	}
 	if len(positionals) != 1 {
-		return errors.New("usage: fora search <query> [--author x] [--tag x] [--board id] [--since t] [--threads-only]")
+		return errors.New("usage: fora agent wait <name> [--status online,busy] [--timeout 5m] [--format f] [--quiet]")
 	}
+	name := positionals[0]
 	cl, err := defaultClient()
 	if err != nil {
 		return err
 	}
-	path := "/api/v1/search?q=" + url.QueryEscape(positionals[0]) +
-		"&limit=" + strconv.Itoa(*limit) + "&offset=" + strconv.Itoa(*offset)
-	if strings.TrimSpace(*author) != "" {
-		path += "&author=" + url.QueryEscape(strings.TrimSpace(*author))
+	const maxWait = time.Minute
+	cl = cl.WithTimeout(maxWait + 15*time.Second)
+	deadline := time.Now().Add(*timeout)
+	for {
+		wait := min(time.Until(deadline), maxWait).Round(time.Second)
+		q := url.Values{}
+		q.Set("status", *status)
+		q.Set("wait", max(wait, 0).String())
+		var resp map[string]any
+		if err := cl.Get("/api/v1/hive/agents/"+url.PathEscape(name)+"/presence?"+q.Encode(), &resp); err != nil {
+			return err
+		}
+		if ready, _ := resp["ready"].(bool); ready {
+			return outFlags.print(resp)
+		}
+		if !time.Now().Before(deadline) {
+			_ = outFlags.print(resp)
+			return fmt.Errorf("timed out after %s waiting for %s to be %s", *timeout, name, *status)
+		}
 	}
-	if strings.TrimSpace(*tag) != "" {
-		path += "&tag=" + url.QueryEscape(strings.TrimSpace(*tag))
+}
+
+func cmdAgentProfile(args []string) error {
+	if len(args) > 0 && args[0] == "set" {
+		return cmdAgentProfileSet(args[1:])
 	}
-	if strings.TrimSpace(*board) != "" {
-		path += "&board=" + url.QueryEscape(strings.TrimSpace(*board))
+	fs := flag.NewFlagSet("agent profile", flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "IDs only")
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
-	if strings.TrimSpace(*since) != "" {
-		path += "&since=" + url.QueryEscape(strings.TrimSpace(*since))
+	if fs.NArg() != 0 {
+		return errors.New("usage: fora agent profile [set] [--format f] [--quiet]")
 	}
-	if *threadsOnly {
-		path += "&threads_only=true"
+	cl, err := defaultClient()
+	if err != nil {
+		return err
 	}
 	var resp map[string]any
-	if err := cl.Get(path, &resp); err != nil {
+	if err := cl.Get("/api/v1/me/profile", &resp); err != nil {
 		return err
 	}
-	return output.Print(resp, *format, *quiet)
+	return outFlags.print(resp)
 }
 
-func cmdActivity(args []string) error {
-	fs := flag.NewFlagSet("activity", flag.ContinueOnError)
-	limit := fs.Int("limit", 20, "Limit")
-	offset := fs.Int("offset", 0, "Offset")
-	author := fs.String("author", "", "Filter by author")
-	format := fs.String("format", "", "Output format: json|table|plain|md|quiet")
-	quiet := fs.Bool("quiet", false, "IDs only")
+func cmdAgentProfileSet(args []string) error {
+	fs := flag.NewFlagSet("agent profile set", flag.ContinueOnError)
+	displayName := fs.String("display-name", "", "Display name")
+	description := fs.String("description", "", "What the agent does")
+	team := fs.String("team", "", "Owning team")
+	model := fs.String("model", "", "Model the agent runs on")
+	contact := fs.String("contact", "", "Contact or handoff instructions")
+	capabilities := fs.String("capabilities", "", "Capabilities or skills, replacing the current list")
+	outFlags := addOutputFlags(fs, "IDs only")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: fora agent profile set [--display-name n] [--description text] [--team t] [--model m] [--contact text] [--capabilities a,b] [--format f] [--quiet]")
+	}
+	req := map[string]any{}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "display-name":
+			req["display_name"] = *displayName
+		case "description":
+			req["description"] = *description
+		case "team":
+			req["team"] = *team
+		case "model":
+			req["model"] = *model
+		case "contact":
+			req["contact"] = *contact
+		case "capabilities":
+			req["capabilities"] = parseTags(*capabilities)
+		}
+	})
+	if len(req) == 0 {
+		return errors.New("nothing to update: pass --display-name, --description, --team, --model, --contact or --capabilities")
+	}
 	cl, err := defaultClient()
 	if err != nil {
 		return err
 	}
-	path := "/api/v1/activity?limit=" + strconv.Itoa(*limit) + "&offset=" + strconv.Itoa(*offset)
-	if strings.TrimSpace(*author) != "" {
-		path += "&author=" + url.QueryEscape(strings.TrimSpace(*author))
-	}
 	var resp map[string]any
-	if err := cl.Get(path, &resp); err != nil {
+	if err := cl.Patch("/api/v1/me/profile", req, &resp); err != nil {
 		return err
 	}
-	return output.Print(resp, *format, *quiet)
+	return outFlags.print(resp)
 }
 
-func cmdAgent(args []string) error {
+func cmdGroup(args []string) error {
+	const usage = "usage: fora group <list|add|remove>"
 	if len(args) == 0 {
-		return cmdAgentList(nil)
+		return cmdGroupList(nil)
 	}
 	switch args[0] {
-	case "add":
-		return cmdAgentAdd(args[1:])
 	case "list":
-		return cmdAgentList(args[1:])
+		return cmdGroupList(args[1:])
+	case "add":
+		return cmdGroupAdd(args[1:])
 	case "remove":
-		return cmdAgentRemove(args[1:])
-	case "info":
-		return cmdAgentInfo(args[1:])
-	case "inspect":
-		return cmdHiveAgent(args[1:])
+		return cmdGroupRemove(args[1:])
 	default:
-		return errors.New("usage: fora agent <add|list|remove|info|inspect>")
+		return errors.New(usage)
+	}
+}
+
+func cmdGroupList(args []string) error {
+	fs := flag.NewFlagSet("group list", flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "Names only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) > 1 {
+		return errors.New("usage: fora group list [group] [--format f] [--quiet]")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	path := "/api/v1/groups"
+	if len(positionals) == 1 {
+		path += "/" + url.PathEscape(strings.TrimSpace(positionals[0]))
+	}
+	var resp map[string]any
+	if err := cl.Get(path, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+// cmdGroupAdd adds agents to a group, creating the group if it does not
+// exist yet.
+func cmdGroupAdd(args []string) error {
+	fs := flag.NewFlagSet("group add", flag.ContinueOnError)
+	description := fs.String("description", "", "Description, used when the group is created")
+	outFlags := addOutputFlags(fs, "Names only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) == 0 {
+		return errors.New("usage: fora group add <group> [agent...] [--description text] [--format f] [--quiet]")
+	}
+	name := strings.TrimSpace(positionals[0])
+	agents := parseTags(strings.Join(positionals[1:], ","))
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	err = cl.Post("/api/v1/groups/"+url.PathEscape(name)+"/members", map[string]any{"agents": agents}, &resp)
+	var httpErr *client.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+		req := map[string]any{"name": name, "members": agents}
+		if strings.TrimSpace(*description) != "" {
+			req["description"] = strings.TrimSpace(*description)
+		}
+		err = cl.Post("/api/v1/groups", req, &resp)
+	}
+	if err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+// cmdGroupRemove removes the given agents from a group, or the whole group
+// when no agents are given.
+func cmdGroupRemove(args []string) error {
+	fs := flag.NewFlagSet("group remove", flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "Names only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) == 0 {
+		return errors.New("usage: fora group remove <group> [agent...] [--format f] [--quiet]")
+	}
+	if len(positionals) == 1 {
+		return deleteResource(args, "group remove", "/api/v1/groups/", "usage: fora group remove <group> [agent...] [--format f] [--quiet]")
+	}
+	name := strings.TrimSpace(positionals[0])
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	for _, agent := range parseTags(strings.Join(positionals[1:], ",")) {
+		resp = nil
+		if err := cl.Do(http.MethodDelete, "/api/v1/groups/"+url.PathEscape(name)+"/members/"+url.PathEscape(agent), nil, &resp); err != nil {
+			return err
+		}
 	}
+	return outFlags.print(resp)
 }
 
 func cmdHive(args []string) error {
+	const usage = "usage: fora hive <agent|agents> ..."
 	if len(args) == 0 {
-		return errors.New("usage: fora hive agent <name> [--limit n] [--offset n] [--board id] [--format f] [--quiet]")
+		return errors.New(usage)
 	}
 	switch args[0] {
 	case "agent":
 		return cmdHiveAgent(args[1:])
+	case "agents":
+		return cmdHiveAgents(args[1:])
 	default:
-		return errors.New("usage: fora hive agent <name> [--limit n] [--offset n] [--board id] [--format f] [--quiet]")
+		return errors.New(usage)
+	}
+}
+
+func cmdHiveAgents(args []string) error {
+	fs := flag.NewFlagSet("hive agents", flag.ContinueOnError)
+	capability := fs.String("capability", "", "Required capabilities (all must match)")
+	team := fs.String("team", "", "Filter by team")
+	group := fs.String("group", "", "Filter by group membership")
+	query := fs.String("query", "", "Match name, display name or description")
+	presence := fs.String("presence", "", "Presence statuses, comma-separated (online, busy, away, offline)")
+	limit := fs.Int("limit", 20, "Limit")
+	offset := fs.Int("offset", 0, "Offset")
+	outFlags := addOutputFlags(fs, "Names only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: fora hive agents [--capability a,b] [--team t] [--group g] [--query q] [--presence online,busy] [--limit n] [--offset n] [--format f] [--quiet]")
+	}
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(*limit))
+	q.Set("offset", strconv.Itoa(*offset))
+	if parsed := parseTags(*capability); len(parsed) > 0 {
+		q.Set("capability", strings.Join(parsed, ","))
+	}
+	if strings.TrimSpace(*team) != "" {
+		q.Set("team", strings.TrimSpace(*team))
+	}
+	if strings.TrimSpace(*group) != "" {
+		q.Set("group", strings.TrimSpace(*group))
+	}
+	if strings.TrimSpace(*query) != "" {
+		q.Set("q", strings.TrimSpace(*query))
+	}
+	if parsed := parseTags(*presence); len(parsed) > 0 {
+		q.Set("presence", strings.Join(parsed, ","))
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Get("/api/v1/hive/agents?"+q.Encode(), &resp); err != nil {
+		return err
 	}
+	return outFlags.print(resp)
 }
 
 func cmdHiveAgent(args []string) error {
@@ -1039,8 +3003,7 @@ func cmdHiveAgent(args []string) error {
 	limit := fs.Int("limit", 20, "Limit")
 	offset := fs.Int("offset", 0, "Offset")
 	board := fs.String("board", "", "Filter by board id")
-	format := fs.String("format", "", "Output format: json|table|plain|md|quiet")
-	quiet := fs.Bool("quiet", false, "IDs only")
+	outFlags := addOutputFlags(fs, "IDs only")
 	positionals, err := parseInterspersedFlags(fs, args)
 	if err != nil {
 		return err
@@ -1065,7 +3028,7 @@ func cmdHiveAgent(args []string) error {
 	if err := cl.Get(path, &resp); err != nil {
 		return err
 	}
-	return output.Print(resp, *format, *quiet)
+	return outFlags.print(resp)
 }
 
 func cmdAgentAdd(args []string) error {
@@ -1080,9 +3043,9 @@ func cmdAgentAdd(args []string) error {
 	if err != nil {
 		return err
 	}
-	srv, ok := cfg.Default()
-	if !ok {
-		return errors.New("not connected. run: fora connect <url> --api-key <key>")
+	srv, err := activeServer(cfg)
+	if err != nil {
+		return err
 	}
 	cl := client.New(srv.URL, srv.APIKey)
 	if cl == nil {
@@ -1183,8 +3146,7 @@ func parseAgentAddArgs(args []string) (string, string, string, bool, error) {
 
 func cmdAgentList(args []string) error {
 	fs := flag.NewFlagSet("agent list", flag.ContinueOnError)
-	format := fs.String("format", "", "Output format: json|table|plain|md|quiet")
-	quiet := fs.Bool("quiet", false, "IDs only")
+	outFlags := addOutputFlags(fs, "IDs only")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -1199,7 +3161,7 @@ func cmdAgentList(args []string) error {
 	if err := cl.Get("/api/v1/agents", &resp); err != nil {
 		return err
 	}
-	return output.Print(resp, *format, *quiet)
+	return outFlags.print(resp)
 }
 
 func cmdAgentRemove(args []string) error {
@@ -1219,8 +3181,7 @@ func cmdAgentRemove(args []string) error {
 
 func cmdAgentInfo(args []string) error {
 	fs := flag.NewFlagSet("agent info", flag.ContinueOnError)
-	format := fs.String("format", "", "Output format: json|table|plain|md|quiet")
-	quiet := fs.Bool("quiet", false, "IDs only")
+	outFlags := addOutputFlags(fs, "IDs only")
 	positionals, err := parseInterspersedFlags(fs, args)
 	if err != nil {
 		return err
@@ -1236,21 +3197,169 @@ func cmdAgentInfo(args []string) error {
 	if err := cl.Get("/api/v1/agents/"+url.PathEscape(positionals[0]), &resp); err != nil {
 		return err
 	}
-	return output.Print(resp, *format, *quiet)
+	return outFlags.print(resp)
 }
 
 func cmdAdmin(args []string) error {
 	if len(args) == 0 {
-		return errors.New("usage: fora admin <export|stats>")
+		return errors.New("usage: fora admin <export|stats|primer>")
 	}
 	switch args[0] {
 	case "export":
 		return cmdAdminExport(args[1:])
 	case "stats":
 		return cmdAdminStats(args[1:])
+	case "primer":
+		return cmdAdminPrimer(args[1:])
+	default:
+		return errors.New("usage: fora admin <export|stats|primer>")
+	}
+}
+
+func cmdAdminPrimer(args []string) error {
+	const usage = "usage: fora admin primer <get|set> [content] [--from-file file] [--format f] [--quiet]"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("admin primer "+action, flag.ContinueOnError)
+	fromFile := fs.String("from-file", "", "Read primer markdown from file")
+	outFlags := addOutputFlags(fs, "Suppress output")
+	positionals, err := parseInterspersedFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	switch action {
+	case "get":
+		if len(positionals) != 0 || *fromFile != "" {
+			return errors.New(usage)
+		}
+		if err := cl.Get("/api/v1/primer", &resp); err != nil {
+			return err
+		}
+	case "set":
+		body, err := resolveBodyInput(positionals, *fromFile)
+		if err != nil {
+			return err
+		}
+		if err := cl.Put("/api/v1/admin/primer", map[string]any{"primer": body + "\n"}, &resp); err != nil {
+			return err
+		}
+		if *outFlags.quiet {
+			return nil
+		}
+	default:
+		return errors.New(usage)
+	}
+	return outFlags.print(resp)
+}
+
+func cmdWebhooks(args []string) error {
+	const usage = "usage: fora webhooks <add|list|remove|test>"
+	if len(args) == 0 {
+		return cmdWebhooksList(nil)
+	}
+	switch args[0] {
+	case "add":
+		return cmdWebhooksAdd(args[1:])
+	case "list":
+		return cmdWebhooksList(args[1:])
+	case "remove":
+		return deleteResource(args[1:], "webhooks remove", "/api/v1/admin/webhooks/", "usage: fora webhooks remove <id> [--format f] [--quiet]")
+	case "test":
+		return cmdWebhooksTest(args[1:])
 	default:
-		return errors.New("usage: fora admin <export|stats>")
+		return errors.New(usage)
+	}
+}
+
+func cmdWebhooksAdd(args []string) error {
+	const usage = "usage: fora webhooks add <url> --events a,b [--secret s] [--format f] [--quiet]"
+	fs := flag.NewFlagSet("webhooks add", flag.ContinueOnError)
+	var events multiStringFlag
+	fs.Var(&events, "events", "Event types to deliver (repeat or comma-separated, * for all)")
+	secret := fs.String("secret", "", "HMAC secret for X-Fora-Signature")
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New(usage)
+	}
+	rawURL := strings.TrimSpace(positionals[0])
+	if _, err := url.ParseRequestURI(rawURL); err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	parsed := parseCSVUnique(events.values)
+	if len(parsed) == 0 {
+		return errors.New("missing --events")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	req := map[string]any{"url": rawURL, "events": parsed}
+	if strings.TrimSpace(*secret) != "" {
+		req["secret"] = strings.TrimSpace(*secret)
+	}
+	var resp map[string]any
+	if err := cl.Post("/api/v1/admin/webhooks", req, &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdWebhooksList(args []string) error {
+	fs := flag.NewFlagSet("webhooks list", flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "IDs only")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: fora webhooks list [--format f] [--quiet]")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Get("/api/v1/admin/webhooks", &resp); err != nil {
+		return err
+	}
+	return outFlags.print(resp)
+}
+
+func cmdWebhooksTest(args []string) error {
+	fs := flag.NewFlagSet("webhooks test", flag.ContinueOnError)
+	outFlags := addOutputFlags(fs, "IDs only")
+	positionals, err := parseInterspersedFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positionals) != 1 {
+		return errors.New("usage: fora webhooks test <id> [--format f] [--quiet]")
+	}
+	cl, err := defaultClient()
+	if err != nil {
+		return err
+	}
+	var resp map[string]any
+	if err := cl.Post("/api/v1/admin/webhooks/"+url.PathEscape(positionals[0])+"/test", map[string]any{}, &resp); err != nil {
+		return err
+	}
+	if err := outFlags.print(resp); err != nil {
+		return err
 	}
+	if delivered, _ := resp["delivered"].(bool); !delivered {
+		return fmt.Errorf("test delivery failed: status %v %v", resp["status_code"], resp["error"])
+	}
+	return nil
 }
 
 func cmdAdminExport(args []string) error {
@@ -1259,6 +3368,7 @@ func cmdAdminExport(args []string) error {
 	out := fs.String("out", "", "Output path (file for json, directory for markdown)")
 	threadID := fs.String("thread", "", "Single thread ID")
 	since := fs.String("since", "", "Only content since duration/date")
+	directMessages := fs.Bool("include-direct-messages", false, "Include private conversations (json only)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -1276,6 +3386,9 @@ func cmdAdminExport(args []string) error {
 	if strings.TrimSpace(*since) != "" {
 		req["since"] = strings.TrimSpace(*since)
 	}
+	if *directMessages {
+		req["include_direct_messages"] = true
+	}
 	var resp map[string]any
 	if err := cl.Post("/api/v1/admin/export", req, &resp); err != nil {
 		return err
@@ -1370,11 +3483,83 @@ func defaultClient() (*client.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	srv, err := activeServer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cl := client.New(srv.URL, srv.APIKey)
+	if os.Getenv("FORA_NO_CACHE") == "" {
+		if dir, err := stateDir(cfg, "cache"); err == nil {
+			cl = cl.WithCache(dir)
+		}
+	}
+	return cl, nil
+}
+
+// stateDir is a per-profile directory next to the config file.
+func stateDir(cfg *config.Config, kind string) (string, error) {
+	p, err := config.Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(p), kind, cfg.ActiveProfile()), nil
+}
+
+func activeServer(cfg *config.Config) (config.Server, error) {
 	srv, ok := cfg.Default()
-	if !ok {
-		return nil, errors.New("not connected. run: fora connect <url> --api-key <key>")
+	if ok {
+		return srv, nil
+	}
+	if name := cfg.ActiveProfile(); name != cfg.DefaultServer {
+		return config.Server{}, fmt.Errorf("profile %q not found. run: fora profile list", name)
 	}
-	return client.New(srv.URL, srv.APIKey), nil
+	return config.Server{}, errors.New("not connected. run: fora connect <url> --api-key <key>")
+}
+
+// profilePreference reads a preference of the active profile, or "" when
+// there is no usable config.
+func profilePreference(key string) string {
+	cfg, err := config.Load()
+	if err != nil {
+		return ""
+	}
+	return cfg.Preference(key)
+}
+
+// outputFlags are the output options shared by every command that prints
+// through the output package.
+type outputFlags struct {
+	format   *string
+	quiet    *bool
+	fields   *string
+	template *string
+	jsonPath *string
+}
+
+func addOutputFlags(fs *flag.FlagSet, quietUsage string) *outputFlags {
+	return &outputFlags{
+		format:   fs.String("format", "", "Output format: json|ndjson|table|plain|md|quiet"),
+		quiet:    fs.Bool("quiet", false, quietUsage),
+		fields:   fs.String("fields", "", "Comma-separated fields to print, e.g. id,title,author"),
+		template: fs.String("template", "", "Go text/template applied to each item, e.g. '{{.id}} {{.title}}'"),
+		jsonPath: fs.String("jsonpath", "", "Print the values selected by a JSONPath, e.g. '.threads[*].id'"),
+	}
+}
+
+// print renders payload with the active profile's default_format used when
+// --format is not given.
+func (o *outputFlags) print(payload map[string]any) error {
+	format := strings.TrimSpace(*o.format)
+	if format == "" {
+		format = profilePreference(config.PrefDefaultFormat)
+	}
+	return output.PrintWith(payload, output.Options{
+		Format:   format,
+		Quiet:    *o.quiet,
+		Fields:   parseCSVUnique([]string{*o.fields}),
+		Template: *o.template,
+		JSONPath: strings.TrimSpace(*o.jsonPath),
+	})
 }
 
 type multiStringFlag struct {
@@ -1563,42 +3748,106 @@ func cmdSkillInstall(args []string) error {
 }
 
 func usage() error {
-	return errors.New(`usage:
+	return errors.New(usageText)
+}
+
+// usageText doubles as the command tree for shell completion: keep one line
+// per command with its flags spelled as [--name value] or [--switch].
+const usageText = `usage:
+  fora [--profile name] <command> ...   (or FORA_PROFILE=name)
+  commands taking [--format f] also take [--fields a,b] [--template tmpl] [--jsonpath expr]
   fora install [--image ref] [--container name] [--port n]
   fora connect <url> --api-key <key> [--in-dir]
   fora disconnect
   fora status
+  fora profile list [--format f] [--quiet]
+  fora profile use <name>
+  fora profile add <name> --url <url> --api-key <key> [--agent name] [--board id] [--format f] [--use]
+  fora profile remove <name>
+  fora profile rename <old> <new>
+  fora profile set <default_board|default_format|outbox> [value]
   fora whoami
   fora primer
-  fora boards list
+  fora boards list [--all] [--format f] [--quiet]
   fora boards add <name> [--description text] [--icon text] [--tags a,b]
   fora boards info <id>
+  fora boards update <id> [--name n] [--description text] [--icon text] [--sort-order n] [--restrict-tags] [--add-tags a,b] [--remove-tags a,b] [--format f] [--quiet]
+  fora boards archive <id> [--undo] [--format f] [--quiet]
+  fora boards delete <id> [--format f] [--quiet]
+  fora boards suggest-tags <id> [text] [--from-file file] [--tags a,b] [--limit n] [--format f] [--quiet]
   fora boards subscribe <id>
   fora boards unsubscribe <id>
-  fora notifications [--all]
+  fora tags list [--board id] [--prefix p] [--limit n] [--offset n] [--format f] [--quiet]
+  fora tags rename <from> <to> [--alias] [--format f] [--quiet]
+  fora tags merge <tag>... --into <tag> [--alias] [--format f] [--quiet]
+  fora tags alias list [--format f] [--quiet]
+  fora tags alias add <alias> <tag> [--format f] [--quiet]
+  fora tags alias remove <alias> [--format f] [--quiet]
+  fora notifications [--all] [--format f] [--quiet]
   fora notifications read <notification-id>
   fora notifications clear
+  fora dm [list] [--limit n] [--offset n] [--format f] [--quiet]
+  fora dm send <agent[,agent]> [message] [--from-file file] [--format f] [--quiet]
+  fora dm read <conversation-id> [--limit n] [--offset n] [--format f] [--quiet]
+  fora dm reply <conversation-id> [message] [--from-file file] [--format f] [--quiet]
   fora watch [--interval 10s] [--thread id] [--tag tag]
-  fora search <query> [--author x] [--tag x] [--board id] [--since t] [--threads-only]
-  fora activity [--limit n] [--offset n] [--author a]
+  fora tui [--board id] [--interval 10s]
+  fora sync [--dry-run] [--force] [--format f] [--quiet]
+  fora sync list [--format f] [--quiet]
+  fora sync drop <outbox-id> | --all
+  fora cache clear
+  fora search <query> [--author x] [--tag x] [--board id] [--since t] [--threads-only] [--include-direct-messages] [--limit n] [--offset n] [--format f] [--quiet]
+  fora activity [--limit n] [--offset n] [--author a] [--format f] [--quiet]
   fora hive agent <name> [--limit n] [--offset n] [--board id] [--format f] [--quiet]
+  fora hive agents [--capability a,b] [--team t] [--group g] [--query q] [--presence online,busy] [--limit n] [--offset n] [--format f] [--quiet]
   fora agent add <name> [--role agent|admin] [--metadata text] [--in-dir]
   fora agent list [--format f] [--quiet]
   fora agent info <name> [--format f] [--quiet]
   fora agent inspect <name> [--limit n] [--offset n] [--board id] [--format f] [--quiet]
   fora agent remove <name>
-  fora admin export --format json|markdown --out <path> [--thread id] [--since t]
+  fora agent suspend <name> [--reason text] [--until 72h|time] [--format f] [--quiet]
+  fora agent deactivate <name> [--reason text] [--format f] [--quiet]
+  fora agent reactivate <name> [--format f] [--quiet]
+  fora agent reassign <name> --to <agent> [--format f] [--quiet]
+  fora agent anonymize <name> [--format f] [--quiet]
+  fora agent profile [--format f] [--quiet]
+  fora agent profile set [--display-name n] [--description text] [--team t] [--model m] [--contact text] [--capabilities a,b] [--format f] [--quiet]
+  fora agent heartbeat [--status online|busy|away|offline] [--message text] [--ttl 10m] [--format f] [--quiet]
+  fora agent presence [name] [--format f] [--quiet]
+  fora agent wait <name> [--status online,busy] [--timeout 5m] [--format f] [--quiet]
+  fora group list [group] [--format f] [--quiet]
+  fora group add <group> [agent...] [--description text] [--format f] [--quiet]
+  fora group remove <group> [agent...] [--format f] [--quiet]
+  fora admin export --format json|markdown --out <path> [--thread id] [--since t] [--include-direct-messages]
   fora admin stats
+  fora admin primer get [--format f] [--quiet]
+  fora admin primer set [content] [--from-file file] [--format f] [--quiet]
+  fora webhooks add <url> --events a,b [--secret s] [--format f] [--quiet]
+  fora webhooks list [--format f] [--quiet]
+  fora webhooks remove <id> [--format f] [--quiet]
+  fora webhooks test <id> [--format f] [--quiet]
   fora skill install [--dir path]
-  fora posts add [content] [--title t] [--from-file file] [--tags a,b] [--board id] [--mention a,b]
-  fora posts list [--limit n] [--offset n] [--author a] [--tag t] [--status s] [--board id] [--since t] [--sort s] [--order o]
+  fora posts add [content] [--title t] [--from-file file] [--tags a,b] [--board id|auto] [--mention a,b] [--strict-mentions] [--attach file] [--poll-option text]... [--poll-multiple] [--poll-anonymous] [--poll-deadline t] [--outbox]
+  fora posts list [--limit n] [--offset n] [--author a] [--tag t] [--status open|closed|pinned|archived] [--board id] [--since t] [--links-to host] [--references id] [--sort activity|created|replies] [--order asc|desc] [--unread] [--format f] [--quiet]
   fora posts latest <n>
   fora posts read <post-id>
-  fora posts thread <post-id> [--raw] [--depth n] [--since t] [--flat]
-  fora posts reply <post-or-reply-id> [content] [--from-file file] [--mention a,b]
-  fora posts edit <post-id> [content] [--from-file file]
+  fora posts thread <post-id> [--raw] [--depth n] [--since t] [--flat] [--since-last-read]
+  fora posts reply <post-or-reply-id> [content] [--from-file file] [--mention a,b] [--strict-mentions] [--attach file] [--outbox]
+  fora posts edit <post-id> [content] [--from-file file] [--outbox]
   fora posts tag <post-id> --add a,b --remove c
   fora posts close <post-id>
   fora posts reopen <post-id>
-  fora posts pin <post-id>`)
-}
+  fora posts pin <post-id>
+  fora posts poll <post-id> [--close] [--format f] [--quiet]
+  fora posts vote <post-id> <option>... [--format f] [--quiet]
+  fora posts vote <post-id> --retract
+  fora posts history <post-id> [--format f] [--quiet]
+  fora posts summary <post-id> [--format f] [--quiet]
+  fora posts metadata <post-or-reply-id> [--format f] [--quiet]
+  fora posts delete <post-id> [--format f] [--quiet]
+  fora replies edit <reply-id> [content] [--from-file file] [--format f] [--quiet]
+  fora replies delete <reply-id> [--format f] [--quiet]
+  fora attachments list <post-or-reply-id> [--format f] [--quiet]
+  fora attachments add <post-or-reply-id> <file>... [--format f] [--quiet]
+  fora attachments get <attachment-id> [--out path]
+  fora completion bash|zsh|fish`
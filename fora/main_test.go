@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
+
+	"fora/internal/api"
+	"fora/internal/auth"
+	"fora/internal/db"
 )
 
 func TestCmdPrimerPrintsPrimerMarkdown(t *testing.T) {
@@ -121,3 +127,698 @@ func captureStdout(t *testing.T, fn func() error) (string, error) {
 	}
 	return string(out), runErr
 }
+
+func TestCmdWebhooksAddAndListUseAdminAPI(t *testing.T) {
+	const apiKey = "fora_ak_admin"
+	var created map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/admin/webhooks":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "wh_1", "url": created["url"], "events": created["events"]})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/admin/webhooks":
+			_ = json.NewEncoder(w).Encode(map[string]any{"webhooks": []map[string]any{
+				{"id": "wh_1", "url": "https://hooks.example.com", "events": []string{"thread.created", "reply.created"}, "active": true},
+			}})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	writeCLIConfig(t, srv.URL, apiKey)
+
+	out, err := captureStdout(t, func() error {
+		return run([]string{"webhooks", "add", "https://hooks.example.com", "--events", "thread.created,reply.created", "--quiet"})
+	})
+	if err != nil {
+		t.Fatalf("webhooks add: %v", err)
+	}
+	if out != "wh_1\n" {
+		t.Fatalf("unexpected quiet output %q", out)
+	}
+	if events, _ := created["events"].([]any); len(events) != 2 {
+		t.Fatalf("expected two events in request, got %v", created["events"])
+	}
+
+	out, err = captureStdout(t, func() error {
+		return run([]string{"webhooks", "list", "--format", "plain"})
+	})
+	if err != nil {
+		t.Fatalf("webhooks list: %v", err)
+	}
+	if out != "wh_1 https://hooks.example.com thread.created,reply.created\n" {
+		t.Fatalf("unexpected plain output %q", out)
+	}
+}
+
+func TestCmdRepliesDeleteAndPrimerSet(t *testing.T) {
+	const apiKey = "fora_ak_admin"
+	var primer string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v1/replies/r_1":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/admin/primer":
+			var req map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			primer = req["primer"]
+			_ = json.NewEncoder(w).Encode(req)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	writeCLIConfig(t, srv.URL, apiKey)
+
+	out, err := captureStdout(t, func() error {
+		return run([]string{"replies", "delete", "r_1", "--format", "quiet"})
+	})
+	if err != nil || out != "r_1\n" {
+		t.Fatalf("replies delete: out=%q err=%v", out, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "primer.md")
+	if err := os.WriteFile(path, []byte("# New primer\n"), 0o644); err != nil {
+		t.Fatalf("write primer: %v", err)
+	}
+	out, err = captureStdout(t, func() error {
+		return run([]string{"admin", "primer", "set", "--from-file", path, "--quiet"})
+	})
+	if err != nil || out != "" {
+		t.Fatalf("primer set: out=%q err=%v", out, err)
+	}
+	if primer != "# New primer\n" {
+		t.Fatalf("unexpected primer sent: %q", primer)
+	}
+}
+
+func TestGlobalProfileFlagSelectsServerAndStatusShowsConfig(t *testing.T) {
+	const stagingKey = "fora_ak_staging"
+	staging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer "+stagingKey {
+			t.Fatalf("unexpected auth header: %q", got)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": "ok"})
+	}))
+	defer staging.Close()
+
+	writeCLIConfig(t, "http://127.0.0.1:1", "fora_ak_main")
+	if _, err := captureStdout(t, func() error {
+		return run([]string{"profile", "add", "staging", "--url", staging.URL, "--api-key", stagingKey, "--format", "json"})
+	}); err != nil {
+		t.Fatalf("profile add: %v", err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return run([]string{"status", "--profile", "staging"})
+	})
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	var status map[string]any
+	if err := json.Unmarshal([]byte(out), &status); err != nil {
+		t.Fatalf("decode status %q: %v", out, err)
+	}
+	if status["profile"] != "staging" || status["config_source"] != "home" || status["server"] != staging.URL {
+		t.Fatalf("unexpected status: %v", status)
+	}
+	if path, _ := status["config_path"].(string); !strings.HasSuffix(path, filepath.Join(".fora", "config.json")) {
+		t.Fatalf("unexpected config path %q", path)
+	}
+
+	out, err = captureStdout(t, func() error {
+		return run([]string{"profile", "list", "--quiet"})
+	})
+	if err != nil || out != "main\nstaging\n" {
+		t.Fatalf("profile list: out=%q err=%v", out, err)
+	}
+
+	if err := run([]string{"--profile", "missing", "whoami"}); err == nil || !strings.Contains(err.Error(), `profile "missing" not found`) {
+		t.Fatalf("expected missing profile error, got %v", err)
+	}
+}
+
+func TestOutboxQueuesWhenServerDownAndSyncReportsConflicts(t *testing.T) {
+	down := true
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/posts":
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "p-new"})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/posts/p-closed/thread":
+			_ = json.NewEncoder(w).Encode(map[string]any{"thread": map[string]any{"id": "p-closed", "status": "closed"}})
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	writeCLIConfig(t, srv.URL, "fora_ak_test")
+
+	if err := run([]string{"posts", "add", "not queued"}); err == nil {
+		t.Fatal("expected error without --outbox")
+	}
+	out, err := captureStdout(t, func() error {
+		return run([]string{"posts", "add", "hello", "--title", "Offline", "--outbox"})
+	})
+	if err != nil || !strings.Contains(out, `"queued": true`) {
+		t.Fatalf("posts add: out=%q err=%v", out, err)
+	}
+	if _, err := captureStdout(t, func() error {
+		return run([]string{"posts", "reply", "p-closed", "late reply", "--outbox"})
+	}); err != nil {
+		t.Fatalf("posts reply: %v", err)
+	}
+	out, err = captureStdout(t, func() error { return run([]string{"sync", "list", "--quiet"}) })
+	if err != nil {
+		t.Fatalf("sync list: %v", err)
+	}
+	queued := strings.Fields(out)
+	if len(queued) != 2 {
+		t.Fatalf("expected two queued writes, got %q", out)
+	}
+
+	down = false
+	out, err = captureStdout(t, func() error { return run([]string{"sync", "--format", "json"}) })
+	if err == nil || !strings.Contains(err.Error(), "1 conflicted write") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	var report struct {
+		Outbox []map[string]any `json:"outbox"`
+		Sent   int              `json:"sent"`
+	}
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("decode sync report %q: %v", out, err)
+	}
+	if report.Sent != 1 || report.Outbox[0]["result"] != "sent" || report.Outbox[1]["detail"] != "thread is closed" {
+		t.Fatalf("unexpected sync report: %+v", report)
+	}
+	if len(keys) != 1 || keys[0] != queued[0] {
+		t.Fatalf("post should be replayed with its outbox id as Idempotency-Key, got %v", keys)
+	}
+
+	out, err = captureStdout(t, func() error { return run([]string{"sync", "list", "--quiet"}) })
+	if err != nil || strings.TrimSpace(out) != queued[1] {
+		t.Fatalf("conflict should stay queued: out=%q err=%v", out, err)
+	}
+	if _, err := captureStdout(t, func() error { return run([]string{"sync", "drop", queued[1]}) }); err != nil {
+		t.Fatalf("sync drop: %v", err)
+	}
+	out, _ = captureStdout(t, func() error { return run([]string{"sync", "list", "--quiet"}) })
+	if out != "" {
+		t.Fatalf("outbox should be empty, got %q", out)
+	}
+}
+
+func TestPostsThreadSinceLastReadUsesCacheAndPrintsOnlyChanges(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fora.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	if err := db.ApplyMigrations(database); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateAgent(context.Background(), database, "alice", "agent", auth.HashAPIKey(apiKey), nil); err != nil {
+		t.Fatal(err)
+	}
+	router := api.NewRouter(database, "test")
+	notModified := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			notModified++
+		}
+		router.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	writeCLIConfig(t, srv.URL, apiKey)
+
+	out, err := captureStdout(t, func() error { return run([]string{"posts", "add", "root body", "--board", "general"}) })
+	if err != nil {
+		t.Fatalf("posts add: %v", err)
+	}
+	var post map[string]any
+	_ = json.Unmarshal([]byte(out), &post)
+	postID, _ := post["id"].(string)
+
+	changes := func() []map[string]any {
+		t.Helper()
+		out, err := captureStdout(t, func() error { return run([]string{"posts", "thread", postID, "--since-last-read"}) })
+		if err != nil {
+			t.Fatalf("thread --since-last-read: %v", err)
+		}
+		var resp struct {
+			Changes []map[string]any `json:"changes"`
+		}
+		if err := json.Unmarshal([]byte(out), &resp); err != nil {
+			t.Fatalf("decode %q: %v", out, err)
+		}
+		return resp.Changes
+	}
+
+	if got := changes(); len(got) != 1 || got[0]["id"] != postID {
+		t.Fatalf("first read should return the whole thread, got %v", got)
+	}
+	if got := changes(); len(got) != 0 {
+		t.Fatalf("second read should be empty, got %v", got)
+	}
+
+	// A plain thread read revalidates the cached copy and moves the same
+	// server-side read marker.
+	if _, err := captureStdout(t, func() error { return run([]string{"posts", "reply", postID, "seen elsewhere"}) }); err != nil {
+		t.Fatalf("reply: %v", err)
+	}
+	for range 2 {
+		if _, err := captureStdout(t, func() error { return run([]string{"posts", "thread", postID}) }); err != nil {
+			t.Fatalf("posts thread: %v", err)
+		}
+	}
+	if notModified != 1 {
+		t.Fatalf("second plain read should revalidate the cached thread, conditional requests = %d", notModified)
+	}
+	if got := changes(); len(got) != 0 {
+		t.Fatalf("reply read through posts thread should not show again, got %v", got)
+	}
+
+	if _, err := captureStdout(t, func() error { return run([]string{"posts", "reply", postID, "new reply"}) }); err != nil {
+		t.Fatalf("reply: %v", err)
+	}
+	got := changes()
+	if len(got) != 1 || got[0]["body"] != "new reply" {
+		t.Fatalf("expected only the new reply, got %v", got)
+	}
+
+	if _, err := captureStdout(t, func() error { return run([]string{"cache", "clear"}) }); err != nil {
+		t.Fatalf("cache clear: %v", err)
+	}
+}
+
+func TestCompleteSuggestsServerValuesFromCache(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fora.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	if err := db.ApplyMigrations(database); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateAgent(context.Background(), database, "alice", "agent", auth.HashAPIKey(apiKey), nil); err != nil {
+		t.Fatal(err)
+	}
+	router := api.NewRouter(database, "test")
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		router.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	writeCLIConfig(t, srv.URL, apiKey)
+
+	out, err := captureStdout(t, func() error {
+		return run([]string{"posts", "add", "body", "--title", "Deploy plan", "--board", "general", "--tags", "ops"})
+	})
+	if err != nil {
+		t.Fatalf("posts add: %v", err)
+	}
+	var post map[string]any
+	_ = json.Unmarshal([]byte(out), &post)
+	postID, _ := post["id"].(string)
+
+	complete := func(words ...string) []string {
+		t.Helper()
+		out, err := captureStdout(t, func() error { return run(append([]string{"__complete", "--"}, words...)) })
+		if err != nil {
+			t.Fatalf("complete %v: %v", words, err)
+		}
+		return strings.Split(strings.TrimSpace(out), "\n")
+	}
+
+	if got := complete("posts", "list", "--board", "gen"); len(got) != 1 || !strings.HasPrefix(got[0], "general") {
+		t.Fatalf("board completion = %q", got)
+	}
+	if got := complete("posts", "read", ""); len(got) != 1 || got[0] != postID+"\tDeploy plan" {
+		t.Fatalf("thread completion = %q", got)
+	}
+	if got := complete("posts", "add", "--tags", "x,o"); len(got) != 1 || got[0] != "x,ops" {
+		t.Fatalf("tag completion = %q", got)
+	}
+	// Non-admins cannot list agents; recent participants stand in.
+	if got := complete("posts", "list", "--author", ""); len(got) != 1 || got[0] != "alice" {
+		t.Fatalf("agent completion = %q", got)
+	}
+	if got := complete("posts", "add", "--from-file", ""); got[0] != ":files" {
+		t.Fatalf("file completion = %q", got)
+	}
+
+	before := requests
+	complete("posts", "list", "--board", "")
+	complete("posts", "read", "")
+	if requests != before {
+		t.Fatalf("cached completion hit the server %d times", requests-before)
+	}
+
+	t.Setenv("FORA_COMPLETION_TTL", "0s")
+	complete("posts", "list", "--board", "")
+	if requests == before {
+		t.Fatal("expired completion cache was not refreshed")
+	}
+}
+
+func TestListOutputFieldsTemplateNDJSONAndJSONPath(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fora.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	if err := db.ApplyMigrations(database); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateAgent(context.Background(), database, "alice", "agent", auth.HashAPIKey(apiKey), nil); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(api.NewRouter(database, "test"))
+	defer srv.Close()
+	writeCLIConfig(t, srv.URL, apiKey)
+
+	for title, tags := range map[string]string{"First": "ops,deploy", "Second": "ops"} {
+		if _, err := captureStdout(t, func() error {
+			return run([]string{"posts", "add", title + " body", "--title", title, "--board", "general", "--tags", tags})
+		}); err != nil {
+			t.Fatalf("posts add: %v", err)
+		}
+	}
+
+	runOut := func(args ...string) string {
+		t.Helper()
+		out, err := captureStdout(t, func() error { return run(args) })
+		if err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out
+	}
+	// Both posts land in the same second, so list order is not fixed.
+	sortedLines := func(s string) string {
+		lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+		sort.Strings(lines)
+		return strings.Join(lines, "\n")
+	}
+
+	if got := runOut("posts", "list", "--tag", "deploy", "--format", "table", "--fields", "title,author,tags"); got != "TITLE\tAUTHOR\tTAGS\nFirst\talice\tdeploy,ops\n" {
+		t.Fatalf("table --fields = %q", got)
+	}
+	if got := sortedLines(runOut("posts", "list", "--template", "{{.title}} by {{.author}} [{{join \"|\" .tags}}]")); got != "First by alice [deploy|ops]\nSecond by alice [ops]" {
+		t.Fatalf("--template = %q", got)
+	}
+	if got := sortedLines(runOut("posts", "list", "--jsonpath", ".threads[*].title")); got != "First\nSecond" {
+		t.Fatalf("--jsonpath = %q", got)
+	}
+
+	lines := strings.Split(strings.TrimSpace(runOut("posts", "list", "--format", "ndjson", "--fields", "id,title")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("ndjson lines = %q", lines)
+	}
+	for _, line := range lines {
+		var row map[string]any
+		if err := json.Unmarshal([]byte(line), &row); err != nil || len(row) != 2 || row["id"] == nil {
+			t.Fatalf("ndjson row %q: %v", line, err)
+		}
+	}
+
+	if got := runOut("boards", "list", "--jsonpath", ".boards[*].id"); !strings.Contains(got, "general\n") {
+		t.Fatalf("boards list --jsonpath = %q", got)
+	}
+	if got := runOut("search", "Second", "--format", "plain", "--fields", "type,author"); got != "post alice\n" {
+		t.Fatalf("search --fields = %q", got)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return run([]string{"posts", "list", "--template", "{{.id}}", "--jsonpath", ".threads"})
+	}); err == nil {
+		t.Fatal("expected --template with --jsonpath to fail")
+	}
+}
+
+func TestPostsAddAttachUploadsFilesAndAttachmentsGetDownloads(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fora.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	if err := db.ApplyMigrations(database); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateAgent(context.Background(), database, "alice", "agent", auth.HashAPIKey(apiKey), nil); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(api.NewRouter(database, "test"))
+	defer srv.Close()
+	writeCLIConfig(t, srv.URL, apiKey)
+
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.txt")
+	data := filepath.Join(dir, "data.bin")
+	if err := os.WriteFile(notes, []byte("remember the milk\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(data, []byte{0, 1, 2, 3}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return run([]string{"posts", "add", "body", "--title", "T", "--board", "general", "--attach", notes, "--outbox"})
+	}); err == nil || !strings.Contains(err.Error(), "--outbox") {
+		t.Fatalf("expected --attach with --outbox to fail, got %v", err)
+	}
+	if _, err := captureStdout(t, func() error {
+		return run([]string{"posts", "add", "body", "--title", "T", "--board", "general", "--attach", filepath.Join(dir, "missing")})
+	}); err == nil {
+		t.Fatal("expected a missing --attach file to fail before posting")
+	}
+
+	out, err := captureStdout(t, func() error {
+		return run([]string{"posts", "add", "see files", "--title", "Files", "--board", "general", "--attach", notes, "--attach", data})
+	})
+	if err != nil {
+		t.Fatalf("posts add --attach: %v", err)
+	}
+	var created struct {
+		ID          string `json:"id"`
+		Attachments []struct {
+			ID       string `json:"id"`
+			Filename string `json:"filename"`
+			Size     int    `json:"size"`
+		} `json:"attachments"`
+	}
+	if err := json.Unmarshal([]byte(out), &created); err != nil {
+		t.Fatalf("decode %q: %v", out, err)
+	}
+	if len(created.Attachments) != 2 || created.Attachments[0].Filename != "notes.txt" || created.Attachments[1].Size != 4 {
+		t.Fatalf("unexpected attachments: %+v", created.Attachments)
+	}
+
+	ids, err := captureStdout(t, func() error { return run([]string{"attachments", "list", created.ID, "--quiet"}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := created.Attachments[0].ID + "\n" + created.Attachments[1].ID + "\n"; ids != want {
+		t.Fatalf("attachments list --quiet = %q, want %q", ids, want)
+	}
+
+	dest := filepath.Join(dir, "copy.txt")
+	if _, err := captureStdout(t, func() error {
+		return run([]string{"attachments", "get", created.Attachments[0].ID, "--out", dest})
+	}); err != nil {
+		t.Fatalf("attachments get: %v", err)
+	}
+	if b, err := os.ReadFile(dest); err != nil || string(b) != "remember the milk\n" {
+		t.Fatalf("downloaded %q, %v", b, err)
+	}
+}
+
+func TestBoardsUpdateArchiveAndDeleteSendOnlyGivenFields(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "boards.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	if err := db.ApplyMigrations(database); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateAgent(context.Background(), database, "root", "admin", auth.HashAPIKey(apiKey), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateBoard(context.Background(), database, "Ops", "keep me", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(api.NewRouter(database, "test"))
+	defer srv.Close()
+	writeCLIConfig(t, srv.URL, apiKey)
+
+	runOut := func(args ...string) string {
+		t.Helper()
+		out, err := captureStdout(t, func() error { return run(args) })
+		if err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out
+	}
+
+	if got := runOut("boards", "update", "ops", "--name", "Operations", "--sort-order", "2", "--template", "{{.name}}/{{.description}}/{{.sort_order}}"); got != "Operations/keep me/2\n" {
+		t.Fatalf("boards update = %q", got)
+	}
+	if _, err := captureStdout(t, func() error { return run([]string{"boards", "update", "ops"}) }); err == nil {
+		t.Fatal("boards update without fields should fail")
+	}
+	runOut("boards", "archive", "ops")
+	if got := runOut("boards", "list", "--jsonpath", ".boards[*].id"); strings.Contains(got, "ops") {
+		t.Fatalf("archived board listed: %q", got)
+	}
+	if got := runOut("boards", "list", "--all", "--jsonpath", ".boards[*].id"); !strings.Contains(got, "ops\n") {
+		t.Fatalf("boards list --all = %q", got)
+	}
+	if got := runOut("boards", "archive", "ops", "--undo", "--jsonpath", ".archived"); got != "false\n" {
+		t.Fatalf("boards archive --undo = %q", got)
+	}
+	if got := runOut("boards", "delete", "ops", "--quiet"); got != "ops\n" {
+		t.Fatalf("boards delete = %q", got)
+	}
+}
+
+func TestTagsCommandsRenameMergeAndAlias(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "tags.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	if err := db.ApplyMigrations(database); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := db.CreateAgent(ctx, database, "root", "admin", auth.HashAPIKey(apiKey), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateBoard(ctx, database, "Ops", "", "", []string{"infra"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(api.NewRouter(database, "test"))
+	defer srv.Close()
+	writeCLIConfig(t, srv.URL, apiKey)
+
+	runOut := func(args ...string) string {
+		t.Helper()
+		out, err := captureStdout(t, func() error { return run(args) })
+		if err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out
+	}
+
+	runOut("posts", "add", "first", "--board", "ops", "--tags", "k8s,infra")
+	runOut("posts", "add", "second", "--board", "ops", "--tags", "kube")
+	if got := runOut("tags", "rename", "k8s", "kubernetes", "--alias", "--jsonpath", ".posts_updated"); got != "1\n" {
+		t.Fatalf("tags rename = %q", got)
+	}
+	runOut("tags", "merge", "kube", "--into", "kubernetes")
+	if got := runOut("tags", "list", "--board", "ops", "--format", "plain"); got != "kubernetes 2\ninfra 1\n" {
+		t.Fatalf("tags list = %q", got)
+	}
+	runOut("tags", "alias", "add", "K", "kubernetes")
+	if got := runOut("tags", "alias", "list", "--quiet"); got != "K\nk8s\n" {
+		t.Fatalf("tags alias list = %q", got)
+	}
+	runOut("tags", "alias", "remove", "K")
+	if got := runOut("boards", "update", "ops", "--restrict-tags", "--add-tags", "kubernetes", "--template", "{{.restrict_tags}} {{len .tags}}"); got != "true 2\n" {
+		t.Fatalf("boards update --restrict-tags = %q", got)
+	}
+	if _, err := captureStdout(t, func() error { return run([]string{"posts", "add", "third", "--board", "ops", "--tags", "random"}) }); err == nil {
+		t.Fatal("post with a tag outside the board's allow-list should fail")
+	}
+}
+
+func TestGroupAddCreatesGroupThenAddsMembers(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "groups.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	if err := db.ApplyMigrations(database); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, name := range []string{"root", "ann", "ben"} {
+		role := "agent"
+		if name == "root" {
+			role = "admin"
+		}
+		hash := auth.HashAPIKey(apiKey + name)
+		if name == "root" {
+			hash = auth.HashAPIKey(apiKey)
+		}
+		if err := db.CreateAgent(ctx, database, name, role, hash, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := httptest.NewServer(api.NewRouter(database, "test"))
+	defer srv.Close()
+	writeCLIConfig(t, srv.URL, apiKey)
+
+	runOut := func(args ...string) string {
+		t.Helper()
+		out, err := captureStdout(t, func() error { return run(args) })
+		if err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out
+	}
+
+	if got := runOut("group", "add", "research", "ann", "--description", "R&D", "--template", "{{.description}} {{.members}}"); got != "R&D [ann]\n" {
+		t.Fatalf("group add (create) = %q", got)
+	}
+	if got := runOut("group", "add", "research", "ben", "--template", "{{.members}}"); got != "[ann ben]\n" {
+		t.Fatalf("group add (existing) = %q", got)
+	}
+	if got := runOut("group", "remove", "research", "ann", "--template", "{{.members}}"); got != "[ben]\n" {
+		t.Fatalf("group remove member = %q", got)
+	}
+	if got := runOut("group", "list", "--format", "plain"); got != "research ben\n" {
+		t.Fatalf("group list = %q", got)
+	}
+	runOut("group", "remove", "research")
+	if got := runOut("group", "list", "--quiet"); got != "" {
+		t.Fatalf("group list after delete = %q", got)
+	}
+}
@@ -13,6 +13,8 @@ type exportRequest struct {
 	Format   string `json:"format"`
 	ThreadID string `json:"thread_id,omitempty"`
 	Since    string `json:"since,omitempty"`
+	// IncludeDirectMessages adds private conversations to JSON exports.
+	IncludeDirectMessages bool `json:"include_direct_messages,omitempty"`
 }
 
 func adminExportHandler(database *sql.DB) http.Handler {
@@ -32,7 +34,8 @@ func adminExportHandler(database *sql.DB) http.Handler {
 		}
 
 		opts := db.ExportOptions{
-			ThreadID: strings.TrimSpace(req.ThreadID),
+			ThreadID:              strings.TrimSpace(req.ThreadID),
+			IncludeDirectMessages: req.IncludeDirectMessages,
 		}
 		if strings.TrimSpace(req.Since) != "" {
 			since, err := parseSince(strings.TrimSpace(req.Since))
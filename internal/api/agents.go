@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"fora/internal/auth"
 	"fora/internal/db"
@@ -27,6 +28,17 @@ type createAgentResponse struct {
 	APIKey string `json:"api_key"`
 }
 
+type agentStateRequest struct {
+	State  string `json:"state"`
+	Reason string `json:"reason"`
+	// Until ends a suspension: an RFC3339 time or a duration such as 72h.
+	Until string `json:"until"`
+}
+
+type reassignContentRequest struct {
+	To string `json:"to"`
+}
+
 type agentDetailResponse struct {
 	models.Agent
 	Stats *models.AgentStats `json:"stats"`
@@ -68,6 +80,13 @@ func agentsCollectionHandler(database *sql.DB) http.Handler {
 				writeError(w, http.StatusInternalServerError, "failed to generate api key")
 				return
 			}
+			if isGroup, err := db.GroupExists(r.Context(), database, req.Name); err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to create agent")
+				return
+			} else if isGroup {
+				writeError(w, http.StatusConflict, "name is taken by an agent group")
+				return
+			}
 			if err := db.CreateAgent(
 				r.Context(),
 				database,
@@ -102,6 +121,10 @@ func agentItemHandler(database *sql.DB) http.Handler {
 			writeError(w, http.StatusBadRequest, "missing agent name")
 			return
 		}
+		if agentName, action, ok := strings.Cut(name, "/"); ok {
+			agentActionHandler(w, r, database, agentName, action)
+			return
+		}
 
 		switch r.Method {
 		case http.MethodGet:
@@ -161,3 +184,120 @@ func agentItemHandler(database *sql.DB) http.Handler {
 		}
 	})
 }
+
+// agentActionHandler serves PATCH /agents/{name}/state and POST
+// /agents/{name}/reassign and /agents/{name}/anonymize.
+func agentActionHandler(w http.ResponseWriter, r *http.Request, database *sql.DB, name, action string) {
+	method := http.MethodPost
+	if action == "state" {
+		method = http.MethodPatch
+	}
+	switch action {
+	case "state", "reassign", "anonymize":
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != method {
+		methodNotAllowed(w)
+		return
+	}
+	agent, err := db.GetAgent(r.Context(), database, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "agent not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to read agent")
+		return
+	}
+
+	switch action {
+	case "state":
+		var req agentStateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json payload")
+			return
+		}
+		var until *time.Time
+		if raw := strings.TrimSpace(req.Until); raw != "" {
+			t, err := parseUntil(raw)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "until must be an RFC3339 time or a duration like 72h")
+				return
+			}
+			until = &t
+		}
+		if state := strings.ToLower(strings.TrimSpace(req.State)); state != db.AgentActive && agent.State == db.AgentActive {
+			if caller := currentAgent(r.Context()); caller != nil && caller.Name == agent.Name {
+				writeError(w, http.StatusConflict, "cannot suspend or deactivate yourself")
+				return
+			}
+			if agent.Role == "admin" {
+				adminCount, err := db.CountAdmins(r.Context(), database)
+				if err != nil {
+					writeError(w, http.StatusInternalServerError, "failed to validate admin state change")
+					return
+				}
+				if adminCount <= 1 {
+					writeError(w, http.StatusConflict, "cannot suspend or deactivate the last admin")
+					return
+				}
+			}
+		}
+		updated, err := db.SetAgentState(r.Context(), database, name, req.State, req.Reason, until)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				writeError(w, http.StatusNotFound, "agent not found")
+			case errors.Is(err, db.ErrInvalidAgentState), errors.Is(err, db.ErrInvalidStateChange):
+				writeError(w, http.StatusBadRequest, err.Error())
+			default:
+				writeError(w, http.StatusInternalServerError, "failed to update agent state")
+			}
+			return
+		}
+		writeJSON(w, http.StatusOK, updated)
+	case "reassign", "anonymize":
+		to := db.AnonymousAgent
+		var moved int
+		if action == "reassign" {
+			var req reassignContentRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid json payload")
+				return
+			}
+			if to = strings.TrimSpace(req.To); to == "" {
+				writeError(w, http.StatusBadRequest, "to is required")
+				return
+			}
+			moved, err = db.ReassignAgentContent(r.Context(), database, name, to)
+		} else {
+			if name == db.AnonymousAgent {
+				writeError(w, http.StatusBadRequest, "the anonymous agent cannot be anonymized")
+				return
+			}
+			moved, err = db.AnonymizeAgentContent(r.Context(), database, name)
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrAnonymousNameTaken):
+				writeError(w, http.StatusConflict, err.Error())
+			case errors.Is(err, db.ErrUnknownAgent), errors.Is(err, db.ErrSameAgent):
+				writeError(w, http.StatusBadRequest, err.Error())
+			default:
+				writeError(w, http.StatusInternalServerError, "failed to reassign content")
+			}
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"from": name, "to": to, "content_updated": moved})
+	}
+}
+
+// parseUntil reads an absolute RFC3339 time or a duration from now.
+func parseUntil(raw string) (time.Time, error) {
+	if d, err := time.ParseDuration(raw); err == nil {
+		return time.Now().UTC().Add(d), nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
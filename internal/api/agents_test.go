@@ -2,6 +2,8 @@ package api
 
 import (
 	"net/http"
+	"slices"
+	"strings"
 	"testing"
 
 	"fora/internal/models"
@@ -140,3 +142,189 @@ func TestAgentItemIncludesDerivedStats(t *testing.T) {
 		t.Fatalf("expected recent_notification_at in stats: %+v", payload.Stats)
 	}
 }
+
+func TestAgentSuspendReactivateAndLapsedSuspension(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	workerKey := createAgentForTest(t, database, "worker-s", "agent")
+	postResp := doReq(t, server.URL, workerKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Before suspension", "body": "still readable", "board_id": "general",
+	})
+	if postResp.StatusCode != http.StatusCreated {
+		t.Fatalf("create post status = %d", postResp.StatusCode)
+	}
+	post := decodeContent(t, postResp)
+
+	self := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/agents/admin/state", map[string]any{"state": "suspended"})
+	if self.StatusCode != http.StatusConflict {
+		t.Fatalf("suspend self status = %d, want 409", self.StatusCode)
+	}
+	_ = self.Body.Close()
+
+	suspend := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/agents/worker-s/state", map[string]any{
+		"state": "suspended", "reason": "runaway posting", "until": "72h",
+	})
+	var suspended models.Agent
+	decodeJSON(t, suspend, &suspended)
+	if suspended.State != "suspended" || suspended.StateUntil == nil || suspended.StateReason == nil {
+		t.Fatalf("unexpected suspended agent: %+v", suspended)
+	}
+
+	denied := doReq(t, server.URL, workerKey, http.MethodGet, "/api/v1/posts", nil)
+	var deniedBody map[string]string
+	if denied.StatusCode != http.StatusForbidden {
+		t.Fatalf("suspended agent status = %d, want 403", denied.StatusCode)
+	}
+	decodeJSON(t, denied, &deniedBody)
+	if !strings.Contains(deniedBody["error"], "suspended until") || !strings.Contains(deniedBody["error"], "runaway posting") {
+		t.Fatalf("unexpected denial message: %q", deniedBody["error"])
+	}
+
+	read := doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/posts/"+post.ID, nil)
+	if got := decodeContent(t, read); got.Author != "worker-s" || got.Body != "still readable" {
+		t.Fatalf("suspended agent's post should stay readable and attributed: %+v", got)
+	}
+
+	if _, err := database.Exec(`UPDATE agents SET state_until = '2000-01-01T00:00:00Z' WHERE name = 'worker-s'`); err != nil {
+		t.Fatalf("expire suspension: %v", err)
+	}
+	lapsed := doReq(t, server.URL, workerKey, http.MethodGet, "/api/v1/posts", nil)
+	if lapsed.StatusCode != http.StatusOK {
+		t.Fatalf("lapsed suspension status = %d, want 200", lapsed.StatusCode)
+	}
+	_ = lapsed.Body.Close()
+
+	deactivate := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/agents/worker-s/state", map[string]any{"state": "deactivated"})
+	_ = deactivate.Body.Close()
+	denied = doReq(t, server.URL, workerKey, http.MethodGet, "/api/v1/posts", nil)
+	if denied.StatusCode != http.StatusForbidden {
+		t.Fatalf("deactivated agent status = %d, want 403", denied.StatusCode)
+	}
+	_ = denied.Body.Close()
+
+	reactivate := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/agents/worker-s/state", map[string]any{"state": "active"})
+	var active models.Agent
+	decodeJSON(t, reactivate, &active)
+	if active.State != "active" || active.StateReason != nil {
+		t.Fatalf("unexpected reactivated agent: %+v", active)
+	}
+	allowed := doReq(t, server.URL, workerKey, http.MethodGet, "/api/v1/posts", nil)
+	if allowed.StatusCode != http.StatusOK {
+		t.Fatalf("reactivated agent status = %d, want 200", allowed.StatusCode)
+	}
+	_ = allowed.Body.Close()
+
+	for _, body := range []map[string]any{
+		{"state": "paused"},
+		{"state": "suspended", "reason": strings.Repeat("x", 501)},
+		{"state": "suspended", "until": "-1h"},
+	} {
+		bad := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/agents/worker-s/state", body)
+		if bad.StatusCode != http.StatusBadRequest {
+			t.Fatalf("invalid state change %v status = %d, want 400", body, bad.StatusCode)
+		}
+		_ = bad.Body.Close()
+	}
+}
+
+func TestAgentReassignAndAnonymizeContent(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	oldKey := createAgentForTest(t, database, "old-bot", "agent")
+	newKey := createAgentForTest(t, database, "new-bot", "agent")
+
+	post := decodeContent(t, doReq(t, server.URL, oldKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Handover", "body": "old work", "board_id": "general",
+	}))
+	reply := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts/"+post.ID+"/replies", map[string]any{"body": "noted"})
+	_ = reply.Body.Close()
+
+	var dm struct {
+		Conversation models.Conversation `json:"conversation"`
+	}
+	decodeJSON(t, doReq(t, server.URL, oldKey, http.MethodPost, "/api/v1/conversations", map[string]any{
+		"to": []string{"admin"}, "body": "handing this over privately",
+	}), &dm)
+	poll := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Next step", "body": "pick one", "board_id": "general",
+		"poll": map[string]any{"options": []string{"ship", "wait"}},
+	}))
+	pollPath := "/api/v1/posts/" + poll.ID + "/poll"
+	for _, vote := range []struct {
+		key    string
+		option int
+	}{{oldKey, 1}, {newKey, 2}} {
+		resp := doReq(t, server.URL, vote.key, http.MethodPost, pollPath+"/vote", map[string]any{"options": []int{vote.option}})
+		_ = resp.Body.Close()
+	}
+
+	missing := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/agents/old-bot/reassign", map[string]any{"to": "nobody"})
+	if missing.StatusCode != http.StatusBadRequest {
+		t.Fatalf("reassign to unknown agent status = %d, want 400", missing.StatusCode)
+	}
+	_ = missing.Body.Close()
+
+	var moved struct {
+		To             string `json:"to"`
+		ContentUpdated int    `json:"content_updated"`
+	}
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/agents/old-bot/reassign", map[string]any{"to": "new-bot"}), &moved)
+	if moved.To != "new-bot" || moved.ContentUpdated != 1 {
+		t.Fatalf("unexpected reassign response: %+v", moved)
+	}
+	var list struct {
+		Threads []models.ThreadListItem `json:"threads"`
+	}
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/posts?author=new-bot", nil), &list)
+	if len(list.Threads) != 1 || list.Threads[0].ID != post.ID {
+		t.Fatalf("new-bot should own the thread: %+v", list.Threads)
+	}
+	for _, p := range list.Threads[0].Participants {
+		if p == "old-bot" {
+			t.Fatalf("participants still list old-bot: %v", list.Threads[0].Participants)
+		}
+	}
+	// new-bot had already voted, so old-bot's vote is merged away.
+	var tally models.Poll
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, pollPath, nil), &tally)
+	if tally.TotalVoters != 1 || tally.Options[0].Votes != 0 || !slices.Equal(tally.Options[1].Voters, []string{"new-bot"}) {
+		t.Fatalf("unexpected tally after reassign: %+v", tally)
+	}
+
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/agents/new-bot/anonymize", nil), &moved)
+	if moved.To != "anonymous" || moved.ContentUpdated != 1 {
+		t.Fatalf("unexpected anonymize response: %+v", moved)
+	}
+	if got := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/posts/"+post.ID, nil)); got.Author != "anonymous" {
+		t.Fatalf("post author = %q, want anonymous", got.Author)
+	}
+	var anon models.Agent
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/agents/anonymous", nil), &anon)
+	if anon.State != "deactivated" {
+		t.Fatalf("anonymous agent state = %q, want deactivated", anon.State)
+	}
+	again := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/agents/anonymous/anonymize", nil)
+	if again.StatusCode != http.StatusBadRequest {
+		t.Fatalf("anonymizing the anonymous agent status = %d, want 400", again.StatusCode)
+	}
+	_ = again.Body.Close()
+	same := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/agents/anonymous/reassign", map[string]any{"to": "anonymous"})
+	if same.StatusCode != http.StatusBadRequest {
+		t.Fatalf("reassigning to the same agent status = %d, want 400", same.StatusCode)
+	}
+	_ = same.Body.Close()
+
+	var conv models.Conversation
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/conversations/"+dm.Conversation.ID, nil), &conv)
+	if !slices.Equal(conv.Members, []string{"admin", "anonymous"}) || len(conv.Messages) != 1 || conv.Messages[0].Author != "anonymous" {
+		t.Fatalf("direct message still attributed to the agent: %+v", conv)
+	}
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, pollPath, nil), &tally)
+	if !slices.Equal(tally.Options[1].Voters, []string{"anonymous"}) {
+		t.Fatalf("poll vote still attributed to the agent: %+v", tally.Options)
+	}
+}
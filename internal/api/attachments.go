@@ -0,0 +1,428 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"fora/internal/db"
+	"fora/internal/models"
+)
+
+// DefaultAttachmentMaxBytes is the per-file upload limit when Options does
+// not set one.
+const DefaultAttachmentMaxBytes = 10 << 20
+
+// attachmentStore keeps attachment bytes in SQLite, or as files named after
+// the attachment ID under dir when one is configured.
+type attachmentStore struct {
+	database *sql.DB
+	dir      string
+	maxBytes int64
+}
+
+func newAttachmentStore(database *sql.DB, opts AttachmentOptions) *attachmentStore {
+	s := &attachmentStore{database: database, dir: opts.Dir, maxBytes: opts.MaxBytes}
+	if s.maxBytes <= 0 {
+		s.maxBytes = DefaultAttachmentMaxBytes
+	}
+	return s
+}
+
+func (s *attachmentStore) save(ctx context.Context, a models.Attachment, data []byte) (*models.Attachment, error) {
+	if s.dir == "" {
+		return db.CreateAttachment(ctx, s.database, a, data, "")
+	}
+	id, err := db.GenerateAttachmentID()
+	if err != nil {
+		return nil, err
+	}
+	a.ID = id
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	path := filepath.Join(s.dir, id)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	created, err := db.CreateAttachment(ctx, s.database, a, nil, id)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *attachmentStore) open(ctx context.Context, id string) (*models.Attachment, io.ReadSeekCloser, error) {
+	a, data, key, err := db.GetAttachment(ctx, s.database, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key == "" {
+		return a, nopSeekCloser{bytes.NewReader(data)}, nil
+	}
+	if s.dir == "" {
+		return nil, nil, errors.New("attachment is stored on disk but no attachments directory is configured")
+	}
+	f, err := os.Open(filepath.Join(s.dir, filepath.Base(key)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, f, nil
+}
+
+// attachmentFileName matches the names save gives attachment files. The
+// sweeper never touches anything else, so a directory shared with other files
+// is safe.
+var attachmentFileName = regexp.MustCompile(`^att_[0-9a-f]{16}$`)
+
+// remove deletes the files behind keys once their rows are gone.
+func (s *attachmentStore) remove(keys []string) {
+	for _, k := range keys {
+		if attachmentFileName.MatchString(k) {
+			os.Remove(filepath.Join(s.dir, k))
+		}
+	}
+}
+
+// SweepAttachmentFiles removes attachment files under dir whose rows are
+// gone, e.g. after a crash between writing a file and recording it. Only
+// files named like attachments (and abandoned upload temp files) are
+// considered, and recent ones are kept: an upload writes its file before
+// inserting the row.
+func SweepAttachmentFiles(ctx context.Context, database *sql.DB, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	keys, err := db.AttachmentStorageKeys(ctx, database)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-time.Hour)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || keys[name] {
+			continue
+		}
+		if !attachmentFileName.MatchString(name) && !strings.HasPrefix(name, ".upload-") {
+			continue
+		}
+		if info, err := e.Info(); err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		os.Remove(filepath.Join(dir, name))
+	}
+	return nil
+}
+
+// RunAttachmentSweeper runs SweepAttachmentFiles every interval until ctx is
+// done.
+func RunAttachmentSweeper(ctx context.Context, database *sql.DB, dir string, interval time.Duration) {
+	if dir == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := SweepAttachmentFiles(ctx, database, dir); err != nil && ctx.Err() == nil {
+			log.Printf("sweep attachment files: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type nopSeekCloser struct {
+	*bytes.Reader
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+// pruneAfterDelete removes the attachment files of the content (and its
+// replies) deleted by a DELETE handled by next.
+func pruneAfterDelete(store *attachmentStore, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || store.dir == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id := path.Base(strings.TrimSuffix(r.URL.Path, "/"))
+		keys, err := db.SubtreeAttachmentStorageKeys(r.Context(), store.database, id)
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if err == nil && rec.status >= 200 && rec.status < 300 {
+			store.remove(keys)
+		}
+	})
+}
+
+func postAttachmentsHandler(database *sql.DB, store *attachmentStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1/posts/")
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		if len(parts) != 2 || parts[1] != "attachments" || parts[0] == "" {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		contentID := parts[0]
+		content, err := db.GetContent(r.Context(), database, contentID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeError(w, http.StatusNotFound, "content not found")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "failed to load content")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			list, err := db.ListAttachments(r.Context(), database, contentID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to list attachments")
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"attachments": list})
+		case http.MethodPost:
+			agent := currentAgent(r.Context())
+			if agent == nil {
+				writeError(w, http.StatusUnauthorized, "missing auth context")
+				return
+			}
+			if content.Author != agent.Name && agent.Role != "admin" {
+				writeError(w, http.StatusForbidden, "not allowed to attach files to this content")
+				return
+			}
+			filename, declared, data, status, err := readUpload(w, r, store.maxBytes)
+			if err != nil {
+				writeError(w, status, err.Error())
+				return
+			}
+			sum := sha256.Sum256(data)
+			created, err := store.save(r.Context(), models.Attachment{
+				ContentID:   contentID,
+				Filename:    filename,
+				ContentType: attachmentContentType(filename, declared, data),
+				Size:        int64(len(data)),
+				SHA256:      hex.EncodeToString(sum[:]),
+				Uploader:    agent.Name,
+			}, data)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to store attachment")
+				return
+			}
+			writeJSON(w, http.StatusCreated, created)
+		default:
+			methodNotAllowed(w)
+		}
+	})
+}
+
+// readUpload accepts either a multipart/form-data body with a "file" part or
+// the raw file as the body, named by ?filename= or Content-Disposition.
+func readUpload(w http.ResponseWriter, r *http.Request, maxBytes int64) (string, string, []byte, int, error) {
+	tooLarge := fmt.Errorf("attachment exceeds %d bytes", maxBytes)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var (
+		filename string
+		declared string
+		src      io.Reader
+	)
+	if mediaType == "multipart/form-data" {
+		// Leave room for part headers and boundaries.
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10)
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return "", "", nil, http.StatusBadRequest, errors.New("invalid multipart body")
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return "", "", nil, http.StatusBadRequest, errors.New(`multipart body has no "file" part`)
+			}
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					return "", "", nil, http.StatusRequestEntityTooLarge, tooLarge
+				}
+				return "", "", nil, http.StatusBadRequest, errors.New("invalid multipart body")
+			}
+			if part.FormName() == "file" {
+				filename = part.FileName()
+				declared = part.Header.Get("Content-Type")
+				src = part
+				break
+			}
+		}
+	} else {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		filename = r.URL.Query().Get("filename")
+		if filename == "" {
+			if _, params, err := mime.ParseMediaType(r.Header.Get("Content-Disposition")); err == nil {
+				filename = params["filename"]
+			}
+		}
+		declared = r.Header.Get("Content-Type")
+		src = r.Body
+	}
+	data, err := io.ReadAll(io.LimitReader(src, maxBytes+1))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return "", "", nil, http.StatusRequestEntityTooLarge, tooLarge
+		}
+		return "", "", nil, http.StatusBadRequest, errors.New("failed to read attachment")
+	}
+	if int64(len(data)) > maxBytes {
+		return "", "", nil, http.StatusRequestEntityTooLarge, tooLarge
+	}
+	if len(data) == 0 {
+		return "", "", nil, http.StatusBadRequest, errors.New("attachment is empty")
+	}
+	return cleanFilename(filename), declared, data, 0, nil
+}
+
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if r := []rune(name); len(r) > 200 {
+		name = string(r[:200])
+	}
+	return name
+}
+
+// attachmentContentType trusts the sniffed type unless sniffing only says
+// "some text" or "some bytes"; then the file extension, and after it the
+// declared type, may refine it as long as they agree on text vs binary.
+func attachmentContentType(filename, declared string, data []byte) string {
+	sniffed := http.DetectContentType(data)
+	sniffedText := strings.HasPrefix(sniffed, "text/plain")
+	if !sniffedText && sniffed != "application/octet-stream" {
+		return sniffed
+	}
+	for _, candidate := range []string{mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))), declared} {
+		mt, _, err := mime.ParseMediaType(candidate)
+		if err != nil || mt == "application/octet-stream" || strings.HasPrefix(mt, "multipart/") {
+			continue
+		}
+		if isTextMediaType(mt) != sniffedText {
+			continue
+		}
+		if sniffedText {
+			return mt + "; charset=utf-8"
+		}
+		return mt
+	}
+	return sniffed
+}
+
+func isTextMediaType(mt string) bool {
+	switch {
+	case strings.HasPrefix(mt, "text/"),
+		strings.HasSuffix(mt, "+json"), strings.HasSuffix(mt, "+xml"),
+		mt == "application/json", mt == "application/xml", mt == "application/x-ndjson",
+		mt == "application/yaml", mt == "application/x-yaml", mt == "application/javascript":
+		return true
+	}
+	return false
+}
+
+func attachmentItemHandler(store *attachmentStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		id := pathTail(r.URL.Path, "/api/v1/attachments/")
+		if id == "" || strings.Contains(id, "/") {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		a, body, err := store.open(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) || errors.Is(err, os.ErrNotExist) {
+				writeError(w, http.StatusNotFound, "attachment not found")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "failed to read attachment")
+			return
+		}
+		defer body.Close()
+
+		h := w.Header()
+		h.Set("Content-Type", a.ContentType)
+		h.Set("X-Content-Type-Options", "nosniff")
+		// Uploaded HTML or SVG must never run in the API's origin.
+		h.Set("Content-Security-Policy", "sandbox")
+		disposition := "attachment"
+		if inlineSafe(a.ContentType) {
+			disposition = "inline"
+		}
+		if v := mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}); v != "" {
+			h.Set("Content-Disposition", v)
+		} else {
+			h.Set("Content-Disposition", disposition)
+		}
+		h.Set("ETag", `"`+a.SHA256+`"`)
+		h.Set("Cache-Control", "private, no-cache")
+		created, _ := time.Parse(time.RFC3339, a.Created)
+		http.ServeContent(w, r, "", created, body)
+	})
+}
+
+func inlineSafe(contentType string) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch mt {
+	case "image/png", "image/jpeg", "image/gif", "image/webp", "text/plain", "application/json", "application/pdf":
+		return true
+	}
+	return false
+}
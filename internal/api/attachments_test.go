@@ -0,0 +1,193 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fora/internal/models"
+)
+
+func upload(t *testing.T, baseURL, apiKey, path, contentType string, body []byte) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestAttachmentUploadDownloadAndRawThread(t *testing.T) {
+	_, database, adminKey := setupTestServer(t)
+	defer database.Close()
+	server := httptest.NewServer(NewRouterWithOptions(database, "test", Options{
+		Attachments: AttachmentOptions{MaxBytes: 64},
+	}))
+	defer server.Close()
+	bobKey := createAgentForTest(t, database, "bob", "agent")
+
+	post := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Report", "body": "see attached", "board_id": "general",
+	}))
+	path := "/api/v1/posts/" + post.ID + "/attachments"
+
+	// Raw body named by query; the declared type is ignored in favour of the
+	// sniffed one.
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 16)...)
+	resp := upload(t, server.URL, adminKey, path+"?filename=../chart.png", "text/html", png)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("raw upload status = %d", resp.StatusCode)
+	}
+	var img models.Attachment
+	decodeJSON(t, resp, &img)
+	if img.Filename != "chart.png" || img.ContentType != "image/png" || img.Size != int64(len(png)) || img.Uploader != "admin" {
+		t.Fatalf("unexpected attachment: %+v", img)
+	}
+
+	// Multipart upload; sniffing says text, the extension refines it.
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, _ := mw.CreateFormFile("file", "data.json")
+	part.Write([]byte(`{"ok":true}`))
+	mw.Close()
+	resp = upload(t, server.URL, adminKey, path, mw.FormDataContentType(), buf.Bytes())
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("multipart upload status = %d", resp.StatusCode)
+	}
+	var doc models.Attachment
+	decodeJSON(t, resp, &doc)
+	if doc.ContentType != "application/json; charset=utf-8" {
+		t.Fatalf("json content type = %q", doc.ContentType)
+	}
+
+	for name, tc := range map[string]struct {
+		key    string
+		body   []byte
+		status int
+	}{
+		"too large":  {adminKey, bytes.Repeat([]byte("x"), 65), http.StatusRequestEntityTooLarge},
+		"empty":      {adminKey, nil, http.StatusBadRequest},
+		"not author": {bobKey, []byte("hi"), http.StatusForbidden},
+	} {
+		resp := upload(t, server.URL, tc.key, path+"?filename=a.txt", "text/plain", tc.body)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Fatalf("%s: status = %d, want %d", name, resp.StatusCode, tc.status)
+		}
+	}
+
+	var listed struct {
+		Attachments []models.Attachment `json:"attachments"`
+	}
+	decodeJSON(t, doReq(t, server.URL, bobKey, http.MethodGet, path, nil), &listed)
+	if len(listed.Attachments) != 2 || listed.Attachments[0].ID != img.ID {
+		t.Fatalf("unexpected list: %+v", listed.Attachments)
+	}
+
+	resp = doReq(t, server.URL, bobKey, http.MethodGet, img.URL, nil)
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(got, png) {
+		t.Fatalf("download status = %d, %d bytes", resp.StatusCode, len(got))
+	}
+	h := resp.Header
+	if h.Get("Content-Type") != "image/png" || h.Get("X-Content-Type-Options") != "nosniff" ||
+		h.Get("Content-Disposition") != `inline; filename=chart.png` || h.Get("ETag") != `"`+img.SHA256+`"` {
+		t.Fatalf("unexpected download headers: %v", h)
+	}
+	if resp := doReq(t, server.URL, "", http.MethodGet, img.URL, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("anonymous download status = %d", resp.StatusCode)
+	}
+
+	resp = doReq(t, server.URL, bobKey, http.MethodGet, "/api/v1/posts/"+post.ID+"/thread?format=raw", nil)
+	raw, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(raw), "- [chart.png]("+img.URL+") (image/png, 24 B)") || strings.Contains(string(raw), "PNG") {
+		t.Fatalf("raw thread should reference, not inline, attachments:\n%s", raw)
+	}
+}
+
+func TestAttachmentDirectoryStorageIsPrunedOnDelete(t *testing.T) {
+	_, database, adminKey := setupTestServer(t)
+	defer database.Close()
+	dir := filepath.Join(t.TempDir(), "files")
+	server := httptest.NewServer(NewRouterWithOptions(database, "test", Options{
+		Attachments: AttachmentOptions{Dir: dir},
+	}))
+	defer server.Close()
+
+	post := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Logs", "body": "root", "board_id": "general",
+	}))
+	reply := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts/"+post.ID+"/replies", map[string]any{
+		"body": "trace attached",
+	}))
+	trace := []byte{0, 1, 2, 3, 0xff}
+	resp := upload(t, server.URL, adminKey, "/api/v1/posts/"+reply.ID+"/attachments?filename=trace.bin", "application/octet-stream", trace)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("upload status = %d", resp.StatusCode)
+	}
+	var a models.Attachment
+	decodeJSON(t, resp, &a)
+	stored := filepath.Join(dir, a.ID)
+	if b, err := os.ReadFile(stored); err != nil || !bytes.Equal(b, trace) {
+		t.Fatalf("attachment file: %q, %v", b, err)
+	}
+
+	resp = doReq(t, server.URL, adminKey, http.MethodGet, a.URL, nil)
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !bytes.Equal(got, trace) || resp.Header.Get("Content-Disposition") != "attachment; filename=trace.bin" {
+		t.Fatalf("download: %q %v", got, resp.Header)
+	}
+
+	// Files in the directory that fora did not write are never touched, even
+	// when they are old.
+	old := time.Now().Add(-2 * time.Hour)
+	foreign := filepath.Join(dir, "backup.db")
+	orphan := filepath.Join(dir, "att_00112233445566ff")
+	for _, p := range []string{foreign, orphan} {
+		if err := os.WriteFile(p, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodDelete, "/api/v1/replies/"+reply.ID, nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete reply status = %d", resp.StatusCode)
+	}
+	if _, err := os.Stat(stored); !os.IsNotExist(err) {
+		t.Fatalf("attachment file should be removed with its reply: %v", err)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodGet, a.URL, nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("download after delete status = %d", resp.StatusCode)
+	}
+	if _, err := os.Stat(orphan); err != nil {
+		t.Fatalf("deleting a reply removed an unrelated attachment file: %v", err)
+	}
+
+	if err := SweepAttachmentFiles(context.Background(), database, dir); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Fatalf("sweep should remove the orphaned attachment file: %v", err)
+	}
+	if _, err := os.Stat(foreign); err != nil {
+		t.Fatalf("sweep removed a file it does not own: %v", err)
+	}
+}
@@ -3,12 +3,24 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
 	"fora/internal/db"
 )
 
+type updateBoardRequest struct {
+	Name         *string  `json:"name"`
+	Description  *string  `json:"description"`
+	Icon         *string  `json:"icon"`
+	SortOrder    *int     `json:"sort_order"`
+	Archived     *bool    `json:"archived"`
+	RestrictTags *bool    `json:"restrict_tags"`
+	AddTags      []string `json:"add_tags"`
+	RemoveTags   []string `json:"remove_tags"`
+}
+
 type createBoardRequest struct {
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
@@ -20,11 +32,19 @@ func boardsHandler(database *sql.DB) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
+			includeArchived, err := parseBool(r.URL.Query().Get("include_archived"))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid include_archived value")
+				return
+			}
 			boards, err := db.ListBoards(r.Context(), database)
 			if err != nil {
 				writeError(w, http.StatusInternalServerError, "failed to list boards")
 				return
 			}
+			if !includeArchived {
+				boards = activeBoards(boards)
+			}
 			writeJSON(w, http.StatusOK, map[string]any{"boards": boards})
 		case http.MethodPost:
 			agent := currentAgent(r.Context())
@@ -52,28 +72,95 @@ func boardsHandler(database *sql.DB) http.Handler {
 
 func boardItemHandler(database *sql.DB) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			methodNotAllowed(w)
-			return
-		}
 		id := pathTail(r.URL.Path, "/api/v1/boards/")
 		if id == "" || strings.Contains(id, "/") {
 			writeError(w, http.StatusNotFound, "not found")
 			return
 		}
-		board, err := db.GetBoard(r.Context(), database, id)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				writeError(w, http.StatusNotFound, "board not found")
+		switch r.Method {
+		case http.MethodGet:
+			board, err := db.GetBoard(r.Context(), database, id)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					writeError(w, http.StatusNotFound, "board not found")
+					return
+				}
+				writeError(w, http.StatusInternalServerError, "failed to load board")
 				return
 			}
-			writeError(w, http.StatusInternalServerError, "failed to load board")
-			return
+			writeJSON(w, http.StatusOK, board)
+		case http.MethodPatch:
+			agent := currentAgent(r.Context())
+			if agent == nil || agent.Role != "admin" {
+				writeError(w, http.StatusForbidden, "admin role required")
+				return
+			}
+			var req updateBoardRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid json payload")
+				return
+			}
+			board, err := db.UpdateBoard(r.Context(), database, id, db.BoardUpdate{
+				Name:         req.Name,
+				Description:  req.Description,
+				Icon:         req.Icon,
+				SortOrder:    req.SortOrder,
+				Archived:     req.Archived,
+				RestrictTags: req.RestrictTags,
+				AddTags:      req.AddTags,
+				RemoveTags:   req.RemoveTags,
+			})
+			if err != nil {
+				switch {
+				case errors.Is(err, sql.ErrNoRows):
+					writeError(w, http.StatusNotFound, "board not found")
+				case errors.Is(err, db.ErrBoardNameTaken):
+					writeError(w, http.StatusConflict, err.Error())
+				case errors.Is(err, db.ErrBoardNameReserved):
+					writeError(w, http.StatusBadRequest, err.Error())
+				case req.Name != nil && strings.TrimSpace(*req.Name) == "":
+					writeError(w, http.StatusBadRequest, err.Error())
+				default:
+					writeError(w, http.StatusInternalServerError, "failed to update board")
+				}
+				return
+			}
+			writeJSON(w, http.StatusOK, board)
+		case http.MethodDelete:
+			agent := currentAgent(r.Context())
+			if agent == nil || agent.Role != "admin" {
+				writeError(w, http.StatusForbidden, "admin role required")
+				return
+			}
+			if err := db.DeleteBoard(r.Context(), database, id); err != nil {
+				switch {
+				case errors.Is(err, sql.ErrNoRows):
+					writeError(w, http.StatusNotFound, "board not found")
+				case errors.Is(err, db.ErrBoardNotEmpty):
+					writeError(w, http.StatusConflict, "board still has posts; archive it instead")
+				default:
+					writeError(w, http.StatusInternalServerError, "failed to delete board")
+				}
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			methodNotAllowed(w)
 		}
-		writeJSON(w, http.StatusOK, board)
 	})
 }
 
+// activeBoards drops archived boards from a listing.
+func activeBoards(boards []db.Board) []db.Board {
+	out := make([]db.Board, 0, len(boards))
+	for _, b := range boards {
+		if !b.Archived {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
 func boardSubscriptionHandler(database *sql.DB) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		agent := currentAgent(r.Context())
@@ -116,3 +203,39 @@ func boardSubscriptionHandler(database *sql.DB) http.Handler {
 		}
 	})
 }
+
+// boardSuggestTagsHandler serves GET /api/v1/boards/{id}/suggest-tags,
+// ranking the board's tag vocabulary against ?text=. Tags listed in ?tags=
+// (already chosen) are left out.
+func boardSuggestTagsHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1/boards/")
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		if len(parts) != 2 || parts[1] != "suggest-tags" || parts[0] == "" {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		boardID := strings.TrimSpace(parts[0])
+		ok, err := db.BoardExists(r.Context(), database, boardID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to validate board")
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusNotFound, "board not found")
+			return
+		}
+		q := r.URL.Query()
+		limit, _ := parseLimitOffset(r)
+		suggestions, err := db.SuggestTags(r.Context(), database, boardID, q.Get("text"), strings.Split(q.Get("tags"), ","), limit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to suggest tags")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"board_id": boardID, "suggestions": suggestions})
+	})
+}
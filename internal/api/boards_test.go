@@ -2,8 +2,11 @@ package api
 
 import (
 	"net/http"
+	"net/url"
 	"slices"
 	"testing"
+
+	"fora/internal/db"
 )
 
 func TestBoardsCRUDAndPostFiltering(t *testing.T) {
@@ -131,3 +134,139 @@ func TestBoardSubscribeUnsubscribe(t *testing.T) {
 	}
 	_ = unsub.Body.Close()
 }
+
+func TestBoardUpdateArchiveReorderAndDelete(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+	userKey := createAgentForTest(t, database, "board-user", "agent")
+
+	for _, name := range []string{"Alpha", "Beta"} {
+		if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/boards", map[string]any{"name": name}); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create %s status = %d", name, resp.StatusCode)
+		}
+	}
+
+	if resp := doReq(t, server.URL, userKey, http.MethodPatch, "/api/v1/boards/alpha", map[string]any{"name": "x"}); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("non-admin patch status = %d", resp.StatusCode)
+	}
+	var board db.Board
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/boards/alpha", map[string]any{
+		"name": "Alpha Team", "description": "renamed", "sort_order": -1,
+	}), &board)
+	if board.ID != "alpha" || board.Name != "Alpha Team" || board.Description != "renamed" || board.SortOrder != -1 {
+		t.Fatalf("unexpected updated board: %+v", board)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/boards/beta", map[string]any{"name": "Alpha Team"}); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("duplicate name status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/boards/beta", map[string]any{"name": " "}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("empty name status = %d", resp.StatusCode)
+	}
+	// "auto" asks for tag routing, so no board may take that ID.
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/boards", map[string]any{"name": "Auto"}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("reserved board name status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/boards/beta", map[string]any{"name": "auto"}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("rename to reserved name status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/boards/missing", map[string]any{"icon": "x"}); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing board status = %d", resp.StatusCode)
+	}
+
+	post := decodeContent(t, doReq(t, server.URL, userKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "t", "body": "before archive", "board_id": "beta",
+	}))
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/boards/beta", map[string]any{"archived": true}), &board)
+	if !board.Archived {
+		t.Fatalf("board should be archived: %+v", board)
+	}
+	if resp := doReq(t, server.URL, userKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "t", "body": "after archive", "board_id": "beta",
+	}); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("post to archived board status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, userKey, http.MethodPost, "/api/v1/posts/"+post.ID+"/replies", map[string]any{"body": "still open"}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("reply in archived board status = %d", resp.StatusCode)
+	}
+
+	listIDs := func(query string) []string {
+		var out struct {
+			Boards []db.Board `json:"boards"`
+		}
+		decodeJSON(t, doReq(t, server.URL, userKey, http.MethodGet, "/api/v1/boards"+query, nil), &out)
+		ids := []string{}
+		for _, b := range out.Boards {
+			ids = append(ids, b.ID)
+		}
+		return ids
+	}
+	if ids := listIDs(""); ids[0] != "alpha" || slices.Contains(ids, "beta") {
+		t.Fatalf("default listing should be sorted and hide archived boards: %v", ids)
+	}
+	if ids := listIDs("?include_archived=true"); !slices.Contains(ids, "beta") {
+		t.Fatalf("include_archived should list archived boards: %v", ids)
+	}
+
+	if resp := doReq(t, server.URL, adminKey, http.MethodDelete, "/api/v1/boards/beta", nil); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("delete non-empty board status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, userKey, http.MethodDelete, "/api/v1/boards/alpha", nil); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("non-admin delete status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodDelete, "/api/v1/boards/alpha", nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete empty board status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/boards/alpha", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("deleted board status = %d", resp.StatusCode)
+	}
+}
+
+func TestBoardSuggestTagsRanksBoardVocabulary(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/boards", map[string]any{
+		"name": "Infra", "tags": []string{"terraform", "load-balancer"},
+	}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create board status = %d", resp.StatusCode)
+	}
+	for i, tags := range [][]string{{"postgres", "outage"}, {"postgres"}} {
+		if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
+			"title": "p", "body": "infra post " + string(rune('a'+i)), "board_id": "infra", "tags": tags,
+		}); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create post status = %d", resp.StatusCode)
+		}
+	}
+
+	suggest := func(query string) []db.TagSuggestion {
+		t.Helper()
+		var out struct {
+			Suggestions []db.TagSuggestion `json:"suggestions"`
+		}
+		decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/boards/infra/suggest-tags"+query, nil), &out)
+		return out.Suggestions
+	}
+	tags := func(in []db.TagSuggestion) []string {
+		out := []string{}
+		for _, s := range in {
+			out = append(out, s.Tag)
+		}
+		return out
+	}
+
+	if got := tags(suggest("")); !slices.Equal(got, []string{"load-balancer", "terraform", "postgres", "outage"}) {
+		t.Fatalf("vocabulary ranking = %v", got)
+	}
+	got := suggest("?text=" + url.QueryEscape("Postgres failover behind the load balancer; postgres lag") + "&tags=outage")
+	if !slices.Equal(tags(got), []string{"postgres", "load-balancer"}) || got[0].Matches != 2 || got[0].Uses != 2 || !got[1].BoardTag {
+		t.Fatalf("text suggestions = %+v", got)
+	}
+	if got := suggest("?text=postgres&tags=Postgres"); len(got) != 0 {
+		t.Fatalf("already chosen tags should be skipped: %+v", got)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/boards/missing/suggest-tags", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing board status = %d", resp.StatusCode)
+	}
+}
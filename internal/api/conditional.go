@@ -0,0 +1,88 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"fora/internal/models"
+)
+
+// writeJSONConditional is writeJSON for cacheable GET responses: it sets
+// ETag and Last-Modified and answers 304 Not Modified when the client's
+// validators still match.
+func writeJSONConditional(w http.ResponseWriter, r *http.Request, v any, lastModified time.Time) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+	writeConditional(w, r, "application/json", append(b, '\n'), lastModified)
+}
+
+func writeConditional(w http.ResponseWriter, r *http.Request, contentType string, body []byte, lastModified time.Time) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	h := w.Header()
+	h.Set("ETag", etag)
+	h.Set("Cache-Control", "private, no-cache")
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// notModified applies If-None-Match, falling back to If-Modified-Since only
+// when no entity tags were sent (RFC 9110 §13.2.2).
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(ims)
+}
+
+// latestChange is the newest created/updated timestamp among items, their
+// attachments and polls.
+func latestChange(items []models.Content) time.Time {
+	var latest time.Time
+	for _, item := range items {
+		stamps := []string{item.Created, item.Updated}
+		for _, a := range item.Attachments {
+			stamps = append(stamps, a.Created)
+		}
+		if item.Poll != nil {
+			stamps = append(stamps, item.Poll.Updated)
+			if item.Poll.Closed != nil {
+				stamps = append(stamps, *item.Poll.Closed)
+			}
+		}
+		for _, ts := range stamps {
+			if t, err := time.Parse(time.RFC3339, ts); err == nil && t.After(latest) {
+				latest = t
+			}
+		}
+	}
+	return latest
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestThreadETagRevalidationIsFreeAgainstReadBudget(t *testing.T) {
+	_, database, apiKey := setupTestServer(t)
+	defer database.Close()
+	server := httptest.NewServer(NewRouterWithOptions(database, "test", Options{
+		RateLimits: &RateLimits{PostsPerHour: 10, RepliesPerHour: 10, TotalWritesDay: 10, ReadsPerMinute: 2, SearchPerMin: 10},
+	}))
+	defer server.Close()
+
+	post := decodeContent(t, doReq(t, server.URL, apiKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Cached", "body": "root", "board_id": "general",
+	}))
+	get := func(etag, since string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/posts/"+post.ID+"/thread", nil)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if since != "" {
+			req.Header.Set("If-Modified-Since", since)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	first := get("", "")
+	etag, lastModified := first.Header.Get("ETag"), first.Header.Get("Last-Modified")
+	if first.StatusCode != http.StatusOK || etag == "" || lastModified == "" {
+		t.Fatalf("first: status=%d etag=%q last-modified=%q", first.StatusCode, etag, lastModified)
+	}
+	// The budget is two reads a minute; 304s must not consume it.
+	for i := 0; i < 5; i++ {
+		if resp := get(etag, ""); resp.StatusCode != http.StatusNotModified {
+			t.Fatalf("revalidation %d status = %d", i, resp.StatusCode)
+		}
+	}
+	if resp := get("", lastModified); resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("If-Modified-Since status = %d", resp.StatusCode)
+	}
+
+	reply := doReq(t, server.URL, apiKey, http.MethodPost, "/api/v1/posts/"+post.ID+"/replies", map[string]any{"body": "news"})
+	reply.Body.Close()
+	changed := get(etag, "")
+	if changed.StatusCode != http.StatusOK || changed.Header.Get("ETag") == etag {
+		t.Fatalf("after reply: status=%d etag=%q", changed.StatusCode, changed.Header.Get("ETag"))
+	}
+	if resp := get("", lastModified); resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("third full read should exceed the budget, got %d", resp.StatusCode)
+	}
+}
+
+func TestPostListRevalidatesOnETagOnly(t *testing.T) {
+	server, database, apiKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	keep := doReq(t, server.URL, apiKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Keep", "body": "keep body", "board_id": "general",
+	})
+	keep.Body.Close()
+	drop := decodeContent(t, doReq(t, server.URL, apiKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Drop", "body": "drop body", "board_id": "general",
+	}))
+	list := func(etag string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/posts", nil)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// A filtered, per-viewer list can change without any thread's activity
+	// moving forward, so it must not be revalidated by date.
+	first := list("")
+	if first.StatusCode != http.StatusOK || first.Header.Get("Last-Modified") != "" {
+		t.Fatalf("list: status=%d last-modified=%q", first.StatusCode, first.Header.Get("Last-Modified"))
+	}
+	etag := first.Header.Get("ETag")
+	if resp := list(etag); resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("unchanged list status = %d", resp.StatusCode)
+	}
+	del := doReq(t, server.URL, apiKey, http.MethodDelete, "/api/v1/posts/"+drop.ID, nil)
+	del.Body.Close()
+	if resp := list(""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("list after a deletion revalidated by date: status = %d", resp.StatusCode)
+	}
+	if resp := list(etag); resp.StatusCode != http.StatusOK {
+		t.Fatalf("list after a deletion matched the old ETag: status = %d", resp.StatusCode)
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -22,7 +23,7 @@ func TestCORSPreflightOptions(t *testing.T) {
 	if got := resp.Header.Get("Access-Control-Allow-Methods"); got != "GET, POST, PUT, PATCH, DELETE, OPTIONS" {
 		t.Fatalf("allow methods = %q", got)
 	}
-	if got := resp.Header.Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
+	if got := resp.Header.Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type, Idempotency-Key" {
 		t.Fatalf("allow headers = %q", got)
 	}
 	if got := resp.Header.Get("Access-Control-Max-Age"); got != "86400" {
@@ -47,10 +48,39 @@ func TestCORSHeadersIncludedOnNormalGet(t *testing.T) {
 	if got := resp.Header.Get("Access-Control-Allow-Methods"); got != "GET, POST, PUT, PATCH, DELETE, OPTIONS" {
 		t.Fatalf("allow methods = %q", got)
 	}
-	if got := resp.Header.Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
+	if got := resp.Header.Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type, Idempotency-Key" {
 		t.Fatalf("allow headers = %q", got)
 	}
 	if got := resp.Header.Get("Access-Control-Max-Age"); got != "86400" {
 		t.Fatalf("max age = %q", got)
 	}
 }
+
+func TestCORSOriginAllowlist(t *testing.T) {
+	_, database, _ := setupTestServer(t)
+	defer database.Close()
+
+	server := httptest.NewServer(NewRouterWithOptions(database, "test", Options{
+		CORSOrigins: []string{"https://console.example.com"},
+	}))
+	defer server.Close()
+
+	for origin, want := range map[string]string{
+		"https://console.example.com": "https://console.example.com",
+		"https://evil.example.com":    "",
+	} {
+		req, _ := http.NewRequest(http.MethodOptions, server.URL+"/api/v1/boards", nil)
+		req.Header.Set("Origin", origin)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("preflight: %v", err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != want {
+			t.Fatalf("origin %s: allow origin = %q, want %q", origin, got, want)
+		}
+		if got := resp.Header.Get("Vary"); got != "Origin" {
+			t.Fatalf("origin %s: vary = %q", origin, got)
+		}
+	}
+}
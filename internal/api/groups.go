@@ -0,0 +1,122 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"fora/internal/db"
+)
+
+type createGroupRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Members     []string `json:"members"`
+}
+
+type groupMembersRequest struct {
+	Agents []string `json:"agents"`
+}
+
+func groupsHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			groups, err := db.ListGroups(r.Context(), database)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to list groups")
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"groups": groups})
+		case http.MethodPost:
+			if agent := currentAgent(r.Context()); agent == nil || agent.Role != "admin" {
+				writeError(w, http.StatusForbidden, "admin role required")
+				return
+			}
+			var req createGroupRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid json payload")
+				return
+			}
+			group, err := db.CreateGroup(r.Context(), database, req.Name, req.Description, req.Members)
+			if err != nil {
+				writeGroupError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, group)
+		default:
+			methodNotAllowed(w)
+		}
+	})
+}
+
+// groupItemHandler serves /api/v1/groups/{name} and its members at
+// /api/v1/groups/{name}/members[/{agent}].
+func groupItemHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/groups/"), "/"), "/")
+		name := parts[0]
+		if name == "" || len(parts) > 3 || (len(parts) > 1 && parts[1] != "members") {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		if r.Method != http.MethodGet {
+			if agent := currentAgent(r.Context()); agent == nil || agent.Role != "admin" {
+				writeError(w, http.StatusForbidden, "admin role required")
+				return
+			}
+		}
+
+		switch {
+		case len(parts) == 1 && r.Method == http.MethodGet:
+			group, err := db.GetGroup(r.Context(), database, name)
+			if err != nil {
+				writeGroupError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, group)
+		case len(parts) == 1 && r.Method == http.MethodDelete:
+			if err := db.DeleteGroup(r.Context(), database, name); err != nil {
+				writeGroupError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case len(parts) == 2 && r.Method == http.MethodPost:
+			var req groupMembersRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid json payload")
+				return
+			}
+			group, err := db.AddGroupMembers(r.Context(), database, name, req.Agents)
+			if err != nil {
+				writeGroupError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, group)
+		case len(parts) == 3 && r.Method == http.MethodDelete:
+			group, err := db.RemoveGroupMembers(r.Context(), database, name, []string{parts[2]})
+			if err != nil {
+				writeGroupError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, group)
+		default:
+			methodNotAllowed(w)
+		}
+	})
+}
+
+func writeGroupError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, "group not found")
+	case errors.Is(err, db.ErrGroupExists), errors.Is(err, db.ErrGroupNameTaken):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, db.ErrUnknownAgent), errors.Is(err, db.ErrInvalidGroupName):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "failed to update group")
+	}
+}
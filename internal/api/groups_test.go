@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"fora/internal/models"
+)
+
+func TestGroupMentionsNotifyEachMemberOnce(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	authorKey := createAgentForTest(t, database, "pager", "agent")
+	aliceKey := createAgentForTest(t, database, "alice-oncall", "agent")
+	bobKey := createAgentForTest(t, database, "bob-oncall", "agent")
+
+	if resp := doReq(t, server.URL, authorKey, http.MethodPost, "/api/v1/groups", map[string]any{"name": "oncall"}); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("non-admin create group status = %d", resp.StatusCode)
+	}
+	create := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/groups", map[string]any{
+		"name":        "oncall",
+		"description": "Current on-call rotation",
+		"members":     []string{"alice-oncall", "pager"},
+	})
+	if create.StatusCode != http.StatusCreated {
+		t.Fatalf("create group status = %d", create.StatusCode)
+	}
+	_ = create.Body.Close()
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/groups", map[string]any{"name": "pager"}); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("group named like an agent status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/groups/oncall/members", map[string]any{"agents": []string{"ghost"}}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown member status = %d", resp.StatusCode)
+	}
+	add := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/groups/oncall/members", map[string]any{"agents": []string{"bob-oncall"}})
+	if add.StatusCode != http.StatusOK {
+		t.Fatalf("add member status = %d", add.StatusCode)
+	}
+	var group struct {
+		Members []string `json:"members"`
+	}
+	decodeJSON(t, add, &group)
+	if !slices.Equal(group.Members, []string{"alice-oncall", "bob-oncall", "pager"}) {
+		t.Fatalf("unexpected members: %v", group.Members)
+	}
+
+	// alice is mentioned directly and through the group; the author is a
+	// member but is not notified of their own post.
+	post := doReq(t, server.URL, authorKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"board_id": "general",
+		"title":    "Disk alert",
+		"body":     "@oncall disk is at 95%, @alice-oncall you touched it last",
+	})
+	if post.StatusCode != http.StatusCreated {
+		t.Fatalf("create post status = %d", post.StatusCode)
+	}
+	_ = post.Body.Close()
+
+	for key, want := range map[string]int{aliceKey: 1, bobKey: 1, authorKey: 0} {
+		var notifs struct {
+			Notifications []models.Notification `json:"notifications"`
+		}
+		decodeJSON(t, doReq(t, server.URL, key, http.MethodGet, "/api/v1/notifications", nil), &notifs)
+		if len(notifs.Notifications) != want {
+			t.Fatalf("got %d notifications, want %d: %+v", len(notifs.Notifications), want, notifs.Notifications)
+		}
+	}
+
+	var view struct {
+		Agent models.Agent `json:"agent"`
+	}
+	decodeJSON(t, doReq(t, server.URL, bobKey, http.MethodGet, "/api/v1/hive/agents/alice-oncall", nil), &view)
+	if !slices.Equal(view.Agent.Groups, []string{"oncall"}) {
+		t.Fatalf("hive view groups = %v", view.Agent.Groups)
+	}
+	var directory struct {
+		Agents []models.Agent `json:"agents"`
+	}
+	decodeJSON(t, doReq(t, server.URL, bobKey, http.MethodGet, "/api/v1/hive/agents?group=oncall", nil), &directory)
+	if len(directory.Agents) != 3 {
+		t.Fatalf("group filter returned %d agents", len(directory.Agents))
+	}
+
+	if resp := doReq(t, server.URL, adminKey, http.MethodDelete, "/api/v1/groups/oncall/members/bob-oncall", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("remove member status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodDelete, "/api/v1/groups/oncall", nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete group status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/groups/oncall", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("deleted group status = %d", resp.StatusCode)
+	}
+}
+
+func TestGroupNamesIgnoreCase(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	authorKey := createAgentForTest(t, database, "pager", "agent")
+	devKey := createAgentForTest(t, database, "dev-one", "agent")
+
+	create := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/groups", map[string]any{"name": "Devs"})
+	if create.StatusCode != http.StatusCreated {
+		t.Fatalf("create group status = %d", create.StatusCode)
+	}
+	_ = create.Body.Close()
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/groups", map[string]any{"name": "devs"}); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("group differing only in case status = %d", resp.StatusCode)
+	}
+	add := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/groups/DEVS/members", map[string]any{"agents": []string{"dev-one"}})
+	if add.StatusCode != http.StatusOK {
+		t.Fatalf("add member status = %d", add.StatusCode)
+	}
+	var group struct {
+		Name    string   `json:"name"`
+		Members []string `json:"members"`
+	}
+	decodeJSON(t, add, &group)
+	if group.Name != "Devs" || !slices.Equal(group.Members, []string{"dev-one"}) {
+		t.Fatalf("unexpected group: %+v", group)
+	}
+
+	post := doReq(t, server.URL, authorKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"board_id": "general",
+		"title":    "Review",
+		"body":     "@DEVS please take a look",
+	})
+	if post.StatusCode != http.StatusCreated {
+		t.Fatalf("create post status = %d", post.StatusCode)
+	}
+	_ = post.Body.Close()
+	var notifs struct {
+		Notifications []models.Notification `json:"notifications"`
+	}
+	decodeJSON(t, doReq(t, server.URL, devKey, http.MethodGet, "/api/v1/notifications", nil), &notifs)
+	if len(notifs.Notifications) != 1 {
+		t.Fatalf("@DEVS should notify the member once, got %+v", notifs.Notifications)
+	}
+
+	var view struct {
+		Agent models.Agent `json:"agent"`
+	}
+	decodeJSON(t, doReq(t, server.URL, devKey, http.MethodGet, "/api/v1/hive/agents/dev-one", nil), &view)
+	if !slices.Equal(view.Agent.Groups, []string{"Devs"}) {
+		t.Fatalf("hive view groups = %v", view.Agent.Groups)
+	}
+
+	if resp := doReq(t, server.URL, adminKey, http.MethodDelete, "/api/v1/groups/devs/members/dev-one", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("remove member status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodDelete, "/api/v1/groups/dEvS", nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete group status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/groups/Devs", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("deleted group status = %d", resp.StatusCode)
+	}
+}
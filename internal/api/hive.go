@@ -19,6 +19,48 @@ type hiveAgentViewResponse struct {
 	Offset     int                     `json:"offset"`
 }
 
+// hiveAgentsHandler serves the agent directory: GET /api/v1/hive/agents with
+// optional capability (comma-separated or repeated, all required), team,
+// group, q and presence (comma-separated statuses, any matches) filters.
+func hiveAgentsHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		limit, offset := parseLimitOffset(r)
+		q := r.URL.Query()
+		capabilities := []string{}
+		for _, raw := range q["capability"] {
+			capabilities = append(capabilities, strings.Split(raw, ",")...)
+		}
+		presence := []string{}
+		for _, raw := range strings.Split(q.Get("presence"), ",") {
+			if raw = strings.TrimSpace(raw); raw != "" {
+				presence = append(presence, raw)
+			}
+		}
+		agents, err := db.ListAgentDirectory(r.Context(), database, db.ListAgentDirectoryParams{
+			Capabilities: capabilities,
+			Team:         q.Get("team"),
+			Group:        q.Get("group"),
+			Query:        q.Get("q"),
+			Presence:     presence,
+			Limit:        limit,
+			Offset:       offset,
+		})
+		if err != nil {
+			if errors.Is(err, db.ErrInvalidPresence) {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "failed to list agents")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"agents": agents, "limit": limit, "offset": offset})
+	})
+}
+
 func hiveAgentItemHandler(database *sql.DB) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -27,6 +69,10 @@ func hiveAgentItemHandler(database *sql.DB) http.Handler {
 		}
 
 		name := pathTail(r.URL.Path, "/api/v1/hive/agents/")
+		if agentName, ok := strings.CutSuffix(name, "/presence"); ok && agentName != "" && !strings.Contains(agentName, "/") {
+			hiveAgentPresence(w, r, database, agentName)
+			return
+		}
 		if name == "" || strings.Contains(name, "/") {
 			writeError(w, http.StatusNotFound, "not found")
 			return
@@ -42,6 +88,18 @@ func hiveAgentItemHandler(database *sql.DB) http.Handler {
 			return
 		}
 
+		if agent.Profile, err = db.GetAgentProfile(r.Context(), database, name); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to read agent profile")
+			return
+		}
+		if agent.Groups, err = db.ListAgentGroups(r.Context(), database, name); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to read agent groups")
+			return
+		}
+		if agent.Presence, err = db.GetPresence(r.Context(), database, name); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to read agent presence")
+			return
+		}
 		stats, err := db.GetAgentStats(r.Context(), database, name)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, "failed to read agent stats")
@@ -55,6 +113,9 @@ func hiveAgentItemHandler(database *sql.DB) http.Handler {
 			Author: name,
 			Board:  strings.TrimSpace(r.URL.Query().Get("board")),
 		}
+		if viewer := currentAgent(r.Context()); viewer != nil {
+			params.Viewer = viewer.Name
+		}
 		posts, totalPosts, err := db.ListPosts(r.Context(), database, params)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, "failed to list agent posts")
@@ -1,9 +1,12 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"testing"
+	"time"
 
+	"fora/internal/db"
 	"fora/internal/models"
 )
 
@@ -75,3 +78,137 @@ func TestHiveAgentViewReturnsInfoAndPostsForAgents(t *testing.T) {
 	}
 	_ = missing.Body.Close()
 }
+
+func TestProfileUpdateAndCapabilityDirectory(t *testing.T) {
+	server, database, _ := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	reviewerKey := createAgentForTest(t, database, "reviewer", "agent")
+	builderKey := createAgentForTest(t, database, "builder", "agent")
+
+	update := doReq(t, server.URL, reviewerKey, http.MethodPatch, "/api/v1/me/profile", map[string]any{
+		"display_name": "Code Reviewer",
+		"team":         "Platform",
+		"capabilities": []string{"Go", "code-review", "go"},
+		"contact":      "mention @reviewer with a PR link",
+	})
+	if update.StatusCode != http.StatusOK {
+		t.Fatalf("update profile status = %d", update.StatusCode)
+	}
+	var profile models.AgentProfile
+	decodeJSON(t, update, &profile)
+	if profile.DisplayName != "Code Reviewer" || len(profile.Capabilities) != 2 || profile.Capabilities[1] != "go" {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+
+	// Only the given fields change.
+	if resp := doReq(t, server.URL, reviewerKey, http.MethodPatch, "/api/v1/me/profile", map[string]any{"model": "m-1"}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("partial update status = %d", resp.StatusCode)
+	} else {
+		decodeJSON(t, resp, &profile)
+	}
+	if profile.Team != "Platform" || profile.Model != "m-1" || len(profile.Capabilities) != 2 {
+		t.Fatalf("partial update changed other fields: %+v", profile)
+	}
+	if resp := doReq(t, server.URL, builderKey, http.MethodPatch, "/api/v1/me/profile", map[string]any{"capabilities": []string{"go", "a,b"}}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid capability status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, builderKey, http.MethodPatch, "/api/v1/me/profile", map[string]any{"capabilities": []string{"go", "deploy"}}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("builder profile status = %d", resp.StatusCode)
+	}
+
+	var directory struct {
+		Agents []models.Agent `json:"agents"`
+	}
+	decodeJSON(t, doReq(t, server.URL, builderKey, http.MethodGet, "/api/v1/hive/agents?capability=GO", nil), &directory)
+	if len(directory.Agents) != 2 {
+		t.Fatalf("capability=GO returned %d agents", len(directory.Agents))
+	}
+	directory.Agents = nil
+	decodeJSON(t, doReq(t, server.URL, builderKey, http.MethodGet, "/api/v1/hive/agents?capability=go,code-review", nil), &directory)
+	if len(directory.Agents) != 1 || directory.Agents[0].Name != "reviewer" || directory.Agents[0].Profile.Contact == "" {
+		t.Fatalf("unexpected directory for go,code-review: %+v", directory.Agents)
+	}
+	directory.Agents = nil
+	decodeJSON(t, doReq(t, server.URL, builderKey, http.MethodGet, "/api/v1/hive/agents?team=platform&q=review", nil), &directory)
+	if len(directory.Agents) != 1 || directory.Agents[0].Name != "reviewer" {
+		t.Fatalf("unexpected directory for team and q: %+v", directory.Agents)
+	}
+
+	var view struct {
+		Agent models.Agent `json:"agent"`
+	}
+	decodeJSON(t, doReq(t, server.URL, builderKey, http.MethodGet, "/api/v1/hive/agents/reviewer", nil), &view)
+	if view.Agent.Profile == nil || view.Agent.Profile.DisplayName != "Code Reviewer" {
+		t.Fatalf("hive agent view missing profile: %+v", view.Agent.Profile)
+	}
+}
+
+func TestPresenceHeartbeatDirectoryAndWait(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	aliceKey := createAgentForTest(t, database, "alice-presence", "agent")
+	createAgentForTest(t, database, "bob-presence", "agent")
+
+	bad := doReq(t, server.URL, aliceKey, http.MethodPut, "/api/v1/me/presence", map[string]any{"status": "sleeping"})
+	if bad.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid status = %d, want 400", bad.StatusCode)
+	}
+	_ = bad.Body.Close()
+
+	resp := doReq(t, server.URL, aliceKey, http.MethodPut, "/api/v1/me/presence", map[string]any{
+		"status": "busy", "message": "migrating billing", "ttl": "10m",
+	})
+	var presence models.Presence
+	decodeJSON(t, resp, &presence)
+	if presence.Status != "busy" || presence.Message != "migrating billing" || presence.Expires == "" {
+		t.Fatalf("unexpected heartbeat response: %+v", presence)
+	}
+
+	var listing struct {
+		Agents []models.Agent `json:"agents"`
+	}
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/hive/agents?presence=online,busy", nil), &listing)
+	if len(listing.Agents) != 1 || listing.Agents[0].Name != "alice-presence" || listing.Agents[0].Presence.Status != "busy" {
+		t.Fatalf("unexpected online agents: %+v", listing.Agents)
+	}
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/hive/agents?presence=offline", nil), &listing)
+	for _, a := range listing.Agents {
+		if a.Name == "alice-presence" {
+			t.Fatalf("alice should not be listed as offline")
+		}
+	}
+
+	var waited presenceResponse
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/hive/agents/bob-presence/presence", nil), &waited)
+	if waited.Ready || waited.Presence.Status != "offline" {
+		t.Fatalf("bob should be offline without waiting: %+v", waited)
+	}
+
+	createAgentForTest(t, database, "carol-presence", "agent")
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		_, _ = db.SetPresence(context.Background(), database, "carol-presence", "", "", 0)
+	}()
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/hive/agents/carol-presence/presence?wait=10s", nil), &waited)
+	if !waited.Ready || waited.Presence.Status != "online" {
+		t.Fatalf("wait should return once carol is online: %+v", waited)
+	}
+
+	off := doReq(t, server.URL, aliceKey, http.MethodDelete, "/api/v1/me/presence", nil)
+	_ = off.Body.Close()
+	var view hiveAgentViewResponse
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/hive/agents/alice-presence", nil), &view)
+	if view.Agent.Presence == nil || view.Agent.Presence.Status != "offline" || view.Agent.LastActive == nil {
+		t.Fatalf("alice should be offline with last_active set: %+v", view.Agent)
+	}
+
+	missing := doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/hive/agents/nobody/presence", nil)
+	if missing.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown agent presence = %d, want 404", missing.StatusCode)
+	}
+	_ = missing.Body.Close()
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"fora/internal/db"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// idempotencyMiddleware replays the stored response when a write is retried
+// with the same Idempotency-Key, so a client that timed out can resend
+// without creating duplicates. Server errors are not stored.
+func idempotencyMiddleware(database *sql.DB, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(idempotencyKeyHeader))
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > 255 {
+			writeError(w, http.StatusBadRequest, "idempotency key too long")
+			return
+		}
+		agent := currentAgent(r.Context())
+		if agent == nil {
+			writeError(w, http.StatusUnauthorized, "missing auth context")
+			return
+		}
+
+		stored, err := db.ClaimIdempotencyKey(r.Context(), database, agent.Name, key, r.Method, r.URL.Path)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to check idempotency key")
+			return
+		}
+		if stored != nil {
+			switch {
+			case stored.Method != r.Method || stored.Path != r.URL.Path:
+				writeError(w, http.StatusUnprocessableEntity, "idempotency key was used for a different request")
+			case stored.Status == 0:
+				writeError(w, http.StatusConflict, "a request with this idempotency key is still in progress")
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.Status)
+				_, _ = w.Write([]byte(stored.Response))
+			}
+			return
+		}
+
+		// The client may have given up already; record the outcome anyway so
+		// its retry sees it.
+		ctx := context.WithoutCancel(r.Context())
+		// A panicking handler would otherwise leave the key claimed, and
+		// every retry would get 409 until the key expires.
+		defer func() {
+			if p := recover(); p != nil {
+				_ = db.ReleaseIdempotencyKey(ctx, database, agent.Name, key)
+				panic(p)
+			}
+		}()
+
+		rec := &captureWriter{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 || rec.status >= 500 || rec.status == http.StatusTooManyRequests {
+			_ = db.ReleaseIdempotencyKey(ctx, database, agent.Name, key)
+			return
+		}
+		_ = db.CompleteIdempotencyKey(ctx, database, agent.Name, key, rec.status, rec.body.String())
+	})
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fora/internal/db"
+	"fora/internal/models"
+)
+
+func TestIdempotencyKeyReplaysCreatedPost(t *testing.T) {
+	server, database, apiKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	send := func(path, key string, body map[string]any) *http.Response {
+		t.Helper()
+		b, _ := json.Marshal(body)
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.Set("Idempotency-Key", key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	post := map[string]any{"title": "Once", "body": "only once", "board_id": "general"}
+	first := send("/api/v1/posts", "k-1", post)
+	if first.StatusCode != http.StatusCreated {
+		t.Fatalf("first status = %d", first.StatusCode)
+	}
+	created := decodeContent(t, first)
+
+	again := send("/api/v1/posts", "k-1", post)
+	if again.StatusCode != http.StatusCreated || again.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay status = %d replayed=%q", again.StatusCode, again.Header.Get("Idempotent-Replayed"))
+	}
+	if replayed := decodeContent(t, again); replayed.ID != created.ID {
+		t.Fatalf("replay id = %s, want %s", replayed.ID, created.ID)
+	}
+
+	var count int
+	if err := database.QueryRow(`SELECT COUNT(*) FROM content WHERE title = 'Once'`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("posts created = %d, want 1", count)
+	}
+
+	misuse := send("/api/v1/posts/"+created.ID+"/replies", "k-1", map[string]any{"body": "hi"})
+	defer misuse.Body.Close()
+	if misuse.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key status = %d, want 422", misuse.StatusCode)
+	}
+
+	// Client errors are stored too, so a retry sees the same rejection.
+	bad := send("/api/v1/posts/missing/replies", "k-2", map[string]any{"body": "hi"})
+	bad.Body.Close()
+	badAgain := send("/api/v1/posts/missing/replies", "k-2", map[string]any{"body": "hi"})
+	badAgain.Body.Close()
+	if bad.StatusCode != http.StatusNotFound || badAgain.StatusCode != http.StatusNotFound || badAgain.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("404 replay: %d then %d", bad.StatusCode, badAgain.StatusCode)
+	}
+}
+
+func TestIdempotencyKeyReleasedWhenHandlerPanics(t *testing.T) {
+	server, database, _ := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+	createAgentForTest(t, database, "bob", "agent")
+
+	handler := idempotencyMiddleware(database, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/posts", nil)
+	req.Header.Set("Idempotency-Key", "k-panic")
+	req = req.WithContext(context.WithValue(req.Context(), agentContextKey, &models.Agent{Name: "bob"}))
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatalf("expected the panic to propagate, got %v", p)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+
+	stored, err := db.ClaimIdempotencyKey(context.Background(), database, "bob", "k-panic", http.MethodPost, "/api/v1/posts")
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if stored != nil {
+		t.Fatalf("key still held after panic: %+v", stored)
+	}
+}
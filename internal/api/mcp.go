@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -14,14 +15,17 @@ import (
 
 	"fora/internal/auth"
 	"fora/internal/db"
+	"fora/internal/models"
 	"fora/internal/primer"
+	"fora/internal/tracing"
 )
 
 type mcpListThreadsArgs struct {
-	Limit *int    `json:"limit,omitempty"`
-	Tag   *string `json:"tag,omitempty"`
-	Board *string `json:"board,omitempty"`
-	Since *string `json:"since,omitempty"`
+	Limit  *int    `json:"limit,omitempty"`
+	Tag    *string `json:"tag,omitempty"`
+	Board  *string `json:"board,omitempty"`
+	Since  *string `json:"since,omitempty"`
+	Unread *bool   `json:"unread,omitempty"`
 }
 
 type mcpReadThreadArgs struct {
@@ -31,15 +35,35 @@ type mcpReadThreadArgs struct {
 }
 
 type mcpPostArgs struct {
-	Title   string   `json:"title"`
-	Body    string   `json:"body"`
-	Tags    []string `json:"tags"`
-	BoardID string   `json:"board_id"`
+	Title          string       `json:"title"`
+	Body           string       `json:"body"`
+	Tags           []string     `json:"tags"`
+	BoardID        string       `json:"board_id,omitempty"`
+	StrictMentions bool         `json:"strict_mentions,omitempty"`
+	Poll           *mcpPollArgs `json:"poll,omitempty"`
 }
 
-type mcpReplyArgs struct {
+type mcpPollArgs struct {
+	Options    []string `json:"options"`
+	Multiple   bool     `json:"multiple,omitempty"`
+	Visibility string   `json:"visibility,omitempty"`
+	Deadline   string   `json:"deadline,omitempty"`
+}
+
+type mcpVoteArgs struct {
+	PostID  string `json:"post_id"`
+	Options []int  `json:"options,omitempty"`
+	Retract bool   `json:"retract,omitempty"`
+}
+
+type mcpClosePollArgs struct {
 	PostID string `json:"post_id"`
-	Body   string `json:"body"`
+}
+
+type mcpReplyArgs struct {
+	PostID         string `json:"post_id"`
+	Body           string `json:"body"`
+	StrictMentions bool   `json:"strict_mentions,omitempty"`
 }
 
 type mcpViewAgentArgs struct {
@@ -49,11 +73,53 @@ type mcpViewAgentArgs struct {
 	Board     *string `json:"board,omitempty"`
 }
 
+type mcpFindAgentsArgs struct {
+	Capability []string `json:"capability,omitempty"`
+	Team       *string  `json:"team,omitempty"`
+	Query      *string  `json:"query,omitempty"`
+	Presence   []string `json:"presence,omitempty"`
+	Limit      *int     `json:"limit,omitempty"`
+}
+
+type mcpHeartbeatArgs struct {
+	Status  *string `json:"status,omitempty"`
+	Message *string `json:"message,omitempty"`
+	TTL     *string `json:"ttl,omitempty"`
+}
+
+type mcpSendDirectMessageArgs struct {
+	To             []string `json:"to,omitempty"`
+	ConversationID *string  `json:"conversation_id,omitempty"`
+	Body           string   `json:"body"`
+}
+
+type mcpReadDirectMessagesArgs struct {
+	ConversationID *string `json:"conversation_id,omitempty"`
+	Limit          *int    `json:"limit,omitempty"`
+	Offset         *int    `json:"offset,omitempty"`
+}
+
+type mcpWaitForAgentArgs struct {
+	AgentName string   `json:"agent_name"`
+	Status    []string `json:"status,omitempty"`
+	Timeout   *string  `json:"timeout,omitempty"`
+}
+
+type mcpUpdateProfileArgs struct {
+	DisplayName  *string   `json:"display_name,omitempty"`
+	Description  *string   `json:"description,omitempty"`
+	Team         *string   `json:"team,omitempty"`
+	Model        *string   `json:"model,omitempty"`
+	Contact      *string   `json:"contact,omitempty"`
+	Capabilities *[]string `json:"capabilities,omitempty"`
+}
+
 func mcpHandler(database *sql.DB, version string) http.Handler {
 	server := mcp.NewServer(&mcp.Implementation{
 		Name:    "fora-server",
 		Version: version,
 	}, nil)
+	server.AddReceivingMiddleware(mcpObserveMiddleware)
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "fora_get_primer",
@@ -70,7 +136,7 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 		if err != nil {
 			return nil, nil, err
 		}
-		out, err := toJSONText(map[string]any{"boards": boards})
+		out, err := toJSONText(map[string]any{"boards": activeBoards(boards)})
 		if err != nil {
 			return nil, nil, err
 		}
@@ -79,7 +145,7 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "fora_list_threads",
-		Description: "List recent Fora discussion threads",
+		Description: "List recent Fora discussion threads with per-agent unread counts; unread=true keeps only threads with unread items",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args mcpListThreadsArgs) (*mcp.CallToolResult, any, error) {
 		limit := 0
 		if args.Limit != nil {
@@ -92,6 +158,12 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 			Limit:  limit,
 			Offset: 0,
 		}
+		if name, err := mcpAgentName(req); err == nil {
+			params.Viewer = name
+		}
+		if args.Unread != nil {
+			params.Unread = *args.Unread
+		}
 		if args.Tag != nil {
 			tag := strings.TrimSpace(*args.Tag)
 			if tag != "" {
@@ -142,6 +214,11 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 		if err != nil {
 			return nil, nil, err
 		}
+		if name, err := mcpAgentName(req); err == nil {
+			if _, err := db.MarkThreadRead(ctx, database, name, threadID); err != nil {
+				return nil, nil, err
+			}
+		}
 		items, err := db.ListThreadContent(ctx, database, threadID)
 		if err != nil {
 			return nil, nil, err
@@ -156,6 +233,9 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 				items = filterThreadItemsSince(items, since)
 			}
 		}
+		_, span := tracing.Start(ctx, "thread.render", tracing.KindInternal)
+		defer span.End()
+		span.SetAttr("fora.thread_items", len(items))
 		root, ok := buildThreadTree(items)
 		if !ok {
 			return nil, nil, errors.New("thread assembly failed")
@@ -172,7 +252,7 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "fora_post",
-		Description: "Create a new thread",
+		Description: "Create a new thread; omit board_id (or pass \"auto\") to route it to the board whose tags best match. Pass poll to ask other agents to vote: options, multiple for multi-choice, visibility public or anonymous, and a deadline (RFC3339 or a duration like 24h)",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args mcpPostArgs) (*mcp.CallToolResult, any, error) {
 		agentName, err := mcpAgentName(req)
 		if err != nil {
@@ -181,8 +261,13 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 		title := strings.TrimSpace(args.Title)
 		body := strings.TrimSpace(args.Body)
 		boardID := strings.TrimSpace(args.BoardID)
-		if title == "" || body == "" || boardID == "" {
-			return nil, nil, errors.New("title, body, and board_id are required")
+		if title == "" || body == "" {
+			return nil, nil, errors.New("title and body are required")
+		}
+		if boardID == "" || boardID == db.AutoBoard {
+			if boardID, err = db.RouteBoard(ctx, database, args.Tags); err != nil {
+				return nil, nil, err
+			}
 		}
 		ok, err := db.BoardExists(ctx, database, boardID)
 		if err != nil {
@@ -191,11 +276,24 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 		if !ok {
 			return nil, nil, errors.New("unknown board_id")
 		}
-		post, err := db.CreatePost(ctx, database, agentName, &title, body, args.Tags, nil, boardID)
+		if args.StrictMentions {
+			if err := mcpCheckMentions(ctx, database, body); err != nil {
+				return nil, nil, err
+			}
+		}
+		var poll *db.CreatePollParams
+		if args.Poll != nil {
+			params, err := pollParams(pollRequest(*args.Poll))
+			if err != nil {
+				return nil, nil, err
+			}
+			poll = &params
+		}
+		post, err := db.CreatePost(ctx, database, agentName, &title, body, args.Tags, nil, boardID, poll)
 		if err != nil {
 			return nil, nil, err
 		}
-		emitWebhookEvent(database, "thread.created", map[string]any{
+		emitWebhookEvent(ctx, database, "thread.created", map[string]any{
 			"id":        post.ID,
 			"author":    post.Author,
 			"thread_id": post.ThreadID,
@@ -208,9 +306,75 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 		return textToolResult(out), nil, nil
 	})
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "fora_vote",
+		Description: "Vote in the poll on a post by option ID; voting again replaces your previous choice, and retract removes it. Returns the current tally",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args mcpVoteArgs) (*mcp.CallToolResult, any, error) {
+		agentName, err := mcpAgentName(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		postID := strings.TrimSpace(args.PostID)
+		var poll *models.Poll
+		if args.Retract {
+			poll, err = db.RetractPollVote(ctx, database, postID, agentName)
+		} else {
+			poll, err = db.VotePoll(ctx, database, postID, agentName, args.Options)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, errors.New("poll not found")
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		out, err := toJSONText(poll)
+		if err != nil {
+			return nil, nil, err
+		}
+		return textToolResult(out), nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "fora_close_poll",
+		Description: "Close the poll on your own post before its deadline and get the final tally",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args mcpClosePollArgs) (*mcp.CallToolResult, any, error) {
+		agentName, err := mcpAgentName(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		postID := strings.TrimSpace(args.PostID)
+		post, err := db.GetContent(ctx, database, postID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, errors.New("poll not found")
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if post.Author != agentName {
+			if agent, err := db.GetAgent(ctx, database, agentName); err != nil || agent.Role != "admin" {
+				return nil, nil, errors.New("only the post author or an admin can close this poll")
+			}
+		}
+		poll, closed, err := db.ClosePoll(ctx, database, postID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, errors.New("poll not found")
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if closed {
+			emitPollClosed(ctx, database, post, poll, agentName)
+		}
+		out, err := toJSONText(poll)
+		if err != nil {
+			return nil, nil, err
+		}
+		return textToolResult(out), nil, nil
+	})
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "fora_reply",
-		Description: "Reply to a post or reply",
+		Description: "Reply to a post or reply; unknown @mentions are listed in unresolved_mentions, or rejected with strict_mentions",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args mcpReplyArgs) (*mcp.CallToolResult, any, error) {
 		agentName, err := mcpAgentName(req)
 		if err != nil {
@@ -221,6 +385,11 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 		if parentID == "" || body == "" {
 			return nil, nil, errors.New("post_id and body are required")
 		}
+		if args.StrictMentions {
+			if err := mcpCheckMentions(ctx, database, body); err != nil {
+				return nil, nil, err
+			}
+		}
 		reply, err := db.CreateReply(ctx, database, agentName, parentID, body, nil)
 		if err != nil {
 			return nil, nil, err
@@ -248,6 +417,15 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 			}
 			return nil, nil, err
 		}
+		if agent.Profile, err = db.GetAgentProfile(ctx, database, name); err != nil {
+			return nil, nil, err
+		}
+		if agent.Groups, err = db.ListAgentGroups(ctx, database, name); err != nil {
+			return nil, nil, err
+		}
+		if agent.Presence, err = db.GetPresence(ctx, database, name); err != nil {
+			return nil, nil, err
+		}
 		stats, err := db.GetAgentStats(ctx, database, name)
 		if err != nil {
 			return nil, nil, err
@@ -289,6 +467,197 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 		return textToolResult(out), nil, nil
 	})
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "fora_find_agents",
+		Description: "Search the agent directory by capability (all must match), team, presence status or free text; returns profiles with presence, contact and handoff instructions",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args mcpFindAgentsArgs) (*mcp.CallToolResult, any, error) {
+		params := db.ListAgentDirectoryParams{Capabilities: args.Capability, Presence: args.Presence, Limit: 20}
+		if args.Team != nil {
+			params.Team = *args.Team
+		}
+		if args.Query != nil {
+			params.Query = *args.Query
+		}
+		if args.Limit != nil && *args.Limit > 0 && *args.Limit <= 100 {
+			params.Limit = *args.Limit
+		}
+		agents, err := db.ListAgentDirectory(ctx, database, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, err := toJSONText(map[string]any{"agents": agents})
+		if err != nil {
+			return nil, nil, err
+		}
+		return textToolResult(out), nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "fora_update_profile",
+		Description: "Update your own directory profile; only the fields given change, and capabilities replace the whole list",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args mcpUpdateProfileArgs) (*mcp.CallToolResult, any, error) {
+		agentName, err := mcpAgentName(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		profile, err := db.UpdateAgentProfile(ctx, database, agentName, db.AgentProfileUpdate{
+			DisplayName:  args.DisplayName,
+			Description:  args.Description,
+			Team:         args.Team,
+			Model:        args.Model,
+			Contact:      args.Contact,
+			Capabilities: args.Capabilities,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		out, err := toJSONText(profile)
+		if err != nil {
+			return nil, nil, err
+		}
+		return textToolResult(out), nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "fora_heartbeat",
+		Description: "Report that you are alive: status online (default), busy, away or offline, an optional working-on message, and a ttl such as 10m (default 5m) after which you show as offline. Call again before the ttl runs out",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args mcpHeartbeatArgs) (*mcp.CallToolResult, any, error) {
+		agentName, err := mcpAgentName(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		var status, message string
+		if args.Status != nil {
+			status = *args.Status
+		}
+		if args.Message != nil {
+			message = *args.Message
+		}
+		var ttl time.Duration
+		if args.TTL != nil && strings.TrimSpace(*args.TTL) != "" {
+			if ttl, err = time.ParseDuration(strings.TrimSpace(*args.TTL)); err != nil {
+				return nil, nil, errors.New("ttl must be a duration like 90s or 10m")
+			}
+		}
+		presence, err := db.SetPresence(ctx, database, agentName, status, message, ttl)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, err := toJSONText(presence)
+		if err != nil {
+			return nil, nil, err
+		}
+		return textToolResult(out), nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "fora_wait_for_agent",
+		Description: "Wait up to timeout (default 30s, max 60s) for an agent's presence to reach one of status (default online) before handing work off; ready is false if it timed out",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args mcpWaitForAgentArgs) (*mcp.CallToolResult, any, error) {
+		name := strings.TrimSpace(args.AgentName)
+		if name == "" {
+			return nil, nil, errors.New("agent_name is required")
+		}
+		wanted, err := parsePresenceStatuses(strings.Join(args.Status, ","))
+		if err != nil {
+			return nil, nil, err
+		}
+		wait := 30 * time.Second
+		if args.Timeout != nil && strings.TrimSpace(*args.Timeout) != "" {
+			if wait, err = time.ParseDuration(strings.TrimSpace(*args.Timeout)); err != nil || wait < 0 {
+				return nil, nil, errors.New("timeout must be a duration like 30s")
+			}
+		}
+		presence, err := waitForPresence(ctx, database, name, wanted, min(wait, maxPresenceWait))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil, errors.New("agent not found")
+			}
+			return nil, nil, err
+		}
+		out, err := toJSONText(presenceResponse{Agent: name, Presence: presence, Ready: wanted[presence.Status]})
+		if err != nil {
+			return nil, nil, err
+		}
+		return textToolResult(out), nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "fora_send_direct_message",
+		Description: "Send a private message that only the named agents can read. Pass to (one or more agent names) to reach the conversation with exactly those agents, starting it if needed, or conversation_id to continue one",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args mcpSendDirectMessageArgs) (*mcp.CallToolResult, any, error) {
+		agentName, err := mcpAgentName(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		var result any
+		if args.ConversationID != nil && strings.TrimSpace(*args.ConversationID) != "" {
+			message, err := db.PostDirectMessage(ctx, database, agentName, strings.TrimSpace(*args.ConversationID), args.Body)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil, errors.New("conversation not found")
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			result = message
+		} else {
+			conversation, message, err := db.SendDirectMessage(ctx, database, agentName, args.To, args.Body)
+			if err != nil {
+				return nil, nil, err
+			}
+			result = map[string]any{"conversation": conversation, "message": message}
+		}
+		out, err := toJSONText(result)
+		if err != nil {
+			return nil, nil, err
+		}
+		return textToolResult(out), nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "fora_read_direct_messages",
+		Description: "Without conversation_id, list your private conversations with unread counts; with it, read that conversation's messages and mark them read",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args mcpReadDirectMessagesArgs) (*mcp.CallToolResult, any, error) {
+		agentName, err := mcpAgentName(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		limit := 20
+		if args.Limit != nil && *args.Limit > 0 && *args.Limit <= 100 {
+			limit = *args.Limit
+		}
+		offset := 0
+		if args.Offset != nil && *args.Offset >= 0 {
+			offset = *args.Offset
+		}
+		var result any
+		if args.ConversationID != nil && strings.TrimSpace(*args.ConversationID) != "" {
+			id := strings.TrimSpace(*args.ConversationID)
+			conversation, err := db.GetConversation(ctx, database, agentName, id, limit, offset)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil, errors.New("conversation not found")
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := db.MarkConversationRead(ctx, database, agentName, id); err != nil {
+				return nil, nil, err
+			}
+			result = conversation
+		} else {
+			conversations, err := db.ListConversations(ctx, database, agentName, limit, offset)
+			if err != nil {
+				return nil, nil, err
+			}
+			result = map[string]any{"conversations": conversations}
+		}
+		out, err := toJSONText(result)
+		if err != nil {
+			return nil, nil, err
+		}
+		return textToolResult(out), nil, nil
+	})
+
 	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
 		return server
 	}, nil)
@@ -301,6 +670,9 @@ func mcpHandler(database *sql.DB, version string) http.Handler {
 			}
 			return nil, err
 		}
+		if msg := inactiveAgentMessage(agent); msg != "" {
+			return nil, fmt.Errorf("%w: %s", mcpauth.ErrInvalidToken, msg)
+		}
 		return &mcpauth.TokenInfo{
 			Scopes:     []string{"read", "write"},
 			Expiration: time.Now().UTC().Add(10 * 365 * 24 * time.Hour),
@@ -327,6 +699,17 @@ func mcpAgentName(req *mcp.CallToolRequest) (string, error) {
 	return name, nil
 }
 
+func mcpCheckMentions(ctx context.Context, database *sql.DB, body string) error {
+	unresolved, err := db.UnresolvedMentions(ctx, database, nil, body)
+	if err != nil {
+		return err
+	}
+	if len(unresolved) > 0 {
+		return errors.New("unknown mentions: " + strings.Join(unresolved, ", "))
+	}
+	return nil
+}
+
 func textToolResult(text string) *mcp.CallToolResult {
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
@@ -71,13 +71,21 @@ func TestMCPToolsFlow(t *testing.T) {
 		t.Fatalf("list tools: %v", err)
 	}
 	wantTools := map[string]bool{
-		"fora_get_primer":   false,
-		"fora_list_boards":  false,
-		"fora_list_threads": false,
-		"fora_read_thread":  false,
-		"fora_post":         false,
-		"fora_reply":        false,
-		"fora_view_agent":   false,
+		"fora_get_primer":           false,
+		"fora_list_boards":          false,
+		"fora_list_threads":         false,
+		"fora_read_thread":          false,
+		"fora_post":                 false,
+		"fora_reply":                false,
+		"fora_view_agent":           false,
+		"fora_find_agents":          false,
+		"fora_update_profile":       false,
+		"fora_heartbeat":            false,
+		"fora_wait_for_agent":       false,
+		"fora_send_direct_message":  false,
+		"fora_read_direct_messages": false,
+		"fora_vote":                 false,
+		"fora_close_poll":           false,
 	}
 	for _, tool := range tools.Tools {
 		if _, ok := wantTools[tool.Name]; ok {
@@ -146,15 +154,17 @@ func TestMCPToolsFlow(t *testing.T) {
 		t.Fatalf("call fora_post: %v", err)
 	}
 
-	_, err = session.CallTool(ctx, &mcp.CallToolParams{
+	badRes, err := session.CallTool(ctx, &mcp.CallToolParams{
 		Name: "fora_post",
 		Arguments: map[string]any{
-			"title": "missing board",
-			"body":  "body",
+			"title":    "unknown board",
+			"body":     "body",
+			"tags":     []string{},
+			"board_id": "nope",
 		},
 	})
-	if err == nil || !strings.Contains(err.Error(), "board_id") {
-		t.Fatalf("expected fora_post error requiring board_id, got: %v", err)
+	if err != nil || !badRes.IsError || !strings.Contains(firstTextContent(t, badRes), "unknown board_id") {
+		t.Fatalf("expected fora_post error for unknown board_id, got: %v %+v", err, badRes)
 	}
 
 	postText := firstTextContent(t, postRes)
@@ -244,6 +254,56 @@ func TestMCPToolsFlow(t *testing.T) {
 	if !foundPost {
 		t.Fatalf("view agent response does not include created post id")
 	}
+
+	if _, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "fora_update_profile",
+		Arguments: map[string]any{"team": "platform", "capabilities": []string{"Go", "triage"}},
+	}); err != nil {
+		t.Fatalf("call fora_update_profile: %v", err)
+	}
+	findRes, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "fora_find_agents",
+		Arguments: map[string]any{"capability": []string{"go"}},
+	})
+	if err != nil {
+		t.Fatalf("call fora_find_agents: %v", err)
+	}
+	var found struct {
+		Agents []struct {
+			Name    string `json:"name"`
+			Profile struct {
+				Team         string   `json:"team"`
+				Capabilities []string `json:"capabilities"`
+			} `json:"profile"`
+		} `json:"agents"`
+	}
+	if err := json.Unmarshal([]byte(firstTextContent(t, findRes)), &found); err != nil {
+		t.Fatalf("decode find agents response: %v", err)
+	}
+	if len(found.Agents) != 1 || found.Agents[0].Name != "admin" || found.Agents[0].Profile.Team != "platform" {
+		t.Fatalf("unexpected find agents response: %+v", found.Agents)
+	}
+
+	if _, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "fora_heartbeat",
+		Arguments: map[string]any{"status": "busy", "message": "reviewing", "ttl": "10m"},
+	}); err != nil {
+		t.Fatalf("call fora_heartbeat: %v", err)
+	}
+	waitRes, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "fora_wait_for_agent",
+		Arguments: map[string]any{"agent_name": "admin", "status": []string{"online", "busy"}, "timeout": "0s"},
+	})
+	if err != nil {
+		t.Fatalf("call fora_wait_for_agent: %v", err)
+	}
+	var waited presenceResponse
+	if err := json.Unmarshal([]byte(firstTextContent(t, waitRes)), &waited); err != nil {
+		t.Fatalf("decode wait response: %v", err)
+	}
+	if !waited.Ready || waited.Presence.Status != "busy" || waited.Presence.Message != "reviewing" {
+		t.Fatalf("unexpected wait response: %+v", waited)
+	}
 }
 
 type authHeaderTransport struct {
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"fora/internal/db"
+)
+
+type sendMessageRequest struct {
+	To   []string `json:"to"`
+	Body string   `json:"body"`
+}
+
+type postMessageRequest struct {
+	Body string `json:"body"`
+}
+
+// conversationsHandler lists the caller's conversations (GET) and sends a
+// direct message, reusing the conversation with the same members (POST).
+func conversationsHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent := currentAgent(r.Context())
+		if agent == nil {
+			writeError(w, http.StatusUnauthorized, "missing auth context")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			limit, offset := parseLimitOffset(r)
+			conversations, err := db.ListConversations(r.Context(), database, agent.Name, limit, offset)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to list conversations")
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"conversations": conversations, "limit": limit, "offset": offset})
+		case http.MethodPost:
+			var req sendMessageRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid json payload")
+				return
+			}
+			conversation, message, err := db.SendDirectMessage(r.Context(), database, agent.Name, req.To, req.Body)
+			if err != nil {
+				writeMessageError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, map[string]any{"conversation": conversation, "message": message})
+		default:
+			methodNotAllowed(w)
+		}
+	})
+}
+
+// conversationItemHandler serves GET /conversations/{id}, which marks the
+// conversation read, and POST /conversations/{id}/messages. Agents outside
+// a conversation get 404, as if it did not exist.
+func conversationItemHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent := currentAgent(r.Context())
+		if agent == nil {
+			writeError(w, http.StatusUnauthorized, "missing auth context")
+			return
+		}
+		id, sub, _ := strings.Cut(pathTail(r.URL.Path, "/api/v1/conversations/"), "/")
+		if id == "" || (sub != "" && sub != "messages") {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		switch {
+		case sub == "" && r.Method == http.MethodGet:
+			limit, offset := parseLimitOffset(r)
+			conversation, err := db.GetConversation(r.Context(), database, agent.Name, id, limit, offset)
+			if err != nil {
+				writeMessageError(w, err)
+				return
+			}
+			if err := db.MarkConversationRead(r.Context(), database, agent.Name, id); err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to mark conversation read")
+				return
+			}
+			writeJSON(w, http.StatusOK, conversation)
+		case sub == "messages" && r.Method == http.MethodPost:
+			var req postMessageRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid json payload")
+				return
+			}
+			message, err := db.PostDirectMessage(r.Context(), database, agent.Name, id, req.Body)
+			if err != nil {
+				writeMessageError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, message)
+		default:
+			methodNotAllowed(w)
+		}
+	})
+}
+
+func writeMessageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, "conversation not found")
+	case errors.Is(err, db.ErrUnknownAgent), errors.Is(err, db.ErrInvalidMessage):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "failed to handle direct message")
+	}
+}
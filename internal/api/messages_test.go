@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"fora/internal/models"
+)
+
+func TestDirectMessagesArePrivateAndNotify(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	aliceKey := createAgentForTest(t, database, "alice-dm", "agent")
+	bobKey := createAgentForTest(t, database, "bob-dm", "agent")
+	eveKey := createAgentForTest(t, database, "eve-dm", "agent")
+
+	if resp := doReq(t, server.URL, aliceKey, http.MethodPost, "/api/v1/conversations", map[string]any{"to": []string{"ghost"}, "body": "hello?"}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown recipient status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, aliceKey, http.MethodPost, "/api/v1/conversations", map[string]any{"to": []string{"bob-dm"}, "body": "  "}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("empty body status = %d", resp.StatusCode)
+	}
+
+	send := doReq(t, server.URL, aliceKey, http.MethodPost, "/api/v1/conversations", map[string]any{
+		"to":   []string{"Bob-DM"},
+		"body": "can you review the zebrafish rollout privately?",
+	})
+	if send.StatusCode != http.StatusCreated {
+		t.Fatalf("send status = %d", send.StatusCode)
+	}
+	var sent struct {
+		Conversation models.Conversation  `json:"conversation"`
+		Message      models.DirectMessage `json:"message"`
+	}
+	decodeJSON(t, send, &sent)
+	if !slices.Equal(sent.Conversation.Members, []string{"alice-dm", "bob-dm"}) {
+		t.Fatalf("unexpected members: %v", sent.Conversation.Members)
+	}
+
+	// A second message between the same agents lands in the same conversation.
+	again := doReq(t, server.URL, aliceKey, http.MethodPost, "/api/v1/conversations", map[string]any{
+		"to":   []string{"bob-dm"},
+		"body": "no rush, tomorrow is fine",
+	})
+	var second struct {
+		Conversation models.Conversation `json:"conversation"`
+	}
+	decodeJSON(t, again, &second)
+	if second.Conversation.ID != sent.Conversation.ID {
+		t.Fatalf("expected conversation %s to be reused, got %s", sent.Conversation.ID, second.Conversation.ID)
+	}
+
+	var notifs struct {
+		Notifications []models.Notification `json:"notifications"`
+	}
+	decodeJSON(t, doReq(t, server.URL, bobKey, http.MethodGet, "/api/v1/notifications", nil), &notifs)
+	if len(notifs.Notifications) != 2 || notifs.Notifications[0].Type != "direct_message" {
+		t.Fatalf("unexpected notifications: %+v", notifs.Notifications)
+	}
+
+	var list struct {
+		Conversations []models.Conversation `json:"conversations"`
+	}
+	decodeJSON(t, doReq(t, server.URL, bobKey, http.MethodGet, "/api/v1/conversations", nil), &list)
+	if len(list.Conversations) != 1 || list.Conversations[0].UnreadCount != 2 {
+		t.Fatalf("unexpected conversation list: %+v", list.Conversations)
+	}
+
+	path := "/api/v1/conversations/" + sent.Conversation.ID
+	if resp := doReq(t, server.URL, eveKey, http.MethodGet, path, nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("non-member read status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, eveKey, http.MethodPost, path+"/messages", map[string]any{"body": "let me in"}); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("non-member send status = %d", resp.StatusCode)
+	}
+
+	var read models.Conversation
+	decodeJSON(t, doReq(t, server.URL, bobKey, http.MethodGet, path, nil), &read)
+	if len(read.Messages) != 2 || read.Messages[0].Author != "alice-dm" {
+		t.Fatalf("unexpected messages: %+v", read.Messages)
+	}
+	reply := doReq(t, server.URL, bobKey, http.MethodPost, path+"/messages", map[string]any{"body": "sure, looking now"})
+	if reply.StatusCode != http.StatusCreated {
+		t.Fatalf("reply status = %d", reply.StatusCode)
+	}
+	_ = reply.Body.Close()
+	decodeJSON(t, doReq(t, server.URL, bobKey, http.MethodGet, "/api/v1/conversations", nil), &list)
+	if list.Conversations[0].UnreadCount != 0 || list.Conversations[0].MessageCount != 3 {
+		t.Fatalf("unexpected counts after reading: %+v", list.Conversations[0])
+	}
+
+	// Direct messages never show up in regular search.
+	query := "/api/v1/search?q=" + url.QueryEscape("zebrafish")
+	var search struct {
+		Total          int                    `json:"total"`
+		DirectMessages []models.DirectMessage `json:"direct_messages"`
+	}
+	decodeJSON(t, doReq(t, server.URL, bobKey, http.MethodGet, query, nil), &search)
+	if search.Total != 0 || search.DirectMessages != nil {
+		t.Fatalf("direct message leaked into search: %+v", search)
+	}
+	if resp := doReq(t, server.URL, bobKey, http.MethodGet, query+"&include_direct_messages=true", nil); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("non-admin direct message search status = %d", resp.StatusCode)
+	}
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, query+"&include_direct_messages=true", nil), &search)
+	if len(search.DirectMessages) != 1 || search.DirectMessages[0].ConversationID != sent.Conversation.ID {
+		t.Fatalf("unexpected admin direct message search: %+v", search.DirectMessages)
+	}
+
+	var export struct {
+		Data struct {
+			Conversations []models.Conversation `json:"conversations"`
+			Notifications []models.Notification `json:"notifications"`
+		} `json:"data"`
+	}
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/admin/export", map[string]any{"format": "json"}), &export)
+	if len(export.Data.Conversations) != 0 {
+		t.Fatalf("default export included conversations: %+v", export.Data.Conversations)
+	}
+	for _, n := range export.Data.Notifications {
+		if n.Type == "direct_message" {
+			t.Fatalf("default export included a direct message notification: %+v", n)
+		}
+	}
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/admin/export", map[string]any{
+		"format":                  "json",
+		"include_direct_messages": true,
+	}), &export)
+	if len(export.Data.Conversations) != 1 || len(export.Data.Conversations[0].Messages) != 3 {
+		t.Fatalf("unexpected exported conversations: %+v", export.Data.Conversations)
+	}
+}
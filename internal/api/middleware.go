@@ -19,7 +19,9 @@ type contextKey string
 
 const agentContextKey contextKey = "agent"
 
-type rateLimits struct {
+// RateLimits are the per-agent request budgets enforced on authenticated
+// routes.
+type RateLimits struct {
 	PostsPerHour   int
 	RepliesPerHour int
 	TotalWritesDay int
@@ -27,7 +29,7 @@ type rateLimits struct {
 	SearchPerMin   int
 }
 
-var defaultRateLimits = rateLimits{
+var defaultRateLimits = RateLimits{
 	PostsPerHour:   20,
 	RepliesPerHour: 60,
 	TotalWritesDay: 500,
@@ -52,12 +54,33 @@ func authMiddleware(database *sql.DB, next http.Handler) http.Handler {
 			writeError(w, http.StatusInternalServerError, "failed to authenticate")
 			return
 		}
+		if msg := inactiveAgentMessage(agent); msg != "" {
+			writeError(w, http.StatusForbidden, msg)
+			return
+		}
 
+		recordAgent(r.Context(), agent.Name)
 		ctx := context.WithValue(r.Context(), agentContextKey, agent)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// inactiveAgentMessage explains why a suspended or deactivated agent cannot
+// sign in, or returns "" for an active one.
+func inactiveAgentMessage(agent *models.Agent) string {
+	if agent.State == db.AgentActive {
+		return ""
+	}
+	msg := "agent " + agent.State
+	if agent.StateUntil != nil {
+		msg += " until " + *agent.StateUntil
+	}
+	if agent.StateReason != nil {
+		msg += ": " + *agent.StateReason
+	}
+	return msg
+}
+
 func adminOnly(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		agent := currentAgent(r.Context())
@@ -75,7 +98,7 @@ func currentAgent(ctx context.Context) *models.Agent {
 	return agent
 }
 
-func rateLimitMiddleware(database *sql.DB, limiter *ratelimit.Limiter, next http.Handler) http.Handler {
+func rateLimitMiddleware(database *sql.DB, limiter *ratelimit.Limiter, limits RateLimits, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		agent := currentAgent(r.Context())
 		if agent == nil {
@@ -84,12 +107,17 @@ func rateLimitMiddleware(database *sql.DB, limiter *ratelimit.Limiter, next http
 		}
 
 		now := time.Now().UTC()
-		checks := classifyRateChecks(r)
+		checks := classifyRateChecks(r, limits)
+		var charged []string
 		for _, c := range checks {
 			key := agent.Name + ":" + c.name
 			res := limiter.Allow(key, c.limit, c.window, now)
+			if res.Allowed {
+				charged = append(charged, key)
+			}
 			setRateLimitHeaders(w, res.Limit, res.Remaining, res.ResetAt)
 			if !res.Allowed {
+				rateLimitRejections.Inc(c.name)
 				setRetryAfter(w, res.ResetAt)
 				writeError(w, http.StatusTooManyRequests, "rate limit exceeded: "+c.name)
 				return
@@ -101,6 +129,7 @@ func rateLimitMiddleware(database *sql.DB, limiter *ratelimit.Limiter, next http
 				return
 			}
 			if supported && count >= c.limit {
+				rateLimitRejections.Inc(c.name)
 				setRateLimitHeaders(w, c.limit, 0, resetAt)
 				setRetryAfter(w, resetAt)
 				writeError(w, http.StatusTooManyRequests, "rate limit exceeded: "+c.name)
@@ -108,7 +137,19 @@ func rateLimitMiddleware(database *sql.DB, limiter *ratelimit.Limiter, next http
 			}
 		}
 
-		next.ServeHTTP(w, r)
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// A conditional read answered with 304 is free, so agents that
+		// revalidate their cache do not use up their read budget.
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == http.StatusNotModified {
+			for _, key := range charged {
+				limiter.Refund(key, now)
+			}
+		}
 	})
 }
 
@@ -153,41 +194,41 @@ type rateCheck struct {
 	window time.Duration
 }
 
-func classifyRateChecks(r *http.Request) []rateCheck {
+func classifyRateChecks(r *http.Request, limits RateLimits) []rateCheck {
 	checks := make([]rateCheck, 0, 3)
 	path := r.URL.Path
 	method := r.Method
 	if method == http.MethodGet {
 		checks = append(checks, rateCheck{
 			name:   "reads",
-			limit:  defaultRateLimits.ReadsPerMinute,
+			limit:  limits.ReadsPerMinute,
 			window: time.Minute,
 		})
 	} else {
 		checks = append(checks, rateCheck{
 			name:   "writes",
-			limit:  defaultRateLimits.TotalWritesDay,
+			limit:  limits.TotalWritesDay,
 			window: 24 * time.Hour,
 		})
 	}
 	if method == http.MethodGet && path == "/api/v1/search" {
 		checks = append(checks, rateCheck{
 			name:   "search",
-			limit:  defaultRateLimits.SearchPerMin,
+			limit:  limits.SearchPerMin,
 			window: time.Minute,
 		})
 	}
 	if method == http.MethodPost && path == "/api/v1/posts" {
 		checks = append(checks, rateCheck{
 			name:   "posts",
-			limit:  defaultRateLimits.PostsPerHour,
+			limit:  limits.PostsPerHour,
 			window: time.Hour,
 		})
 	}
 	if method == http.MethodPost && strings.HasPrefix(path, "/api/v1/posts/") && strings.HasSuffix(path, "/replies") {
 		checks = append(checks, rateCheck{
 			name:   "replies",
-			limit:  defaultRateLimits.RepliesPerHour,
+			limit:  limits.RepliesPerHour,
 			window: time.Hour,
 		})
 	}
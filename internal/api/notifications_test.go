@@ -82,3 +82,44 @@ func TestNotificationsLifecycle(t *testing.T) {
 	}
 	_ = clearResp.Body.Close()
 }
+
+func TestMentionsResolveCaseInsensitivelyAndReportUnknown(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	aliceKey := createAgentForTest(t, database, "alice", "agent")
+
+	resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title":    "Mentions",
+		"body":     "hi @ALICE and @ghost, not `@alice` again\n\n```\n@nobody\n```",
+		"board_id": "general",
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create post status = %d", resp.StatusCode)
+	}
+	post := decodeContent(t, resp)
+	if len(post.UnresolvedMentions) != 1 || post.UnresolvedMentions[0] != "ghost" {
+		t.Fatalf("unresolved_mentions = %v, want [ghost]", post.UnresolvedMentions)
+	}
+
+	notifs := doReq(t, server.URL, aliceKey, http.MethodGet, "/api/v1/notifications", nil)
+	var payload struct {
+		Notifications []struct {
+			Type string `json:"type"`
+		} `json:"notifications"`
+	}
+	decodeJSON(t, notifs, &payload)
+	if len(payload.Notifications) != 1 || payload.Notifications[0].Type != "mention" {
+		t.Fatalf("alice notifications = %+v, want one mention", payload.Notifications)
+	}
+
+	strict := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts/"+post.ID+"/replies", map[string]any{
+		"body":            "ping @ghost",
+		"strict_mentions": true,
+	})
+	if strict.StatusCode != http.StatusBadRequest {
+		t.Fatalf("strict reply status = %d, want 400", strict.StatusCode)
+	}
+	_ = strict.Body.Close()
+}
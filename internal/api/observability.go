@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"fora/internal/metrics"
+	"fora/internal/tracing"
+)
+
+const requestIDContextKey contextKey = "request_id"
+
+var (
+	httpRequests = metrics.NewCounterVec(
+		"fora_http_requests_total",
+		"HTTP requests by route, method and status.",
+		"route", "method", "status",
+	)
+	httpDuration = metrics.NewHistogramVec(
+		"fora_http_request_duration_seconds",
+		"HTTP request latency by route and method.",
+		metrics.DefaultBuckets,
+		"route", "method",
+	)
+	rateLimitRejections = metrics.NewCounterVec(
+		"fora_rate_limit_rejections_total",
+		"Requests rejected by the rate limiter, by bucket.",
+		"bucket",
+	)
+	webhookDeliveries = metrics.NewCounterVec(
+		"fora_webhook_deliveries_total",
+		"Webhook delivery attempts by event and outcome.",
+		"event", "outcome",
+	)
+	mcpToolCalls = metrics.NewCounterVec(
+		"fora_mcp_tool_calls_total",
+		"MCP tool calls by tool and outcome.",
+		"tool", "outcome",
+	)
+)
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestIDMiddleware reuses a well-formed incoming X-Request-ID or mints a
+// new one, and echoes it on the response.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get("X-Request-ID"))
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// observeMiddleware records request metrics and a server span and, when
+// logger is set, writes one access log line per request.
+func observeMiddleware(mux *http.ServeMux, logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		holder := &agentHolder{}
+		ctx := context.WithValue(r.Context(), agentHolderContextKey, holder)
+		ctx, span := tracing.Start(tracing.Extract(ctx, r.Header), r.Method, tracing.KindServer)
+		defer span.End()
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		elapsed := time.Since(started)
+
+		route := routeLabel(mux, r, rec.status)
+		httpRequests.Inc(route, r.Method, strconv.Itoa(rec.status))
+		httpDuration.Observe(elapsed.Seconds(), route, r.Method)
+
+		if span != nil {
+			span.SetName(r.Method + " " + route)
+			span.SetAttr("http.method", r.Method)
+			span.SetAttr("http.route", route)
+			span.SetAttr("http.status_code", rec.status)
+			span.SetAttr("fora.request_id", requestID(r.Context()))
+			if holder.name != "" {
+				span.SetAttr("fora.agent", holder.name)
+			}
+			if rec.status >= http.StatusInternalServerError {
+				span.RecordError(errors.New(http.StatusText(rec.status)))
+			}
+		}
+
+		if logger == nil {
+			return
+		}
+		attrs := []slog.Attr{
+			slog.String("request_id", requestID(r.Context())),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", route),
+			slog.Int("status", rec.status),
+			slog.Int("bytes", rec.bytes),
+			slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+			slog.String("remote", r.RemoteAddr),
+		}
+		if holder.name != "" {
+			attrs = append(attrs, slog.String("agent", holder.name))
+		}
+		logger.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+	})
+}
+
+const agentHolderContextKey contextKey = "agent_holder"
+
+// agentHolder lets authMiddleware, which runs inside the mux, report the
+// authenticated caller back to the access log wrapped around it.
+type agentHolder struct {
+	name string
+}
+
+func recordAgent(ctx context.Context, name string) {
+	if holder, _ := ctx.Value(agentHolderContextKey).(*agentHolder); holder != nil {
+		holder.name = name
+	}
+}
+
+// routeSegments are the literal path segments routes use below a subtree
+// pattern: sub-resources and actions. Every other segment is a name or ID.
+var routeSegments = map[string]bool{
+	"aliases": true, "anonymize": true, "attachments": true, "close": true,
+	"history": true, "members": true, "merge": true, "messages": true,
+	"metadata": true, "poll": true, "presence": true, "read": true,
+	"reassign": true, "rename": true, "replies": true, "state": true,
+	"status": true, "subscribe": true, "suggest-tags": true, "summary": true,
+	"tags": true, "test": true, "thread": true, "vote": true,
+}
+
+// maxRouteSegments is the deepest path below a subtree pattern any route
+// serves; deeper paths are labelled as such rather than one series each.
+const maxRouteSegments = 3
+
+// routeLabel maps a request to its mux pattern. Below a subtree pattern,
+// known sub-resource segments stay literal and every other segment is shown
+// as {id}, so names and IDs never create new series. 404s collapse to
+// "unmatched" for the same reason.
+func routeLabel(mux *http.ServeMux, r *http.Request, status int) string {
+	_, pattern := mux.Handler(r)
+	if pattern == "" || status == http.StatusNotFound {
+		return "unmatched"
+	}
+	if !strings.HasSuffix(pattern, "/") || pattern == "/" {
+		return pattern
+	}
+	tail := strings.Trim(strings.TrimPrefix(r.URL.Path, pattern), "/")
+	if tail == "" {
+		return pattern
+	}
+	segments := strings.Split(tail, "/")
+	if len(segments) > maxRouteSegments {
+		return pattern + "{deep}"
+	}
+	for i, seg := range segments {
+		if !routeSegments[seg] {
+			segments[i] = "{id}"
+		}
+	}
+	return pattern + strings.Join(segments, "/")
+}
+
+func mcpObserveMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		call, ok := req.(*mcp.CallToolRequest)
+		if !ok || call.Params == nil {
+			return next(ctx, method, req)
+		}
+		ctx, span := tracing.Start(ctx, "mcp.tool "+call.Params.Name, tracing.KindInternal)
+		defer span.End()
+		span.SetAttr("mcp.tool", call.Params.Name)
+
+		result, err := next(ctx, method, req)
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
+			span.RecordError(err)
+		} else if res, ok := result.(*mcp.CallToolResult); ok && res.IsError {
+			outcome = "error"
+			span.RecordError(errors.New("tool returned error result"))
+		}
+		mcpToolCalls.Inc(call.Params.Name, outcome)
+		return result, err
+	}
+}
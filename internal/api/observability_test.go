@@ -0,0 +1,125 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsEndpointReportsRequestsByRoute(t *testing.T) {
+	server, database, apiKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	resp := doReq(t, server.URL, apiKey, http.MethodGet, "/api/v1/boards/general", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("get board returned %d", resp.StatusCode)
+	}
+
+	resp = doReq(t, server.URL, "", http.MethodGet, "/metrics", nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("metrics returned %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	body := string(raw)
+	for _, want := range []string{
+		`fora_http_requests_total{route="/api/v1/boards/{id}",method="GET",status="200"}`,
+		`# TYPE fora_http_request_duration_seconds histogram`,
+		`# TYPE fora_db_query_duration_seconds histogram`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestRequestIDEchoedAndGenerated(t *testing.T) {
+	server, database, _ := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/status", nil)
+	req.Header.Set("X-Request-ID", "trace-123")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("do request: %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Request-ID"); got != "trace-123" {
+		t.Fatalf("expected incoming request id echoed, got %q", got)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/api/v1/status", nil)
+	req.Header.Set("X-Request-ID", "bad id with spaces")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("do request: %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Request-ID"); got == "" || strings.Contains(got, " ") {
+		t.Fatalf("expected generated request id, got %q", got)
+	}
+}
+
+func TestAccessLogWritesJSONLine(t *testing.T) {
+	_, database, apiKey := setupTestServer(t)
+	defer database.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	server := httptest.NewServer(NewRouterWithOptions(database, "test", Options{AccessLog: logger}))
+	defer server.Close()
+
+	resp := doReq(t, server.URL, apiKey, http.MethodGet, "/api/v1/whoami", nil)
+	resp.Body.Close()
+
+	var line map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &line); err != nil {
+		t.Fatalf("decode access log %q: %v", buf.String(), err)
+	}
+	if line["route"] != "/api/v1/whoami" || line["agent"] != "admin" || line["status"] != float64(http.StatusOK) {
+		t.Fatalf("unexpected access log line: %v", line)
+	}
+	if line["request_id"] == "" || line["request_id"] != resp.Header.Get("X-Request-ID") {
+		t.Fatalf("expected request id in access log, got %v", line["request_id"])
+	}
+}
+
+func TestRouteLabelCollapsesNamesAndIDs(t *testing.T) {
+	mux := http.NewServeMux()
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	for _, p := range []string{"/api/v1/status", "/api/v1/posts/", "/api/v1/groups/", "/api/v1/tags/"} {
+		mux.Handle(p, noop)
+	}
+	for path, want := range map[string]string{
+		"/api/v1/status":                     "/api/v1/status",
+		"/api/v1/posts/":                     "/api/v1/posts/",
+		"/api/v1/posts/p1":                   "/api/v1/posts/{id}",
+		"/api/v1/posts/p1/thread":            "/api/v1/posts/{id}/thread",
+		"/api/v1/posts/p1/poll/vote":         "/api/v1/posts/{id}/poll/vote",
+		"/api/v1/groups/infra/members/alice": "/api/v1/groups/{id}/members/{id}",
+		"/api/v1/groups/infra/members/bob":   "/api/v1/groups/{id}/members/{id}",
+		"/api/v1/tags/aliases/k8s":           "/api/v1/tags/aliases/{id}",
+		"/api/v1/tags/rename":                "/api/v1/tags/rename",
+		"/api/v1/posts/p1/a/b/c":             "/api/v1/posts/{deep}",
+	} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if got := routeLabel(mux, r, http.StatusOK); got != want {
+			t.Errorf("routeLabel(%s) = %q, want %q", path, got, want)
+		}
+	}
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/posts/missing", nil)
+	if got := routeLabel(mux, r, http.StatusNotFound); got != "unmatched" {
+		t.Errorf("404 label = %q, want unmatched", got)
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"fora/internal/db"
+	"fora/internal/models"
+)
+
+type pollRequest struct {
+	Options    []string `json:"options"`
+	Multiple   bool     `json:"multiple"`
+	Visibility string   `json:"visibility"`
+	// Deadline is an RFC3339 time or a duration from now.
+	Deadline string `json:"deadline"`
+}
+
+type voteRequest struct {
+	Options []int `json:"options"`
+}
+
+func pollParams(req pollRequest) (db.CreatePollParams, error) {
+	params := db.CreatePollParams{
+		Options:    req.Options,
+		Multiple:   req.Multiple,
+		Visibility: req.Visibility,
+	}
+	if raw := strings.TrimSpace(req.Deadline); raw != "" {
+		deadline, err := parseUntil(raw)
+		if err != nil {
+			return params, errors.New("deadline must be an RFC3339 time or a duration")
+		}
+		params.Deadline = &deadline
+	}
+	return params, db.ValidatePoll(params)
+}
+
+// postPollHandler serves /posts/{id}/poll, /poll/vote and /poll/close.
+func postPollHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent := currentAgent(r.Context())
+		if agent == nil {
+			writeError(w, http.StatusUnauthorized, "missing auth context")
+			return
+		}
+		id, action, _ := strings.Cut(pathTail(r.URL.Path, "/api/v1/posts/"), "/poll")
+		action = strings.Trim(action, "/")
+		if id == "" || strings.Contains(id, "/") {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+
+		switch action {
+		case "":
+			if r.Method != http.MethodGet {
+				methodNotAllowed(w)
+				return
+			}
+			poll, err := db.GetPoll(r.Context(), database, id, agent.Name)
+			if err != nil {
+				writePollError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, poll)
+		case "vote":
+			var (
+				poll *models.Poll
+				err  error
+			)
+			switch r.Method {
+			case http.MethodPost:
+				var req voteRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					writeError(w, http.StatusBadRequest, "invalid json payload")
+					return
+				}
+				poll, err = db.VotePoll(r.Context(), database, id, agent.Name, req.Options)
+			case http.MethodDelete:
+				poll, err = db.RetractPollVote(r.Context(), database, id, agent.Name)
+			default:
+				methodNotAllowed(w)
+				return
+			}
+			if err != nil {
+				writePollError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, poll)
+		case "close":
+			if r.Method != http.MethodPost {
+				methodNotAllowed(w)
+				return
+			}
+			post, err := db.GetContent(r.Context(), database, id)
+			if err != nil {
+				writePollError(w, err)
+				return
+			}
+			if post.Author != agent.Name && agent.Role != "admin" {
+				writeError(w, http.StatusForbidden, "only the post author or an admin can close this poll")
+				return
+			}
+			poll, closed, err := db.ClosePoll(r.Context(), database, id)
+			if err != nil {
+				writePollError(w, err)
+				return
+			}
+			if closed {
+				emitPollClosed(r.Context(), database, post, poll, agent.Name)
+			}
+			writeJSON(w, http.StatusOK, poll)
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	})
+}
+
+func writePollError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, "poll not found")
+	case errors.Is(err, db.ErrInvalidPoll):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, db.ErrPollClosed):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "failed to update poll")
+	}
+}
+
+// emitPollClosed sends poll.closed with the final tally. closedBy is empty
+// when the deadline closed the poll.
+func emitPollClosed(ctx context.Context, database *sql.DB, post *models.Content, poll *models.Poll, closedBy string) {
+	payload := map[string]any{
+		"post_id":   post.ID,
+		"thread_id": post.ThreadID,
+		"board_id":  post.BoardID,
+		"author":    post.Author,
+		"poll":      poll,
+	}
+	if closedBy != "" {
+		payload["closed_by"] = closedBy
+	}
+	emitWebhookEvent(ctx, database, "poll.closed", payload)
+}
+
+// closeDuePolls records polls whose deadline has passed and announces each
+// one once.
+func closeDuePolls(ctx context.Context, database *sql.DB) error {
+	polls, err := db.CloseDuePolls(ctx, database)
+	for i := range polls {
+		post, getErr := db.GetContent(ctx, database, polls[i].PostID)
+		if getErr != nil {
+			continue
+		}
+		emitPollClosed(ctx, database, post, &polls[i], "")
+	}
+	return err
+}
+
+// RunPollCloser closes polls as their deadlines pass, checking every
+// interval until ctx is done.
+func RunPollCloser(ctx context.Context, database *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := closeDuePolls(ctx, database); err != nil && ctx.Err() == nil {
+			log.Printf("close due polls: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"fora/internal/models"
+)
+
+func TestPollVotingTallyAndClose(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	authorKey := createAgentForTest(t, database, "poll-author", "agent")
+	aliceKey := createAgentForTest(t, database, "alice-voter", "agent")
+	bobKey := createAgentForTest(t, database, "bob-voter", "agent")
+
+	type closedEvent struct {
+		Event string `json:"event"`
+		Data  struct {
+			PostID string      `json:"post_id"`
+			Poll   models.Poll `json:"poll"`
+		} `json:"data"`
+	}
+	events := make(chan closedEvent, 4)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var ev closedEvent
+		_ = json.NewDecoder(r.Body).Decode(&ev)
+		events <- ev
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer sink.Close()
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/admin/webhooks", map[string]any{
+		"url":    sink.URL,
+		"events": []string{"poll.closed"},
+	}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create webhook status = %d", resp.StatusCode)
+	}
+
+	if resp := doReq(t, server.URL, authorKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"board_id": "general",
+		"title":    "One option",
+		"body":     "not much of a choice",
+		"poll":     map[string]any{"options": []string{"only"}},
+	}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("single-option poll status = %d", resp.StatusCode)
+	}
+
+	create := doReq(t, server.URL, authorKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"board_id": "general",
+		"title":    "Which queue?",
+		"body":     "We need to pick a queue for the ingest rewrite.",
+		"poll": map[string]any{
+			"options":  []string{"Kafka", "NATS", "SQS"},
+			"deadline": "1h",
+		},
+	})
+	if create.StatusCode != http.StatusCreated {
+		t.Fatalf("create post status = %d", create.StatusCode)
+	}
+	post := decodeContent(t, create)
+	if post.Poll == nil || len(post.Poll.Options) != 3 || post.Poll.Status != "open" || post.Poll.Visibility != "public" {
+		t.Fatalf("unexpected poll on created post: %+v", post.Poll)
+	}
+
+	pollPath := "/api/v1/posts/" + post.ID + "/poll"
+	if resp := doReq(t, server.URL, aliceKey, http.MethodPost, pollPath+"/vote", map[string]any{"options": []int{1, 2}}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("two choices on single-choice poll status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, aliceKey, http.MethodPost, pollPath+"/vote", map[string]any{"options": []int{7}}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown option status = %d", resp.StatusCode)
+	}
+	// Voting again replaces the earlier choice.
+	for _, vote := range []struct {
+		key    string
+		option int
+	}{{aliceKey, 1}, {aliceKey, 2}, {bobKey, 2}} {
+		resp := doReq(t, server.URL, vote.key, http.MethodPost, pollPath+"/vote", map[string]any{"options": []int{vote.option}})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("vote status = %d", resp.StatusCode)
+		}
+		_ = resp.Body.Close()
+	}
+
+	var poll models.Poll
+	decodeJSON(t, doReq(t, server.URL, bobKey, http.MethodGet, pollPath, nil), &poll)
+	if poll.Options[0].Votes != 0 || poll.Options[1].Votes != 2 || poll.TotalVoters != 2 {
+		t.Fatalf("unexpected tally: %+v", poll.Options)
+	}
+	if !slices.Equal(poll.Options[1].Voters, []string{"alice-voter", "bob-voter"}) || !slices.Equal(poll.MyVotes, []int{2}) {
+		t.Fatalf("unexpected voters or my_votes: %+v", poll)
+	}
+
+	raw := doReq(t, server.URL, bobKey, http.MethodGet, "/api/v1/posts/"+post.ID+"/thread?format=raw", nil)
+	body, _ := io.ReadAll(raw.Body)
+	_ = raw.Body.Close()
+	if !strings.Contains(string(body), "2. NATS — 2 votes (alice-voter, bob-voter)") {
+		t.Fatalf("raw thread lacks the tally:\n%s", body)
+	}
+	var thread struct {
+		Thread models.ThreadNode `json:"thread"`
+	}
+	decodeJSON(t, doReq(t, server.URL, bobKey, http.MethodGet, "/api/v1/posts/"+post.ID+"/thread", nil), &thread)
+	if thread.Thread.Poll == nil || thread.Thread.Poll.TotalVoters != 2 {
+		t.Fatalf("thread view lacks the poll: %+v", thread.Thread.Poll)
+	}
+
+	if resp := doReq(t, server.URL, aliceKey, http.MethodPost, pollPath+"/close", nil); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("non-author close status = %d", resp.StatusCode)
+	}
+
+	// Move the deadline into the past: voting stops at once, and the closer
+	// announces the poll exactly once.
+	if _, err := database.Exec(`UPDATE polls SET deadline = ? WHERE post_id = ?`,
+		time.Now().UTC().Add(-time.Minute).Format(time.RFC3339), post.ID); err != nil {
+		t.Fatalf("move deadline: %v", err)
+	}
+	if resp := doReq(t, server.URL, aliceKey, http.MethodPost, pollPath+"/vote", map[string]any{"options": []int{3}}); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("vote after deadline status = %d", resp.StatusCode)
+	}
+	for range 2 {
+		if err := closeDuePolls(context.Background(), database); err != nil {
+			t.Fatalf("close due polls: %v", err)
+		}
+	}
+	select {
+	case ev := <-events:
+		if ev.Event != "poll.closed" || ev.Data.PostID != post.ID || ev.Data.Poll.Options[1].Votes != 2 {
+			t.Fatalf("unexpected poll.closed event: %+v", ev)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected a poll.closed event")
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("poll.closed sent twice: %+v", ev)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Anonymous polls count votes without naming voters.
+	create = doReq(t, server.URL, authorKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"board_id": "general",
+		"title":    "Retro format",
+		"body":     "How should we run the retro?",
+		"poll": map[string]any{
+			"options":    []string{"async", "live", "skip"},
+			"multiple":   true,
+			"visibility": "anonymous",
+		},
+	})
+	anon := decodeContent(t, create)
+	decodeJSON(t, doReq(t, server.URL, aliceKey, http.MethodPost, "/api/v1/posts/"+anon.ID+"/poll/vote", map[string]any{"options": []int{1, 2}}), &poll)
+	if poll.Options[0].Votes != 1 || poll.Options[1].Votes != 1 || poll.Options[0].Voters != nil {
+		t.Fatalf("unexpected anonymous tally: %+v", poll.Options)
+	}
+	decodeJSON(t, doReq(t, server.URL, authorKey, http.MethodPost, "/api/v1/posts/"+anon.ID+"/poll/close", nil), &poll)
+	if poll.Status != "closed" {
+		t.Fatalf("expected closed poll, got %+v", poll)
+	}
+	select {
+	case ev := <-events:
+		if ev.Data.PostID != anon.ID {
+			t.Fatalf("unexpected poll.closed event: %+v", ev)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected a poll.closed event for the manual close")
+	}
+}
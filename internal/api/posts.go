@@ -12,6 +12,7 @@ import (
 
 	"fora/internal/db"
 	"fora/internal/models"
+	"fora/internal/tracing"
 )
 
 type createPostRequest struct {
@@ -20,6 +21,10 @@ type createPostRequest struct {
 	Tags     []string `json:"tags"`
 	Mentions []string `json:"mentions"`
 	BoardID  string   `json:"board_id"`
+	// StrictMentions rejects the post when a mention matches no agent or
+	// group instead of reporting it in unresolved_mentions.
+	StrictMentions bool         `json:"strict_mentions"`
+	Poll           *pollRequest `json:"poll"`
 }
 
 type updatePostRequest struct {
@@ -28,8 +33,9 @@ type updatePostRequest struct {
 }
 
 type createReplyRequest struct {
-	Body     string   `json:"body"`
-	Mentions []string `json:"mentions"`
+	Body           string   `json:"body"`
+	Mentions       []string `json:"mentions"`
+	StrictMentions bool     `json:"strict_mentions"`
 }
 
 type updateReplyRequest struct {
@@ -45,6 +51,21 @@ type updateStatusRequest struct {
 	Status string `json:"status"`
 }
 
+// rejectUnresolvedMentions answers 400 and returns true when a mention in
+// explicit or body matches no agent or group.
+func rejectUnresolvedMentions(w http.ResponseWriter, r *http.Request, database *sql.DB, explicit []string, body string) bool {
+	unresolved, err := db.UnresolvedMentions(r.Context(), database, explicit, body)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to resolve mentions")
+		return true
+	}
+	if len(unresolved) == 0 {
+		return false
+	}
+	writeError(w, http.StatusBadRequest, "unknown mentions: "+strings.Join(unresolved, ", "))
+	return true
+}
+
 func postsCollectionHandler(database *sql.DB) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -60,9 +81,17 @@ func postsCollectionHandler(database *sql.DB) http.Handler {
 				return
 			}
 			req.BoardID = strings.TrimSpace(req.BoardID)
-			if req.BoardID == "" {
-				writeError(w, http.StatusBadRequest, "board_id is required")
-				return
+			if req.BoardID == "" || req.BoardID == db.AutoBoard {
+				boardID, err := db.RouteBoard(r.Context(), database, req.Tags)
+				if err != nil {
+					if errors.Is(err, db.ErrNoBoard) {
+						writeError(w, http.StatusBadRequest, err.Error())
+						return
+					}
+					writeError(w, http.StatusInternalServerError, "failed to route post")
+					return
+				}
+				req.BoardID = boardID
 			}
 			ok, err := db.BoardExists(r.Context(), database, req.BoardID)
 			if err != nil {
@@ -73,8 +102,28 @@ func postsCollectionHandler(database *sql.DB) http.Handler {
 				writeError(w, http.StatusBadRequest, "unknown board_id")
 				return
 			}
-			post, err := db.CreatePost(r.Context(), database, agent.Name, req.Title, req.Body, req.Tags, req.Mentions, req.BoardID)
+			if req.StrictMentions && rejectUnresolvedMentions(w, r, database, req.Mentions, req.Body) {
+				return
+			}
+			var poll *db.CreatePollParams
+			if req.Poll != nil {
+				params, err := pollParams(*req.Poll)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, err.Error())
+					return
+				}
+				poll = &params
+			}
+			post, err := db.CreatePost(r.Context(), database, agent.Name, req.Title, req.Body, req.Tags, req.Mentions, req.BoardID, poll)
 			if err != nil {
+				if errors.Is(err, db.ErrBoardArchived) {
+					writeError(w, http.StatusConflict, err.Error())
+					return
+				}
+				if errors.Is(err, db.ErrTagNotAllowed) || errors.Is(err, db.ErrInvalidPoll) {
+					writeError(w, http.StatusBadRequest, err.Error())
+					return
+				}
 				if strings.Contains(err.Error(), "body is required") || strings.Contains(err.Error(), "board_id is required") {
 					writeError(w, http.StatusBadRequest, err.Error())
 					return
@@ -82,14 +131,14 @@ func postsCollectionHandler(database *sql.DB) http.Handler {
 				writeError(w, http.StatusInternalServerError, "failed to create post")
 				return
 			}
-			emitWebhookEvent(database, "thread.created", map[string]any{
+			emitWebhookEvent(r.Context(), database, "thread.created", map[string]any{
 				"id":        post.ID,
 				"author":    post.Author,
 				"thread_id": post.ThreadID,
 				"board_id":  post.BoardID,
 			})
 			if len(req.Mentions) > 0 {
-				emitWebhookEvent(database, "mention.created", map[string]any{
+				emitWebhookEvent(r.Context(), database, "mention.created", map[string]any{
 					"content_id": post.ID,
 					"thread_id":  post.ThreadID,
 					"from":       post.Author,
@@ -109,12 +158,15 @@ func postsCollectionHandler(database *sql.DB) http.Handler {
 				writeError(w, http.StatusInternalServerError, "failed to list posts")
 				return
 			}
-			writeJSON(w, http.StatusOK, map[string]any{
+			// No Last-Modified: deletions, threads leaving the filter and the
+			// viewer's read markers change the list without moving any
+			// thread's last activity, so only the ETag is reliable.
+			writeJSONConditional(w, r, map[string]any{
 				"threads": posts,
 				"total":   total,
 				"limit":   params.Limit,
 				"offset":  params.Offset,
-			})
+			}, time.Time{})
 		default:
 			methodNotAllowed(w)
 		}
@@ -148,7 +200,17 @@ func postItemHandler(database *sql.DB) http.Handler {
 				writeError(w, http.StatusNotFound, "post not found")
 				return
 			}
-			writeJSON(w, http.StatusOK, content)
+			items := []models.Content{*content}
+			if html, err := renderHTML(r); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			} else if html {
+				if err := renderBodies(r.Context(), database, items); err != nil {
+					writeError(w, http.StatusInternalServerError, "failed to render post")
+					return
+				}
+			}
+			writeJSONConditional(w, r, items[0], latestChange(items))
 		case http.MethodPut:
 			agent := currentAgent(r.Context())
 			if agent == nil {
@@ -255,6 +317,9 @@ func repliesHandler(database *sql.DB) http.Handler {
 				writeError(w, http.StatusBadRequest, "invalid json payload")
 				return
 			}
+			if req.StrictMentions && rejectUnresolvedMentions(w, r, database, req.Mentions, req.Body) {
+				return
+			}
 			reply, err := db.CreateReply(r.Context(), database, agent.Name, parentID, req.Body, req.Mentions)
 			if err != nil {
 				if strings.Contains(err.Error(), "body is required") {
@@ -268,14 +333,14 @@ func repliesHandler(database *sql.DB) http.Handler {
 				writeError(w, http.StatusInternalServerError, "failed to create reply")
 				return
 			}
-			emitWebhookEvent(database, "reply.created", map[string]any{
+			emitWebhookEvent(r.Context(), database, "reply.created", map[string]any{
 				"id":        reply.ID,
 				"author":    reply.Author,
 				"thread_id": reply.ThreadID,
 				"parent_id": reply.ParentID,
 			})
 			if len(req.Mentions) > 0 {
-				emitWebhookEvent(database, "mention.created", map[string]any{
+				emitWebhookEvent(r.Context(), database, "mention.created", map[string]any{
 					"content_id": reply.ID,
 					"thread_id":  reply.ThreadID,
 					"from":       reply.Author,
@@ -298,11 +363,20 @@ func repliesHandler(database *sql.DB) http.Handler {
 				writeError(w, http.StatusInternalServerError, "failed to list replies")
 				return
 			}
-			writeJSON(w, http.StatusOK, map[string]any{
+			if html, err := renderHTML(r); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			} else if html {
+				if err := renderBodies(r.Context(), database, replies); err != nil {
+					writeError(w, http.StatusInternalServerError, "failed to render replies")
+					return
+				}
+			}
+			writeJSONConditional(w, r, map[string]any{
 				"replies": replies,
 				"limit":   limit,
 				"offset":  offset,
-			})
+			}, latestChange(replies))
 		default:
 			methodNotAllowed(w)
 		}
@@ -425,11 +499,49 @@ func threadHandler(database *sql.DB) http.Handler {
 			writeError(w, http.StatusInternalServerError, "failed to resolve thread")
 			return
 		}
+		sinceLastRead, err := parseBool(strings.TrimSpace(r.URL.Query().Get("since_last_read")))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid since_last_read value")
+			return
+		}
+		if sinceLastRead && (r.URL.Query().Get("since") != "" || r.URL.Query().Get("format") != "") {
+			writeError(w, http.StatusBadRequest, "since_last_read cannot be combined with since or format")
+			return
+		}
+		// Mark before loading: a reply landing in between is then shown and
+		// still counted as unread, never hidden.
+		var prevRead *db.ThreadRead
+		if agent := currentAgent(r.Context()); agent != nil {
+			prevRead, err = db.MarkThreadRead(r.Context(), database, agent.Name, threadID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to record read")
+				return
+			}
+		}
 		items, err := db.ListThreadContent(r.Context(), database, threadID)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, "failed to load thread")
 			return
 		}
+		if sinceLastRead {
+			changes := make([]models.Content, 0, len(items))
+			lastRead := ""
+			if prevRead != nil {
+				lastRead = prevRead.LastReadAt
+				for _, item := range items {
+					if prevRead.Changed[item.ID] {
+						changes = append(changes, item)
+					}
+				}
+			}
+			writeJSON(w, http.StatusOK, map[string]any{
+				"thread_id": threadID,
+				"last_read": lastRead,
+				"changes":   changes,
+			})
+			return
+		}
+		lastModified := latestChange(items)
 		if sinceRaw := strings.TrimSpace(r.URL.Query().Get("since")); sinceRaw != "" {
 			since, err := parseSince(sinceRaw)
 			if err != nil {
@@ -438,12 +550,27 @@ func threadHandler(database *sql.DB) http.Handler {
 			}
 			items = filterThreadItemsSince(items, since)
 		}
+		html, err := renderHTML(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		raw := strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("format")), "raw")
+		if html && !raw {
+			if err := renderBodies(r.Context(), database, items); err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to render thread")
+				return
+			}
+		}
+		_, span := tracing.Start(r.Context(), "thread.render", tracing.KindInternal)
+		defer span.End()
+		span.SetAttr("fora.thread_items", len(items))
 		root, ok := buildThreadTree(items)
 		if !ok {
 			writeError(w, http.StatusInternalServerError, "thread assembly failed")
 			return
 		}
-		if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("format")), "raw") {
+		if raw {
 			depth, err := parseDepth(r.URL.Query().Get("depth"))
 			if err != nil {
 				writeError(w, http.StatusBadRequest, err.Error())
@@ -458,14 +585,11 @@ func threadHandler(database *sql.DB) http.Handler {
 				}
 				maxTokens = n
 			}
-			raw := renderThreadRaw(root, depth)
-			raw = truncateByMaxTokens(raw, maxTokens)
-			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
-			w.WriteHeader(http.StatusOK)
-			_, _ = w.Write([]byte(raw))
+			out := truncateByMaxTokens(renderThreadRaw(root, depth), maxTokens)
+			writeConditional(w, r, "text/markdown; charset=utf-8", []byte(out), lastModified)
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"thread": root})
+		writeJSONConditional(w, r, map[string]any{"thread": root}, lastModified)
 	})
 }
 
@@ -517,6 +641,10 @@ func postTagsHandler(database *sql.DB) http.Handler {
 				writeError(w, http.StatusNotFound, "post not found")
 				return
 			}
+			if errors.Is(err, db.ErrTagNotAllowed) {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
 			writeError(w, http.StatusInternalServerError, "failed to update tags")
 			return
 		}
@@ -601,7 +729,7 @@ func postStatusHandler(database *sql.DB) http.Handler {
 			writeError(w, http.StatusInternalServerError, "failed to update status")
 			return
 		}
-		emitWebhookEvent(database, "status.changed", map[string]any{
+		emitWebhookEvent(r.Context(), database, "status.changed", map[string]any{
 			"id":         updated.ID,
 			"thread_id":  updated.ThreadID,
 			"status":     updated.Status,
@@ -683,7 +811,7 @@ func postSummaryHandler(database *sql.DB) http.Handler {
 		}
 		raw := renderThreadRaw(root, 0)
 		summary := summarizeRaw(raw, 3)
-		emitWebhookEvent(database, "summary.requested", map[string]any{
+		emitWebhookEvent(r.Context(), database, "summary.requested", map[string]any{
 			"thread_id": threadID,
 		})
 		writeJSON(w, http.StatusOK, map[string]any{
@@ -741,6 +869,16 @@ func parseListPostsParams(r *http.Request) (db.ListPostsParams, error) {
 		Sort:   strings.TrimSpace(q.Get("sort")),
 		Order:  strings.TrimSpace(q.Get("order")),
 	}
+	if agent := currentAgent(r.Context()); agent != nil {
+		params.Viewer = agent.Name
+	}
+	if raw := strings.TrimSpace(q.Get("unread")); raw != "" {
+		unread, err := strconv.ParseBool(raw)
+		if err != nil {
+			return db.ListPostsParams{}, errors.New("invalid unread value")
+		}
+		params.Unread = unread
+	}
 	if params.Status != "" {
 		switch params.Status {
 		case "open", "closed", "pinned", "archived":
@@ -770,6 +908,8 @@ func parseListPostsParams(r *http.Request) (db.ListPostsParams, error) {
 		}
 		params.Since = &t
 	}
+	params.LinksTo = strings.TrimSpace(q.Get("links_to"))
+	params.References = strings.TrimSpace(q.Get("references"))
 	return params, nil
 }
 
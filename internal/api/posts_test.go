@@ -5,10 +5,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -113,24 +115,81 @@ func TestPostsAndRepliesLifecycle(t *testing.T) {
 	_ = deleteResp.Body.Close()
 }
 
-func TestCreatePostRequiresBoardID(t *testing.T) {
+func TestCreatePostWithoutBoardIDIsRoutedByTags(t *testing.T) {
 	server, database, adminKey := setupTestServer(t)
 	defer server.Close()
 	defer database.Close()
 
+	for name, tags := range map[string][]string{
+		"Infra":    {"k8s", "terraform", "ci"},
+		"Frontend": {"css", "ci"},
+	} {
+		if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/boards", map[string]any{"name": name, "tags": tags}); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create board %s status = %d", name, resp.StatusCode)
+		}
+	}
+
+	for i, tc := range []struct {
+		board string
+		tags  []string
+		want  string
+	}{
+		{"", []string{"CI", "Terraform"}, "infra"},
+		{"auto", []string{"css"}, "frontend"},
+		{"", []string{"unrelated"}, "general"},
+		{"auto", nil, "general"},
+	} {
+		req := map[string]any{"title": "Routed", "body": fmt.Sprintf("routed body %d", i), "tags": tc.tags}
+		if tc.board != "" {
+			req["board_id"] = tc.board
+		}
+		resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", req)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("case %d: status = %d", i, resp.StatusCode)
+		}
+		if post := decodeContent(t, resp); post.BoardID != tc.want {
+			t.Fatalf("case %d: routed to %q, want %q", i, post.BoardID, tc.want)
+		}
+	}
+
+	// Tags are routed after alias resolution, and a board whose allow-list
+	// would reject the post is passed over.
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/tags/aliases", map[string]any{"alias": "styles", "tag": "css"}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create alias status = %d", resp.StatusCode)
+	}
+	aliased := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Routed", "body": "styles only", "tags": []string{"styles"},
+	}))
+	if aliased.BoardID != "frontend" || !slices.Equal(aliased.Tags, []string{"css"}) {
+		t.Fatalf("aliased tag routed to %q with tags %v", aliased.BoardID, aliased.Tags)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/boards", map[string]any{
+		"name": "Ops", "tags": []string{"k8s", "terraform", "ci", "deploy"},
+	}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create board Ops status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/boards/ops", map[string]any{"restrict_tags": true}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("restrict board status = %d", resp.StatusCode)
+	}
 	resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
-		"title": "Missing board",
-		"body":  "body",
+		"title": "Routed", "body": "off-list tag", "tags": []string{"k8s", "terraform", "ci", "deploy", "misc"},
 	})
-	if resp.StatusCode != http.StatusBadRequest {
-		t.Fatalf("expected 400 when board_id is omitted, got %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("routing onto a restricted board status = %d", resp.StatusCode)
 	}
-	var payload struct {
-		Error string `json:"error"`
+	if post := decodeContent(t, resp); post.BoardID != "infra" {
+		t.Fatalf("expected the restricted board to be skipped, routed to %q", post.BoardID)
+	}
+
+	// Archived boards are never picked.
+	if resp := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/boards/frontend", map[string]any{"archived": true}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("archive status = %d", resp.StatusCode)
 	}
-	decodeJSON(t, resp, &payload)
-	if !strings.Contains(payload.Error, "board_id is required") {
-		t.Fatalf("unexpected error payload: %q", payload.Error)
+	post := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Routed", "body": "css only", "tags": []string{"css"},
+	}))
+	if post.BoardID != "general" {
+		t.Fatalf("post routed to archived board: %q", post.BoardID)
 	}
 }
 
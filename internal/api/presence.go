@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"fora/internal/db"
+	"fora/internal/models"
+)
+
+const (
+	maxPresenceWait      = 60 * time.Second
+	presencePollInterval = 500 * time.Millisecond
+)
+
+type heartbeatRequest struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	// TTL is a Go duration such as "90s" or "10m".
+	TTL string `json:"ttl"`
+}
+
+type presenceResponse struct {
+	Agent    string           `json:"agent"`
+	Presence *models.Presence `json:"presence"`
+	// Ready reports whether the status is one the caller asked for.
+	Ready bool `json:"ready"`
+}
+
+// mePresenceHandler serves the calling agent's heartbeat: PUT sets it, GET
+// reads it back and DELETE goes offline.
+func mePresenceHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent := currentAgent(r.Context())
+		if agent == nil {
+			writeError(w, http.StatusUnauthorized, "missing auth context")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			presence, err := db.GetPresence(r.Context(), database, agent.Name)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to read presence")
+				return
+			}
+			writeJSON(w, http.StatusOK, presence)
+		case http.MethodPut, http.MethodDelete:
+			req := heartbeatRequest{Status: db.PresenceOffline}
+			if r.Method == http.MethodPut {
+				req.Status = ""
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					writeError(w, http.StatusBadRequest, "invalid json payload")
+					return
+				}
+			}
+			var ttl time.Duration
+			if raw := strings.TrimSpace(req.TTL); raw != "" {
+				d, err := time.ParseDuration(raw)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, "ttl must be a duration like 90s or 10m")
+					return
+				}
+				ttl = d
+			}
+			presence, err := db.SetPresence(r.Context(), database, agent.Name, req.Status, req.Message, ttl)
+			if err != nil {
+				if errors.Is(err, db.ErrInvalidPresence) {
+					writeError(w, http.StatusBadRequest, err.Error())
+					return
+				}
+				writeError(w, http.StatusInternalServerError, "failed to set presence")
+				return
+			}
+			writeJSON(w, http.StatusOK, presence)
+		default:
+			methodNotAllowed(w)
+		}
+	})
+}
+
+// hiveAgentPresence serves GET /api/v1/hive/agents/{name}/presence. With
+// ?wait=30s it holds the request until the agent's status is one of ?status
+// (default online) or the wait runs out, whichever comes first.
+func hiveAgentPresence(w http.ResponseWriter, r *http.Request, database *sql.DB, name string) {
+	q := r.URL.Query()
+	wanted, err := parsePresenceStatuses(q.Get("status"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var wait time.Duration
+	if raw := strings.TrimSpace(q.Get("wait")); raw != "" {
+		if wait, err = time.ParseDuration(raw); err != nil || wait < 0 {
+			writeError(w, http.StatusBadRequest, "wait must be a duration like 30s")
+			return
+		}
+		wait = min(wait, maxPresenceWait)
+	}
+
+	presence, err := waitForPresence(r.Context(), database, name, wanted, wait)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "agent not found")
+			return
+		}
+		if r.Context().Err() != nil {
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to read presence")
+		return
+	}
+	writeJSON(w, http.StatusOK, presenceResponse{Agent: name, Presence: presence, Ready: wanted[presence.Status]})
+}
+
+// waitForPresence polls the agent's presence until its status is wanted, the
+// wait elapses or ctx is done, and returns the last presence read.
+func waitForPresence(ctx context.Context, database *sql.DB, name string, wanted map[string]bool, wait time.Duration) (*models.Presence, error) {
+	deadline := time.Now().Add(wait)
+	ticker := time.NewTicker(presencePollInterval)
+	defer ticker.Stop()
+	for {
+		presence, err := db.GetPresence(ctx, database, name)
+		if err != nil || wanted[presence.Status] || !time.Now().Before(deadline) {
+			return presence, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// parsePresenceStatuses reads a comma-separated status list; empty means
+// online.
+func parsePresenceStatuses(raw string) (map[string]bool, error) {
+	wanted := map[string]bool{}
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		status, err := db.ParsePresenceStatus(s)
+		if err != nil {
+			return nil, err
+		}
+		wanted[status] = true
+	}
+	if len(wanted) == 0 {
+		wanted[db.PresenceOnline] = true
+	}
+	return wanted, nil
+}
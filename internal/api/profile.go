@@ -0,0 +1,64 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"fora/internal/db"
+)
+
+type updateProfileRequest struct {
+	DisplayName  *string   `json:"display_name"`
+	Description  *string   `json:"description"`
+	Team         *string   `json:"team"`
+	Model        *string   `json:"model"`
+	Contact      *string   `json:"contact"`
+	Capabilities *[]string `json:"capabilities"`
+}
+
+// meProfileHandler lets the calling agent read and edit its own profile.
+func meProfileHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent := currentAgent(r.Context())
+		if agent == nil {
+			writeError(w, http.StatusUnauthorized, "missing auth context")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			profile, err := db.GetAgentProfile(r.Context(), database, agent.Name)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to read profile")
+				return
+			}
+			writeJSON(w, http.StatusOK, profile)
+		case http.MethodPatch:
+			var req updateProfileRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid json payload")
+				return
+			}
+			profile, err := db.UpdateAgentProfile(r.Context(), database, agent.Name, db.AgentProfileUpdate{
+				DisplayName:  req.DisplayName,
+				Description:  req.Description,
+				Team:         req.Team,
+				Model:        req.Model,
+				Contact:      req.Contact,
+				Capabilities: req.Capabilities,
+			})
+			if err != nil {
+				if errors.Is(err, db.ErrInvalidProfile) {
+					writeError(w, http.StatusBadRequest, err.Error())
+					return
+				}
+				writeError(w, http.StatusInternalServerError, "failed to update profile")
+				return
+			}
+			writeJSON(w, http.StatusOK, profile)
+		default:
+			methodNotAllowed(w)
+		}
+	})
+}
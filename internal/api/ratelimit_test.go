@@ -11,7 +11,7 @@ import (
 
 func TestRateLimitOnPostCreation(t *testing.T) {
 	orig := defaultRateLimits
-	defaultRateLimits = rateLimits{
+	defaultRateLimits = RateLimits{
 		PostsPerHour:   1,
 		RepliesPerHour: 10,
 		TotalWritesDay: 100,
@@ -50,7 +50,7 @@ func TestRateLimitOnPostCreation(t *testing.T) {
 
 func TestRateLimitDBFallbackAcrossRestart(t *testing.T) {
 	orig := defaultRateLimits
-	defaultRateLimits = rateLimits{
+	defaultRateLimits = RateLimits{
 		PostsPerHour:   1,
 		RepliesPerHour: 10,
 		TotalWritesDay: 100,
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"fora/internal/db"
+	"fora/internal/markdown"
+	"fora/internal/models"
+)
+
+// renderHTML reports whether the request asked for ?render=html.
+func renderHTML(r *http.Request) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("render"))) {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	}
+	return false, errors.New("invalid render value (want html)")
+}
+
+// refResolver links #thread-id references to content that exists and
+// @mentions to registered agents; anything else stays plain text.
+type refResolver struct {
+	threads map[string]bool
+	// agents maps mentioned names to agent names, which may differ in case.
+	agents map[string]string
+}
+
+func (res refResolver) ThreadHref(id string) (string, bool) {
+	return "/api/v1/posts/" + id + "/thread", res.threads[id]
+}
+
+func (res refResolver) AgentHref(name string) (string, bool) {
+	agent, ok := res.agents[name]
+	return "/api/v1/hive/agents/" + agent, ok
+}
+
+// renderBodies sets BodyHTML on every item.
+func renderBodies(ctx context.Context, database *sql.DB, items []models.Content) error {
+	var threads, agents []string
+	for _, item := range items {
+		meta := markdown.Extract(item.Body)
+		threads = append(threads, meta.ThreadRefs...)
+		agents = append(agents, meta.Mentions...)
+	}
+	existingThreads, err := db.ExistingContentIDs(ctx, database, threads)
+	if err != nil {
+		return err
+	}
+	existingAgents, err := db.ExistingAgents(ctx, database, agents)
+	if err != nil {
+		return err
+	}
+	res := refResolver{threads: existingThreads, agents: existingAgents}
+	for i := range items {
+		items[i].BodyHTML = markdown.HTML(items[i].Body, res)
+	}
+	return nil
+}
+
+// contentMetadataHandler serves GET /api/v1/posts/{id}/metadata for posts
+// and replies.
+func contentMetadataHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1/posts/")
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		if len(parts) != 2 || parts[1] != "metadata" || parts[0] == "" {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		content, err := db.GetContent(r.Context(), database, parts[0])
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeError(w, http.StatusNotFound, "content not found")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "failed to load content")
+			return
+		}
+		meta, err := db.GetContentMetadata(r.Context(), database, content.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to load metadata")
+			return
+		}
+		writeJSONConditional(w, r, struct {
+			ContentID string `json:"content_id"`
+			*markdown.Metadata
+		}{content.ID, meta}, latestChange([]models.Content{*content}))
+	})
+}
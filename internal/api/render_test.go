@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"fora/internal/models"
+)
+
+func TestRenderHTMLResolvesRefsAndMetadataIsQueryable(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+	createAgentForTest(t, database, "bob", "agent")
+
+	target := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Design", "body": "the design doc", "board_id": "general",
+	}))
+	body := "## Plan\n\nSee #" + target.ID + " and #20200101T000000Z-deadbeef, ask @bob.\n\n" +
+		"<script>alert(1)</script> [x](javascript:alert(1)) https://docs.example.com/a\n\n```go\nfmt.Println(1)\n```\n"
+	post := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Follow-up", "body": body, "board_id": "general",
+	}))
+	if post.BodyHTML != "" {
+		t.Fatalf("body_html should only be set on request: %q", post.BodyHTML)
+	}
+
+	rendered := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/posts/"+post.ID+"?render=html", nil))
+	for _, want := range []string{
+		`<h2 id="plan">Plan</h2>`,
+		`<a class="thread-ref" href="/api/v1/posts/` + target.ID + `/thread">#` + target.ID + `</a>`,
+		`#20200101T000000Z-deadbeef`,
+		`<a class="mention" href="/api/v1/hive/agents/bob">@bob</a>`,
+		`&lt;script&gt;`,
+		`rel="nofollow noopener noreferrer"`,
+		`<code class="language-go">`,
+	} {
+		if !strings.Contains(rendered.BodyHTML, want) {
+			t.Fatalf("body_html missing %q:\n%s", want, rendered.BodyHTML)
+		}
+	}
+	for _, bad := range []string{"<script>", "javascript:", `deadbeef/thread`} {
+		if strings.Contains(rendered.BodyHTML, bad) {
+			t.Fatalf("body_html should not contain %q:\n%s", bad, rendered.BodyHTML)
+		}
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/posts/"+post.ID+"?render=pdf", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid render status = %d", resp.StatusCode)
+	}
+
+	reply := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts/"+target.ID+"/replies", map[string]any{
+		"body": "mirror at https://mirror.example.org/x",
+	}))
+	var out struct {
+		Thread models.ThreadNode `json:"thread"`
+	}
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/posts/"+target.ID+"/thread?render=html", nil), &out)
+	thread := out.Thread
+	if thread.BodyHTML != "<p>the design doc</p>\n" || len(thread.Replies) != 1 || !strings.Contains(thread.Replies[0].BodyHTML, `href="https://mirror.example.org/x"`) {
+		t.Fatalf("unexpected rendered thread: %+v", thread)
+	}
+
+	var meta struct {
+		ContentID  string `json:"content_id"`
+		Links      []struct{ URL, Host string }
+		CodeBlocks []struct{ Language, Code string } `json:"code_blocks"`
+		Headings   []struct{ Anchor string }
+		ThreadRefs []string `json:"thread_refs"`
+		Mentions   []string
+	}
+	decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/posts/"+post.ID+"/metadata", nil), &meta)
+	if meta.ContentID != post.ID || len(meta.Links) != 1 || meta.Links[0].Host != "docs.example.com" ||
+		len(meta.CodeBlocks) != 1 || meta.CodeBlocks[0].Language != "go" || len(meta.Headings) != 1 ||
+		len(meta.ThreadRefs) != 2 || meta.ThreadRefs[0] != target.ID || len(meta.Mentions) != 1 {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/posts/nope/metadata", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing metadata status = %d", resp.StatusCode)
+	}
+
+	listIDs := func(query string) []string {
+		var out struct {
+			Threads []models.Content `json:"threads"`
+		}
+		decodeJSON(t, doReq(t, server.URL, adminKey, http.MethodGet, "/api/v1/posts?"+query, nil), &out)
+		var ids []string
+		for _, th := range out.Threads {
+			ids = append(ids, th.ID)
+		}
+		return ids
+	}
+	for query, want := range map[string]string{
+		"links_to=example.com":                        post.ID,
+		"links_to=example.org":                        target.ID,
+		"links_to=docs.example.com":                   post.ID,
+		"links_to=" + "https://mirror.example.org/":   target.ID,
+		"links_to=ample.com":                          "",
+		"references=%23" + target.ID:                  post.ID,
+		"references=" + target.ID + "&links_to=a.com": "",
+	} {
+		got := listIDs(query + "&sort=created&order=desc")
+		if strings.Join(got, ",") != want {
+			t.Fatalf("%s: got %v, want %s", query, got, want)
+		}
+	}
+
+	// Edits replace the extracted metadata.
+	if resp := doReq(t, server.URL, adminKey, http.MethodPut, "/api/v1/replies/"+reply.ID, map[string]any{"body": "gone"}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("edit reply status = %d", resp.StatusCode)
+	}
+	if got := listIDs("links_to=mirror.example.org"); len(got) != 0 {
+		t.Fatalf("links_to after edit = %v", got)
+	}
+}
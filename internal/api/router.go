@@ -3,57 +3,117 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"fora/internal/db"
+	"fora/internal/metrics"
 	"fora/internal/ratelimit"
 )
 
+// Options tunes optional router behaviour; the zero value matches NewRouter.
+type Options struct {
+	// AccessLog receives one structured line per request when non-nil.
+	AccessLog *slog.Logger
+	// RateLimits overrides the built-in per-agent budgets when non-nil.
+	RateLimits *RateLimits
+	// CORSOrigins lists allowed browser origins; empty or "*" allows any.
+	CORSOrigins    []string
+	DisableMCP     bool
+	DisableMetrics bool
+	Attachments    AttachmentOptions
+}
+
+// AttachmentOptions configures uploaded file storage. With an empty Dir the
+// bytes are kept in SQLite; MaxBytes <= 0 means DefaultAttachmentMaxBytes.
+type AttachmentOptions struct {
+	Dir      string
+	MaxBytes int64
+}
+
 func NewRouter(database *sql.DB, version string) http.Handler {
+	return NewRouterWithOptions(database, version, Options{})
+}
+
+func NewRouterWithOptions(database *sql.DB, version string, opts Options) http.Handler {
 	mux := http.NewServeMux()
 	limiter := ratelimit.NewLimiter()
+	limits := defaultRateLimits
+	if opts.RateLimits != nil {
+		limits = *opts.RateLimits
+	}
 	withAuth := func(h http.Handler) http.Handler {
-		return authMiddleware(database, rateLimitMiddleware(database, limiter, h))
+		return authMiddleware(database, idempotencyMiddleware(database, rateLimitMiddleware(database, limiter, limits, h)))
 	}
 
 	ps := newPrimerStore(database)
+	attachments := newAttachmentStore(database, opts.Attachments)
 	mux.HandleFunc("/api/v1/status", statusHandler(database, version))
 	mux.HandleFunc("/api/v1/primer", primerHandler(ps))
 	mux.Handle("/api/v1/admin/primer", withAuth(adminOnly(adminPrimerUpdateHandler(database, ps))))
-	mux.Handle("/mcp", mcpHandler(database, version))
+	if !opts.DisableMCP {
+		mux.Handle("/mcp", mcpHandler(database, version))
+	}
 	mux.Handle("/api/v1/whoami", withAuth(whoAmIHandler()))
 	mux.Handle("/api/v1/agents", withAuth(adminOnly(agentsCollectionHandler(database))))
 	mux.Handle("/api/v1/agents/", withAuth(adminOnly(agentItemHandler(database))))
+	mux.Handle("/api/v1/groups", withAuth(groupsHandler(database)))
+	mux.Handle("/api/v1/groups/", withAuth(groupItemHandler(database)))
+	mux.Handle("/api/v1/me/profile", withAuth(meProfileHandler(database)))
+	mux.Handle("/api/v1/me/presence", withAuth(mePresenceHandler(database)))
+	mux.Handle("/api/v1/hive/agents", withAuth(hiveAgentsHandler(database)))
 	mux.Handle("/api/v1/hive/agents/", withAuth(hiveAgentItemHandler(database)))
 	mux.Handle("/api/v1/posts", withAuth(postsCollectionHandler(database)))
-	mux.Handle("/api/v1/posts/", withAuth(postsScopedHandler(database)))
-	mux.Handle("/api/v1/replies/", withAuth(replyItemHandler(database)))
+	mux.Handle("/api/v1/posts/", withAuth(postsScopedHandler(database, attachments)))
+	mux.Handle("/api/v1/replies/", withAuth(pruneAfterDelete(attachments, replyItemHandler(database))))
+	mux.Handle("/api/v1/attachments/", withAuth(attachmentItemHandler(attachments)))
 	mux.Handle("/api/v1/boards", withAuth(boardsHandler(database)))
 	mux.Handle("/api/v1/boards/", withAuth(boardsScopedHandler(database)))
+	mux.Handle("/api/v1/tags", withAuth(tagsHandler(database)))
+	mux.Handle("/api/v1/tags/", withAuth(tagsScopedHandler(database)))
 	mux.Handle("/api/v1/search", withAuth(searchHandler(database)))
 	mux.Handle("/api/v1/activity", withAuth(activityHandler(database)))
 	mux.Handle("/api/v1/stats", withAuth(forumStatsHandler(database)))
+	mux.Handle("/api/v1/conversations", withAuth(conversationsHandler(database)))
+	mux.Handle("/api/v1/conversations/", withAuth(conversationItemHandler(database)))
 	mux.Handle("/api/v1/notifications", withAuth(notificationsCollectionHandler(database)))
 	mux.Handle("/api/v1/notifications/clear", withAuth(notificationsClearHandler(database)))
 	mux.Handle("/api/v1/notifications/", withAuth(notificationsItemHandler(database)))
 	mux.Handle("/api/v1/admin/export", withAuth(adminOnly(adminExportHandler(database))))
 	mux.Handle("/api/v1/admin/webhooks", withAuth(adminOnly(webhooksCollectionHandler(database))))
 	mux.Handle("/api/v1/admin/webhooks/", withAuth(adminOnly(webhookItemHandler(database))))
-	return corsMiddleware(mux)
+	if !opts.DisableMetrics {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+	return requestIDMiddleware(observeMiddleware(mux, opts.AccessLog, corsMiddleware(opts.CORSOrigins, mux)))
 }
 
-func corsMiddleware(next http.Handler) http.Handler {
+func corsMiddleware(origins []string, next http.Handler) http.Handler {
 	const (
-		allowOrigin  = "*"
 		allowMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
-		allowHeaders = "Authorization, Content-Type"
+		allowHeaders = "Authorization, Content-Type, Idempotency-Key"
 		maxAge       = "86400"
 	)
+	anyOrigin := len(origins) == 0
+	allowed := map[string]bool{}
+	for _, o := range origins {
+		if o == "*" {
+			anyOrigin = true
+		}
+		allowed[strings.TrimRight(o, "/")] = true
+	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+		if anyOrigin {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); allowed[origin] {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+		}
 		w.Header().Set("Access-Control-Allow-Methods", allowMethods)
 		w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
 		w.Header().Set("Access-Control-Max-Age", maxAge)
@@ -134,16 +194,31 @@ func pathTail(path, prefix string) string {
 	return tail
 }
 
-func postsScopedHandler(database *sql.DB) http.Handler {
-	post := postItemHandler(database)
+func postsScopedHandler(database *sql.DB, attachments *attachmentStore) http.Handler {
+	post := pruneAfterDelete(attachments, postItemHandler(database))
 	reply := repliesHandler(database)
 	thread := threadHandler(database)
 	tags := postTagsHandler(database)
 	status := postStatusHandler(database)
 	history := postHistoryHandler(database)
 	summary := postSummaryHandler(database)
+	files := postAttachmentsHandler(database, attachments)
+	metadata := contentMetadataHandler(database)
+	poll := postPollHandler(database)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/poll") || strings.HasSuffix(r.URL.Path, "/poll/vote") || strings.HasSuffix(r.URL.Path, "/poll/close") {
+			poll.ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/attachments") {
+			files.ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/metadata") {
+			metadata.ServeHTTP(w, r)
+			return
+		}
 		if strings.HasSuffix(r.URL.Path, "/thread") {
 			thread.ServeHTTP(w, r)
 			return
@@ -175,11 +250,16 @@ func postsScopedHandler(database *sql.DB) http.Handler {
 func boardsScopedHandler(database *sql.DB) http.Handler {
 	board := boardItemHandler(database)
 	subscribe := boardSubscriptionHandler(database)
+	suggest := boardSuggestTagsHandler(database)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.HasSuffix(r.URL.Path, "/subscribe") {
 			subscribe.ServeHTTP(w, r)
 			return
 		}
+		if strings.HasSuffix(r.URL.Path, "/suggest-tags") {
+			suggest.ServeHTTP(w, r)
+			return
+		}
 		board.ServeHTTP(w, r)
 	})
 }
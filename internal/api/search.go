@@ -26,6 +26,17 @@ func searchHandler(database *sql.DB) http.Handler {
 			writeError(w, http.StatusBadRequest, "invalid threads_only value")
 			return
 		}
+		// Direct messages are private; only admins may search them, and only
+		// when they ask.
+		directMessages, err := parseBool(strings.TrimSpace(r.URL.Query().Get("include_direct_messages")))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid include_direct_messages value")
+			return
+		}
+		if agent := currentAgent(r.Context()); directMessages && (agent == nil || agent.Role != "admin") {
+			writeError(w, http.StatusForbidden, "admin role required to search direct messages")
+			return
+		}
 		params := db.SearchParams{
 			Query:       q,
 			Author:      strings.TrimSpace(r.URL.Query().Get("author")),
@@ -53,11 +64,25 @@ func searchHandler(database *sql.DB) http.Handler {
 			writeError(w, http.StatusInternalServerError, "search failed")
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]any{
+		resp := map[string]any{
 			"results": results,
 			"total":   total,
 			"query":   q,
-		})
+		}
+		if directMessages {
+			messages, err := db.SearchDirectMessages(r.Context(), database, db.SearchDirectMessagesParams{
+				Query:  q,
+				Author: params.Author,
+				Limit:  limit,
+				Offset: offset,
+			})
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "search failed")
+				return
+			}
+			resp["direct_messages"] = messages
+		}
+		writeJSON(w, http.StatusOK, resp)
 	})
 }
 
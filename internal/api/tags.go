@@ -0,0 +1,167 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"fora/internal/db"
+)
+
+type renameTagRequest struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Alias bool   `json:"alias"`
+}
+
+type mergeTagsRequest struct {
+	From  []string `json:"from"`
+	To    string   `json:"to"`
+	Alias bool     `json:"alias"`
+}
+
+type tagAliasRequest struct {
+	Alias string `json:"alias"`
+	Tag   string `json:"tag"`
+}
+
+// tagsHandler serves GET /api/v1/tags.
+func tagsHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		limit, offset := parseLimitOffset(r)
+		q := r.URL.Query()
+		tags, err := db.ListTagUsage(r.Context(), database, db.ListTagsParams{
+			Board:  strings.TrimSpace(q.Get("board")),
+			Prefix: strings.TrimSpace(q.Get("prefix")),
+			Limit:  limit,
+			Offset: offset,
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list tags")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"tags": tags, "limit": limit, "offset": offset})
+	})
+}
+
+// tagsScopedHandler serves the admin operations under /api/v1/tags/:
+// rename, merge and aliases.
+func tagsScopedHandler(database *sql.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/tags/"), "/")
+		if r.Method != http.MethodGet {
+			agent := currentAgent(r.Context())
+			if agent == nil || agent.Role != "admin" {
+				writeError(w, http.StatusForbidden, "admin role required")
+				return
+			}
+		}
+		switch {
+		case rest == "rename":
+			renameTag(w, r, database)
+		case rest == "merge":
+			mergeTags(w, r, database)
+		case rest == "aliases":
+			tagAliases(w, r, database)
+		case strings.HasPrefix(rest, "aliases/"):
+			deleteTagAlias(w, r, database, strings.TrimPrefix(rest, "aliases/"))
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	})
+}
+
+func renameTag(w http.ResponseWriter, r *http.Request, database *sql.DB) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	var req renameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+	n, err := db.RenameTag(r.Context(), database, req.From, req.To, req.Alias)
+	if err != nil {
+		writeTagChangeError(w, err, "failed to rename tag")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"from": req.From, "to": req.To, "posts_updated": n, "alias": req.Alias})
+}
+
+func mergeTags(w http.ResponseWriter, r *http.Request, database *sql.DB) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	var req mergeTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+	n, err := db.MergeTags(r.Context(), database, req.From, req.To, req.Alias)
+	if err != nil {
+		writeTagChangeError(w, err, "failed to merge tags")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"from": req.From, "to": req.To, "posts_updated": n, "alias": req.Alias})
+}
+
+func tagAliases(w http.ResponseWriter, r *http.Request, database *sql.DB) {
+	switch r.Method {
+	case http.MethodGet:
+		aliases, err := db.ListTagAliases(r.Context(), database)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list tag aliases")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"aliases": aliases})
+	case http.MethodPost:
+		var req tagAliasRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json payload")
+			return
+		}
+		alias, err := db.SetTagAlias(r.Context(), database, req.Alias, req.Tag)
+		if err != nil {
+			writeTagChangeError(w, err, "failed to set tag alias")
+			return
+		}
+		writeJSON(w, http.StatusCreated, alias)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func writeTagChangeError(w http.ResponseWriter, err error, internal string) {
+	switch {
+	case errors.Is(err, db.ErrInvalidTagChange):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, db.ErrTagExists):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, internal)
+	}
+}
+
+func deleteTagAlias(w http.ResponseWriter, r *http.Request, database *sql.DB, alias string) {
+	if r.Method != http.MethodDelete {
+		methodNotAllowed(w)
+		return
+	}
+	if err := db.DeleteTagAlias(r.Context(), database, alias); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "tag alias not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to delete tag alias")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"fora/internal/models"
+)
+
+type tagListResponse struct {
+	Tags []struct {
+		Tag      string   `json:"tag"`
+		Count    int      `json:"count"`
+		LastUsed string   `json:"last_used"`
+		Boards   []string `json:"boards"`
+	} `json:"tags"`
+}
+
+func TestTagsListRenameMergeAndAliases(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	userKey := createAgentForTest(t, database, "tagger", "agent")
+	for i, tags := range [][]string{{"bug", "ui"}, {"bugs"}, {"Bug-Report", "ui"}} {
+		resp := doReq(t, server.URL, userKey, http.MethodPost, "/api/v1/posts", map[string]any{
+			"board_id": "general",
+			"body":     fmt.Sprintf("tagged %d", i),
+			"tags":     tags,
+		})
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create post status = %d", resp.StatusCode)
+		}
+		_ = resp.Body.Close()
+	}
+
+	var list tagListResponse
+	decodeJSON(t, doReq(t, server.URL, userKey, http.MethodGet, "/api/v1/tags?board=general", nil), &list)
+	if len(list.Tags) == 0 || list.Tags[0].Tag != "ui" || list.Tags[0].Count != 2 || list.Tags[0].LastUsed == "" {
+		t.Fatalf("unexpected tag usage: %+v", list.Tags)
+	}
+
+	if resp := doReq(t, server.URL, userKey, http.MethodPost, "/api/v1/tags/merge", map[string]any{"from": []string{"bugs"}, "to": "bug"}); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("non-admin merge status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/tags/rename", map[string]any{"from": "bugs", "to": "bug"}); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("rename onto existing tag status = %d", resp.StatusCode)
+	}
+
+	merge := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/tags/merge", map[string]any{
+		"from":  []string{"bugs", "Bug-Report"},
+		"to":    "bug",
+		"alias": true,
+	})
+	if merge.StatusCode != http.StatusOK {
+		t.Fatalf("merge status = %d", merge.StatusCode)
+	}
+	var merged struct {
+		PostsUpdated int `json:"posts_updated"`
+	}
+	decodeJSON(t, merge, &merged)
+	if merged.PostsUpdated != 2 {
+		t.Fatalf("posts updated = %d, want 2", merged.PostsUpdated)
+	}
+
+	list = tagListResponse{}
+	decodeJSON(t, doReq(t, server.URL, userKey, http.MethodGet, "/api/v1/tags?prefix=bug", nil), &list)
+	if len(list.Tags) != 1 || list.Tags[0].Tag != "bug" || list.Tags[0].Count != 3 {
+		t.Fatalf("unexpected tags after merge: %+v", list.Tags)
+	}
+
+	// The merged names are aliases now, in any case, on write and on read.
+	post := decodeContent(t, doReq(t, server.URL, userKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"board_id": "general",
+		"body":     "aliased",
+		"tags":     []string{"BUGS", "bug"},
+	}))
+	if !slices.Equal(post.Tags, []string{"bug"}) {
+		t.Fatalf("alias not normalized on write: %v", post.Tags)
+	}
+	var threads struct {
+		Threads []any `json:"threads"`
+	}
+	decodeJSON(t, doReq(t, server.URL, userKey, http.MethodGet, "/api/v1/posts?tag=bug-report", nil), &threads)
+	if len(threads.Threads) != 4 {
+		t.Fatalf("alias filter returned %d threads, want 4", len(threads.Threads))
+	}
+
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/tags/aliases", map[string]any{"alias": "defect", "tag": "bugs"}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create alias status = %d", resp.StatusCode)
+	} else {
+		var alias struct {
+			Alias string `json:"alias"`
+			Tag   string `json:"tag"`
+		}
+		decodeJSON(t, resp, &alias)
+		if alias.Tag != "bug" {
+			t.Fatalf("alias of an alias should resolve to the final tag, got %q", alias.Tag)
+		}
+	}
+	var aliases struct {
+		Aliases []struct {
+			Alias string `json:"alias"`
+		} `json:"aliases"`
+	}
+	decodeJSON(t, doReq(t, server.URL, userKey, http.MethodGet, "/api/v1/tags/aliases", nil), &aliases)
+	if len(aliases.Aliases) != 3 {
+		t.Fatalf("unexpected aliases: %+v", aliases.Aliases)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodDelete, "/api/v1/tags/aliases/defect", nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete alias status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, adminKey, http.MethodDelete, "/api/v1/tags/aliases/defect", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("delete missing alias status = %d", resp.StatusCode)
+	}
+
+	// Aliasing a tag that is already in use folds its posts into the target,
+	// so filtering by either name still finds them.
+	flaky := decodeContent(t, doReq(t, server.URL, userKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"board_id": "general",
+		"body":     "intermittent failure",
+		"tags":     []string{"flaky"},
+	}))
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/tags/aliases", map[string]any{"alias": "flaky", "tag": "bug"}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("alias a used tag status = %d", resp.StatusCode)
+	}
+	for _, tag := range []string{"flaky", "bug"} {
+		var found struct {
+			Threads []models.ThreadListItem `json:"threads"`
+		}
+		decodeJSON(t, doReq(t, server.URL, userKey, http.MethodGet, "/api/v1/posts?tag="+tag, nil), &found)
+		ids := make([]string, 0, len(found.Threads))
+		for _, th := range found.Threads {
+			ids = append(ids, th.ID)
+		}
+		if !slices.Contains(ids, flaky.ID) {
+			t.Fatalf("?tag=%s lost the post tagged before the alias: %v", tag, ids)
+		}
+	}
+
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/tags/merge", map[string]any{"from": []string{}, "to": "bug"}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("empty merge status = %d", resp.StatusCode)
+	}
+}
+
+func TestRestrictedBoardRejectsUnlistedTags(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	userKey := createAgentForTest(t, database, "restricted-poster", "agent")
+	if resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/boards", map[string]any{"name": "Releases", "tags": []string{"release"}}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create board status = %d", resp.StatusCode)
+	}
+	update := doReq(t, server.URL, adminKey, http.MethodPatch, "/api/v1/boards/releases", map[string]any{
+		"restrict_tags": true,
+		"add_tags":      []string{"hotfix"},
+	})
+	if update.StatusCode != http.StatusOK {
+		t.Fatalf("update board status = %d", update.StatusCode)
+	}
+	var board struct {
+		RestrictTags bool     `json:"restrict_tags"`
+		Tags         []string `json:"tags"`
+	}
+	decodeJSON(t, update, &board)
+	if !board.RestrictTags || !slices.Contains(board.Tags, "hotfix") {
+		t.Fatalf("unexpected board after update: %+v", board)
+	}
+
+	if resp := doReq(t, server.URL, userKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"board_id": "releases",
+		"body":     "off topic",
+		"tags":     []string{"release", "random"},
+	}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("disallowed tag status = %d", resp.StatusCode)
+	}
+	post := decodeContent(t, doReq(t, server.URL, userKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"board_id": "releases",
+		"body":     "v1.2",
+		"tags":     []string{"release"},
+	}))
+	if resp := doReq(t, server.URL, userKey, http.MethodPatch, "/api/v1/posts/"+post.ID+"/tags", map[string]any{"add": []string{"random"}}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("disallowed tag edit status = %d", resp.StatusCode)
+	}
+	if resp := doReq(t, server.URL, userKey, http.MethodPatch, "/api/v1/posts/"+post.ID+"/tags", map[string]any{"add": []string{"hotfix"}}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("allowed tag edit status = %d", resp.StatusCode)
+	}
+}
@@ -16,19 +16,22 @@ func buildThreadTree(items []models.Content) (models.ThreadNode, bool) {
 	for _, c := range items {
 		n := &treeNode{
 			val: models.ThreadNode{
-				ID:       c.ID,
-				Type:     c.Type,
-				Author:   c.Author,
-				Title:    c.Title,
-				Body:     c.Body,
-				Created:  c.Created,
-				Updated:  c.Updated,
-				ThreadID: c.ThreadID,
-				ParentID: c.ParentID,
-				Status:   c.Status,
-				BoardID:  c.BoardID,
-				Tags:     c.Tags,
-				Replies:  []models.ThreadNode{},
+				ID:          c.ID,
+				Type:        c.Type,
+				Author:      c.Author,
+				Title:       c.Title,
+				Body:        c.Body,
+				BodyHTML:    c.BodyHTML,
+				Created:     c.Created,
+				Updated:     c.Updated,
+				ThreadID:    c.ThreadID,
+				ParentID:    c.ParentID,
+				Status:      c.Status,
+				BoardID:     c.BoardID,
+				Tags:        c.Tags,
+				Attachments: c.Attachments,
+				Poll:        c.Poll,
+				Replies:     []models.ThreadNode{},
 			},
 			replies: []*treeNode{},
 		}
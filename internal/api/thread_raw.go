@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"fora/internal/db"
 	"fora/internal/models"
 )
 
@@ -21,6 +22,8 @@ func renderThreadRaw(root models.ThreadNode, depthLimit int) string {
 	b.WriteString("\n---\n\n")
 	b.WriteString(root.Body)
 	b.WriteString("\n")
+	renderAttachmentsRaw(&b, root.Attachments)
+	renderPollRaw(&b, root.Poll)
 
 	for _, child := range root.Replies {
 		renderReplyRaw(&b, child, 1, depthLimit)
@@ -58,8 +61,69 @@ func renderReplyRaw(b *strings.Builder, n models.ThreadNode, level int, depthLim
 	fmt.Fprintf(b, " Reply by %s (%s)\n\n", n.Author, n.Created)
 	b.WriteString(n.Body)
 	b.WriteString("\n")
+	renderAttachmentsRaw(b, n.Attachments)
 
 	for _, child := range n.Replies {
 		renderReplyRaw(b, child, level+1, depthLimit)
 	}
 }
+
+// renderAttachmentsRaw lists attachments by reference; their bytes are never
+// inlined into the markdown.
+func renderAttachmentsRaw(b *strings.Builder, attachments []models.Attachment) {
+	if len(attachments) == 0 {
+		return
+	}
+	b.WriteString("\n**Attachments:**\n")
+	for _, a := range attachments {
+		fmt.Fprintf(b, "- [%s](%s) (%s, %s)\n", a.Filename, a.URL, a.ContentType, formatBytes(a.Size))
+	}
+}
+
+// renderPollRaw prints the poll's options with their current tally.
+func renderPollRaw(b *strings.Builder, poll *models.Poll) {
+	if poll == nil {
+		return
+	}
+	kind := "single choice"
+	if poll.Multiple {
+		kind = "multiple choice"
+	}
+	votes := "public votes"
+	if poll.Visibility == db.PollAnonymous {
+		votes = "anonymous votes"
+	}
+	state := "open"
+	switch {
+	case poll.Closed != nil:
+		state = "closed " + *poll.Closed
+	case poll.Deadline != nil:
+		state = "closes " + *poll.Deadline
+	}
+	fmt.Fprintf(b, "\n**Poll** (%s, %s, %s):\n", kind, votes, state)
+	for _, o := range poll.Options {
+		noun := "votes"
+		if o.Votes == 1 {
+			noun = "vote"
+		}
+		fmt.Fprintf(b, "%d. %s — %d %s", o.ID, o.Text, o.Votes, noun)
+		if len(o.Voters) > 0 {
+			fmt.Fprintf(b, " (%s)", strings.Join(o.Voters, ", "))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(b, "\n%d voted\n", poll.TotalVoters)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
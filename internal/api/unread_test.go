@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"fora/internal/db"
+	"fora/internal/models"
+)
+
+func TestUnreadCountsFollowThreadReads(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+	bobKey := createAgentForTest(t, database, "bob", "agent")
+
+	post := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Status", "body": "root", "board_id": "general",
+	}))
+	quiet := decodeContent(t, doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts", map[string]any{
+		"title": "Quiet", "body": "nobody replies", "board_id": "general",
+	}))
+
+	list := func(key, query string) map[string]int {
+		t.Helper()
+		var payload struct {
+			Threads []models.ThreadListItem `json:"threads"`
+		}
+		resp := doReq(t, server.URL, key, http.MethodGet, "/api/v1/posts"+query, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("list%s status = %d", query, resp.StatusCode)
+		}
+		decodeJSON(t, resp, &payload)
+		out := map[string]int{}
+		for _, th := range payload.Threads {
+			out[th.ID] = th.UnreadCount
+		}
+		return out
+	}
+
+	if got := list(bobKey, ""); got[post.ID] != 1 || got[quiet.ID] != 1 {
+		t.Fatalf("bob has read nothing yet: %v", got)
+	}
+	if got := list(adminKey, ""); got[post.ID] != 0 {
+		t.Fatalf("own posts are never unread: %v", got)
+	}
+
+	resp := doReq(t, server.URL, bobKey, http.MethodGet, "/api/v1/posts/"+post.ID+"/thread", nil)
+	resp.Body.Close()
+	if got := list(bobKey, "?unread=true"); len(got) != 1 || got[quiet.ID] != 1 {
+		t.Fatalf("after reading, only the quiet thread stays unread: %v", got)
+	}
+
+	for _, body := range []string{"one", "two"} {
+		r := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/posts/"+post.ID+"/replies", map[string]any{"body": body})
+		r.Body.Close()
+	}
+	// Bob's own reply does not count as unread for him.
+	r := doReq(t, server.URL, bobKey, http.MethodPost, "/api/v1/posts/"+post.ID+"/replies", map[string]any{"body": "mine"})
+	r.Body.Close()
+	if got := list(bobKey, "?unread=true"); got[post.ID] != 2 {
+		t.Fatalf("expected 2 unread replies, got %v", got)
+	}
+
+	// Read markers survive VACUUM and a re-import of the same content; an
+	// edit by someone else makes the item unread again.
+	resp = doReq(t, server.URL, bobKey, http.MethodGet, "/api/v1/posts/"+post.ID+"/thread", nil)
+	resp.Body.Close()
+	if _, err := database.Exec(`VACUUM`); err != nil {
+		t.Fatalf("vacuum: %v", err)
+	}
+	export, err := db.ExportJSON(context.Background(), database, db.ExportOptions{})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if err := db.ImportPayload(context.Background(), database, export); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if got := list(bobKey, "?unread=true"); len(got) != 1 || got[quiet.ID] != 1 {
+		t.Fatalf("vacuum or re-import changed read state: %v", got)
+	}
+	edit := doReq(t, server.URL, adminKey, http.MethodPut, "/api/v1/posts/"+post.ID, map[string]any{"body": "root, revised"})
+	edit.Body.Close()
+	if got := list(bobKey, "?unread=true"); got[post.ID] != 1 {
+		t.Fatalf("an edited post should be unread again: %v", got)
+	}
+
+	if bad := doReq(t, server.URL, bobKey, http.MethodGet, "/api/v1/posts?unread=maybe", nil); bad.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid unread value status = %d", bad.StatusCode)
+	}
+}
@@ -9,11 +9,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"fora/internal/db"
+	"fora/internal/models"
+	"fora/internal/tracing"
 )
 
 type createWebhookRequest struct {
@@ -57,11 +60,15 @@ func webhooksCollectionHandler(database *sql.DB) http.Handler {
 
 func webhookItemHandler(database *sql.DB) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := pathTail(r.URL.Path, "/api/v1/admin/webhooks/")
+		if testID, ok := strings.CutSuffix(id, "/test"); ok {
+			webhookTestHandler(w, r, database, testID)
+			return
+		}
 		if r.Method != http.MethodDelete {
 			methodNotAllowed(w)
 			return
 		}
-		id := pathTail(r.URL.Path, "/api/v1/admin/webhooks/")
 		if strings.TrimSpace(id) == "" {
 			writeError(w, http.StatusBadRequest, "missing webhook id")
 			return
@@ -78,20 +85,53 @@ func webhookItemHandler(database *sql.DB) http.Handler {
 	})
 }
 
-func emitWebhookEvent(database *sql.DB, eventType string, payload map[string]any) {
+// webhookTestHandler sends a synchronous webhook.test event to one webhook,
+// regardless of its event filter, and reports how the receiver answered.
+func webhookTestHandler(w http.ResponseWriter, r *http.Request, database *sql.DB, id string) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	wh, err := db.GetWebhook(r.Context(), database, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "webhook not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to load webhook")
+		return
+	}
+	agent := currentAgent(r.Context())
+	body, err := webhookBody("webhook.test", map[string]any{"webhook_id": wh.ID, "requested_by": agent.Name})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to encode test event")
+		return
+	}
+	status, err := deliverWebhook(r.Context(), &http.Client{Timeout: 5 * time.Second}, *wh, "webhook.test", body)
+	resp := map[string]any{
+		"id":          wh.ID,
+		"event":       "webhook.test",
+		"delivered":   err == nil && status >= 200 && status < 300,
+		"status_code": status,
+	}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// emitWebhookEvent delivers asynchronously. The trace position of ctx (or an
+// incoming traceparent) is carried into each delivery's traceparent header.
+func emitWebhookEvent(parent context.Context, database *sql.DB, eventType string, payload map[string]any) {
+	detached := tracing.Detach(parent)
 	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := context.WithTimeout(detached, 5*time.Second)
 		defer cancel()
 		items, err := db.ListWebhooks(ctx, database, true)
 		if err != nil {
 			return
 		}
-		body := map[string]any{
-			"event": eventType,
-			"at":    time.Now().UTC().Format(time.RFC3339),
-			"data":  payload,
-		}
-		b, err := json.Marshal(body)
+		b, err := webhookBody(eventType, payload)
 		if err != nil {
 			return
 		}
@@ -100,21 +140,54 @@ func emitWebhookEvent(database *sql.DB, eventType string, payload map[string]any
 			if !eventAllowed(wh.Events, eventType) {
 				continue
 			}
-			req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(b))
-			if err != nil {
-				continue
-			}
-			req.Header.Set("Content-Type", "application/json")
-			if strings.TrimSpace(wh.Secret) != "" {
-				mac := hmac.New(sha256.New, []byte(wh.Secret))
-				_, _ = mac.Write(b)
-				req.Header.Set("X-Fora-Signature", hex.EncodeToString(mac.Sum(nil)))
-			}
-			_, _ = client.Do(req)
+			_, _ = deliverWebhook(ctx, client, wh, eventType, b)
 		}
 	}()
 }
 
+func webhookBody(eventType string, payload map[string]any) ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"event": eventType,
+		"at":    time.Now().UTC().Format(time.RFC3339),
+		"data":  payload,
+	})
+}
+
+// deliverWebhook posts one signed event and returns the receiver's status.
+func deliverWebhook(ctx context.Context, client *http.Client, wh models.Webhook, eventType string, b []byte) (int, error) {
+	deliverCtx, span := tracing.Start(ctx, "webhook "+eventType, tracing.KindClient)
+	defer span.End()
+	span.SetAttr("webhook.id", wh.ID)
+	span.SetAttr("webhook.event", eventType)
+	req, err := http.NewRequestWithContext(deliverCtx, http.MethodPost, wh.URL, bytes.NewReader(b))
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(deliverCtx, req.Header)
+	if strings.TrimSpace(wh.Secret) != "" {
+		mac := hmac.New(sha256.New, []byte(wh.Secret))
+		_, _ = mac.Write(b)
+		req.Header.Set("X-Fora-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		webhookDeliveries.Inc(eventType, "error")
+		span.RecordError(err)
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	span.SetAttr("http.status_code", resp.StatusCode)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		webhookDeliveries.Inc(eventType, "success")
+	} else {
+		webhookDeliveries.Inc(eventType, "http_error")
+		span.RecordError(fmt.Errorf("http %d", resp.StatusCode))
+	}
+	return resp.StatusCode, nil
+}
+
 func eventAllowed(events []string, event string) bool {
 	for _, e := range events {
 		if e == "*" || strings.EqualFold(strings.TrimSpace(e), event) {
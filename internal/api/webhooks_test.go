@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -64,3 +65,89 @@ func TestWebhookManagementAndDispatch(t *testing.T) {
 		}
 	}
 }
+
+func TestWebhookDeliveryCarriesTraceparent(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	traceCh := make(chan string, 1)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceCh <- r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer sink.Close()
+
+	createWH := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/admin/webhooks", map[string]any{
+		"url":    sink.URL,
+		"events": []string{"thread.created"},
+	})
+	_ = createWH.Body.Close()
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	payload, _ := json.Marshal(map[string]any{"title": "Traced", "body": "hello", "board_id": "general"})
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/api/v1/posts", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+adminKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", traceparent)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	select {
+	case got := <-traceCh:
+		if got != traceparent {
+			t.Fatalf("webhook traceparent = %q, want %q", got, traceparent)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected webhook delivery")
+	}
+}
+
+func TestWebhookTestDeliversSynchronously(t *testing.T) {
+	server, database, adminKey := setupTestServer(t)
+	defer server.Close()
+	defer database.Close()
+
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		if payload["event"] != "webhook.test" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer sink.Close()
+
+	createResp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/admin/webhooks", map[string]any{
+		"url":    sink.URL,
+		"events": []string{"thread.created"},
+	})
+	var wh struct {
+		ID string `json:"id"`
+	}
+	_ = json.NewDecoder(createResp.Body).Decode(&wh)
+	createResp.Body.Close()
+
+	resp := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/admin/webhooks/"+wh.ID+"/test", nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("test webhook status = %d", resp.StatusCode)
+	}
+	var result map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if result["delivered"] != true || result["status_code"] != float64(http.StatusAccepted) {
+		t.Fatalf("unexpected test result: %v", result)
+	}
+
+	missing := doReq(t, server.URL, adminKey, http.MethodPost, "/api/v1/admin/webhooks/nope/test", nil)
+	missing.Body.Close()
+	if missing.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing webhook test status = %d", missing.StatusCode)
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheEntry is one cached GET response with the validators needed to
+// revalidate it.
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Body         string `json:"body"`
+	StoredAt     string `json:"stored_at"`
+}
+
+// WithCache returns a copy of c that keeps GET responses carrying an ETag or
+// Last-Modified under dir and revalidates them with conditional requests.
+func (c *Client) WithCache(dir string) *Client {
+	cp := *c
+	cp.cacheDir = dir
+	return &cp
+}
+
+func (c *Client) cachePath(path string) string {
+	// The API key is part of the key: responses can differ per agent.
+	sum := sha256.Sum256([]byte(c.baseURL + "\n" + c.apiKey + "\n" + path))
+	return filepath.Join(c.cacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Client) loadCache(path string) (*cacheEntry, bool) {
+	if c.cacheDir == "" {
+		return nil, false
+	}
+	b, err := os.ReadFile(c.cachePath(path))
+	if err != nil {
+		return nil, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(b, &e); err != nil || e.URL != c.baseURL+path {
+		return nil, false
+	}
+	return &e, true
+}
+
+func (c *Client) storeCache(path string, e cacheEntry) error {
+	if c.cacheDir == "" || (e.ETag == "" && e.LastModified == "") {
+		return nil
+	}
+	if err := os.MkdirAll(c.cacheDir, 0o700); err != nil {
+		return err
+	}
+	e.URL = c.baseURL + path
+	e.StoredAt = time.Now().UTC().Format(time.RFC3339)
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.cacheDir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.cachePath(path))
+}
+
+// ClearCache removes every cached response under dir.
+func ClearCache(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -3,17 +3,69 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
 
 type Client struct {
-	baseURL string
-	apiKey  string
-	http    *http.Client
+	baseURL        string
+	apiKey         string
+	idempotencyKey string
+	cacheDir       string
+	http           *http.Client
+}
+
+// HTTPError is returned for responses with a status of 400 or above.
+type HTTPError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("http %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("http %d", e.StatusCode)
+}
+
+// IsUnreachable reports whether err means the request never got a real answer
+// from the server (connection failure, timeout or a gateway error), so it is
+// safe to retry later.
+func IsUnreachable(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// WithIdempotencyKey returns a copy of c that sends key as Idempotency-Key on
+// every request, so a retried write is applied at most once.
+func (c *Client) WithIdempotencyKey(key string) *Client {
+	cp := *c
+	cp.idempotencyKey = key
+	return &cp
+}
+
+// WithTimeout returns a copy of c whose requests give up after d.
+func (c *Client) WithTimeout(d time.Duration) *Client {
+	cp := *c
+	hc := *c.http
+	hc.Timeout = d
+	cp.http = &hc
+	return &cp
 }
 
 func New(baseURL, apiKey string) *Client {
@@ -46,65 +98,152 @@ func (c *Client) Delete(path string) error {
 	return c.do(http.MethodDelete, path, nil, nil)
 }
 
+// Do sends a request with an arbitrary method.
+func (c *Client) Do(method, path string, body any, out any) error {
+	return c.do(method, path, body, out)
+}
+
 func (c *Client) GetRaw(path string) (string, error) {
-	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	b, err := c.send(http.MethodGet, path, nil)
 	if err != nil {
 		return "", err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	if c.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	}
-	resp, err := c.http.Do(req)
+	return string(b), nil
+}
+
+func (c *Client) do(method, path string, body any, out any) error {
+	b, err := c.send(method, path, body)
 	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		return "", fmt.Errorf("http %d", resp.StatusCode)
+		return err
 	}
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	if out != nil && len(bytes.TrimSpace(b)) > 0 {
+		return json.Unmarshal(b, out)
 	}
-	return string(b), nil
+	return nil
 }
 
-func (c *Client) do(method, path string, body any, out any) error {
+// send performs the request and returns the response body. GETs go through
+// the on-disk cache when one is configured.
+func (c *Client) send(method, path string, body any) ([]byte, error) {
 	var reader io.Reader
 	if body != nil {
 		b, err := json.Marshal(body)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		reader = bytes.NewReader(b)
 	}
-	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	req, err := c.newRequest(method, path, "application/json", reader)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	if c.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	var cached *cacheEntry
+	if method == http.MethodGet {
+		if e, ok := c.loadCache(path); ok {
+			cached = e
+			if e.ETag != "" {
+				req.Header.Set("If-None-Match", e.ETag)
+			}
+			if e.LastModified != "" {
+				req.Header.Set("If-Modified-Since", e.LastModified)
+			}
+		}
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return []byte(cached.Body), nil
+	}
+	if resp.StatusCode >= 400 {
+		return nil, responseError(resp)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodGet && resp.StatusCode == http.StatusOK {
+		// A cache that cannot be written only costs the next revalidation.
+		_ = c.storeCache(path, cacheEntry{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Body:         string(b),
+		})
+	}
+	return b, nil
+}
+
+// Upload POSTs body as-is with the given content type and decodes the JSON
+// response into out.
+func (c *Client) Upload(path, contentType string, body io.Reader, out any) error {
+	req, err := c.newRequest(http.MethodPost, path, contentType, body)
+	if err != nil {
+		return err
 	}
 	resp, err := c.http.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return responseError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
 
+// Download GETs path without going through the cache and returns the open
+// response; the caller must close its body.
+func (c *Client) Download(path string) (*http.Response, error) {
+	req, err := c.newRequest(http.MethodGet, path, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
 	if resp.StatusCode >= 400 {
-		var payload map[string]any
-		if err := json.NewDecoder(resp.Body).Decode(&payload); err == nil {
-			if msg, ok := payload["error"].(string); ok {
-				return fmt.Errorf("http %d: %s", resp.StatusCode, msg)
-			}
-		}
-		return fmt.Errorf("http %d", resp.StatusCode)
+		defer resp.Body.Close()
+		return nil, responseError(resp)
 	}
+	return resp, nil
+}
 
-	if out != nil {
-		return json.NewDecoder(resp.Body).Decode(out)
+func (c *Client) newRequest(method, path, contentType string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if c.idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", c.idempotencyKey)
+	}
+	return req, nil
+}
+
+func responseError(resp *http.Response) error {
+	httpErr := &HTTPError{StatusCode: resp.StatusCode}
+	var payload map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err == nil {
+		httpErr.Message, _ = payload["error"].(string)
+	}
+	return httpErr
 }
@@ -0,0 +1,314 @@
+// Package completion implements shell completion for the fora CLI. The
+// command tree is derived from the CLI usage text so new commands and flags
+// complete without extra wiring; values such as board IDs or agent names are
+// looked up through a Source.
+package completion
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of values a flag or positional argument can take.
+const (
+	KindBoards   = "boards"
+	KindThreads  = "threads"
+	KindTags     = "tags"
+	KindAgents   = "agents"
+	KindProfiles = "profiles"
+	KindOutbox   = "outbox"
+	KindFiles    = "files"
+)
+
+// FilesDirective is printed instead of candidates when the shell should fall
+// back to its own file name completion.
+const FilesDirective = ":files"
+
+var formats = []string{"json", "ndjson", "table", "plain", "md", "quiet"}
+
+// outputFlags come with every --format flag; the usage text says so once
+// instead of repeating them on each line.
+var outputFlags = map[string]*flagSpec{
+	"fields":   {takesValue: true},
+	"template": {takesValue: true},
+	"jsonpath": {takesValue: true},
+}
+
+// Candidate is one suggestion; Description is shown by shells that support it.
+type Candidate struct {
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// Source returns the candidates for a dynamic kind.
+type Source func(kind string) []Candidate
+
+// Result is what Complete suggests for the word under the cursor.
+type Result struct {
+	Candidates []Candidate
+	Files      bool
+}
+
+type valueSpec struct {
+	kind    string
+	choices []string
+	// list values are comma-separated; only the last element is completed.
+	list bool
+	// repeat marks a trailing "<arg>..." that takes any number of values.
+	repeat bool
+}
+
+type flagSpec struct {
+	takesValue bool
+	value      valueSpec
+}
+
+// Tree is the command tree parsed from usage text.
+type Tree struct {
+	children map[string]*Tree
+	order    []string
+	flags    map[string]*flagSpec
+	args     []valueSpec
+}
+
+func newTree() *Tree {
+	return &Tree{children: map[string]*Tree{}, flags: map[string]*flagSpec{}}
+}
+
+var commandWord = regexp.MustCompile(`^[a-z][a-z-]*$`)
+
+// Parse builds a command tree from usage lines of the form
+// "fora <command> [sub] <arg> [--flag value] [--switch]".
+func Parse(usage string) *Tree {
+	root := newTree()
+	for _, raw := range strings.Split(usage, "\n") {
+		fields := strings.Fields(raw)
+		if len(fields) < 2 || fields[0] != "fora" || strings.HasPrefix(fields[1], "[") {
+			continue
+		}
+		node := root
+		var path []string
+		i := 1
+		for ; i < len(fields) && commandWord.MatchString(fields[i]); i++ {
+			path = append(path, fields[i])
+			node = node.child(fields[i])
+		}
+		argIdx := 0
+		for ; i < len(fields); i++ {
+			tok := fields[i]
+			switch {
+			case tok == "|" || tok == "...":
+			case strings.HasPrefix(strings.TrimPrefix(tok, "["), "--"):
+				name := strings.Trim(tok, "[]-")
+				spec := &flagSpec{}
+				if !strings.HasSuffix(tok, "]") && i+1 < len(fields) && isFlagValue(fields[i+1]) {
+					i++
+					spec.takesValue = true
+					spec.value = flagValue(name, fields[i])
+				}
+				if _, ok := node.flags[name]; !ok {
+					node.flags[name] = spec
+				}
+				if name == "format" && len(spec.value.choices) == len(formats) {
+					for n, s := range outputFlags {
+						node.flags[n] = s
+					}
+				}
+			default:
+				if argIdx >= len(node.args) {
+					node.args = append(node.args, argValue(path, tok))
+				}
+				argIdx++
+			}
+		}
+	}
+	return root
+}
+
+func (t *Tree) child(name string) *Tree {
+	c, ok := t.children[name]
+	if !ok {
+		c = newTree()
+		t.children[name] = c
+		t.order = append(t.order, name)
+	}
+	return c
+}
+
+func isFlagValue(tok string) bool {
+	return !strings.HasPrefix(tok, "-") && !strings.HasPrefix(tok, "[") && tok != "|"
+}
+
+func placeholder(tok string) string {
+	return strings.Trim(tok, "[]<>")
+}
+
+func flagValue(name, tok string) valueSpec {
+	raw := placeholder(tok)
+	v := valueSpec{list: strings.Contains(raw, ",")}
+	if strings.Contains(raw, "|") {
+		v.choices = strings.Split(raw, "|")
+		return v
+	}
+	switch name {
+	case "board":
+		v.kind = KindBoards
+	case "tag", "tags", "add", "remove":
+		v.kind = KindTags
+	case "author", "mention", "agent":
+		v.kind = KindAgents
+	case "thread":
+		v.kind = KindThreads
+	case "format":
+		v.choices = formats
+	case "from-file", "out", "dir", "attach":
+		v.kind = KindFiles
+	}
+	return v
+}
+
+func argValue(path []string, tok string) valueSpec {
+	if rest, ok := strings.CutSuffix(tok, "..."); ok {
+		v := argValue(path, rest)
+		v.repeat = true
+		return v
+	}
+	raw := placeholder(tok)
+	if strings.Contains(raw, "|") {
+		return valueSpec{choices: strings.Split(raw, "|")}
+	}
+	// Placeholders naming something new (agent add <name>) get no values.
+	cmd := strings.Join(path, " ")
+	switch {
+	case raw == "post-id" || raw == "post-or-reply-id":
+		return valueSpec{kind: KindThreads}
+	case raw == "outbox-id":
+		return valueSpec{kind: KindOutbox}
+	case raw == "file":
+		return valueSpec{kind: KindFiles}
+	case strings.HasPrefix(cmd, "boards ") && raw == "id":
+		return valueSpec{kind: KindBoards}
+	case cmd == "hive agent" || cmd == "agent info" || cmd == "agent inspect" || cmd == "agent remove":
+		return valueSpec{kind: KindAgents}
+	case cmd == "profile use" || cmd == "profile remove" || (cmd == "profile rename" && raw == "old"):
+		return valueSpec{kind: KindProfiles}
+	}
+	return valueSpec{}
+}
+
+// Complete suggests values for the last element of words, which holds every
+// word after "fora" up to and including the (possibly empty) one being typed.
+func (t *Tree) Complete(words []string, src Source) Result {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+	node := t
+	positional := 0
+	var pending *flagSpec
+	for _, w := range words[:len(words)-1] {
+		if pending != nil {
+			pending = nil
+			continue
+		}
+		if strings.HasPrefix(w, "-") {
+			name, _, hasValue := strings.Cut(strings.TrimLeft(w, "-"), "=")
+			if spec := node.flag(name); spec != nil && spec.takesValue && !hasValue {
+				pending = spec
+			}
+			continue
+		}
+		if c, ok := node.children[w]; ok && positional == 0 {
+			node = c
+			continue
+		}
+		positional++
+	}
+
+	if pending != nil {
+		return complete(pending.value, "", cur, src)
+	}
+	if strings.HasPrefix(cur, "--") {
+		if name, value, ok := strings.Cut(cur[2:], "="); ok {
+			if spec := node.flag(name); spec != nil && spec.takesValue {
+				return complete(spec.value, "--"+name+"=", value, src)
+			}
+			return Result{}
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		return Result{Candidates: filter(node.flagCandidates(), cur)}
+	}
+	if positional == 0 && len(node.children) > 0 {
+		out := make([]Candidate, 0, len(node.order))
+		for _, name := range node.order {
+			out = append(out, Candidate{Value: name})
+		}
+		return Result{Candidates: filter(out, cur)}
+	}
+	if positional < len(node.args) {
+		return complete(node.args[positional], "", cur, src)
+	}
+	if n := len(node.args); n > 0 && node.args[n-1].repeat {
+		return complete(node.args[n-1], "", cur, src)
+	}
+	return Result{}
+}
+
+func (t *Tree) flag(name string) *flagSpec {
+	if name == "profile" {
+		return &flagSpec{takesValue: true, value: valueSpec{kind: KindProfiles}}
+	}
+	return t.flags[name]
+}
+
+func (t *Tree) flagCandidates() []Candidate {
+	names := make([]string, 0, len(t.flags)+1)
+	for name := range t.flags {
+		names = append(names, name)
+	}
+	names = append(names, "profile")
+	sort.Strings(names)
+	out := make([]Candidate, 0, len(names))
+	for _, name := range names {
+		out = append(out, Candidate{Value: "--" + name})
+	}
+	return out
+}
+
+func complete(v valueSpec, prefix, cur string, src Source) Result {
+	if v.kind == KindFiles {
+		return Result{Files: true}
+	}
+	if v.list {
+		if i := strings.LastIndex(cur, ","); i >= 0 {
+			prefix += cur[:i+1]
+			cur = cur[i+1:]
+		}
+	}
+	var values []Candidate
+	switch {
+	case len(v.choices) > 0:
+		for _, c := range v.choices {
+			values = append(values, Candidate{Value: c})
+		}
+	case v.kind != "" && src != nil:
+		values = src(v.kind)
+	}
+	values = filter(values, cur)
+	for i := range values {
+		values[i].Value = prefix + values[i].Value
+	}
+	return Result{Candidates: values}
+}
+
+func filter(in []Candidate, prefix string) []Candidate {
+	out := make([]Candidate, 0, len(in))
+	for _, c := range in {
+		if strings.HasPrefix(c.Value, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
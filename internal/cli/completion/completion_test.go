@@ -0,0 +1,93 @@
+package completion
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testUsage = `usage:
+  fora [--profile name] <command> ...
+  fora posts list [--limit n] [--board id] [--sort activity|created] [--unread] [--format f]
+  fora posts reply <post-or-reply-id> [content] [--from-file file] [--mention a,b]
+  fora agent add <name> [--role agent|admin]
+  fora agent info <name>
+  fora sync drop <outbox-id> | --all
+  fora completion bash|zsh|fish`
+
+func values(r Result) []string {
+	out := []string{}
+	for _, c := range r.Candidates {
+		out = append(out, c.Value)
+	}
+	return out
+}
+
+func TestCompleteWalksUsageTree(t *testing.T) {
+	tree := Parse(testUsage)
+	src := func(kind string) []Candidate {
+		switch kind {
+		case KindBoards:
+			return []Candidate{{Value: "general"}, {Value: "gossip"}, {Value: "ops"}}
+		case KindAgents:
+			return []Candidate{{Value: "alice"}, {Value: "bob"}}
+		case KindThreads:
+			return []Candidate{{Value: "T1", Description: "first"}}
+		}
+		return nil
+	}
+	cases := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{""}, []string{"posts", "agent", "sync", "completion"}},
+		{[]string{"posts", "l"}, []string{"list"}},
+		{[]string{"posts", "list", "--"}, []string{"--board", "--fields", "--format", "--jsonpath", "--limit", "--profile", "--sort", "--template", "--unread"}},
+		{[]string{"posts", "list", "--board", "g"}, []string{"general", "gossip"}},
+		{[]string{"posts", "list", "--board=o"}, []string{"--board=ops"}},
+		{[]string{"--profile", "work", "posts", "list", "--sort", ""}, []string{"activity", "created"}},
+		{[]string{"posts", "list", "--format", "t"}, []string{"table"}},
+		{[]string{"posts", "list", "--unread", "--limit", ""}, []string{}},
+		{[]string{"posts", "reply", ""}, []string{"T1"}},
+		{[]string{"posts", "reply", "T1", "body", "--mention", "alice,b"}, []string{"alice,bob"}},
+		{[]string{"agent", "add", ""}, []string{}},
+		{[]string{"agent", "add", "x", "--role", ""}, []string{"agent", "admin"}},
+		{[]string{"agent", "info", ""}, []string{"alice", "bob"}},
+		{[]string{"completion", "z"}, []string{"zsh"}},
+		{[]string{"sync", "drop", "--"}, []string{"--all", "--profile"}},
+	}
+	for _, tc := range cases {
+		if got := values(tree.Complete(tc.words, src)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Complete(%q) = %q, want %q", tc.words, got, tc.want)
+		}
+	}
+	if r := tree.Complete([]string{"posts", "reply", "T1", "--from-file", ""}, src); !r.Files {
+		t.Errorf("--from-file should fall back to file completion, got %+v", r)
+	}
+}
+
+func TestCacheReusesFreshValuesAndFallsBackToStale(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir(), Key: "k", TTL: time.Hour}
+	calls := 0
+	fetch := func() ([]Candidate, error) {
+		calls++
+		return []Candidate{{Value: "general"}}, nil
+	}
+	cache.Get(KindBoards, fetch)
+	if got := cache.Get(KindBoards, fetch); len(got) != 1 || calls != 1 {
+		t.Fatalf("fresh entry refetched: got %v after %d calls", got, calls)
+	}
+
+	other := &Cache{Dir: cache.Dir, Key: "other", TTL: time.Hour}
+	other.Get(KindBoards, fetch)
+	if calls != 2 {
+		t.Fatalf("entry for another server was reused")
+	}
+
+	expired := &Cache{Dir: cache.Dir, Key: "other", TTL: time.Nanosecond}
+	failing := func() ([]Candidate, error) { return nil, errors.New("offline") }
+	if got := expired.Get(KindBoards, failing); len(got) != 1 || got[0].Value != "general" {
+		t.Fatalf("stale values not used when offline: %v", got)
+	}
+}
@@ -0,0 +1,106 @@
+package completion
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Every script asks `fora __complete -- <words>` for candidates, one per line
+// as "value<TAB>description", or FilesDirective for file names.
+
+const bashScript = `# bash completion for fora; load with: source <(fora completion bash)
+_fora() {
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a words
+    read -ra words <<< "$line"
+    [[ "$line" == *[[:space:]] ]] && words+=("")
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    [[ "$cur" == "=" ]] && cur=""
+    # bash splits --flag=value at "="; drop what it already considers typed.
+    local strip="${words[${#words[@]}-1]%"$cur"}"
+    local out
+    out=$(fora __complete -- "${words[@]:1}" 2>/dev/null)
+    COMPREPLY=()
+    if [[ "$out" == ":files" ]]; then
+        compopt -o default 2>/dev/null
+        return
+    fi
+    local IFS=$'\n' value
+    for value in $out; do
+        value="${value%%$'\t'*}"
+        COMPREPLY+=("${value#"$strip"}")
+    done
+}
+complete -F _fora fora
+`
+
+const zshScript = `#compdef fora
+# zsh completion for fora; load with: source <(fora completion zsh)
+_fora() {
+    local -a out cands
+    out=("${(@f)$(fora __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ "${out[1]}" == ":files" ]]; then
+        _files
+        return
+    fi
+    local line
+    for line in "${out[@]}"; do
+        [[ -z "$line" ]] && continue
+        if [[ "$line" == *$'\t'* ]]; then
+            cands+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+        else
+            cands+=("${line//:/\\:}")
+        fi
+    done
+    _describe -t values fora cands
+}
+compdef _fora fora
+`
+
+const fishScript = `# fish completion for fora; load with: fora completion fish | source
+function __fora_complete
+    set -l args (commandline -opc)[2..-1] (commandline -ct)
+    set -l out (fora __complete -- $args 2>/dev/null)
+    if test "$out[1]" = ":files"
+        __fish_complete_path (commandline -ct)
+        return
+    end
+    printf '%s\n' $out
+end
+complete -c fora -f -a '(__fora_complete)'
+`
+
+// Shells lists the shells Script supports.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Script returns the completion script for shell.
+func Script(shell string) (string, error) {
+	switch shell {
+	case "bash":
+		return bashScript, nil
+	case "zsh":
+		return zshScript, nil
+	case "fish":
+		return fishScript, nil
+	}
+	return "", fmt.Errorf("unsupported shell %q (want %s)", shell, strings.Join(Shells, ", "))
+}
+
+// Write prints r in the line format the scripts read.
+func Write(w io.Writer, r Result) error {
+	if r.Files {
+		_, err := fmt.Fprintln(w, FilesDirective)
+		return err
+	}
+	for _, c := range r.Candidates {
+		line := c.Value
+		if c.Description != "" {
+			line += "\t" + strings.ReplaceAll(c.Description, "\n", " ")
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
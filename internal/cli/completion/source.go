@@ -0,0 +1,242 @@
+package completion
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"fora/internal/cli/client"
+)
+
+// DefaultTTL is how long fetched values are reused before asking the server
+// again.
+const DefaultTTL = time.Minute
+
+// API is the part of the client that remote lookups need.
+type API interface {
+	Get(path string, out any) error
+}
+
+// Cache keeps fetched values per kind as small JSON files under Dir. Key
+// identifies the server they came from; entries stored for another key are
+// ignored.
+type Cache struct {
+	Dir string
+	Key string
+	TTL time.Duration
+}
+
+type cacheFile struct {
+	Key       string      `json:"key"`
+	FetchedAt time.Time   `json:"fetched_at"`
+	Values    []Candidate `json:"values"`
+}
+
+// Get returns the cached values for kind while they are fresh, and calls
+// fetch otherwise. When fetch fails, stale values are better than none.
+func (c *Cache) Get(kind string, fetch func() ([]Candidate, error)) []Candidate {
+	stale, fresh := c.load(kind)
+	if fresh {
+		return stale.Values
+	}
+	values, err := fetch()
+	if err != nil {
+		if stale != nil {
+			return stale.Values
+		}
+		return nil
+	}
+	c.store(kind, values)
+	return values
+}
+
+func (c *Cache) path(kind string) string {
+	return filepath.Join(c.Dir, kind+".json")
+}
+
+func (c *Cache) load(kind string) (*cacheFile, bool) {
+	if c == nil || c.Dir == "" {
+		return nil, false
+	}
+	b, err := os.ReadFile(c.path(kind))
+	if err != nil {
+		return nil, false
+	}
+	var f cacheFile
+	if err := json.Unmarshal(b, &f); err != nil || f.Key != c.Key {
+		return nil, false
+	}
+	return &f, time.Since(f.FetchedAt) < c.TTL
+}
+
+func (c *Cache) store(kind string, values []Candidate) {
+	if c == nil || c.Dir == "" || c.TTL <= 0 {
+		return
+	}
+	b, err := json.Marshal(cacheFile{Key: c.Key, FetchedAt: time.Now(), Values: values})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return
+	}
+	// Best effort: a cache that cannot be written only costs a refetch.
+	_ = os.WriteFile(c.path(kind), b, 0o600)
+}
+
+// Remote returns a Source that looks up boards, threads, tags and agents on
+// the server, going through cache.
+func Remote(api API, cache *Cache) Source {
+	return func(kind string) []Candidate {
+		var fetch func(API) ([]Candidate, error)
+		switch kind {
+		case KindBoards:
+			fetch = fetchBoards
+		case KindThreads:
+			fetch = fetchThreads
+		case KindTags:
+			fetch = fetchTags
+		case KindAgents:
+			fetch = fetchAgents
+		default:
+			return nil
+		}
+		return cache.Get(kind, func() ([]Candidate, error) { return fetch(api) })
+	}
+}
+
+type boardsResponse struct {
+	Boards []struct {
+		ID          string   `json:"id"`
+		Description string   `json:"description"`
+		Tags        []string `json:"tags"`
+	} `json:"boards"`
+}
+
+type threadsResponse struct {
+	Threads []struct {
+		ID           string   `json:"id"`
+		Title        *string  `json:"title"`
+		Body         string   `json:"body"`
+		Author       string   `json:"author"`
+		Tags         []string `json:"tags"`
+		Participants []string `json:"participants"`
+	} `json:"threads"`
+}
+
+func fetchBoards(api API) ([]Candidate, error) {
+	var resp boardsResponse
+	if err := api.Get("/api/v1/boards", &resp); err != nil {
+		return nil, err
+	}
+	out := make([]Candidate, 0, len(resp.Boards))
+	for _, b := range resp.Boards {
+		out = append(out, Candidate{Value: b.ID, Description: b.Description})
+	}
+	return out, nil
+}
+
+func fetchThreads(api API) ([]Candidate, error) {
+	var resp threadsResponse
+	if err := api.Get("/api/v1/posts?limit=50", &resp); err != nil {
+		return nil, err
+	}
+	out := make([]Candidate, 0, len(resp.Threads))
+	for _, t := range resp.Threads {
+		desc := ""
+		if t.Title != nil {
+			desc = *t.Title
+		}
+		if desc == "" {
+			desc, _, _ = strings.Cut(strings.TrimSpace(t.Body), "\n")
+		}
+		out = append(out, Candidate{Value: t.ID, Description: shorten(desc, 60)})
+	}
+	return out, nil
+}
+
+// fetchTags collects the tags of boards and recent threads.
+func fetchTags(api API) ([]Candidate, error) {
+	seen := map[string]bool{}
+	var boards boardsResponse
+	if err := api.Get("/api/v1/boards", &boards); err != nil {
+		return nil, err
+	}
+	for _, b := range boards.Boards {
+		for _, tag := range b.Tags {
+			seen[tag] = true
+		}
+	}
+	var threads threadsResponse
+	if err := api.Get("/api/v1/posts?limit=100", &threads); err != nil {
+		return nil, err
+	}
+	for _, t := range threads.Threads {
+		for _, tag := range t.Tags {
+			seen[tag] = true
+		}
+	}
+	return sortedCandidates(seen), nil
+}
+
+// fetchAgents lists every agent for admins; other agents only get the ones
+// active in recent threads.
+func fetchAgents(api API) ([]Candidate, error) {
+	var resp struct {
+		Agents []struct {
+			Name string `json:"name"`
+		} `json:"agents"`
+	}
+	err := api.Get("/api/v1/agents", &resp)
+	var httpErr *client.HTTPError
+	if err == nil {
+		out := make([]Candidate, 0, len(resp.Agents))
+		for _, a := range resp.Agents {
+			out = append(out, Candidate{Value: a.Name})
+		}
+		return out, nil
+	}
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusForbidden {
+		return nil, err
+	}
+	var threads threadsResponse
+	if err := api.Get("/api/v1/posts?limit=100", &threads); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, t := range threads.Threads {
+		seen[t.Author] = true
+		for _, p := range t.Participants {
+			seen[p] = true
+		}
+	}
+	return sortedCandidates(seen), nil
+}
+
+func sortedCandidates(set map[string]bool) []Candidate {
+	values := make([]string, 0, len(set))
+	for v := range set {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	out := make([]Candidate, 0, len(values))
+	for _, v := range values {
+		out = append(out, Candidate{Value: v})
+	}
+	return out
+}
+
+func shorten(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 )
@@ -18,24 +20,57 @@ type Config struct {
 	Preferences   map[string]string `json:"preferences,omitempty"`
 }
 
+// Server is one named profile: a server URL plus the agent identity used
+// against it.
 type Server struct {
-	URL         string `json:"url"`
-	APIKey      string `json:"api_key"`
-	Agent       string `json:"agent,omitempty"`
-	ConnectedAt string `json:"connected_at"`
+	URL         string            `json:"url"`
+	APIKey      string            `json:"api_key"`
+	Agent       string            `json:"agent,omitempty"`
+	ConnectedAt string            `json:"connected_at"`
+	Preferences map[string]string `json:"preferences,omitempty"`
 }
 
+// Per-profile preference keys.
+const (
+	PrefDefaultBoard  = "default_board"
+	PrefDefaultFormat = "default_format"
+	// PrefOutbox ("on"/"off") queues writes locally when the server is
+	// unreachable.
+	PrefOutbox = "outbox"
+)
+
+// ProfilePreferences lists the keys accepted by profile preferences.
+var ProfilePreferences = []string{PrefDefaultBoard, PrefDefaultFormat, PrefOutbox}
+
 var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
 
+var profilePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// profileOverride is set from the global --profile flag and wins over
+// FORA_PROFILE and the stored default.
+var profileOverride string
+
+func SetProfileOverride(name string) {
+	profileOverride = strings.TrimSpace(name)
+}
+
 func Path() (string, error) {
+	p, _, err := Resolve()
+	return p, err
+}
+
+// Resolve returns the config path in use and whether it is a project-local
+// .fora/config.json rather than the one in the home directory.
+func Resolve() (string, bool, error) {
 	if cwd, err := os.Getwd(); err == nil {
 		if localPath, ok, err := findLocalPath(cwd); err != nil {
-			return "", err
+			return "", false, err
 		} else if ok {
-			return localPath, nil
+			return localPath, true, nil
 		}
 	}
-	return homePath()
+	p, err := homePath()
+	return p, false, err
 }
 
 func homePath() (string, error) {
@@ -121,27 +156,148 @@ func SaveToPath(c *Config, p string) error {
 	return os.WriteFile(p, append(b, '\n'), 0o600)
 }
 
+// ActiveProfile names the profile commands run against: --profile, then
+// FORA_PROFILE, then the stored default.
+func (c *Config) ActiveProfile() string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+	if env := strings.TrimSpace(os.Getenv("FORA_PROFILE")); env != "" {
+		return env
+	}
+	return c.DefaultServer
+}
+
+// SetDefault stores url and apiKey in the active profile, keeping its
+// preferences, and makes it the default.
 func (c *Config) SetDefault(url, apiKey string) {
 	if c.Servers == nil {
 		c.Servers = map[string]Server{}
 	}
-	c.Servers["main"] = Server{
+	name := c.ActiveProfile()
+	prev := c.Servers[name]
+	c.Servers[name] = Server{
 		URL:         url,
 		APIKey:      apiKey,
 		ConnectedAt: time.Now().UTC().Format(time.RFC3339),
+		Preferences: prev.Preferences,
 	}
-	c.DefaultServer = "main"
+	c.DefaultServer = name
 }
 
 func (c *Config) ClearDefault() {
-	delete(c.Servers, c.DefaultServer)
+	delete(c.Servers, c.ActiveProfile())
 }
 
 func (c *Config) Default() (Server, bool) {
-	s, ok := c.Servers[c.DefaultServer]
+	s, ok := c.Servers[c.ActiveProfile()]
 	return s, ok
 }
 
+func ValidateProfileName(name string) error {
+	if !profilePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (letters, digits, '.', '_' and '-')", name)
+	}
+	return nil
+}
+
+func (c *Config) AddProfile(name string, s Server) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if _, exists := c.Servers[name]; exists {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	if c.Servers == nil {
+		c.Servers = map[string]Server{}
+	}
+	if s.ConnectedAt == "" {
+		s.ConnectedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	c.Servers[name] = s
+	if len(c.Servers) == 1 {
+		c.DefaultServer = name
+	}
+	return nil
+}
+
+func (c *Config) UseProfile(name string) error {
+	if _, ok := c.Servers[name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	c.DefaultServer = name
+	return nil
+}
+
+func (c *Config) RemoveProfile(name string) error {
+	if _, ok := c.Servers[name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	delete(c.Servers, name)
+	if c.DefaultServer == name {
+		c.DefaultServer = "main"
+		if names := c.ProfileNames(); len(names) > 0 {
+			c.DefaultServer = names[0]
+		}
+	}
+	return nil
+}
+
+func (c *Config) RenameProfile(from, to string) error {
+	s, ok := c.Servers[from]
+	if !ok {
+		return fmt.Errorf("profile %q not found", from)
+	}
+	if err := ValidateProfileName(to); err != nil {
+		return err
+	}
+	if _, exists := c.Servers[to]; exists {
+		return fmt.Errorf("profile %q already exists", to)
+	}
+	delete(c.Servers, from)
+	c.Servers[to] = s
+	if c.DefaultServer == from {
+		c.DefaultServer = to
+	}
+	return nil
+}
+
+// SetPreference sets or, with an empty value, clears a profile preference.
+func (c *Config) SetPreference(profile, key, value string) error {
+	s, ok := c.Servers[profile]
+	if !ok {
+		return fmt.Errorf("profile %q not found", profile)
+	}
+	if !slices.Contains(ProfilePreferences, key) {
+		return fmt.Errorf("unknown preference %q (supported: %s)", key, strings.Join(ProfilePreferences, ", "))
+	}
+	if value == "" {
+		delete(s.Preferences, key)
+	} else {
+		if s.Preferences == nil {
+			s.Preferences = map[string]string{}
+		}
+		s.Preferences[key] = value
+	}
+	c.Servers[profile] = s
+	return nil
+}
+
+// Preference returns a preference of the active profile.
+func (c *Config) Preference(key string) string {
+	s, _ := c.Default()
+	return s.Preferences[key]
+}
+
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Servers))
+	for name := range c.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func resolveConfigEnv(c *Config) error {
 	c.DefaultServer = resolveEnvPlaceholders(c.DefaultServer)
 	for key, srv := range c.Servers {
@@ -150,6 +306,9 @@ func resolveConfigEnv(c *Config) error {
 		srv.APIKey = resolveEnvPlaceholders(srv.APIKey)
 		srv.Agent = resolveEnvPlaceholders(srv.Agent)
 		srv.ConnectedAt = resolveEnvPlaceholders(srv.ConnectedAt)
+		for k, v := range srv.Preferences {
+			srv.Preferences[k] = resolveEnvPlaceholders(v)
+		}
 		if resolvedKey != key {
 			delete(c.Servers, key)
 		}
@@ -138,3 +138,68 @@ func TestLoadExpandsEnvPlaceholders(t *testing.T) {
 		t.Fatalf("api_key = %q, want %q", srv.APIKey, "fora_ak_from_env")
 	}
 }
+
+func TestProfilesAddUseRenameRemove(t *testing.T) {
+	t.Setenv("FORA_PROFILE", "")
+	t.Cleanup(func() { SetProfileOverride("") })
+
+	cfg := defaultConfig()
+	cfg.SetDefault("http://prod:8080", "fora_ak_prod")
+	if err := cfg.AddProfile("staging", Server{URL: "http://staging:8080", APIKey: "fora_ak_staging"}); err != nil {
+		t.Fatalf("add staging: %v", err)
+	}
+	if err := cfg.AddProfile("staging", Server{}); err == nil {
+		t.Fatal("expected duplicate profile to be rejected")
+	}
+	if err := cfg.AddProfile("bad name", Server{}); err == nil {
+		t.Fatal("expected invalid profile name to be rejected")
+	}
+	if srv, _ := cfg.Default(); srv.URL != "http://prod:8080" {
+		t.Fatalf("expected main to stay default, got %q", srv.URL)
+	}
+
+	t.Setenv("FORA_PROFILE", "staging")
+	if srv, _ := cfg.Default(); srv.URL != "http://staging:8080" {
+		t.Fatalf("expected FORA_PROFILE to select staging, got %q", srv.URL)
+	}
+	SetProfileOverride("main")
+	if cfg.ActiveProfile() != "main" {
+		t.Fatalf("expected --profile to win over FORA_PROFILE, got %q", cfg.ActiveProfile())
+	}
+	SetProfileOverride("")
+	t.Setenv("FORA_PROFILE", "")
+
+	if err := cfg.SetPreference("staging", PrefDefaultBoard, "ops"); err != nil {
+		t.Fatalf("set preference: %v", err)
+	}
+	if err := cfg.SetPreference("staging", "colour", "blue"); err == nil {
+		t.Fatal("expected unknown preference to be rejected")
+	}
+	if err := cfg.UseProfile("staging"); err != nil {
+		t.Fatalf("use staging: %v", err)
+	}
+	if got := cfg.Preference(PrefDefaultBoard); got != "ops" {
+		t.Fatalf("default board = %q", got)
+	}
+	if err := cfg.RenameProfile("staging", "stage"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if cfg.DefaultServer != "stage" || cfg.Preference(PrefDefaultBoard) != "ops" {
+		t.Fatalf("rename lost default or preferences: %+v", cfg)
+	}
+	if err := cfg.RemoveProfile("stage"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if cfg.DefaultServer != "main" {
+		t.Fatalf("expected default to fall back to main, got %q", cfg.DefaultServer)
+	}
+
+	// Re-connecting keeps the profile's preferences.
+	if err := cfg.SetPreference("main", PrefDefaultFormat, "json"); err != nil {
+		t.Fatalf("set format: %v", err)
+	}
+	cfg.SetDefault("http://prod2:8080", "fora_ak_new")
+	if cfg.Preference(PrefDefaultFormat) != "json" {
+		t.Fatal("expected SetDefault to keep preferences")
+	}
+}
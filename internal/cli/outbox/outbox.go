@@ -0,0 +1,226 @@
+// Package outbox queues CLI writes in a local JSONL file while the server is
+// unreachable so `fora sync` can send them later.
+package outbox
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry is one queued write. ID doubles as the Idempotency-Key, so an entry
+// whose first attempt reached the server before timing out is not applied
+// twice.
+type Entry struct {
+	ID        string         `json:"id"`
+	Profile   string         `json:"profile"`
+	Command   string         `json:"command"`
+	Method    string         `json:"method"`
+	Path      string         `json:"path"`
+	Body      map[string]any `json:"body"`
+	QueuedAt  string         `json:"queued_at"`
+	Attempts  int            `json:"attempts"`
+	LastError string         `json:"last_error,omitempty"`
+	// Conflict is set when the server rejected the write; conflicted entries
+	// are skipped until retried with --force or dropped.
+	Conflict string `json:"conflict,omitempty"`
+}
+
+type Outbox struct {
+	path string
+}
+
+func Open(path string) *Outbox {
+	return &Outbox{path: path}
+}
+
+func (o *Outbox) Path() string {
+	return o.path
+}
+
+func NewID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("ob_%d", time.Now().UnixNano())
+	}
+	return "ob_" + hex.EncodeToString(b)
+}
+
+// lockWait bounds how long a writer waits for another fora process to
+// release the outbox; a lock older than lockStale is left over from a crashed
+// process and is broken.
+const (
+	lockWait  = 10 * time.Second
+	lockStale = 2 * time.Minute
+)
+
+// lock takes the outbox's lock file. Every write holds it, so an Add made
+// while `fora sync` replays the queue is not overwritten by the sync's Save.
+func (o *Outbox) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(o.path), 0o755); err != nil {
+		return nil, err
+	}
+	name := o.path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(name) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(name); statErr == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(name)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("outbox %s is locked by another fora process; remove %s if none is running", o.path, name)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Add appends e, filling in ID and QueuedAt when empty.
+func (o *Outbox) Add(e Entry) (Entry, error) {
+	if e.ID == "" {
+		e.ID = NewID()
+	}
+	if e.QueuedAt == "" {
+		e.QueuedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return Entry{}, err
+	}
+	unlock, err := o.lock()
+	if err != nil {
+		return Entry{}, err
+	}
+	defer unlock()
+	f, err := os.OpenFile(o.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return Entry{}, err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return Entry{}, err
+	}
+	return e, f.Close()
+}
+
+// List returns the queued entries in the order they were added.
+func (o *Outbox) List() ([]Entry, error) {
+	f, err := os.Open(o.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("parse %s line %d: %w", o.path, n, err)
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
+
+// Save replaces the queue with entries; an empty list removes the file.
+// Entries added since the caller listed the queue are lost, so callers that
+// work from an earlier List should use Update instead.
+func (o *Outbox) Save(entries []Entry) error {
+	unlock, err := o.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return o.save(entries)
+}
+
+// Update rewrites the queue with fn's result while holding the lock, so
+// entries added concurrently are seen by fn rather than overwritten.
+func (o *Outbox) Update(fn func([]Entry) []Entry) error {
+	unlock, err := o.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	entries, err := o.List()
+	if err != nil {
+		return err
+	}
+	return o.save(fn(entries))
+}
+
+func (o *Outbox) save(entries []Entry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(o.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(o.path), ".outbox-*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), o.path)
+}
+
+// Remove drops the entry with id and reports whether it was queued.
+func (o *Outbox) Remove(id string) (bool, error) {
+	found := false
+	err := o.Update(func(entries []Entry) []Entry {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.ID == id {
+				found = true
+				continue
+			}
+			kept = append(kept, e)
+		}
+		return kept
+	})
+	return found, err
+}
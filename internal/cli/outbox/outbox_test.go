@@ -0,0 +1,171 @@
+package outbox
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func entryIDs(entries []Entry) []string {
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestAddListSave(t *testing.T) {
+	ob := Open(filepath.Join(t.TempDir(), "nested", "outbox.jsonl"))
+
+	entries, err := ob.List()
+	if err != nil || entries != nil {
+		t.Fatalf("List() on a missing outbox = %v, %v", entries, err)
+	}
+	first, err := ob.Add(Entry{Profile: "default", Command: "posts add", Method: "POST", Path: "/api/v1/posts"})
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	if first.ID == "" || first.QueuedAt == "" {
+		t.Fatalf("Add() did not fill ID and QueuedAt: %+v", first)
+	}
+	if _, err := ob.Add(Entry{ID: "ob_second", Profile: "default", Body: map[string]any{"body": "hi"}}); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	entries, err = ob.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != first.ID || entries[1].Body["body"] != "hi" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	entries[1].Attempts = 3
+	if err := ob.Save(entries[1:]); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	entries, err = ob.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != "ob_second" || entries[0].Attempts != 3 {
+		t.Fatalf("unexpected entries after Save: %+v", entries)
+	}
+	info, err := os.Stat(ob.Path())
+	if err != nil {
+		t.Fatalf("stat outbox: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("outbox mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	if err := ob.Save(nil); err != nil {
+		t.Fatalf("Save(nil) error: %v", err)
+	}
+	if _, err := os.Stat(ob.Path()); !os.IsNotExist(err) {
+		t.Fatalf("Save(nil) kept the file: %v", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	ob := Open(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	for _, id := range []string{"ob_a", "ob_b", "ob_c"} {
+		if _, err := ob.Add(Entry{ID: id}); err != nil {
+			t.Fatalf("Add(%s) error: %v", id, err)
+		}
+	}
+	found, err := ob.Remove("ob_b")
+	if err != nil || !found {
+		t.Fatalf("Remove(ob_b) = %v, %v", found, err)
+	}
+	found, err = ob.Remove("ob_missing")
+	if err != nil || found {
+		t.Fatalf("Remove(ob_missing) = %v, %v", found, err)
+	}
+	entries, err := ob.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if got := entryIDs(entries); len(got) != 2 || got[0] != "ob_a" || got[1] != "ob_c" {
+		t.Fatalf("unexpected entries after Remove: %v", got)
+	}
+}
+
+func TestUpdateKeepsEntriesAddedDuringSync(t *testing.T) {
+	ob := Open(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	if _, err := ob.Add(Entry{ID: "ob_replayed"}); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+
+	// A sync lists the queue, then another command queues a write while the
+	// sync is still replaying.
+	replayed, err := ob.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if _, err := ob.Add(Entry{ID: "ob_queued_during_sync"}); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+
+	done := map[string]bool{}
+	for _, e := range replayed {
+		done[e.ID] = true
+	}
+	if err := ob.Update(func(current []Entry) []Entry {
+		kept := current[:0]
+		for _, e := range current {
+			if !done[e.ID] {
+				kept = append(kept, e)
+			}
+		}
+		return kept
+	}); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	entries, err := ob.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if got := entryIDs(entries); len(got) != 1 || got[0] != "ob_queued_during_sync" {
+		t.Fatalf("write queued during sync was lost: %v", got)
+	}
+}
+
+func TestConcurrentAddsAndUpdates(t *testing.T) {
+	ob := Open(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	const adds = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < adds; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := ob.Add(Entry{Profile: "default"}); err != nil {
+				t.Errorf("Add() error: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := ob.Update(func(current []Entry) []Entry {
+				for i := range current {
+					current[i].Attempts++
+				}
+				return current
+			}); err != nil {
+				t.Errorf("Update() error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := ob.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(entries) != adds {
+		t.Fatalf("got %d entries, want %d", len(entries), adds)
+	}
+	if _, err := os.Stat(ob.Path() + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("lock file left behind: %v", err)
+	}
+}
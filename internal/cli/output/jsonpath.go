@@ -0,0 +1,114 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type pathStep struct {
+	key   string
+	index int
+	all   bool
+	isIdx bool
+}
+
+// parseJSONPath accepts the subset of JSONPath scripts need: an optional
+// leading $ (or kubectl-style braces), .field, ["field"], [n], [-n], [*] and .*
+func parseJSONPath(expr string) ([]pathStep, error) {
+	orig := expr
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "{") && strings.HasSuffix(expr, "}") {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	expr = strings.TrimPrefix(expr, "$")
+	var steps []pathStep
+	for expr != "" {
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			end := strings.IndexAny(expr, ".[")
+			if end < 0 {
+				end = len(expr)
+			}
+			name := expr[:end]
+			expr = expr[end:]
+			switch name {
+			case "":
+				if expr != "" {
+					return nil, fmt.Errorf("invalid jsonpath %q: empty field name", orig)
+				}
+			case "*":
+				steps = append(steps, pathStep{all: true})
+			default:
+				steps = append(steps, pathStep{key: name})
+			}
+		case '[':
+			end := strings.IndexByte(expr, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid jsonpath %q: missing ]", orig)
+			}
+			inner := strings.TrimSpace(expr[1:end])
+			expr = expr[end+1:]
+			switch {
+			case inner == "*":
+				steps = append(steps, pathStep{all: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, pathStep{key: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid jsonpath %q: bad index %q", orig, inner)
+				}
+				steps = append(steps, pathStep{index: n, isIdx: true})
+			}
+		default:
+			if len(steps) == 0 {
+				// Allow a bare first field: "threads[*].id".
+				expr = "." + expr
+				continue
+			}
+			return nil, fmt.Errorf("invalid jsonpath %q near %q", orig, expr)
+		}
+	}
+	return steps, nil
+}
+
+// evalJSONPath returns every value the path selects. Missing fields and
+// out-of-range indexes select nothing rather than failing, so optional fields
+// such as a reply's title do not break a [*] query.
+func evalJSONPath(v any, steps []pathStep) []any {
+	current := []any{v}
+	for _, step := range steps {
+		var next []any
+		for _, item := range current {
+			switch t := item.(type) {
+			case map[string]any:
+				if step.all {
+					for _, k := range sortedKeys(t) {
+						next = append(next, t[k])
+					}
+				} else if !step.isIdx {
+					if val, ok := t[step.key]; ok {
+						next = append(next, val)
+					}
+				}
+			case []any:
+				switch {
+				case step.all:
+					next = append(next, t...)
+				case step.isIdx:
+					i := step.index
+					if i < 0 {
+						i += len(t)
+					}
+					if i >= 0 && i < len(t) {
+						next = append(next, t[i])
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
@@ -18,7 +18,35 @@ func DefaultFormat() string {
 }
 
 func Print(payload map[string]any, format string, quiet bool) error {
-	if quiet {
+	return PrintWith(payload, Options{Format: format, Quiet: quiet})
+}
+
+// Options are the output flags shared by list commands. Template and JSONPath
+// replace the format; Fields narrows it to the named (dotted) fields.
+type Options struct {
+	Format   string
+	Quiet    bool
+	Fields   []string
+	Template string
+	JSONPath string
+}
+
+func PrintWith(payload map[string]any, opts Options) error {
+	if opts.Template != "" && opts.JSONPath != "" {
+		return errors.New("--template and --jsonpath cannot be combined")
+	}
+	if len(opts.Fields) > 0 {
+		payload = selectFields(payload, opts.Fields)
+	}
+	switch {
+	case opts.JSONPath != "":
+		return printJSONPath(payload, opts.JSONPath)
+	case opts.Template != "":
+		return printTemplate(payload, opts.Template)
+	}
+
+	format := opts.Format
+	if opts.Quiet {
 		format = "quiet"
 	}
 	format = strings.TrimSpace(strings.ToLower(format))
@@ -26,9 +54,24 @@ func Print(payload map[string]any, format string, quiet bool) error {
 		format = DefaultFormat()
 	}
 
+	if len(opts.Fields) > 0 {
+		switch format {
+		case "table":
+			printFieldsTable(payload, opts.Fields)
+			return nil
+		case "plain":
+			printFieldsPlain(payload, opts.Fields)
+			return nil
+		case "md":
+			printFieldsMarkdown(payload, opts.Fields)
+			return nil
+		}
+	}
 	switch format {
 	case "json":
 		return printJSON(payload)
+	case "ndjson":
+		return printNDJSON(payload)
 	case "table":
 		return printTable(payload)
 	case "plain":
@@ -54,16 +97,16 @@ func printJSON(v any) error {
 func printTable(payload map[string]any) error {
 	switch {
 	case hasKey(payload, "agents"):
-		fmt.Println("NAME\tROLE\tLAST_ACTIVE\tCREATED")
+		fmt.Println("NAME\tROLE\tSTATE\tPRESENCE\tLAST_ACTIVE\tCREATED")
 		for _, row := range toObjectSlice(payload["agents"]) {
-			fmt.Printf("%s\t%s\t%s\t%s\n",
-				str(row["name"]), str(row["role"]), str(row["last_active"]), str(row["created"]))
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\t%s\n",
+				str(row["name"]), str(row["role"]), str(row["state"]), presenceStatus(row), str(row["last_active"]), str(row["created"]))
 		}
 	case hasKey(payload, "threads"):
-		fmt.Println("ID\tAUTHOR\tTITLE\tSTATUS\tCREATED")
+		fmt.Println("ID\tAUTHOR\tTITLE\tSTATUS\tUNREAD\tCREATED")
 		for _, row := range toObjectSlice(payload["threads"]) {
-			fmt.Printf("%s\t%s\t%s\t%s\t%s\n",
-				str(row["id"]), str(row["author"]), str(row["title"]), str(row["status"]), str(row["created"]))
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\t%s\n",
+				str(row["id"]), str(row["author"]), str(row["title"]), str(row["status"]), str(row["unread_count"]), str(row["created"]))
 		}
 	case hasKey(payload, "activity"):
 		fmt.Println("ID\tTYPE\tAUTHOR\tTHREAD\tCREATED")
@@ -83,6 +126,85 @@ func printTable(payload map[string]any) error {
 			fmt.Printf("%s\t%s\t%s\t%s\t%s\n",
 				str(row["id"]), str(row["type"]), str(row["from_agent"]), str(row["thread_id"]), str(row["created"]))
 		}
+	case hasKey(payload, "profiles"):
+		fmt.Println("ACTIVE\tNAME\tURL\tAGENT")
+		for _, row := range toObjectSlice(payload["profiles"]) {
+			fmt.Printf("%s\t%s\t%s\t%s\n", strings.TrimSpace(activeMark(row)), str(row["name"]), str(row["url"]), str(row["agent"]))
+		}
+	case hasKey(payload, "webhooks"):
+		fmt.Println("ID\tURL\tEVENTS\tACTIVE\tCREATED")
+		for _, row := range toObjectSlice(payload["webhooks"]) {
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n",
+				str(row["id"]), str(row["url"]), joined(row["events"]), str(row["active"]), str(row["created"]))
+		}
+	case hasKey(payload, "outbox"):
+		fmt.Println("ID\tCOMMAND\tPATH\tRESULT\tDETAIL")
+		for _, row := range toObjectSlice(payload["outbox"]) {
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n",
+				str(row["id"]), str(row["command"]), str(row["path"]), str(row["result"]), str(row["detail"]))
+		}
+	case hasKey(payload, "history"):
+		fmt.Println("VERSION\tEDITED_BY\tEDITED_AT\tTITLE")
+		for _, row := range toObjectSlice(payload["history"]) {
+			fmt.Printf("%s\t%s\t%s\t%s\n",
+				str(row["version"]), str(row["edited_by"]), str(row["edited_at"]), str(row["title"]))
+		}
+	case hasKey(payload, "boards"):
+		fmt.Println("ID\tNAME\tTAGS\tARCHIVED\tDESCRIPTION")
+		for _, row := range toObjectSlice(payload["boards"]) {
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n",
+				str(row["id"]), str(row["name"]), joined(row["tags"]), str(row["archived"]), str(row["description"]))
+		}
+	case hasKey(payload, "suggestions"):
+		fmt.Println("TAG\tMATCHES\tUSES\tBOARD_TAG")
+		for _, row := range toObjectSlice(payload["suggestions"]) {
+			fmt.Printf("%s\t%s\t%s\t%s\n", str(row["tag"]), str(row["matches"]), str(row["uses"]), str(row["board_tag"]))
+		}
+	case hasKey(payload, "groups"):
+		fmt.Println("NAME\tMEMBERS\tDESCRIPTION")
+		for _, row := range toObjectSlice(payload["groups"]) {
+			fmt.Printf("%s\t%s\t%s\n", str(row["name"]), joined(row["members"]), str(row["description"]))
+		}
+	case hasKey(payload, "conversations"):
+		fmt.Println("ID\tMEMBERS\tMESSAGES\tUNREAD\tUPDATED")
+		for _, row := range toObjectSlice(payload["conversations"]) {
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n",
+				str(row["id"]), joined(row["members"]), str(row["message_count"]), str(row["unread_count"]), str(row["updated"]))
+		}
+	case isConversation(payload):
+		fmt.Println("ID\tAUTHOR\tCREATED\tBODY")
+		for _, row := range toObjectSlice(payload["messages"]) {
+			fmt.Printf("%s\t%s\t%s\t%s\n", str(row["id"]), str(row["author"]), str(row["created"]), str(row["body"]))
+		}
+	case isPoll(payload):
+		fmt.Println("ID\tOPTION\tVOTES\tVOTERS")
+		for _, row := range toObjectSlice(payload["options"]) {
+			fmt.Printf("%s\t%s\t%s\t%s\n", str(row["id"]), str(row["text"]), str(row["votes"]), joined(row["voters"]))
+		}
+	case isTagList(payload):
+		fmt.Println("TAG\tCOUNT\tLAST_USED\tBOARDS")
+		for _, row := range toObjectSlice(payload["tags"]) {
+			fmt.Printf("%s\t%s\t%s\t%s\n", str(row["tag"]), str(row["count"]), str(row["last_used"]), joined(row["boards"]))
+		}
+	case hasKey(payload, "aliases"):
+		fmt.Println("ALIAS\tTAG\tCREATED")
+		for _, row := range toObjectSlice(payload["aliases"]) {
+			fmt.Printf("%s\t%s\t%s\n", str(row["alias"]), str(row["tag"]), str(row["created"]))
+		}
+	case isAttachmentList(payload):
+		fmt.Println("ID\tFILENAME\tTYPE\tSIZE\tUPLOADER\tCREATED")
+		for _, row := range toObjectSlice(payload["attachments"]) {
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\t%s\n",
+				str(row["id"]), str(row["filename"]), str(row["content_type"]), str(row["size"]), str(row["uploader"]), str(row["created"]))
+		}
+	case hasKey(payload, "summary"):
+		fmt.Println(str(payload["summary"]))
+	case hasKey(payload, "primer"):
+		fmt.Print(str(payload["primer"]))
+	case hasKey(payload, "ready") && hasKey(payload, "presence"):
+		fmt.Println(presenceStatus(payload))
+	case isPresence(payload):
+		fmt.Println(str(payload["status"]))
 	default:
 		return printJSON(payload)
 	}
@@ -93,7 +215,7 @@ func printPlain(payload map[string]any) error {
 	switch {
 	case hasKey(payload, "agents"):
 		for _, row := range toObjectSlice(payload["agents"]) {
-			fmt.Printf("%s %s\n", str(row["name"]), str(row["role"]))
+			fmt.Println(strings.TrimSpace(fmt.Sprintf("%s %s %s", str(row["name"]), str(row["role"]), presenceStatus(row))))
 		}
 	case hasKey(payload, "name") && hasKey(payload, "role"):
 		fmt.Printf("%s %s\n", str(payload["name"]), str(payload["role"]))
@@ -113,6 +235,66 @@ func printPlain(payload map[string]any) error {
 		for _, row := range toObjectSlice(payload["notifications"]) {
 			fmt.Printf("%s %s from=%s\n", str(row["id"]), str(row["type"]), str(row["from_agent"]))
 		}
+	case hasKey(payload, "profiles"):
+		for _, row := range toObjectSlice(payload["profiles"]) {
+			fmt.Printf("%s%s %s\n", activeMark(row), str(row["name"]), str(row["url"]))
+		}
+	case hasKey(payload, "webhooks"):
+		for _, row := range toObjectSlice(payload["webhooks"]) {
+			fmt.Printf("%s %s %s\n", str(row["id"]), str(row["url"]), joined(row["events"]))
+		}
+	case hasKey(payload, "outbox"):
+		for _, row := range toObjectSlice(payload["outbox"]) {
+			fmt.Printf("%s %s %s %s\n", str(row["id"]), str(row["result"]), str(row["command"]), str(row["detail"]))
+		}
+	case hasKey(payload, "history"):
+		for _, row := range toObjectSlice(payload["history"]) {
+			fmt.Printf("v%s %s %s\n", str(row["version"]), str(row["edited_by"]), str(row["edited_at"]))
+		}
+	case hasKey(payload, "boards"):
+		for _, row := range toObjectSlice(payload["boards"]) {
+			fmt.Printf("%s %s\n", str(row["id"]), str(row["description"]))
+		}
+	case hasKey(payload, "suggestions"):
+		for _, row := range toObjectSlice(payload["suggestions"]) {
+			fmt.Printf("%s %s\n", str(row["tag"]), str(row["matches"]))
+		}
+	case hasKey(payload, "groups"):
+		for _, row := range toObjectSlice(payload["groups"]) {
+			fmt.Printf("%s %s\n", str(row["name"]), joined(row["members"]))
+		}
+	case hasKey(payload, "conversations"):
+		for _, row := range toObjectSlice(payload["conversations"]) {
+			fmt.Printf("%s %s %s\n", str(row["id"]), joined(row["members"]), str(row["unread_count"]))
+		}
+	case isConversation(payload):
+		for _, row := range toObjectSlice(payload["messages"]) {
+			fmt.Printf("%s %s: %s\n", str(row["created"]), str(row["author"]), str(row["body"]))
+		}
+	case isPoll(payload):
+		for _, row := range toObjectSlice(payload["options"]) {
+			fmt.Printf("%s %s %s\n", str(row["id"]), str(row["votes"]), str(row["text"]))
+		}
+	case isTagList(payload):
+		for _, row := range toObjectSlice(payload["tags"]) {
+			fmt.Printf("%s %s\n", str(row["tag"]), str(row["count"]))
+		}
+	case hasKey(payload, "aliases"):
+		for _, row := range toObjectSlice(payload["aliases"]) {
+			fmt.Printf("%s -> %s\n", str(row["alias"]), str(row["tag"]))
+		}
+	case isAttachmentList(payload):
+		for _, row := range toObjectSlice(payload["attachments"]) {
+			fmt.Printf("%s %s %s\n", str(row["id"]), str(row["filename"]), str(row["size"]))
+		}
+	case hasKey(payload, "summary"):
+		fmt.Println(str(payload["summary"]))
+	case hasKey(payload, "primer"):
+		fmt.Print(str(payload["primer"]))
+	case hasKey(payload, "ready") && hasKey(payload, "presence"):
+		fmt.Println(presenceStatus(payload))
+	case isPresence(payload):
+		fmt.Println(str(payload["status"]))
 	default:
 		return printJSON(payload)
 	}
@@ -147,6 +329,69 @@ func printMarkdown(payload map[string]any) error {
 			fmt.Printf("- `%s` %s from %s\n",
 				str(row["id"]), str(row["type"]), str(row["from_agent"]))
 		}
+	case hasKey(payload, "profiles"):
+		for _, row := range toObjectSlice(payload["profiles"]) {
+			fmt.Printf("- `%s` %s (%s)%s\n", str(row["name"]), str(row["url"]), str(row["agent"]), strings.TrimSuffix(" "+activeMark(row), " "))
+		}
+	case hasKey(payload, "webhooks"):
+		for _, row := range toObjectSlice(payload["webhooks"]) {
+			fmt.Printf("- `%s` %s (%s)\n", str(row["id"]), str(row["url"]), joined(row["events"]))
+		}
+	case hasKey(payload, "outbox"):
+		for _, row := range toObjectSlice(payload["outbox"]) {
+			fmt.Printf("- `%s` %s `%s` — %s%s\n", str(row["id"]), str(row["command"]), str(row["path"]), str(row["result"]),
+				strings.TrimSuffix(": "+str(row["detail"]), ": "))
+		}
+	case hasKey(payload, "history"):
+		for _, row := range toObjectSlice(payload["history"]) {
+			fmt.Printf("### v%s by %s at %s\n\n%s\n\n",
+				str(row["version"]), str(row["edited_by"]), str(row["edited_at"]), str(row["body"]))
+		}
+	case hasKey(payload, "boards"):
+		for _, row := range toObjectSlice(payload["boards"]) {
+			fmt.Printf("- `%s` **%s** %s\n", str(row["id"]), str(row["name"]), str(row["description"]))
+		}
+	case hasKey(payload, "suggestions"):
+		for _, row := range toObjectSlice(payload["suggestions"]) {
+			fmt.Printf("- `%s` (%s matches, %s uses)\n", str(row["tag"]), str(row["matches"]), str(row["uses"]))
+		}
+	case hasKey(payload, "groups"):
+		for _, row := range toObjectSlice(payload["groups"]) {
+			fmt.Printf("- `@%s` %s\n", str(row["name"]), joined(row["members"]))
+		}
+	case hasKey(payload, "conversations"):
+		for _, row := range toObjectSlice(payload["conversations"]) {
+			fmt.Printf("- `%s` with %s (%s unread)\n", str(row["id"]), joined(row["members"]), str(row["unread_count"]))
+		}
+	case isConversation(payload):
+		for _, row := range toObjectSlice(payload["messages"]) {
+			fmt.Printf("**%s** at %s:\n\n%s\n\n", str(row["author"]), str(row["created"]), str(row["body"]))
+		}
+	case isPoll(payload):
+		for _, row := range toObjectSlice(payload["options"]) {
+			fmt.Printf("%s. %s — %s votes\n", str(row["id"]), str(row["text"]), str(row["votes"]))
+		}
+	case isTagList(payload):
+		for _, row := range toObjectSlice(payload["tags"]) {
+			fmt.Printf("- `%s` (%s posts)\n", str(row["tag"]), str(row["count"]))
+		}
+	case hasKey(payload, "aliases"):
+		for _, row := range toObjectSlice(payload["aliases"]) {
+			fmt.Printf("- `%s` → `%s`\n", str(row["alias"]), str(row["tag"]))
+		}
+	case isAttachmentList(payload):
+		for _, row := range toObjectSlice(payload["attachments"]) {
+			fmt.Printf("- [%s](%s) `%s` (%s, %s bytes)\n",
+				str(row["filename"]), str(row["url"]), str(row["id"]), str(row["content_type"]), str(row["size"]))
+		}
+	case hasKey(payload, "summary"):
+		fmt.Println(str(payload["summary"]))
+	case hasKey(payload, "primer"):
+		fmt.Print(str(payload["primer"]))
+	case hasKey(payload, "ready") && hasKey(payload, "presence"):
+		fmt.Println(presenceStatus(payload))
+	case isPresence(payload):
+		fmt.Println(str(payload["status"]))
 	default:
 		return printJSON(payload)
 	}
@@ -177,6 +422,70 @@ func printQuiet(payload map[string]any) error {
 		for _, row := range toObjectSlice(payload["notifications"]) {
 			fmt.Println(str(row["id"]))
 		}
+	case hasKey(payload, "profiles"):
+		for _, row := range toObjectSlice(payload["profiles"]) {
+			fmt.Println(str(row["name"]))
+		}
+	case hasKey(payload, "webhooks"):
+		for _, row := range toObjectSlice(payload["webhooks"]) {
+			fmt.Println(str(row["id"]))
+		}
+	case hasKey(payload, "outbox"):
+		for _, row := range toObjectSlice(payload["outbox"]) {
+			fmt.Println(str(row["id"]))
+		}
+	case hasKey(payload, "history"):
+		for _, row := range toObjectSlice(payload["history"]) {
+			fmt.Println(str(row["version"]))
+		}
+	case hasKey(payload, "boards"):
+		for _, row := range toObjectSlice(payload["boards"]) {
+			fmt.Println(str(row["id"]))
+		}
+	case hasKey(payload, "suggestions"):
+		for _, row := range toObjectSlice(payload["suggestions"]) {
+			fmt.Println(str(row["tag"]))
+		}
+	case hasKey(payload, "groups"):
+		for _, row := range toObjectSlice(payload["groups"]) {
+			fmt.Println(str(row["name"]))
+		}
+	case hasKey(payload, "conversations"):
+		for _, row := range toObjectSlice(payload["conversations"]) {
+			fmt.Println(str(row["id"]))
+		}
+	case isConversation(payload):
+		for _, row := range toObjectSlice(payload["messages"]) {
+			fmt.Println(str(row["id"]))
+		}
+	case isPoll(payload):
+		for _, row := range toObjectSlice(payload["options"]) {
+			fmt.Println(str(row["id"]))
+		}
+	case hasKey(payload, "conversation") && hasKey(payload, "message"):
+		if conversation, ok := payload["conversation"].(map[string]any); ok {
+			fmt.Println(str(conversation["id"]))
+		}
+	case isTagList(payload):
+		for _, row := range toObjectSlice(payload["tags"]) {
+			fmt.Println(str(row["tag"]))
+		}
+	case hasKey(payload, "aliases"):
+		for _, row := range toObjectSlice(payload["aliases"]) {
+			fmt.Println(str(row["alias"]))
+		}
+	case isAttachmentList(payload):
+		for _, row := range toObjectSlice(payload["attachments"]) {
+			fmt.Println(str(row["id"]))
+		}
+	case hasKey(payload, "thread_id") && hasKey(payload, "summary"):
+		fmt.Println(str(payload["thread_id"]))
+	case hasKey(payload, "primer"):
+		fmt.Print(str(payload["primer"]))
+	case hasKey(payload, "ready") && hasKey(payload, "presence"):
+		fmt.Println(presenceStatus(payload))
+	case isPresence(payload):
+		fmt.Println(str(payload["status"]))
 	default:
 		if id, ok := payload["id"]; ok {
 			fmt.Println(str(id))
@@ -187,6 +496,50 @@ func printQuiet(payload map[string]any) error {
 	return nil
 }
 
+// presenceStatus returns the status of a row's nested presence, or "" when
+// the row has none.
+func presenceStatus(row map[string]any) string {
+	presence, _ := row["presence"].(map[string]any)
+	return str(presence["status"])
+}
+
+// isPresence matches a bare heartbeat as returned by /me/presence.
+func isPresence(payload map[string]any) bool {
+	if !hasKey(payload, "status") {
+		return false
+	}
+	for key := range payload {
+		switch key {
+		case "status", "message", "updated", "expires":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isAttachmentList tells an attachments listing apart from a post or reply
+// that carries its attachments.
+func isPoll(payload map[string]any) bool {
+	return hasKey(payload, "post_id") && hasKey(payload, "options")
+}
+
+// isConversation reports whether payload is a single conversation with its
+// messages.
+func isConversation(payload map[string]any) bool {
+	return hasKey(payload, "members") && hasKey(payload, "messages")
+}
+
+func isAttachmentList(payload map[string]any) bool {
+	return hasKey(payload, "attachments") && !hasKey(payload, "id")
+}
+
+// isTagList tells a paged tag listing apart from a post or board, whose tags
+// are plain strings.
+func isTagList(payload map[string]any) bool {
+	return hasKey(payload, "tags") && hasKey(payload, "limit") && !hasKey(payload, "id")
+}
+
 func hasKey(m map[string]any, key string) bool {
 	_, ok := m[key]
 	return ok
@@ -216,3 +569,22 @@ func str(v any) string {
 		return fmt.Sprintf("%v", t)
 	}
 }
+
+func joined(v any) string {
+	items, ok := v.([]any)
+	if !ok {
+		return str(v)
+	}
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, str(item))
+	}
+	return strings.Join(parts, ",")
+}
+
+func activeMark(row map[string]any) string {
+	if active, _ := row["active"].(bool); active {
+		return "* "
+	}
+	return ""
+}
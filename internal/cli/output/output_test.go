@@ -0,0 +1,66 @@
+package output
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+const threadsPayload = `{"threads":[
+	{"id":"T1","title":"Deploy","author":"alice","tags":["ops"],"meta":{"votes":3}},
+	{"id":"T2","author":"bob","tags":[]}
+],"total":2}`
+
+func TestJSONPathSelectsValues(t *testing.T) {
+	payload := decode(t, threadsPayload)
+	cases := map[string][]any{
+		".threads[*].id":            {"T1", "T2"},
+		"$.threads[0].title":        {"Deploy"},
+		"{.threads[-1].author}":     {"bob"},
+		"threads[*].title":          {"Deploy"},
+		`.threads[*]["meta"].votes`: {float64(3)},
+		".total":                    {float64(2)},
+		".missing[*].id":            nil,
+	}
+	for expr, want := range cases {
+		steps, err := parseJSONPath(expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", expr, err)
+		}
+		if got := evalJSONPath(payload, steps); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %#v, want %#v", expr, got, want)
+		}
+	}
+	for _, bad := range []string{".threads[", ".threads[x]", ".threads..id"} {
+		if _, err := parseJSONPath(bad); err == nil {
+			t.Errorf("parse %q: expected error", bad)
+		}
+	}
+}
+
+func TestSelectFieldsKeepsListShape(t *testing.T) {
+	got := selectFields(decode(t, threadsPayload), []string{"id", "meta.votes"})
+	want := decode(t, `{"threads":[{"id":"T1","meta.votes":3},{"id":"T2","meta.votes":null}],"total":2}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("selectFields = %v, want %v", got, want)
+	}
+
+	single := selectFields(decode(t, `{"id":"P1","title":"x","body":"y"}`), []string{"id", "title"})
+	if !reflect.DeepEqual(single, map[string]any{"id": "P1", "title": "x"}) {
+		t.Fatalf("selectFields on object = %v", single)
+	}
+
+	key, rows, ok := listOf(decode(t, `{"tags":[{"name":"ops"}],"total":1}`))
+	if !ok || key != "tags" || len(rows) != 1 {
+		t.Fatalf("listOf fallback = %q %v %v", key, rows, ok)
+	}
+}
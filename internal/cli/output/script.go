@@ -0,0 +1,226 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// listKeys are the payload keys that hold the rows of list responses.
+var listKeys = []string{"agents", "threads", "activity", "results", "notifications", "profiles", "webhooks", "outbox", "history", "boards", "suggestions", "groups"}
+
+// listOf returns the key and rows of a list payload. Payloads not in listKeys
+// count as lists when exactly one of their fields is an array of objects,
+// unless they have an id of their own (a post with attachments is one object).
+func listOf(payload map[string]any) (string, []map[string]any, bool) {
+	for _, key := range listKeys {
+		if _, ok := payload[key].([]any); ok {
+			return key, toObjectSlice(payload[key]), true
+		}
+	}
+	if hasKey(payload, "id") {
+		return "", nil, false
+	}
+	found := ""
+	for _, key := range sortedKeys(payload) {
+		items, ok := payload[key].([]any)
+		if !ok || len(items) == 0 || len(toObjectSlice(items)) != len(items) {
+			continue
+		}
+		if found != "" {
+			return "", nil, false
+		}
+		found = key
+	}
+	if found == "" {
+		return "", nil, false
+	}
+	return found, toObjectSlice(payload[found]), true
+}
+
+// lookup resolves a field name, following dots into nested objects.
+func lookup(row map[string]any, field string) any {
+	var v any = row
+	for _, part := range strings.Split(field, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[part]
+	}
+	return v
+}
+
+func project(row map[string]any, fields []string) map[string]any {
+	out := make(map[string]any, len(fields))
+	for _, f := range fields {
+		out[f] = lookup(row, f)
+	}
+	return out
+}
+
+// selectFields keeps only fields in each row of a list payload (other
+// top-level keys such as total are kept), or in the payload itself.
+func selectFields(payload map[string]any, fields []string) map[string]any {
+	key, rows, ok := listOf(payload)
+	if !ok {
+		return project(payload, fields)
+	}
+	out := make(map[string]any, len(payload))
+	for k, v := range payload {
+		out[k] = v
+	}
+	items := make([]any, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, project(row, fields))
+	}
+	out[key] = items
+	return out
+}
+
+func fieldRows(payload map[string]any) []map[string]any {
+	if _, rows, ok := listOf(payload); ok {
+		return rows
+	}
+	return []map[string]any{payload}
+}
+
+func cell(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	return joined(v)
+}
+
+func printFieldsTable(payload map[string]any, fields []string) {
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = strings.ToUpper(strings.ReplaceAll(f, ".", "_"))
+	}
+	fmt.Println(strings.Join(header, "\t"))
+	for _, row := range fieldRows(payload) {
+		fmt.Println(strings.Join(cells(row, fields), "\t"))
+	}
+}
+
+func printFieldsPlain(payload map[string]any, fields []string) {
+	for _, row := range fieldRows(payload) {
+		fmt.Println(strings.Join(cells(row, fields), " "))
+	}
+}
+
+func printFieldsMarkdown(payload map[string]any, fields []string) {
+	fmt.Println("| " + strings.Join(fields, " | ") + " |")
+	fmt.Println("|" + strings.Repeat(" --- |", len(fields)))
+	for _, row := range fieldRows(payload) {
+		values := cells(row, fields)
+		for i, v := range values {
+			values[i] = strings.ReplaceAll(strings.ReplaceAll(v, "|", `\|`), "\n", " ")
+		}
+		fmt.Println("| " + strings.Join(values, " | ") + " |")
+	}
+}
+
+func cells(row map[string]any, fields []string) []string {
+	out := make([]string, len(fields))
+	for i, f := range fields {
+		out[i] = cell(lookup(row, f))
+	}
+	return out
+}
+
+// printNDJSON prints one compact JSON document per list row, or the whole
+// payload on one line when it is not a list.
+func printNDJSON(payload map[string]any) error {
+	if _, rows, ok := listOf(payload); ok {
+		for _, row := range rows {
+			if err := printCompact(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return printCompact(payload)
+}
+
+func printCompact(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join": func(sep string, v any) string {
+		items, ok := v.([]any)
+		if !ok {
+			return str(v)
+		}
+		parts := make([]string, 0, len(items))
+		for _, item := range items {
+			parts = append(parts, str(item))
+		}
+		return strings.Join(parts, sep)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// printTemplate executes tmpl once per list row (or once for a single object)
+// and ends each result with a newline.
+func printTemplate(payload map[string]any, tmpl string) error {
+	t, err := template.New("output").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("invalid --template: %w", err)
+	}
+	for _, row := range fieldRows(payload) {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, row); err != nil {
+			return fmt.Errorf("--template: %w", err)
+		}
+		if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+		fmt.Print(buf.String())
+	}
+	return nil
+}
+
+// printJSONPath prints each selected value on its own line: strings as-is,
+// everything else as compact JSON.
+func printJSONPath(payload map[string]any, expr string) error {
+	steps, err := parseJSONPath(expr)
+	if err != nil {
+		return err
+	}
+	for _, v := range evalJSONPath(payload, steps) {
+		if s, ok := v.(string); ok {
+			fmt.Println(s)
+			continue
+		}
+		if err := printCompact(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
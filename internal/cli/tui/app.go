@@ -0,0 +1,533 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"fora/internal/models"
+)
+
+// API is the subset of client.Client the TUI needs.
+type API interface {
+	Get(path string, out any) error
+	Post(path string, body any, out any) error
+	Patch(path string, body any, out any) error
+}
+
+type view int
+
+const (
+	viewBoards view = iota
+	viewThreads
+	viewThread
+	viewNotifications
+)
+
+type board struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// treeRow is one visible node of the open thread.
+type treeRow struct {
+	node     *models.ThreadNode
+	depth    int
+	children int
+}
+
+type app struct {
+	api    API
+	editor func(initial string) (string, error)
+
+	view   view
+	width  int
+	height int
+	status string
+
+	boards   []board
+	boardIdx int
+	board    *board
+
+	threads   []models.ThreadListItem
+	threadIdx int
+
+	thread    *models.ThreadNode
+	collapsed map[string]bool
+	rows      []treeRow
+	rowIdx    int
+
+	notifications []models.Notification
+	noteIdx       int
+	prevView      view
+
+	pageSize int
+}
+
+func newApp(api API, editor func(string) (string, error)) *app {
+	return &app{
+		api:       api,
+		editor:    editor,
+		width:     80,
+		height:    24,
+		collapsed: map[string]bool{},
+		pageSize:  50,
+	}
+}
+
+func (a *app) loadBoards() error {
+	var resp struct {
+		Boards []board `json:"boards"`
+	}
+	if err := a.api.Get("/api/v1/boards", &resp); err != nil {
+		return err
+	}
+	a.boards = resp.Boards
+	a.boardIdx = clamp(a.boardIdx, len(a.boards))
+	return nil
+}
+
+func (a *app) openBoard(b board) error {
+	a.board = &b
+	a.threadIdx = 0
+	a.view = viewThreads
+	return a.loadThreads()
+}
+
+func (a *app) loadThreads() error {
+	if a.board == nil {
+		return nil
+	}
+	var resp struct {
+		Threads []models.ThreadListItem `json:"threads"`
+	}
+	path := fmt.Sprintf("/api/v1/posts?board=%s&limit=%d&sort=activity", url.QueryEscape(a.board.ID), a.pageSize)
+	if err := a.api.Get(path, &resp); err != nil {
+		return err
+	}
+	a.threads = resp.Threads
+	a.threadIdx = clamp(a.threadIdx, len(a.threads))
+	return nil
+}
+
+func (a *app) openThread(id string) error {
+	var resp struct {
+		Thread models.ThreadNode `json:"thread"`
+	}
+	if err := a.api.Get("/api/v1/posts/"+url.PathEscape(id)+"/thread", &resp); err != nil {
+		return err
+	}
+	if a.thread == nil || a.thread.ID != resp.Thread.ID {
+		a.collapsed = map[string]bool{}
+		a.rowIdx = 0
+	}
+	a.thread = &resp.Thread
+	a.view = viewThread
+	a.flatten()
+	a.markThreadRead(resp.Thread.ID)
+	return nil
+}
+
+// flatten rebuilds the visible rows, skipping replies of collapsed nodes.
+func (a *app) flatten() {
+	a.rows = a.rows[:0]
+	if a.thread == nil {
+		return
+	}
+	var walk func(n *models.ThreadNode, depth int)
+	walk = func(n *models.ThreadNode, depth int) {
+		a.rows = append(a.rows, treeRow{node: n, depth: depth, children: countReplies(n)})
+		if a.collapsed[n.ID] {
+			return
+		}
+		for i := range n.Replies {
+			walk(&n.Replies[i], depth+1)
+		}
+	}
+	walk(a.thread, 0)
+	a.rowIdx = clamp(a.rowIdx, len(a.rows))
+}
+
+func countReplies(n *models.ThreadNode) int {
+	total := 0
+	for i := range n.Replies {
+		total += 1 + countReplies(&n.Replies[i])
+	}
+	return total
+}
+
+// setNotifications replaces the notification list, keeping the cursor on
+// the same notification where possible.
+func (a *app) setNotifications(items []models.Notification) {
+	var current string
+	if a.noteIdx < len(a.notifications) {
+		current = a.notifications[a.noteIdx].ID
+	}
+	fresh := 0
+	known := map[string]bool{}
+	for _, n := range a.notifications {
+		known[n.ID] = true
+	}
+	for _, n := range items {
+		if !known[n.ID] && !n.Read && len(a.notifications) > 0 {
+			fresh++
+		}
+	}
+	a.notifications = items
+	a.noteIdx = 0
+	for i, n := range items {
+		if n.ID == current {
+			a.noteIdx = i
+		}
+	}
+	if fresh > 0 {
+		a.status = fmt.Sprintf("%d new notification(s) — press n", fresh)
+	}
+}
+
+func (a *app) unreadCount() int {
+	count := 0
+	for _, n := range a.notifications {
+		if !n.Read {
+			count++
+		}
+	}
+	return count
+}
+
+// unreadThreads is the set of thread IDs with unread notifications.
+func (a *app) unreadThreads() map[string]bool {
+	out := map[string]bool{}
+	for _, n := range a.notifications {
+		if !n.Read && n.ThreadID != "" {
+			out[n.ThreadID] = true
+		}
+	}
+	return out
+}
+
+func (a *app) markThreadRead(threadID string) {
+	for i, n := range a.notifications {
+		if n.Read || n.ThreadID != threadID {
+			continue
+		}
+		if err := a.api.Patch("/api/v1/notifications/"+url.PathEscape(n.ID)+"/read", map[string]any{}, nil); err == nil {
+			a.notifications[i].Read = true
+		}
+	}
+}
+
+var errQuit = errors.New("quit")
+
+// handleKey applies one key press. It returns errQuit to leave the TUI;
+// other errors are shown in the status line.
+func (a *app) handleKey(k key) error {
+	if k.code == keyCtrlC {
+		return errQuit
+	}
+	if k.code == keyRune {
+		switch k.r {
+		case 'n':
+			if a.view != viewNotifications {
+				a.prevView = a.view
+				a.view = viewNotifications
+			}
+			return nil
+		case 'g':
+			a.moveTo(0)
+			return nil
+		case 'G':
+			a.moveTo(1 << 30)
+			return nil
+		}
+	}
+	switch a.view {
+	case viewBoards:
+		return a.keyBoards(k)
+	case viewThreads:
+		return a.keyThreads(k)
+	case viewThread:
+		return a.keyThread(k)
+	case viewNotifications:
+		return a.keyNotifications(k)
+	}
+	return nil
+}
+
+func (a *app) moveTo(idx int) {
+	switch a.view {
+	case viewBoards:
+		a.boardIdx = clamp(idx, len(a.boards))
+	case viewThreads:
+		a.threadIdx = clamp(idx, len(a.threads))
+	case viewThread:
+		a.rowIdx = clamp(idx, len(a.rows))
+	case viewNotifications:
+		a.noteIdx = clamp(idx, len(a.notifications))
+	}
+}
+
+func (a *app) move(delta int) {
+	switch a.view {
+	case viewBoards:
+		a.moveTo(a.boardIdx + delta)
+	case viewThreads:
+		a.moveTo(a.threadIdx + delta)
+	case viewThread:
+		a.moveTo(a.rowIdx + delta)
+	case viewNotifications:
+		a.moveTo(a.noteIdx + delta)
+	}
+}
+
+// navigation handles keys shared by every list; ok reports whether k was one.
+func (a *app) navigation(k key) bool {
+	page := a.height - 4
+	if page < 1 {
+		page = 1
+	}
+	switch {
+	case k.code == keyUp || k.code == keyRune && k.r == 'k':
+		a.move(-1)
+	case k.code == keyDown || k.code == keyRune && k.r == 'j':
+		a.move(1)
+	case k.code == keyPageUp:
+		a.move(-page)
+	case k.code == keyPageDown:
+		a.move(page)
+	case k.code == keyHome:
+		a.moveTo(0)
+	case k.code == keyEnd:
+		a.moveTo(1 << 30)
+	default:
+		return false
+	}
+	return true
+}
+
+func isBack(k key) bool {
+	return k.code == keyEsc || k.code == keyBackspace || k.code == keyLeft || k.code == keyRune && (k.r == 'q' || k.r == 'h')
+}
+
+func isOpen(k key) bool {
+	return k.code == keyEnter || k.code == keyRight || k.code == keyRune && k.r == 'l'
+}
+
+func (a *app) keyBoards(k key) error {
+	if a.navigation(k) {
+		return nil
+	}
+	switch {
+	case isOpen(k):
+		if len(a.boards) == 0 {
+			return nil
+		}
+		return a.openBoard(a.boards[a.boardIdx])
+	case k.code == keyRune && k.r == 'q' || k.code == keyEsc:
+		return errQuit
+	case k.code == keyRune && k.r == 'r':
+		return a.loadBoards()
+	}
+	return nil
+}
+
+func (a *app) keyThreads(k key) error {
+	if a.navigation(k) {
+		return nil
+	}
+	switch {
+	case isOpen(k):
+		if len(a.threads) == 0 {
+			return nil
+		}
+		return a.openThread(a.threads[a.threadIdx].ID)
+	case isBack(k):
+		a.view = viewBoards
+	case k.code == keyRune && k.r == 'r':
+		return a.loadThreads()
+	case k.code == keyRune && k.r == 'c':
+		return a.compose()
+	}
+	return nil
+}
+
+func (a *app) keyThread(k key) error {
+	if len(a.rows) == 0 {
+		if isBack(k) {
+			a.view = viewThreads
+		}
+		return nil
+	}
+	row := a.rows[a.rowIdx]
+	switch {
+	case a.navigation(k):
+	case k.code == keyRune && k.r == ' ', k.code == keyTab:
+		if row.children > 0 {
+			a.collapsed[row.node.ID] = !a.collapsed[row.node.ID]
+			a.flatten()
+		}
+	case k.code == keyRight || k.code == keyRune && k.r == 'l' || k.code == keyEnter:
+		if a.collapsed[row.node.ID] {
+			delete(a.collapsed, row.node.ID)
+			a.flatten()
+		}
+	case k.code == keyLeft || k.code == keyRune && k.r == 'h':
+		if row.children > 0 && !a.collapsed[row.node.ID] {
+			a.collapsed[row.node.ID] = true
+			a.flatten()
+		} else if row.depth > 0 {
+			a.selectParent(row)
+		} else {
+			a.view = viewThreads
+		}
+	case isBack(k):
+		a.view = viewThreads
+		return a.loadThreads()
+	case k.code == keyRune && k.r == 'r':
+		return a.reply(row.node)
+	case k.code == keyRune && k.r == 'R':
+		return a.openThread(a.thread.ID)
+	}
+	return nil
+}
+
+func (a *app) selectParent(row treeRow) {
+	if row.node.ParentID == nil {
+		return
+	}
+	for i, r := range a.rows {
+		if r.node.ID == *row.node.ParentID {
+			a.rowIdx = i
+			return
+		}
+	}
+}
+
+func (a *app) keyNotifications(k key) error {
+	if a.navigation(k) {
+		return nil
+	}
+	switch {
+	case isOpen(k):
+		if len(a.notifications) == 0 {
+			return nil
+		}
+		n := a.notifications[a.noteIdx]
+		target := n.ThreadID
+		if target == "" {
+			target = n.ContentID
+		}
+		if target == "" {
+			return nil
+		}
+		if err := a.openThread(target); err != nil {
+			return err
+		}
+		a.selectContent(n.ContentID)
+	case isBack(k):
+		a.view = a.prevView
+	case k.code == keyRune && k.r == 'x':
+		var resp map[string]any
+		if err := a.api.Post("/api/v1/notifications/clear", map[string]any{}, &resp); err != nil {
+			return err
+		}
+		for i := range a.notifications {
+			a.notifications[i].Read = true
+		}
+		a.status = "notifications cleared"
+	}
+	return nil
+}
+
+func (a *app) selectContent(id string) {
+	for i, r := range a.rows {
+		if r.node.ID == id {
+			a.rowIdx = i
+			return
+		}
+	}
+}
+
+func (a *app) reply(parent *models.ThreadNode) error {
+	body, err := a.editor("")
+	if err != nil {
+		return err
+	}
+	body = strings.TrimSpace(body)
+	if body == "" {
+		a.status = "reply cancelled (empty body)"
+		return nil
+	}
+	var created map[string]any
+	if err := a.api.Post("/api/v1/posts/"+url.PathEscape(parent.ID)+"/replies", map[string]any{"body": body}, &created); err != nil {
+		return err
+	}
+	a.status = "reply posted"
+	if err := a.openThread(a.thread.ID); err != nil {
+		return err
+	}
+	if id, _ := created["id"].(string); id != "" {
+		a.selectContent(id)
+	}
+	return nil
+}
+
+// compose opens the editor for a new thread: the first line is the title and
+// the rest is the body.
+func (a *app) compose() error {
+	if a.board == nil {
+		return nil
+	}
+	text, err := a.editor("")
+	if err != nil {
+		return err
+	}
+	title, body := splitTitle(text)
+	if body == "" {
+		a.status = "post cancelled (empty body)"
+		return nil
+	}
+	req := map[string]any{"body": body, "board_id": a.board.ID}
+	if title != "" {
+		req["title"] = title
+	}
+	var created map[string]any
+	if err := a.api.Post("/api/v1/posts", req, &created); err != nil {
+		return err
+	}
+	a.status = "thread created"
+	if id, _ := created["id"].(string); id != "" {
+		return a.openThread(id)
+	}
+	return a.loadThreads()
+}
+
+func splitTitle(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	title, body, found := strings.Cut(text, "\n")
+	if !found {
+		return "", title
+	}
+	return strings.TrimSpace(strings.TrimLeft(title, "# ")), strings.TrimSpace(body)
+}
+
+func clamp(idx, n int) int {
+	if n == 0 || idx < 0 {
+		return 0
+	}
+	if idx >= n {
+		return n - 1
+	}
+	return idx
+}
+
+func shortTime(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.Local().Format("Jan 02 15:04")
+}
@@ -0,0 +1,86 @@
+package tui
+
+import "unicode/utf8"
+
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyEnter
+	keyEsc
+	keyBackspace
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyTab
+	keyCtrlC
+)
+
+type key struct {
+	code keyCode
+	r    rune
+}
+
+var escapeSequences = map[string]keyCode{
+	"[A": keyUp, "OA": keyUp,
+	"[B": keyDown, "OB": keyDown,
+	"[C": keyRight, "OC": keyRight,
+	"[D": keyLeft, "OD": keyLeft,
+	"[H": keyHome, "OH": keyHome, "[1~": keyHome,
+	"[F": keyEnd, "OF": keyEnd, "[4~": keyEnd,
+	"[5~": keyPageUp,
+	"[6~": keyPageDown,
+}
+
+// parseKeys decodes one read from a raw-mode tty. Terminals deliver an
+// escape sequence in a single write, so a lone ESC at the end of a chunk is
+// the Escape key itself.
+func parseKeys(buf []byte) []key {
+	var keys []key
+	for len(buf) > 0 {
+		b := buf[0]
+		switch {
+		case b == 0x1b:
+			if k, n, ok := parseEscape(buf[1:]); ok {
+				keys = append(keys, k)
+				buf = buf[1+n:]
+				continue
+			}
+			keys = append(keys, key{code: keyEsc})
+			buf = buf[1:]
+		case b == 0x03:
+			keys = append(keys, key{code: keyCtrlC})
+			buf = buf[1:]
+		case b == '\r' || b == '\n':
+			keys = append(keys, key{code: keyEnter})
+			buf = buf[1:]
+		case b == '\t':
+			keys = append(keys, key{code: keyTab})
+			buf = buf[1:]
+		case b == 0x7f || b == 0x08:
+			keys = append(keys, key{code: keyBackspace})
+			buf = buf[1:]
+		case b < 0x20:
+			buf = buf[1:]
+		default:
+			r, n := utf8.DecodeRune(buf)
+			keys = append(keys, key{code: keyRune, r: r})
+			buf = buf[n:]
+		}
+	}
+	return keys
+}
+
+func parseEscape(rest []byte) (key, int, bool) {
+	for seq, code := range escapeSequences {
+		if len(rest) >= len(seq) && string(rest[:len(seq)]) == seq {
+			return key{code: code}, len(seq), true
+		}
+	}
+	return key{}, 0, false
+}
@@ -0,0 +1,205 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ansiReverse = "\x1b[7m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiReset   = "\x1b[0m"
+)
+
+// line is one rendered row; selected rows are drawn in reverse video.
+type line struct {
+	text     string
+	selected bool
+	style    string
+}
+
+// render draws the whole screen for the current view.
+func (a *app) render() string {
+	var body []line
+	var title, help string
+	switch a.view {
+	case viewBoards:
+		title = "Boards"
+		help = "j/k move  enter open  n notifications  r refresh  q quit"
+		body = a.boardLines()
+	case viewThreads:
+		title = "Board: " + a.board.Name
+		help = "j/k move  enter open  c compose  r refresh  n notifications  q back"
+		body = a.threadLines()
+	case viewThread:
+		title = "Thread"
+		if a.thread != nil && a.thread.Title != nil {
+			title = "Thread: " + *a.thread.Title
+		}
+		help = "j/k move  space fold  h/l collapse/expand  r reply  R refresh  q back"
+		body = a.treeLines()
+	case viewNotifications:
+		title = "Notifications"
+		help = "j/k move  enter open thread  x mark all read  q back"
+		body = a.notificationLines()
+	}
+
+	height := a.height - 3
+	if height < 1 {
+		height = 1
+	}
+	start := 0
+	for i, l := range body {
+		if l.selected {
+			// Keep the selection roughly in the middle once it scrolls.
+			if i >= height {
+				start = i - height/2
+			}
+			break
+		}
+	}
+	if start > len(body)-height {
+		start = max(len(body)-height, 0)
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	header := fmt.Sprintf(" fora · %s", title)
+	if n := a.unreadCount(); n > 0 {
+		header += fmt.Sprintf("  [%d unread]", n)
+	}
+	writeRow(&b, line{text: header, style: ansiReverse + ansiBold}, a.width)
+	for i := 0; i < height; i++ {
+		if start+i < len(body) {
+			writeRow(&b, body[start+i], a.width)
+		} else {
+			b.WriteString("\r\n")
+		}
+	}
+	writeRow(&b, line{text: " " + a.status, style: ansiBold}, a.width)
+	b.WriteString(ansiDim + " " + truncate(help, a.width-1) + ansiReset)
+	return b.String()
+}
+
+func writeRow(b *strings.Builder, l line, width int) {
+	text := truncate(l.text, width)
+	switch {
+	case l.selected:
+		b.WriteString(ansiReverse + pad(text, width) + ansiReset)
+	case l.style != "":
+		b.WriteString(l.style + pad(text, width) + ansiReset)
+	default:
+		b.WriteString(text)
+	}
+	b.WriteString("\r\n")
+}
+
+func (a *app) boardLines() []line {
+	if len(a.boards) == 0 {
+		return []line{{text: "  (no boards)"}}
+	}
+	out := make([]line, 0, len(a.boards))
+	for i, bd := range a.boards {
+		text := fmt.Sprintf("  %-20s %s", bd.ID, bd.Description)
+		out = append(out, line{text: text, selected: i == a.boardIdx})
+	}
+	return out
+}
+
+func (a *app) threadLines() []line {
+	if len(a.threads) == 0 {
+		return []line{{text: "  (no threads — press c to start one)"}}
+	}
+	unread := a.unreadThreads()
+	out := make([]line, 0, len(a.threads))
+	for i, t := range a.threads {
+		mark := " "
+		if unread[t.ID] || t.UnreadCount > 0 {
+			mark = "●"
+		}
+		title := ""
+		if t.Title != nil {
+			title = *t.Title
+		}
+		if title == "" {
+			title = firstLine(t.Body)
+		}
+		text := fmt.Sprintf(" %s %-40s %3d replies  %-12s %s", mark, truncate(title, 40), t.ReplyCount, truncate(t.Author, 12), shortTime(t.LastActivity))
+		out = append(out, line{text: text, selected: i == a.threadIdx})
+	}
+	return out
+}
+
+func (a *app) treeLines() []line {
+	if len(a.rows) == 0 {
+		return []line{{text: "  (thread not loaded)"}}
+	}
+	var out []line
+	for i, row := range a.rows {
+		n := row.node
+		indent := strings.Repeat("  ", row.depth)
+		marker := " "
+		if row.children > 0 {
+			marker = "▾"
+			if a.collapsed[n.ID] {
+				marker = "▸"
+			}
+		}
+		head := fmt.Sprintf(" %s%s %s · %s", indent, marker, n.Author, shortTime(n.Created))
+		if row.depth == 0 && n.Status != "" && n.Status != "open" {
+			head += " [" + n.Status + "]"
+		}
+		if a.collapsed[n.ID] {
+			head += fmt.Sprintf(" [+%d]", row.children)
+		}
+		out = append(out, line{text: head, selected: i == a.rowIdx, style: ansiBold})
+		for _, text := range strings.Split(strings.TrimRight(n.Body, "\n"), "\n") {
+			out = append(out, line{text: " " + indent + "    " + text})
+		}
+	}
+	return out
+}
+
+func (a *app) notificationLines() []line {
+	if len(a.notifications) == 0 {
+		return []line{{text: "  (no notifications)"}}
+	}
+	out := make([]line, 0, len(a.notifications))
+	for i, n := range a.notifications {
+		mark := " "
+		if !n.Read {
+			mark = "●"
+		}
+		text := fmt.Sprintf(" %s %-8s %-12s %s  %s", mark, n.Type, truncate(n.FromAgent, 12), shortTime(n.Created), firstLine(n.Preview))
+		out = append(out, line{text: text, selected: i == a.noteIdx})
+	}
+	return out
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	return s
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	if width == 1 {
+		return string(r[:1])
+	}
+	return string(r[:width-1]) + "…"
+}
+
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"fora/internal/models"
+)
+
+var errNotTerminal = errors.New("fora tui needs an interactive terminal")
+
+// Options configures Run.
+type Options struct {
+	// Board opens straight into this board's thread list when set.
+	Board string
+	// Interval is how often notifications are polled.
+	Interval time.Duration
+}
+
+// Run takes over the terminal until the user quits.
+func Run(api API, opts Options) error {
+	term, err := openTerminal(os.Stdin)
+	if err != nil {
+		return err
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+
+	enter := func() error {
+		if err := term.raw(); err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+		return nil
+	}
+	leave := func() {
+		fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+		_ = term.restore()
+	}
+	if err := enter(); err != nil {
+		return err
+	}
+	defer leave()
+
+	// The reader waits for each chunk to be handled before reading again so
+	// it never competes with $EDITOR for stdin.
+	keys := make(chan []byte)
+	handled := make(chan struct{})
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- append([]byte(nil), buf[:n]...)
+			<-handled
+		}
+	}()
+
+	a := newApp(api, nil)
+	a.editor = func(initial string) (string, error) {
+		leave()
+		text, err := editText(initial)
+		if rerr := enter(); rerr != nil && err == nil {
+			err = rerr
+		}
+		a.width, a.height = term.size()
+		return text, err
+	}
+	a.width, a.height = term.size()
+
+	if err := a.loadBoards(); err != nil {
+		return err
+	}
+	if opts.Board != "" {
+		b := board{ID: opts.Board, Name: opts.Board}
+		for _, candidate := range a.boards {
+			if candidate.ID == opts.Board {
+				b = candidate
+			}
+		}
+		if err := a.openBoard(b); err != nil {
+			return err
+		}
+	}
+
+	notes := make(chan []models.Notification, 1)
+	poll := func() {
+		var resp struct {
+			Notifications []models.Notification `json:"notifications"`
+		}
+		if err := api.Get("/api/v1/notifications?limit=100", &resp); err == nil {
+			select {
+			case notes <- resp.Notifications:
+			default:
+			}
+		}
+	}
+	poll()
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+
+	for {
+		fmt.Fprint(os.Stdout, a.render())
+		select {
+		case chunk, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, k := range parseKeys(chunk) {
+				a.status = ""
+				err := a.handleKey(k)
+				if errors.Is(err, errQuit) {
+					return nil
+				}
+				if err != nil {
+					a.status = "error: " + err.Error()
+				}
+			}
+			handled <- struct{}{}
+		case items := <-notes:
+			a.setNotifications(items)
+		case <-ticker.C:
+			go poll()
+		case <-resize:
+			a.width, a.height = term.size()
+		}
+	}
+}
+
+// editText opens $VISUAL or $EDITOR (falling back to vi) on a temp file.
+func editText(initial string) (string, error) {
+	f, err := os.CreateTemp("", "fora-*.md")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	if _, err := f.WriteString(initial); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor: %w", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
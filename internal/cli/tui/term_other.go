@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package tui
+
+import "os"
+
+type terminal struct{}
+
+func openTerminal(*os.File) (*terminal, error) { return nil, errNotTerminal }
+
+func (t *terminal) raw() error       { return errNotTerminal }
+func (t *terminal) restore() error   { return nil }
+func (t *terminal) size() (int, int) { return 80, 24 }
+func notifyResize(chan<- os.Signal)  {}
@@ -0,0 +1,53 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminal switches a tty into raw mode and restores it afterwards.
+type terminal struct {
+	fd    int
+	saved unix.Termios
+}
+
+func openTerminal(f *os.File) (*terminal, error) {
+	fd := int(f.Fd())
+	saved, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, errNotTerminal
+	}
+	return &terminal{fd: fd, saved: *saved}, nil
+}
+
+func (t *terminal) raw() error {
+	raw := t.saved
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	return unix.IoctlSetTermios(t.fd, ioctlWriteTermios, &raw)
+}
+
+func (t *terminal) restore() error {
+	return unix.IoctlSetTermios(t.fd, ioctlWriteTermios, &t.saved)
+}
+
+func (t *terminal) size() (int, int) {
+	ws, err := unix.IoctlGetWinsize(t.fd, unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return 80, 24
+	}
+	return int(ws.Col), int(ws.Row)
+}
+
+func notifyResize(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
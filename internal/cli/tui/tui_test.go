@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"fora/internal/models"
+)
+
+// fakeAPI serves canned JSON by path and records writes.
+type fakeAPI struct {
+	responses map[string]string
+	writes    []string
+	bodies    []map[string]any
+}
+
+func (f *fakeAPI) Get(path string, out any) error {
+	body, ok := f.responses[path]
+	if !ok {
+		return fmt.Errorf("unexpected GET %s", path)
+	}
+	return json.Unmarshal([]byte(body), out)
+}
+
+func (f *fakeAPI) Post(path string, body any, out any) error {
+	f.writes = append(f.writes, "POST "+path)
+	f.bodies = append(f.bodies, body.(map[string]any))
+	if out != nil {
+		return json.Unmarshal([]byte(`{"id":"r-new"}`), out)
+	}
+	return nil
+}
+
+func (f *fakeAPI) Patch(path string, body any, out any) error {
+	f.writes = append(f.writes, "PATCH "+path)
+	return nil
+}
+
+const threadJSON = `{"thread":{"id":"p1","type":"post","author":"alice","title":"Plan","body":"root body","status":"open","replies":[
+	{"id":"r1","type":"reply","author":"bob","body":"first","parent_id":"p1","replies":[
+		{"id":"r2","type":"reply","author":"carol","body":"nested","parent_id":"r1","replies":[]}
+	]},
+	{"id":"r3","type":"reply","author":"dave","body":"second","parent_id":"p1","replies":[]}
+]}}`
+
+func newTestApp() (*app, *fakeAPI) {
+	api := &fakeAPI{responses: map[string]string{
+		"/api/v1/boards": `{"boards":[{"id":"general","name":"General"},{"id":"ops","name":"Ops"}]}`,
+		"/api/v1/posts?board=ops&limit=50&sort=activity": `{"threads":[{"id":"p1","title":"Plan","author":"alice","reply_count":3}]}`,
+		"/api/v1/posts/p1/thread":                        threadJSON,
+	}}
+	return newApp(api, func(string) (string, error) { return "", nil }), api
+}
+
+func press(t *testing.T, a *app, input string) {
+	t.Helper()
+	for _, k := range parseKeys([]byte(input)) {
+		if err := a.handleKey(k); err != nil {
+			t.Fatalf("key %+v: %v", k, err)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("j\x1b[A\x1b[6~\r\x1bq\x03é"))
+	want := []key{
+		{code: keyRune, r: 'j'}, {code: keyUp}, {code: keyPageDown}, {code: keyEnter},
+		{code: keyEsc}, {code: keyRune, r: 'q'}, {code: keyCtrlC}, {code: keyRune, r: 'é'},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseKeys = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("key %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNavigateBoardsIntoThreadTree(t *testing.T) {
+	a, api := newTestApp()
+	api.responses["/api/v1/posts?board=general&limit=50&sort=activity"] = `{"threads":[]}`
+	if err := a.loadBoards(); err != nil {
+		t.Fatal(err)
+	}
+	a.setNotifications([]models.Notification{{ID: "n1", ThreadID: "p1", Type: "reply"}})
+
+	press(t, a, "j\r")
+	if a.view != viewThreads || a.board.ID != "ops" {
+		t.Fatalf("view=%v board=%+v", a.view, a.board)
+	}
+	if screen := a.render(); !strings.Contains(screen, "● Plan") {
+		t.Fatalf("expected unread marker in thread list:\n%s", screen)
+	}
+
+	press(t, a, "\r")
+	if a.view != viewThread || len(a.rows) != 4 {
+		t.Fatalf("view=%v rows=%d", a.view, len(a.rows))
+	}
+	if len(api.writes) != 1 || api.writes[0] != "PATCH /api/v1/notifications/n1/read" || !a.notifications[0].Read {
+		t.Fatalf("opening thread should mark its notification read, writes=%v", api.writes)
+	}
+
+	// Collapse bob's reply and its nested child, then expand again.
+	press(t, a, "j ")
+	if len(a.rows) != 3 || !strings.Contains(a.render(), "▸ bob") || !strings.Contains(a.render(), "[+1]") {
+		t.Fatalf("collapse failed: rows=%d\n%s", len(a.rows), a.render())
+	}
+	press(t, a, "l")
+	if len(a.rows) != 4 {
+		t.Fatalf("expand failed: rows=%d", len(a.rows))
+	}
+
+	// h on a leaf jumps to its parent.
+	press(t, a, "jh")
+	if a.rows[a.rowIdx].node.ID != "r1" {
+		t.Fatalf("selected %s, want r1", a.rows[a.rowIdx].node.ID)
+	}
+
+	press(t, a, "q")
+	if a.view != viewThreads {
+		t.Fatalf("view=%v after q", a.view)
+	}
+	press(t, a, "q")
+	if err := a.handleKey(key{code: keyRune, r: 'q'}); err != errQuit {
+		t.Fatalf("q on boards = %v, want quit", err)
+	}
+}
+
+func TestReplyAndComposeUseEditor(t *testing.T) {
+	a, api := newTestApp()
+	a.editor = func(string) (string, error) { return "Looks good\n", nil }
+	if err := a.openBoard(board{ID: "ops", Name: "Ops"}); err != nil {
+		t.Fatal(err)
+	}
+	press(t, a, "\rjjr")
+	if got := api.writes[len(api.writes)-1]; got != "POST /api/v1/posts/r2/replies" {
+		t.Fatalf("reply went to %s", got)
+	}
+	if api.bodies[0]["body"] != "Looks good" || a.status != "reply posted" {
+		t.Fatalf("body=%v status=%q", api.bodies[0], a.status)
+	}
+
+	a.editor = func(string) (string, error) { return "# New idea\n\nDetails here\n", nil }
+	api.responses["/api/v1/posts/r-new/thread"] = strings.Replace(threadJSON, `"id":"p1"`, `"id":"r-new"`, 1)
+	press(t, a, "qc")
+	last := api.bodies[len(api.bodies)-1]
+	if last["title"] != "New idea" || last["body"] != "Details here" || last["board_id"] != "ops" {
+		t.Fatalf("compose body = %v", last)
+	}
+	if a.view != viewThread || a.thread.ID != "r-new" {
+		t.Fatalf("compose should open the new thread, view=%v", a.view)
+	}
+}
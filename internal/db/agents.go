@@ -24,7 +24,7 @@ func CreateAgent(ctx context.Context, database *sql.DB, name, role, apiKeyHash s
 
 func ListAgents(ctx context.Context, database *sql.DB) ([]models.Agent, error) {
 	rows, err := database.QueryContext(ctx, `
-SELECT name, role, created, last_active, metadata
+SELECT `+agentColumns+`
 FROM agents
 ORDER BY created ASC`)
 	if err != nil {
@@ -35,7 +35,7 @@ ORDER BY created ASC`)
 	agents := make([]models.Agent, 0)
 	for rows.Next() {
 		var a models.Agent
-		if err := rows.Scan(&a.Name, &a.Role, &a.Created, &a.LastActive, &a.Metadata); err != nil {
+		if err := scanAgent(rows.Scan, &a); err != nil {
 			return nil, err
 		}
 		agents = append(agents, a)
@@ -45,11 +45,10 @@ ORDER BY created ASC`)
 
 func GetAgent(ctx context.Context, database *sql.DB, name string) (*models.Agent, error) {
 	var a models.Agent
-	err := database.QueryRowContext(ctx, `
-SELECT name, role, created, last_active, metadata
+	err := scanAgent(database.QueryRowContext(ctx, `
+SELECT `+agentColumns+`
 FROM agents
-WHERE name = ?`, name).
-		Scan(&a.Name, &a.Role, &a.Created, &a.LastActive, &a.Metadata)
+WHERE name = ?`, name).Scan, &a)
 	if err != nil {
 		return nil, err
 	}
@@ -95,9 +94,10 @@ func DeleteAgent(ctx context.Context, database *sql.DB, name string) error {
 	return nil
 }
 
+// CountAdmins counts admins that can currently sign in.
 func CountAdmins(ctx context.Context, database *sql.DB) (int, error) {
 	var count int
-	if err := database.QueryRowContext(ctx, `SELECT COUNT(1) FROM agents WHERE role = 'admin'`).Scan(&count); err != nil {
+	if err := database.QueryRowContext(ctx, `SELECT COUNT(1) FROM agents WHERE role = 'admin' AND `+agentActiveClause, nowRFC3339()).Scan(&count); err != nil {
 		return 0, err
 	}
 	return count, nil
@@ -105,11 +105,10 @@ func CountAdmins(ctx context.Context, database *sql.DB) (int, error) {
 
 func GetAgentByAPIKeyHash(ctx context.Context, database *sql.DB, apiKeyHash string) (*models.Agent, error) {
 	var a models.Agent
-	err := database.QueryRowContext(ctx, `
-SELECT name, role, created, last_active, metadata
+	err := scanAgent(database.QueryRowContext(ctx, `
+SELECT `+agentColumns+`
 FROM agents
-WHERE api_key = ?`, apiKeyHash).
-		Scan(&a.Name, &a.Role, &a.Created, &a.LastActive, &a.Metadata)
+WHERE api_key = ?`, apiKeyHash).Scan, &a)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,285 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fora/internal/auth"
+	"fora/internal/models"
+)
+
+const (
+	AgentActive      = "active"
+	AgentSuspended   = "suspended"
+	AgentDeactivated = "deactivated"
+
+	// AnonymousAgent owns content that was anonymized. It is created
+	// deactivated, with a key nobody holds.
+	AnonymousAgent = "anonymous"
+
+	maxStateReasonLen = 500
+)
+
+var (
+	ErrInvalidAgentState = errors.New("state must be active, suspended or deactivated")
+	// ErrInvalidStateChange wraps a rejected reason or until.
+	ErrInvalidStateChange = errors.New("invalid agent state change")
+	// ErrSameAgent is returned when content would be reassigned to the agent
+	// that already owns it, including anonymizing the anonymous agent.
+	ErrSameAgent = errors.New("cannot reassign content to the same agent")
+	// ErrAnonymousNameTaken is returned when an agent that can sign in is
+	// already called AnonymousAgent.
+	ErrAnonymousNameTaken = fmt.Errorf("an active agent is named %q", AnonymousAgent)
+)
+
+const agentColumns = `name, role, created, last_active, metadata, state, state_reason, state_until, state_changed`
+
+// agentActiveClause matches agents that can sign in, including those whose
+// suspension has run out. It takes the current time as its one argument.
+const agentActiveClause = `(state = 'active' OR (state = 'suspended' AND state_until IS NOT NULL AND state_until <= ?))`
+
+// scanAgent scans agentColumns and reports a lapsed suspension as active.
+func scanAgent(scan func(dest ...any) error, a *models.Agent) error {
+	if err := scan(&a.Name, &a.Role, &a.Created, &a.LastActive, &a.Metadata,
+		&a.State, &a.StateReason, &a.StateUntil, &a.StateChanged); err != nil {
+		return err
+	}
+	normalizeAgentState(a)
+	return nil
+}
+
+func normalizeAgentState(a *models.Agent) {
+	if a.State == AgentSuspended && a.StateUntil != nil && *a.StateUntil <= nowRFC3339() {
+		a.State, a.StateReason, a.StateUntil = AgentActive, nil, nil
+	}
+}
+
+// SetAgentState suspends, deactivates or reactivates an agent. until only
+// applies to suspensions; a nil until suspends indefinitely. Leaving the
+// active state also clears the agent's presence.
+func SetAgentState(ctx context.Context, database *sql.DB, name, state, reason string, until *time.Time) (*models.Agent, error) {
+	state = strings.ToLower(strings.TrimSpace(state))
+	switch state {
+	case AgentActive, AgentSuspended, AgentDeactivated:
+	default:
+		return nil, ErrInvalidAgentState
+	}
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxStateReasonLen {
+		return nil, fmt.Errorf("%w: reason must be at most %d bytes", ErrInvalidStateChange, maxStateReasonLen)
+	}
+	var untilValue *string
+	if until != nil && state == AgentSuspended {
+		if !until.After(time.Now()) {
+			return nil, fmt.Errorf("%w: until must be in the future", ErrInvalidStateChange)
+		}
+		v := until.UTC().Format(time.RFC3339)
+		untilValue = &v
+	}
+	if state == AgentActive {
+		reason = ""
+	}
+
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+UPDATE agents
+SET state = ?, state_reason = ?, state_until = ?, state_changed = ?
+WHERE name = ?`, state, nullableString(reason), untilValue, nowRFC3339(), name)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, sql.ErrNoRows
+	}
+	if state != AgentActive {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM agent_presence WHERE agent = ?`, name); err != nil {
+			return nil, err
+		}
+	}
+	var a models.Agent
+	if err := scanAgent(tx.QueryRowContext(ctx, `SELECT `+agentColumns+` FROM agents WHERE name = ?`, name).Scan, &a); err != nil {
+		return nil, err
+	}
+	return &a, tx.Commit()
+}
+
+// ReassignAgentContent moves authorship of everything from wrote to to:
+// posts, replies, edits, uploads, sent notifications and thread participant
+// lists. It returns the number of posts and replies moved.
+func ReassignAgentContent(ctx context.Context, database *sql.DB, from, to string) (int, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := reassignAgentContentTx(ctx, tx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// AnonymizeAgentContent reassigns the agent's content to AnonymousAgent,
+// creating it on first use.
+func AnonymizeAgentContent(ctx context.Context, database *sql.DB, name string) (int, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var state string
+	err = tx.QueryRowContext(ctx, `SELECT state FROM agents WHERE name = ?`, AnonymousAgent).Scan(&state)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		now := nowRFC3339()
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO agents (name, api_key, role, created, state, state_reason, state_changed)
+VALUES (?, ?, 'agent', ?, ?, 'holds anonymized content', ?)`,
+			AnonymousAgent, auth.HashAPIKey("anonymized:"+AnonymousAgent), now, AgentDeactivated, now); err != nil {
+			return 0, err
+		}
+	case err != nil:
+		return 0, err
+	case state != AgentDeactivated:
+		return 0, ErrAnonymousNameTaken
+	}
+
+	n, err := reassignAgentContentTx(ctx, tx, name, AnonymousAgent)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+func reassignAgentContentTx(ctx context.Context, tx *sql.Tx, from, to string) (int, error) {
+	if from == to {
+		return 0, ErrSameAgent
+	}
+	for _, name := range []string{from, to} {
+		exists, err := agentExistsTx(ctx, tx, name)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			return 0, fmt.Errorf("%w: %s", ErrUnknownAgent, name)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE content SET author = ? WHERE author = ?`, to, from)
+	if err != nil {
+		return 0, err
+	}
+	moved, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	for _, stmt := range []string{
+		`UPDATE content_history SET edited_by = ? WHERE edited_by = ?`,
+		`UPDATE attachments SET uploader = ? WHERE uploader = ?`,
+		`UPDATE notifications SET from_agent = ? WHERE from_agent = ?`,
+		`UPDATE direct_messages SET author = ? WHERE author = ?`,
+		`UPDATE conversations SET created_by = ? WHERE created_by = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, to, from); err != nil {
+			return 0, err
+		}
+	}
+	// Where to already belongs to a conversation or voted in a poll, from's
+	// rows are merged into to's instead of renamed.
+	for _, stmt := range []string{
+		`UPDATE conversation_members AS m
+SET last_read_rowid = MAX(m.last_read_rowid, f.last_read_rowid)
+FROM conversation_members AS f
+WHERE m.agent = ?2 AND f.agent = ?1 AND f.conversation_id = m.conversation_id`,
+		`DELETE FROM conversation_members
+WHERE agent = ?1 AND conversation_id IN (SELECT conversation_id FROM conversation_members WHERE agent = ?2)`,
+		`UPDATE conversation_members SET agent = ?2 WHERE agent = ?1`,
+		`DELETE FROM poll_votes
+WHERE agent = ?1 AND post_id IN (SELECT post_id FROM poll_votes WHERE agent = ?2)`,
+		`UPDATE poll_votes SET agent = ?2 WHERE agent = ?1`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, from, to); err != nil {
+			return 0, err
+		}
+	}
+	if err := renameThreadParticipantTx(ctx, tx, from, to); err != nil {
+		return 0, err
+	}
+	return int(moved), nil
+}
+
+// renameThreadParticipantTx rewrites the JSON participant lists that name
+// from, merging from into to where both took part.
+func renameThreadParticipantTx(ctx context.Context, tx *sql.Tx, from, to string) error {
+	encoded, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	rows, err := tx.QueryContext(ctx, `
+SELECT thread_id, participants
+FROM thread_stats
+WHERE instr(participants, ?) > 0`, string(encoded))
+	if err != nil {
+		return err
+	}
+	type update struct {
+		threadID     string
+		participants []string
+	}
+	var updates []update
+	for rows.Next() {
+		var (
+			threadID, raw string
+			before        []string
+		)
+		if err := rows.Scan(&threadID, &raw); err != nil {
+			rows.Close()
+			return err
+		}
+		if err := json.Unmarshal([]byte(raw), &before); err != nil {
+			continue
+		}
+		after := make([]string, 0, len(before))
+		seen := map[string]bool{}
+		for _, p := range before {
+			if p == from {
+				p = to
+			}
+			if !seen[p] {
+				seen[p] = true
+				after = append(after, p)
+			}
+		}
+		updates = append(updates, update{threadID, after})
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	for _, u := range updates {
+		participants, err := json.Marshal(u.participants)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+UPDATE thread_stats
+SET participants = ?, participant_count = ?
+WHERE thread_id = ?`, string(participants), len(u.participants), u.threadID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
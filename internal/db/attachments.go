@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+
+	"fora/internal/models"
+)
+
+const attachmentColumns = `id, content_id, filename, content_type, size, sha256, uploader, created`
+
+// CreateAttachment records an attachment. data is stored in SQLite when
+// storageKey is empty; otherwise the caller has already written the bytes to
+// the attachments directory under storageKey.
+func CreateAttachment(ctx context.Context, database *sql.DB, a models.Attachment, data []byte, storageKey string) (*models.Attachment, error) {
+	if a.ID == "" {
+		id, err := GenerateAttachmentID()
+		if err != nil {
+			return nil, err
+		}
+		a.ID = id
+	}
+	a.Created = nowRFC3339()
+	var blob any
+	var key any
+	if storageKey == "" {
+		blob = data
+	} else {
+		key = storageKey
+	}
+	if _, err := database.ExecContext(ctx, `
+INSERT INTO attachments (id, content_id, filename, content_type, size, sha256, uploader, created, data, storage_key)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.ID, a.ContentID, a.Filename, a.ContentType, a.Size, a.SHA256, a.Uploader, a.Created, blob, key); err != nil {
+		return nil, err
+	}
+	a.URL = attachmentURL(a.ID)
+	return &a, nil
+}
+
+// GetAttachment returns the attachment metadata and where its bytes live:
+// data for SQLite-backed attachments, storageKey for directory-backed ones.
+func GetAttachment(ctx context.Context, database *sql.DB, id string) (*models.Attachment, []byte, string, error) {
+	var (
+		a          models.Attachment
+		data       []byte
+		storageKey sql.NullString
+	)
+	err := database.QueryRowContext(ctx, `
+SELECT `+attachmentColumns+`, data, storage_key
+FROM attachments
+WHERE id = ?`, id).Scan(
+		&a.ID, &a.ContentID, &a.Filename, &a.ContentType, &a.Size, &a.SHA256, &a.Uploader, &a.Created,
+		&data, &storageKey)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	a.URL = attachmentURL(a.ID)
+	return &a, data, storageKey.String, nil
+}
+
+func ListAttachments(ctx context.Context, database *sql.DB, contentID string) ([]models.Attachment, error) {
+	rows, err := database.QueryContext(ctx, `
+SELECT `+attachmentColumns+`
+FROM attachments
+WHERE content_id = ?
+ORDER BY created ASC, rowid ASC`, contentID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAttachments(rows)
+}
+
+// listThreadAttachments returns the attachments of every item in a thread,
+// keyed by content ID.
+func listThreadAttachments(ctx context.Context, database *sql.DB, threadID string) (map[string][]models.Attachment, error) {
+	rows, err := database.QueryContext(ctx, `
+SELECT a.id, a.content_id, a.filename, a.content_type, a.size, a.sha256, a.uploader, a.created
+FROM attachments a
+JOIN content c ON c.id = a.content_id
+WHERE c.thread_id = ?
+ORDER BY a.created ASC, a.rowid ASC`, threadID)
+	if err != nil {
+		return nil, err
+	}
+	list, err := scanAttachments(rows)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]models.Attachment)
+	for _, a := range list {
+		out[a.ContentID] = append(out[a.ContentID], a)
+	}
+	return out, nil
+}
+
+// SubtreeAttachmentStorageKeys lists the keys of the directory-backed
+// attachments on contentID and every reply below it, i.e. the files deleting
+// that content leaves behind.
+func SubtreeAttachmentStorageKeys(ctx context.Context, database *sql.DB, contentID string) ([]string, error) {
+	rows, err := database.QueryContext(ctx, `
+WITH RECURSIVE subtree(id) AS (
+	SELECT id FROM content WHERE id = ?
+	UNION ALL
+	SELECT c.id
+	FROM content c
+	INNER JOIN subtree s ON c.parent_id = s.id
+)
+SELECT storage_key
+FROM attachments
+WHERE storage_key IS NOT NULL AND content_id IN (SELECT id FROM subtree)`, contentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// AttachmentStorageKeys lists the keys of every directory-backed attachment,
+// so files left behind by deleted content can be removed.
+func AttachmentStorageKeys(ctx context.Context, database *sql.DB) (map[string]bool, error) {
+	rows, err := database.QueryContext(ctx, `SELECT storage_key FROM attachments WHERE storage_key IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := map[string]bool{}
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys[k] = true
+	}
+	return keys, rows.Err()
+}
+
+func scanAttachments(rows *sql.Rows) ([]models.Attachment, error) {
+	defer rows.Close()
+	out := make([]models.Attachment, 0)
+	for rows.Next() {
+		var a models.Attachment
+		if err := rows.Scan(&a.ID, &a.ContentID, &a.Filename, &a.ContentType, &a.Size, &a.SHA256, &a.Uploader, &a.Created); err != nil {
+			return nil, err
+		}
+		a.URL = attachmentURL(a.ID)
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func GenerateAttachmentID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "att_" + hex.EncodeToString(b), nil
+}
+
+func attachmentURL(id string) string {
+	return "/api/v1/attachments/" + id
+}
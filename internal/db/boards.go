@@ -4,17 +4,57 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 )
 
+var (
+	// ErrBoardArchived is returned when posting to an archived board.
+	ErrBoardArchived = errors.New("board is archived")
+	// ErrBoardNotEmpty is returned when deleting a board that still has posts.
+	ErrBoardNotEmpty = errors.New("board still has posts")
+	// ErrBoardNameTaken is returned when a rename collides with another board.
+	ErrBoardNameTaken = errors.New("board name already in use")
+	// ErrBoardNameReserved is returned for a board name that would take the
+	// AutoBoard ID, which every post would be routed away from.
+	ErrBoardNameReserved = fmt.Errorf("board name %q is reserved for tag routing", AutoBoard)
+)
+
 type Board struct {
 	ID          string   `json:"id"`
 	Name        string   `json:"name"`
 	Description string   `json:"description,omitempty"`
 	Icon        string   `json:"icon,omitempty"`
 	Tags        []string `json:"tags,omitempty"`
-	Created     string   `json:"created"`
+	Archived    bool     `json:"archived"`
+	SortOrder   int      `json:"sort_order"`
+	// RestrictTags limits the tags of the board's posts to Tags.
+	RestrictTags bool   `json:"restrict_tags"`
+	Created      string `json:"created"`
+}
+
+func boardIDFromName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+}
+
+// BoardUpdate holds the fields a PATCH may change; nil fields are left as
+// they are. Renaming changes the display name only, never the ID.
+type BoardUpdate struct {
+	Name         *string
+	Description  *string
+	Icon         *string
+	SortOrder    *int
+	Archived     *bool
+	RestrictTags *bool
+	AddTags      []string
+	RemoveTags   []string
+}
+
+const boardColumns = `id, name, COALESCE(description, ''), COALESCE(icon, ''), archived, sort_order, restrict_tags, created`
+
+func scanBoard(row interface{ Scan(...any) error }, b *Board) error {
+	return row.Scan(&b.ID, &b.Name, &b.Description, &b.Icon, &b.Archived, &b.SortOrder, &b.RestrictTags, &b.Created)
 }
 
 func CreateBoard(ctx context.Context, database *sql.DB, name, description, icon string, tags []string) (*Board, error) {
@@ -22,7 +62,10 @@ func CreateBoard(ctx context.Context, database *sql.DB, name, description, icon
 	if name == "" {
 		return nil, errors.New("name is required")
 	}
-	id := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+	id := boardIDFromName(name)
+	if id == AutoBoard {
+		return nil, ErrBoardNameReserved
+	}
 	description = strings.TrimSpace(description)
 	icon = strings.TrimSpace(icon)
 	created := time.Now().UTC().Format(time.RFC3339)
@@ -50,9 +93,9 @@ VALUES (?, ?, ?, ?, ?)`, id, name, nullableString(description), nullableString(i
 
 func ListBoards(ctx context.Context, database *sql.DB) ([]Board, error) {
 	rows, err := database.QueryContext(ctx, `
-SELECT id, name, COALESCE(description, ''), COALESCE(icon, ''), created
+SELECT `+boardColumns+`
 FROM boards
-ORDER BY name ASC`)
+ORDER BY sort_order ASC, name ASC`)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +104,7 @@ ORDER BY name ASC`)
 	out := make([]Board, 0)
 	for rows.Next() {
 		var b Board
-		if err := rows.Scan(&b.ID, &b.Name, &b.Description, &b.Icon, &b.Created); err != nil {
+		if err := scanBoard(rows, &b); err != nil {
 			return nil, err
 		}
 		tags, err := ListBoardTags(ctx, database, b.ID)
@@ -87,12 +130,12 @@ func BoardExists(ctx context.Context, database *sql.DB, boardID string) (bool, e
 
 func GetBoard(ctx context.Context, database *sql.DB, id string) (*Board, error) {
 	row := database.QueryRowContext(ctx, `
-SELECT id, name, COALESCE(description, ''), COALESCE(icon, ''), created
+SELECT `+boardColumns+`
 FROM boards
 WHERE id = ?`, strings.TrimSpace(id))
 
 	b := &Board{}
-	if err := row.Scan(&b.ID, &b.Name, &b.Description, &b.Icon, &b.Created); err != nil {
+	if err := scanBoard(row, b); err != nil {
 		return nil, err
 	}
 	tags, err := ListBoardTags(ctx, database, b.ID)
@@ -103,6 +146,93 @@ WHERE id = ?`, strings.TrimSpace(id))
 	return b, nil
 }
 
+// UpdateBoard applies the non-nil fields of u and returns the board.
+func UpdateBoard(ctx context.Context, database *sql.DB, id string, u BoardUpdate) (*Board, error) {
+	sets := []string{}
+	args := []any{}
+	if u.Name != nil {
+		name := strings.TrimSpace(*u.Name)
+		if name == "" {
+			return nil, errors.New("name cannot be empty")
+		}
+		if boardIDFromName(name) == AutoBoard {
+			return nil, ErrBoardNameReserved
+		}
+		sets = append(sets, "name = ?")
+		args = append(args, name)
+	}
+	if u.Description != nil {
+		sets = append(sets, "description = ?")
+		args = append(args, nullableString(strings.TrimSpace(*u.Description)))
+	}
+	if u.Icon != nil {
+		sets = append(sets, "icon = ?")
+		args = append(args, nullableString(strings.TrimSpace(*u.Icon)))
+	}
+	if u.SortOrder != nil {
+		sets = append(sets, "sort_order = ?")
+		args = append(args, *u.SortOrder)
+	}
+	if u.Archived != nil {
+		sets = append(sets, "archived = ?")
+		args = append(args, *u.Archived)
+	}
+	if u.RestrictTags != nil {
+		sets = append(sets, "restrict_tags = ?")
+		args = append(args, *u.RestrictTags)
+	}
+	id = strings.TrimSpace(id)
+	if len(sets) > 0 {
+		args = append(args, id)
+		res, err := database.ExecContext(ctx, `UPDATE boards SET `+strings.Join(sets, ", ")+` WHERE id = ?`, args...)
+		if err != nil {
+			if strings.Contains(strings.ToLower(err.Error()), "unique") {
+				return nil, ErrBoardNameTaken
+			}
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if n == 0 {
+			return nil, sql.ErrNoRows
+		}
+	}
+	if len(u.AddTags) > 0 || len(u.RemoveTags) > 0 {
+		if _, err := UpdateBoardTags(ctx, database, id, u.AddTags, u.RemoveTags); err != nil {
+			return nil, err
+		}
+	}
+	return GetBoard(ctx, database, id)
+}
+
+// DeleteBoard removes a board with no posts, along with its tags and
+// subscriptions.
+func DeleteBoard(ctx context.Context, database *sql.DB, id string) error {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var posts int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(1) FROM content WHERE board_id = ?`, id).Scan(&posts); err != nil {
+		return err
+	}
+	if posts > 0 {
+		return ErrBoardNotEmpty
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM boards WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
 func UpdateBoardTags(ctx context.Context, database *sql.DB, boardID string, add, remove []string) ([]string, error) {
 	tx, err := database.BeginTx(ctx, nil)
 	if err != nil {
@@ -247,7 +377,11 @@ ORDER BY tag ASC`, boardID)
 }
 
 func upsertBoardTagsTx(ctx context.Context, tx *sql.Tx, boardID string, tags []string) error {
-	for _, tag := range dedupeTags(tags) {
+	tags, err := resolveTagAliases(ctx, tx, tags)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
 		if _, err := tx.ExecContext(ctx,
 			`INSERT OR IGNORE INTO board_tags (board_id, tag) VALUES (?, ?)`,
 			boardID, tag,
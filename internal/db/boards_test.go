@@ -90,7 +90,7 @@ func TestBoardSubscriptionsAndPostNotifications(t *testing.T) {
 		t.Fatalf("expected bob in general subscribers: %v", subs)
 	}
 
-	if _, err := CreatePost(ctx, database, "alice", strPtr("hello"), "board post body", nil, nil, "general"); err != nil {
+	if _, err := CreatePost(ctx, database, "alice", strPtr("hello"), "board post body", nil, nil, "general", nil); err != nil {
 		t.Fatalf("create board post: %v", err)
 	}
 
@@ -8,7 +8,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"regexp"
 	"strings"
 	"time"
 
@@ -38,6 +37,17 @@ type ListPostsParams struct {
 	Since   *time.Time
 	Sort    string
 	Order   string
+
+	// Viewer is the agent unread counts are computed for; Unread keeps only
+	// threads with items the viewer has not read.
+	Viewer string
+	Unread bool
+
+	// LinksTo keeps threads with a post or reply linking to a host (or any
+	// subdomain of it), or to URLs starting with LinksTo when it has a
+	// scheme. References keeps threads that mention #References.
+	LinksTo    string
+	References string
 }
 
 type ListActivityParams struct {
@@ -46,7 +56,9 @@ type ListActivityParams struct {
 	Author string
 }
 
-func CreatePost(ctx context.Context, database *sql.DB, author string, title *string, body string, tags []string, mentions []string, boardID string) (*models.Content, error) {
+// CreatePost creates a post and, when poll is not nil, its poll in the same
+// transaction.
+func CreatePost(ctx context.Context, database *sql.DB, author string, title *string, body string, tags []string, mentions []string, boardID string, poll *CreatePollParams) (*models.Content, error) {
 	if strings.TrimSpace(body) == "" {
 		return nil, errors.New("body is required")
 	}
@@ -54,6 +66,13 @@ func CreatePost(ctx context.Context, database *sql.DB, author string, title *str
 	if boardID == "" {
 		return nil, errors.New("board_id is required")
 	}
+	if poll != nil {
+		normalized, err := normalizePollParams(*poll)
+		if err != nil {
+			return nil, err
+		}
+		poll = &normalized
+	}
 	id := generateContentID(body)
 	now := nowRFC3339()
 	status := "open"
@@ -64,6 +83,14 @@ func CreatePost(ctx context.Context, database *sql.DB, author string, title *str
 	}
 	defer tx.Rollback()
 
+	var archived bool
+	if err := tx.QueryRowContext(ctx, `SELECT archived FROM boards WHERE id = ?`, boardID).Scan(&archived); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if archived {
+		return nil, ErrBoardArchived
+	}
+
 	_, err = tx.ExecContext(ctx, `
 INSERT INTO content (id, type, author, title, body, created, updated, thread_id, parent_id, status, board_id)
 VALUES (?, 'post', ?, ?, ?, ?, ?, ?, NULL, ?, ?)`,
@@ -75,18 +102,36 @@ VALUES (?, 'post', ?, ?, ?, ?, ?, ?, NULL, ?, ?)`,
 				return nil, getErr
 			}
 			existing.Tags, _ = listTagsTx(ctx, tx, existing.ID)
-			return existing, nil
+			if poll == nil {
+				return existing, nil
+			}
+			// A duplicate post may already carry its poll.
+			existing.Poll, err = createPollTx(ctx, tx, id, *poll)
+			if errors.Is(err, ErrPollExists) {
+				existing.Poll, err = getPollTx(ctx, tx, id, "")
+			}
+			if err != nil {
+				return nil, err
+			}
+			return existing, tx.Commit()
 		}
 		return nil, err
 	}
 
-	if err := upsertTagsTx(ctx, tx, id, tags); err != nil {
+	tags, err = upsertTagsTx(ctx, tx, id, tags)
+	if err != nil {
+		return nil, err
+	}
+	resolvedMentions, unresolved, err := resolveMentionsTx(ctx, tx, normalizeMentions(mentions, body))
+	if err != nil {
 		return nil, err
 	}
-	resolvedMentions := normalizeMentions(mentions, body)
 	if err := upsertMentionsTx(ctx, tx, id, resolvedMentions); err != nil {
 		return nil, err
 	}
+	if err := replaceContentRefs(ctx, tx, id, body); err != nil {
+		return nil, err
+	}
 	if err := createMentionNotificationsTx(ctx, tx, author, id, id, body, resolvedMentions); err != nil {
 		return nil, err
 	}
@@ -112,7 +157,14 @@ VALUES (?, 'post', ?, ?, ?, ?, ?, ?, NULL, ?, ?)`,
 		ParentID: nil,
 		Status:   status,
 		BoardID:  boardID,
-		Tags:     dedupeTags(tags),
+		Tags:     tags,
+
+		UnresolvedMentions: unresolved,
+	}
+	if poll != nil {
+		if c.Poll, err = createPollTx(ctx, tx, id, *poll); err != nil {
+			return nil, err
+		}
 	}
 	if err := tx.Commit(); err != nil {
 		return nil, err
@@ -157,10 +209,16 @@ VALUES (?, 'reply', ?, NULL, ?, ?, ?, ?, ?, ?, ?)`,
 	if err := upsertThreadStatsForReplyTx(ctx, tx, threadID, author, now); err != nil {
 		return nil, err
 	}
-	resolvedMentions := normalizeMentions(mentions, body)
+	resolvedMentions, unresolved, err := resolveMentionsTx(ctx, tx, normalizeMentions(mentions, body))
+	if err != nil {
+		return nil, err
+	}
 	if err := upsertMentionsTx(ctx, tx, id, resolvedMentions); err != nil {
 		return nil, err
 	}
+	if err := replaceContentRefs(ctx, tx, id, body); err != nil {
+		return nil, err
+	}
 	if err := createMentionNotificationsTx(ctx, tx, author, threadID, id, body, resolvedMentions); err != nil {
 		return nil, err
 	}
@@ -183,6 +241,8 @@ VALUES (?, 'reply', ?, NULL, ?, ?, ?, ?, ?, ?, ?)`,
 		ParentID: &parentID,
 		Status:   status,
 		BoardID:  parent.BoardID,
+
+		UnresolvedMentions: unresolved,
 	}
 	if err := tx.Commit(); err != nil {
 		return nil, err
@@ -232,7 +292,13 @@ func ListPosts(ctx context.Context, database *sql.DB, params ListPostsParams) ([
 		order = "ASC"
 	}
 
+	tags, err := resolveTagAliases(ctx, database, params.Tags)
+	if err != nil {
+		return nil, 0, err
+	}
+	params.Tags = tags
 	whereClause, whereArgs := listPostsWhereClause(params)
+	unreadExpr, unreadArgs := unreadCountExpr(params.Viewer)
 
 	countQuery := `
 SELECT COUNT(*)
@@ -245,12 +311,13 @@ LEFT JOIN thread_stats ts ON ts.thread_id = c.id` + whereClause
 
 	query := `
 SELECT c.id, c.type, c.author, c.title, c.body, c.created, c.updated, c.thread_id, c.parent_id, c.status, COALESCE(c.board_id, ''),
-       COALESCE(ts.reply_count, 0), COALESCE(ts.last_activity, c.created), COALESCE(ts.participants, '[]'), COALESCE(ts.participant_count, 1)
+       COALESCE(ts.reply_count, 0), COALESCE(ts.last_activity, c.created), COALESCE(ts.participants, '[]'), COALESCE(ts.participant_count, 1),
+       ` + unreadExpr + `
 FROM content c
 LEFT JOIN thread_stats ts ON ts.thread_id = c.id` + whereClause +
 		" ORDER BY " + sortField + " " + order + ", c.created DESC LIMIT ? OFFSET ?"
 
-	args := append(append([]any{}, whereArgs...), limit, offset)
+	args := append(append(append([]any{}, unreadArgs...), whereArgs...), limit, offset)
 	rows, err := database.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
@@ -267,6 +334,7 @@ LEFT JOIN thread_stats ts ON ts.thread_id = c.id` + whereClause +
 			&item.ID, &item.Type, &item.Author, &item.Title, &item.Body, &item.Created,
 			&item.Updated, &item.ThreadID, &item.ParentID, &item.Status, &item.BoardID,
 			&item.ReplyCount, &item.LastActivity, &participantsJSON, &item.ParticipantCount,
+			&item.UnreadCount,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -310,9 +378,107 @@ func listPostsWhereClause(params ListPostsParams) (string, []any) {
 		whereClause += " AND COALESCE(ts.last_activity, c.created) >= ?"
 		args = append(args, params.Since.UTC().Format(time.RFC3339))
 	}
+	if linksTo := strings.TrimSpace(params.LinksTo); linksTo != "" {
+		if strings.Contains(linksTo, "://") {
+			whereClause += " AND EXISTS (SELECT 1 FROM content_refs r JOIN content rc ON rc.id = r.content_id" +
+				" WHERE rc.thread_id = c.id AND r.kind IN ('link', 'image') AND substr(r.value, 1, length(?)) = ?)"
+			args = append(args, linksTo, linksTo)
+		} else {
+			host := strings.ToLower(linksTo)
+			whereClause += " AND EXISTS (SELECT 1 FROM content_refs r JOIN content rc ON rc.id = r.content_id" +
+				" WHERE rc.thread_id = c.id AND r.kind IN ('link', 'image') AND (r.detail = ? OR r.detail LIKE ? ESCAPE '\\'))"
+			args = append(args, host, "%."+escapeLike(host))
+		}
+	}
+	if ref := strings.TrimPrefix(strings.TrimSpace(params.References), "#"); ref != "" {
+		whereClause += " AND EXISTS (SELECT 1 FROM content_refs r JOIN content rc ON rc.id = r.content_id" +
+			" WHERE rc.thread_id = c.id AND r.kind = 'thread' AND r.value = ?)"
+		args = append(args, ref)
+	}
+	if params.Unread && params.Viewer != "" {
+		expr, exprArgs := unreadCountExpr(params.Viewer)
+		whereClause += " AND " + expr + " > 0"
+		args = append(args, exprArgs...)
+	}
 	return whereClause, args
 }
 
+// unreadCountExpr counts the items of thread c written or edited by others
+// since the viewer last read it; threads never read count every such item.
+func unreadCountExpr(viewer string) (string, []any) {
+	if viewer == "" {
+		return "0", nil
+	}
+	return `(SELECT COUNT(*) FROM content u
+        JOIN content_seq us ON us.content_id = u.id
+        WHERE u.thread_id = c.id AND u.author <> ?
+          AND us.seq > COALESCE((SELECT r.last_seq FROM thread_reads r WHERE r.agent = ? AND r.thread_id = c.id), 0))`,
+		[]any{viewer, viewer}
+}
+
+// ThreadRead describes agent's read marker on a thread before MarkThreadRead
+// moved it.
+type ThreadRead struct {
+	// LastReadAt is empty when the thread had never been read.
+	LastReadAt string
+	// Changed holds the IDs of the items written or edited since then, by
+	// anyone; every item when the thread had never been read.
+	Changed map[string]bool
+}
+
+// MarkThreadRead records that agent has read everything currently in
+// threadID and returns the marker it replaced.
+func MarkThreadRead(ctx context.Context, database *sql.DB, agent, threadID string) (*ThreadRead, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	prev := &ThreadRead{Changed: map[string]bool{}}
+	var lastSeq int64
+	err = tx.QueryRowContext(ctx, `
+SELECT last_read_at, last_seq
+FROM thread_reads
+WHERE agent = ? AND thread_id = ?`, agent, threadID).Scan(&prev.LastReadAt, &lastSeq)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, `
+SELECT c.id
+FROM content c
+JOIN content_seq s ON s.content_id = c.id
+WHERE c.thread_id = ? AND s.seq > ?`, threadID, lastSeq)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		prev.Changed[id] = true
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO thread_reads (agent, thread_id, last_read_at, last_seq)
+VALUES (?, ?, ?, (
+	SELECT COALESCE(MAX(s.seq), 0)
+	FROM content c
+	JOIN content_seq s ON s.content_id = c.id
+	WHERE c.thread_id = ?))
+ON CONFLICT (agent, thread_id) DO UPDATE SET
+	last_read_at = excluded.last_read_at,
+	last_seq = MAX(thread_reads.last_seq, excluded.last_seq)`,
+		agent, threadID, nowRFC3339(), threadID); err != nil {
+		return nil, err
+	}
+	return prev, tx.Commit()
+}
+
 func ListReplies(ctx context.Context, database *sql.DB, parentID string, limit, offset int) ([]models.Content, error) {
 	rows, err := database.QueryContext(ctx, `
 SELECT id, type, author, title, body, created, updated, thread_id, parent_id, status, COALESCE(board_id, '')
@@ -418,7 +584,25 @@ ORDER BY created ASC`, threadID)
 		}
 		out = append(out, c)
 	}
-	return out, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	attachments, err := listThreadAttachments(ctx, database, threadID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range out {
+		out[i].Attachments = attachments[out[i].ID]
+		if out[i].Type != "post" {
+			continue
+		}
+		poll, err := GetPoll(ctx, database, out[i].ID, "")
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		out[i].Poll = poll
+	}
+	return out, nil
 }
 
 func UpdatePost(ctx context.Context, database *sql.DB, id string, title *string, body string, editedBy string) (*models.Content, error) {
@@ -472,6 +656,9 @@ WHERE id = ? AND type = 'post'`, title, body, now, id)
 	if n == 0 {
 		return nil, sql.ErrNoRows
 	}
+	if err := replaceContentRefs(ctx, tx, id, body); err != nil {
+		return nil, err
+	}
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -529,6 +716,9 @@ WHERE id = ? AND type = 'reply'`, body, now, id)
 	if n == 0 {
 		return nil, sql.ErrNoRows
 	}
+	if err := replaceContentRefs(ctx, tx, id, body); err != nil {
+		return nil, err
+	}
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -689,10 +879,13 @@ func UpdatePostTags(ctx context.Context, database *sql.DB, postID string, addTag
 		return nil, errors.New("target is not a post")
 	}
 
-	if err := upsertTagsTx(ctx, tx, postID, addTags); err != nil {
+	if _, err := upsertTagsTx(ctx, tx, postID, addTags); err != nil {
+		return nil, err
+	}
+	removeTags, err = resolveTagAliases(ctx, tx, removeTags)
+	if err != nil {
 		return nil, err
 	}
-	removeTags = dedupeTags(removeTags)
 	if len(removeTags) > 0 {
 		placeholders := make([]string, 0, len(removeTags))
 		args := make([]any, 0, len(removeTags)+1)
@@ -736,16 +929,30 @@ ORDER BY tag ASC`, contentID)
 	return out, rows.Err()
 }
 
-func upsertTagsTx(ctx context.Context, tx *sql.Tx, contentID string, tags []string) error {
-	for _, tag := range dedupeTags(tags) {
+// upsertTagsTx adds tags to content after mapping them through tag aliases
+// and checking the allow-list of the content's board. It returns the tags
+// as stored.
+func upsertTagsTx(ctx context.Context, tx *sql.Tx, contentID string, tags []string) ([]string, error) {
+	tags, err := resolveTagAliases(ctx, tx, tags)
+	if err != nil {
+		return nil, err
+	}
+	var boardID string
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(board_id, '') FROM content WHERE id = ?`, contentID).Scan(&boardID); err != nil {
+		return nil, err
+	}
+	if err := checkBoardAllowsTagsTx(ctx, tx, boardID, tags); err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
 		if _, err := tx.ExecContext(ctx,
 			`INSERT OR IGNORE INTO tags (content_id, tag) VALUES (?, ?)`,
 			contentID, tag,
 		); err != nil {
-			return err
+			return nil, err
 		}
 	}
-	return nil
+	return tags, nil
 }
 
 func upsertMentionsTx(ctx context.Context, tx *sql.Tx, contentID string, mentions []string) error {
@@ -784,32 +991,6 @@ func dedupeTags(tags []string) []string {
 	return out
 }
 
-var mentionPattern = regexp.MustCompile(`@([a-zA-Z0-9][a-zA-Z0-9_-]{0,63})`)
-
-func normalizeMentions(explicit []string, body string) []string {
-	candidates := make([]string, 0, len(explicit)+4)
-	candidates = append(candidates, explicit...)
-	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
-		if len(match) > 1 {
-			candidates = append(candidates, match[1])
-		}
-	}
-	seen := map[string]struct{}{}
-	out := make([]string, 0, len(candidates))
-	for _, c := range candidates {
-		c = strings.TrimSpace(c)
-		if c == "" {
-			continue
-		}
-		if _, ok := seen[c]; ok {
-			continue
-		}
-		seen[c] = struct{}{}
-		out = append(out, c)
-	}
-	return out
-}
-
 func generateContentID(body string) string {
 	timestamp := time.Now().UTC().Format("20060102T150405Z")
 	sum := sha256.Sum256([]byte(body))
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"os"
 	"strings"
 	"testing"
@@ -23,7 +24,7 @@ func TestCreatePostUpdatesAuthorLastActive(t *testing.T) {
 		t.Fatalf("expected last_active to be NULL before post creation, got %q", before.String)
 	}
 
-	post, err := CreatePost(ctx, database, "alice", strPtr("hello"), "post body", nil, nil, "general")
+	post, err := CreatePost(ctx, database, "alice", strPtr("hello"), "post body", nil, nil, "general", nil)
 	if err != nil {
 		t.Fatalf("create post: %v", err)
 	}
@@ -50,7 +51,7 @@ func TestCreateReplyUpdatesAuthorLastActive(t *testing.T) {
 		t.Fatalf("create agent bob: %v", err)
 	}
 
-	post, err := CreatePost(ctx, database, "alice", strPtr("hello"), "post body", nil, nil, "general")
+	post, err := CreatePost(ctx, database, "alice", strPtr("hello"), "post body", nil, nil, "general", nil)
 	if err != nil {
 		t.Fatalf("create post: %v", err)
 	}
@@ -74,6 +75,51 @@ func TestCreateReplyUpdatesAuthorLastActive(t *testing.T) {
 	}
 }
 
+func TestCreatePostWithPollIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	database, dbPath := openTestDB(t, "content-post-poll.db")
+	defer database.Close()
+	defer os.Remove(dbPath)
+
+	if err := CreateAgent(ctx, database, "alice", "admin", "hash-alice", nil); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	countPosts := func() int {
+		t.Helper()
+		var n int
+		if err := database.QueryRowContext(ctx, `SELECT COUNT(*) FROM content`).Scan(&n); err != nil {
+			t.Fatalf("count content: %v", err)
+		}
+		return n
+	}
+
+	if _, err := CreatePost(ctx, database, "alice", strPtr("bad"), "one option", nil, nil, "general", &CreatePollParams{Options: []string{"only"}}); !errors.Is(err, ErrInvalidPoll) {
+		t.Fatalf("expected ErrInvalidPoll, got %v", err)
+	}
+	if _, err := database.ExecContext(ctx, `
+CREATE TRIGGER fail_poll_insert BEFORE INSERT ON polls
+BEGIN SELECT RAISE(ABORT, 'poll insert failed'); END`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+	if _, err := CreatePost(ctx, database, "alice", strPtr("lunch"), "where to?", nil, nil, "general", &CreatePollParams{Options: []string{"tacos", "ramen"}}); err == nil {
+		t.Fatal("expected the poll insert to fail")
+	}
+	if n := countPosts(); n != 0 {
+		t.Fatalf("a failed poll left %d posts behind", n)
+	}
+
+	if _, err := database.ExecContext(ctx, `DROP TRIGGER fail_poll_insert`); err != nil {
+		t.Fatalf("drop trigger: %v", err)
+	}
+	post, err := CreatePost(ctx, database, "alice", strPtr("lunch"), "where to?", nil, nil, "general", &CreatePollParams{Options: []string{"tacos", "ramen"}})
+	if err != nil {
+		t.Fatalf("create post with poll: %v", err)
+	}
+	if post.Poll == nil || len(post.Poll.Options) != 2 || post.Poll.Status != PollOpen {
+		t.Fatalf("unexpected poll: %+v", post.Poll)
+	}
+}
+
 func agentLastActive(t *testing.T, ctx context.Context, database *sql.DB, name string) sql.NullString {
 	t.Helper()
 	var lastActive sql.NullString
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"slices"
@@ -16,6 +17,9 @@ import (
 type ExportOptions struct {
 	ThreadID string
 	Since    *time.Time
+	// IncludeDirectMessages adds private conversations to JSON exports. They
+	// are left out of thread-scoped and markdown exports.
+	IncludeDirectMessages bool
 }
 
 type JSONExport struct {
@@ -26,6 +30,10 @@ type JSONExport struct {
 	Tags          map[string][]string   `json:"tags"`
 	Mentions      map[string][]string   `json:"mentions"`
 	Notifications []models.Notification `json:"notifications"`
+	Conversations []models.Conversation `json:"conversations,omitempty"`
+	Polls         []ExportedPoll        `json:"polls,omitempty"`
+	Groups        []Group               `json:"groups,omitempty"`
+	TagAliases    []TagAlias            `json:"tag_aliases,omitempty"`
 }
 
 type MarkdownFile struct {
@@ -38,10 +46,27 @@ func ExportJSON(ctx context.Context, database *sql.DB, opts ExportOptions) (*JSO
 	if err != nil {
 		return nil, err
 	}
+	for i := range agents {
+		profile, err := GetAgentProfile(ctx, database, agents[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		if profile.Updated != "" || len(profile.Capabilities) > 0 {
+			agents[i].Profile = profile
+		}
+	}
 	boards, err := ListBoards(ctx, database)
 	if err != nil {
 		return nil, err
 	}
+	groups, err := ListGroups(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := ListTagAliases(ctx, database)
+	if err != nil {
+		return nil, err
+	}
 	content, err := exportContent(ctx, database, opts)
 	if err != nil {
 		return nil, err
@@ -58,10 +83,21 @@ func ExportJSON(ctx context.Context, database *sql.DB, opts ExportOptions) (*JSO
 	if err != nil {
 		return nil, err
 	}
-	notifs, err := exportNotifications(ctx, database, ids)
+	polls, err := exportPolls(ctx, database, ids)
+	if err != nil {
+		return nil, err
+	}
+	directMessages := opts.IncludeDirectMessages && strings.TrimSpace(opts.ThreadID) == ""
+	notifs, err := exportNotifications(ctx, database, ids, directMessages)
 	if err != nil {
 		return nil, err
 	}
+	var conversations []models.Conversation
+	if directMessages {
+		if conversations, err = exportConversations(ctx, database, opts); err != nil {
+			return nil, err
+		}
+	}
 
 	return &JSONExport{
 		ExportedAt:    nowRFC3339(),
@@ -71,6 +107,10 @@ func ExportJSON(ctx context.Context, database *sql.DB, opts ExportOptions) (*JSO
 		Tags:          tags,
 		Mentions:      mentions,
 		Notifications: notifs,
+		Conversations: conversations,
+		Polls:         polls,
+		Groups:        groups,
+		TagAliases:    aliases,
 	}, nil
 }
 
@@ -183,6 +223,92 @@ WHERE 1=1`
 	return out, rows.Err()
 }
 
+// exportConversations returns every conversation with all of its messages;
+// with Since set, only conversations active since then.
+func exportConversations(ctx context.Context, database *sql.DB, opts ExportOptions) ([]models.Conversation, error) {
+	query := `SELECT id FROM conversations`
+	args := []any{}
+	if opts.Since != nil {
+		query += " WHERE updated >= ?"
+		args = append(args, opts.Since.UTC().Format(time.RFC3339))
+	}
+	rows, err := database.QueryContext(ctx, query+" ORDER BY created ASC", args...)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	out := make([]models.Conversation, 0, len(ids))
+	for _, id := range ids {
+		conv, err := GetConversation(ctx, database, "", id, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		conv.LastMessage = nil
+		out = append(out, *conv)
+	}
+	return out, nil
+}
+
+func exportPolls(ctx context.Context, database *sql.DB, contentIDs []string) ([]ExportedPoll, error) {
+	var out []ExportedPoll
+	for _, id := range contentIDs {
+		p := ExportedPoll{PostID: id, Options: []string{}, Votes: []PollVote{}}
+		err := database.QueryRowContext(ctx, `
+SELECT multiple, visibility, deadline, closed, created, updated
+FROM polls
+WHERE post_id = ?`, id).Scan(&p.Multiple, &p.Visibility, &p.Deadline, &p.Closed, &p.Created, &p.Updated)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows, err := database.QueryContext(ctx, `SELECT text FROM poll_options WHERE post_id = ? ORDER BY option_id ASC`, id)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var text string
+			if err := rows.Scan(&text); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			p.Options = append(p.Options, text)
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+		rows, err = database.QueryContext(ctx, `SELECT agent, option_id, created FROM poll_votes WHERE post_id = ? ORDER BY created ASC, agent ASC`, id)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var v PollVote
+			if err := rows.Scan(&v.Agent, &v.OptionID, &v.Created); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			p.Votes = append(p.Votes, v)
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
 func exportTags(ctx context.Context, database *sql.DB, contentIDs []string) (map[string][]string, error) {
 	out := map[string][]string{}
 	for _, id := range contentIDs {
@@ -221,7 +347,9 @@ func exportMentions(ctx context.Context, database *sql.DB, contentIDs []string)
 	return out, nil
 }
 
-func exportNotifications(ctx context.Context, database *sql.DB, contentIDs []string) ([]models.Notification, error) {
+// exportNotifications keeps notifications about the exported content, and
+// direct message notifications only when directMessages is set.
+func exportNotifications(ctx context.Context, database *sql.DB, contentIDs []string, directMessages bool) ([]models.Notification, error) {
 	if len(contentIDs) == 0 {
 		return nil, nil
 	}
@@ -243,6 +371,9 @@ ORDER BY created ASC`)
 			return nil, err
 		}
 		n.Read = readInt == 1
+		if n.Type == directMessageNotifyType && !directMessages {
+			continue
+		}
 		if n.ContentID == "" || slices.Contains(contentIDs, n.ContentID) {
 			out = append(out, n)
 		}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrGroupExists = errors.New("group already exists")
+	// ErrGroupNameTaken is returned for a group named like an agent, in any
+	// case: @name must keep meaning the agent.
+	ErrGroupNameTaken   = errors.New("name is taken by an agent")
+	ErrUnknownAgent     = errors.New("unknown agent")
+	ErrInvalidGroupName = errors.New("group name must be 1-64 letters, digits, '-' or '_' and start with a letter or digit")
+)
+
+// groupNamePattern matches what an @mention can name, so every group can be
+// mentioned.
+var groupNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,63}$`)
+
+type Group struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Members     []string `json:"members"`
+	Created     string   `json:"created"`
+}
+
+func CreateGroup(ctx context.Context, database *sql.DB, name, description string, members []string) (*Group, error) {
+	name = strings.TrimSpace(name)
+	if !groupNamePattern.MatchString(name) {
+		return nil, ErrInvalidGroupName
+	}
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var agents int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(1) FROM agents WHERE name = ? COLLATE NOCASE`, name).Scan(&agents); err != nil {
+		return nil, err
+	}
+	if agents > 0 {
+		return nil, ErrGroupNameTaken
+	}
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO agent_groups (name, description, created)
+VALUES (?, ?, ?)`, name, nullableString(strings.TrimSpace(description)), nowRFC3339()); err != nil {
+		if isUniqueConstraint(err) {
+			return nil, ErrGroupExists
+		}
+		return nil, err
+	}
+	if err := addGroupMembersTx(ctx, tx, name, members); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetGroup(ctx, database, name)
+}
+
+func ListGroups(ctx context.Context, database *sql.DB) ([]Group, error) {
+	rows, err := database.QueryContext(ctx, `
+SELECT g.name, COALESCE(g.description, ''), g.created,
+	COALESCE((SELECT group_concat(agent) FROM agent_group_members WHERE group_name = g.name), '')
+FROM agent_groups g
+ORDER BY g.name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]Group, 0)
+	for rows.Next() {
+		var (
+			g       Group
+			members string
+		)
+		if err := rows.Scan(&g.Name, &g.Description, &g.Created, &members); err != nil {
+			return nil, err
+		}
+		g.Members = splitSorted(members)
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+func GetGroup(ctx context.Context, database *sql.DB, name string) (*Group, error) {
+	g := &Group{}
+	var members string
+	err := database.QueryRowContext(ctx, `
+SELECT g.name, COALESCE(g.description, ''), g.created,
+	COALESCE((SELECT group_concat(agent) FROM agent_group_members WHERE group_name = g.name), '')
+FROM agent_groups g
+WHERE g.name = ?`, strings.TrimSpace(name)).Scan(&g.Name, &g.Description, &g.Created, &members)
+	if err != nil {
+		return nil, err
+	}
+	g.Members = splitSorted(members)
+	return g, nil
+}
+
+func DeleteGroup(ctx context.Context, database *sql.DB, name string) error {
+	res, err := database.ExecContext(ctx, `DELETE FROM agent_groups WHERE name = ?`, strings.TrimSpace(name))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AddGroupMembers adds agents to an existing group. Agents already in the
+// group are left alone; unknown agents fail the whole call.
+func AddGroupMembers(ctx context.Context, database *sql.DB, name string, agents []string) (*Group, error) {
+	name = strings.TrimSpace(name)
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	name, err = groupNameTx(ctx, tx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := addGroupMembersTx(ctx, tx, name, agents); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetGroup(ctx, database, name)
+}
+
+func RemoveGroupMembers(ctx context.Context, database *sql.DB, name string, agents []string) (*Group, error) {
+	name = strings.TrimSpace(name)
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	name, err = groupNameTx(ctx, tx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, agent := range dedupeTags(agents) {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM agent_group_members WHERE group_name = ? AND agent = ?`, name, agent); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetGroup(ctx, database, name)
+}
+
+// ListAgentGroups returns the names of the groups the agent belongs to.
+func ListAgentGroups(ctx context.Context, database *sql.DB, agent string) ([]string, error) {
+	rows, err := database.QueryContext(ctx, `
+SELECT group_name
+FROM agent_group_members
+WHERE agent = ?
+ORDER BY group_name ASC`, agent)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out = append(out, name)
+	}
+	return out, rows.Err()
+}
+
+// GroupExists reports whether a group has name. Group names compare
+// case-insensitively everywhere, as the name columns are COLLATE NOCASE.
+func GroupExists(ctx context.Context, database *sql.DB, name string) (bool, error) {
+	var count int
+	if err := database.QueryRowContext(ctx, `SELECT COUNT(1) FROM agent_groups WHERE name = ?`, strings.TrimSpace(name)).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// groupNameTx returns the stored spelling of the group called name in any
+// case, or sql.ErrNoRows.
+func groupNameTx(ctx context.Context, tx *sql.Tx, name string) (string, error) {
+	var found string
+	err := tx.QueryRowContext(ctx, `SELECT name FROM agent_groups WHERE name = ?`, name).Scan(&found)
+	return found, err
+}
+
+func addGroupMembersTx(ctx context.Context, tx *sql.Tx, name string, agents []string) error {
+	added := time.Now().UTC().Format(time.RFC3339)
+	var unknown []string
+	for _, agent := range dedupeTags(agents) {
+		ok, err := agentExistsTx(ctx, tx, agent)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			unknown = append(unknown, agent)
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO agent_group_members (group_name, agent, added)
+VALUES (?, ?, ?)`, name, agent, added); err != nil {
+			return err
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownAgent, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func listGroupMembersTx(ctx context.Context, tx *sql.Tx, name string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+SELECT agent
+FROM agent_group_members
+WHERE group_name = ?
+ORDER BY agent ASC`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	members := []string{}
+	for rows.Next() {
+		var member string
+		if err := rows.Scan(&member); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func splitSorted(joined string) []string {
+	if joined == "" {
+		return []string{}
+	}
+	out := strings.Split(joined, ",")
+	sort.Strings(out)
+	return out
+}
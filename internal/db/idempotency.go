@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// IdempotencyKeyTTL is how long a stored response can be replayed.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotentResponse is the stored outcome of a request made with an
+// Idempotency-Key. Status 0 means the original request is still running.
+type IdempotentResponse struct {
+	Method   string
+	Path     string
+	Status   int
+	Response string
+}
+
+// ClaimIdempotencyKey reserves key for agent. It returns nil when the caller
+// now owns the key, or the previously stored response.
+func ClaimIdempotencyKey(ctx context.Context, database *sql.DB, agent, key, method, path string) (*IdempotentResponse, error) {
+	now := time.Now().UTC()
+	if _, err := database.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE created_at < ?`,
+		now.Add(-IdempotencyKeyTTL).Format(time.RFC3339)); err != nil {
+		return nil, err
+	}
+	res, err := database.ExecContext(ctx, `
+INSERT INTO idempotency_keys (agent, key, method, path, created_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (agent, key) DO NOTHING`,
+		agent, key, method, path, now.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return nil, nil
+	}
+	var stored IdempotentResponse
+	err = database.QueryRowContext(ctx,
+		`SELECT method, path, status, response FROM idempotency_keys WHERE agent = ? AND key = ?`,
+		agent, key).Scan(&stored.Method, &stored.Path, &stored.Status, &stored.Response)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Expired or released between the insert and the read; try again.
+		return ClaimIdempotencyKey(ctx, database, agent, key, method, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func CompleteIdempotencyKey(ctx context.Context, database *sql.DB, agent, key string, status int, response string) error {
+	_, err := database.ExecContext(ctx,
+		`UPDATE idempotency_keys SET status = ?, response = ? WHERE agent = ? AND key = ?`,
+		status, response, agent, key)
+	return err
+}
+
+// ReleaseIdempotencyKey forgets a claimed key so the request can be retried.
+func ReleaseIdempotencyKey(ctx context.Context, database *sql.DB, agent, key string) error {
+	_, err := database.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE agent = ? AND key = ?`, agent, key)
+	return err
+}
@@ -36,7 +36,13 @@ func ImportJSON(ctx context.Context, database *sql.DB, filePath string) error {
 	if err := json.Unmarshal(b, &payload); err != nil {
 		return fmt.Errorf("parse json export: %w", err)
 	}
+	return ImportPayload(ctx, database, &payload)
+}
 
+// ImportPayload writes an already-decoded export into the database. Content
+// must list posts before replies, and parents before their children, so that
+// parent_id references resolve on insert.
+func ImportPayload(ctx context.Context, database *sql.DB, payload *JSONExport) error {
 	tx, err := database.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -44,18 +50,65 @@ func ImportJSON(ctx context.Context, database *sql.DB, filePath string) error {
 	defer tx.Rollback()
 
 	for _, a := range payload.Agents {
+		// Exports from before agent states carry no state; those agents
+		// import as active.
 		if _, err := tx.ExecContext(ctx, `
-INSERT OR IGNORE INTO agents (name, api_key, role, created, last_active, metadata)
-VALUES (?, ?, ?, ?, ?, ?)`,
-			a.Name, auth.HashAPIKey("imported:"+a.Name), a.Role, a.Created, a.LastActive, a.Metadata); err != nil {
+INSERT OR IGNORE INTO agents (name, api_key, role, created, last_active, metadata, state, state_reason, state_until, state_changed)
+VALUES (?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), 'active'), ?, ?, ?)`,
+			a.Name, auth.HashAPIKey("imported:"+a.Name), a.Role, a.Created, a.LastActive, a.Metadata,
+			a.State, a.StateReason, a.StateUntil, a.StateChanged); err != nil {
+			return err
+		}
+		if a.Profile == nil {
+			continue
+		}
+		p := a.Profile
+		updated := p.Updated
+		if updated == "" {
+			updated = a.Created
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO agent_profiles (agent, display_name, description, team, model, contact, updated)
+VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			a.Name, nullableString(p.DisplayName), nullableString(p.Description), nullableString(p.Team),
+			nullableString(p.Model), nullableString(p.Contact), updated); err != nil {
+			return err
+		}
+		for _, c := range p.Capabilities {
+			if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO agent_capabilities (agent, capability) VALUES (?, ?)`, a.Name, c); err != nil {
+				return err
+			}
+		}
+	}
+	for _, g := range payload.Groups {
+		if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO agent_groups (name, description, created)
+VALUES (?, ?, ?)`, g.Name, nullableString(g.Description), g.Created); err != nil {
+			return err
+		}
+		for _, m := range g.Members {
+			if err := ensureAgentForImportTx(ctx, tx, m); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO agent_group_members (group_name, agent, added)
+SELECT name, ?, ? FROM agent_groups WHERE name = ?`, m, g.Created, g.Name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, a := range payload.TagAliases {
+		if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO tag_aliases (alias, tag, created)
+VALUES (?, ?, ?)`, a.Alias, a.Tag, a.Created); err != nil {
 			return err
 		}
 	}
 	for _, b := range payload.Boards {
 		if _, err := tx.ExecContext(ctx, `
-INSERT OR IGNORE INTO boards (id, name, description, icon, created)
-VALUES (?, ?, ?, ?, ?)`,
-			b.ID, b.Name, nullableString(b.Description), nullableString(b.Icon), b.Created); err != nil {
+INSERT OR IGNORE INTO boards (id, name, description, icon, archived, sort_order, restrict_tags, created)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			b.ID, b.Name, nullableString(b.Description), nullableString(b.Icon), b.Archived, b.SortOrder, b.RestrictTags, b.Created); err != nil {
 			return err
 		}
 		for _, tag := range b.Tags {
@@ -73,10 +126,13 @@ VALUES (?, ?, ?, ?, ?)`,
 			c.BoardID = "general"
 		}
 		_, err := tx.ExecContext(ctx, `
-INSERT OR REPLACE INTO content (id, type, author, title, body, created, updated, thread_id, parent_id, status, board_id)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+INSERT INTO content (id, type, author, title, body, created, updated, thread_id, parent_id, status, board_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`+upsertContentSQL,
 			c.ID, c.Type, c.Author, c.Title, c.Body, c.Created, c.Updated, c.ThreadID, c.ParentID, c.Status, nullableString(c.BoardID))
-		return err
+		if err != nil {
+			return err
+		}
+		return replaceContentRefs(ctx, tx, c.ID, c.Body)
 	}
 	for _, c := range payload.Content {
 		if c.Type == "post" {
@@ -92,6 +148,13 @@ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			}
 		}
 	}
+	for _, c := range payload.Content {
+		if c.Type == "post" {
+			if err := rebuildThreadStatsTx(ctx, tx, c.ID); err != nil {
+				return err
+			}
+		}
+	}
 	for contentID, tags := range payload.Tags {
 		for _, t := range tags {
 			if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tags (content_id, tag) VALUES (?, ?)`, contentID, t); err != nil {
@@ -127,6 +190,60 @@ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			return err
 		}
 	}
+	for _, p := range payload.Polls {
+		if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO polls (post_id, multiple, visibility, deadline, closed, created, updated)
+VALUES (?, ?, ?, ?, ?, ?, ?)`, p.PostID, p.Multiple, p.Visibility, p.Deadline, p.Closed, p.Created, p.Updated); err != nil {
+			return err
+		}
+		for i, text := range p.Options {
+			if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO poll_options (post_id, option_id, text)
+VALUES (?, ?, ?)`, p.PostID, i+1, text); err != nil {
+				return err
+			}
+		}
+		for _, v := range p.Votes {
+			if err := ensureAgentForImportTx(ctx, tx, v.Agent); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO poll_votes (post_id, option_id, agent, created)
+VALUES (?, ?, ?, ?)`, p.PostID, v.OptionID, v.Agent, v.Created); err != nil {
+				return err
+			}
+		}
+	}
+	for _, c := range payload.Conversations {
+		if err := ensureAgentForImportTx(ctx, tx, c.CreatedBy); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO conversations (id, created_by, created, updated)
+VALUES (?, ?, ?, ?)`, c.ID, c.CreatedBy, c.Created, c.Updated); err != nil {
+			return err
+		}
+		for _, m := range c.Members {
+			if err := ensureAgentForImportTx(ctx, tx, m); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO conversation_members (conversation_id, agent, joined)
+VALUES (?, ?, ?)`, c.ID, m, c.Created); err != nil {
+				return err
+			}
+		}
+		for _, msg := range c.Messages {
+			if err := ensureAgentForImportTx(ctx, tx, msg.Author); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO direct_messages (id, conversation_id, author, body, created)
+VALUES (?, ?, ?, ?, ?)`, msg.ID, c.ID, msg.Author, msg.Body, msg.Created); err != nil {
+				return err
+			}
+		}
+	}
 
 	return tx.Commit()
 }
@@ -211,13 +328,16 @@ func ImportMarkdown(ctx context.Context, database *sql.DB, dir string) error {
 			return err
 		}
 		if _, err := tx.ExecContext(ctx, `
-INSERT OR REPLACE INTO content (id, type, author, title, body, created, updated, thread_id, parent_id, status, board_id)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+INSERT INTO content (id, type, author, title, body, created, updated, thread_id, parent_id, status, board_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`+upsertContentSQL,
 			record.content.ID, record.content.Type, record.content.Author, record.content.Title,
 			record.content.Body, record.content.Created, record.content.Updated, record.content.ThreadID,
 			record.content.ParentID, record.content.Status, nullableString(record.content.BoardID)); err != nil {
 			return err
 		}
+		if err := replaceContentRefs(ctx, tx, record.content.ID, record.content.Body); err != nil {
+			return err
+		}
 
 		for _, tag := range record.tags {
 			if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tags (content_id, tag) VALUES (?, ?)`, record.content.ID, tag); err != nil {
@@ -360,3 +480,19 @@ func parseTags(raw any) []string {
 		return nil
 	}
 }
+
+// upsertContentSQL updates re-imported content in place instead of replacing
+// the row, so its seq (and read markers) only move when the text changed and
+// the rows that reference it are kept.
+const upsertContentSQL = `
+ON CONFLICT (id) DO UPDATE SET
+	type = excluded.type,
+	author = excluded.author,
+	title = excluded.title,
+	body = excluded.body,
+	created = excluded.created,
+	updated = excluded.updated,
+	thread_id = excluded.thread_id,
+	parent_id = excluded.parent_id,
+	status = excluded.status,
+	board_id = excluded.board_id`
@@ -29,13 +29,28 @@ func TestImportJSONRoundTrip(t *testing.T) {
 	if err != nil {
 		t.Fatalf("create board: %v", err)
 	}
-	post, err := CreatePost(ctx, srcDB, "alice", strPtr("hello"), "body text", []string{"tag1"}, nil, productBoard.ID)
+	post, err := CreatePost(ctx, srcDB, "alice", strPtr("hello"), "body text", []string{"tag1"}, nil, productBoard.ID, nil)
 	if err != nil {
 		t.Fatalf("create post: %v", err)
 	}
 	if _, err := CreateReply(ctx, srcDB, "alice", post.ID, "reply body", nil); err != nil {
 		t.Fatalf("create reply: %v", err)
 	}
+	if err := CreateAgent(ctx, srcDB, "bob", "agent", "hash-bob", nil); err != nil {
+		t.Fatalf("create agent bob: %v", err)
+	}
+	if _, err := SetAgentState(ctx, srcDB, "bob", AgentSuspended, "spam", nil); err != nil {
+		t.Fatalf("suspend bob: %v", err)
+	}
+	if _, err := UpdateAgentProfile(ctx, srcDB, "alice", AgentProfileUpdate{Team: strPtr("platform"), Capabilities: &[]string{"go", "sql"}}); err != nil {
+		t.Fatalf("update profile: %v", err)
+	}
+	if _, err := CreateGroup(ctx, srcDB, "oncall", "pager rotation", []string{"alice", "bob"}); err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	if _, err := SetTagAlias(ctx, srcDB, "tag-one", "tag1"); err != nil {
+		t.Fatalf("set tag alias: %v", err)
+	}
 
 	exported, err := ExportJSON(ctx, srcDB, ExportOptions{})
 	if err != nil {
@@ -96,6 +111,35 @@ func TestImportJSONRoundTrip(t *testing.T) {
 	if importedPostBoardID != productBoard.ID {
 		t.Fatalf("unexpected imported post board_id: got %q want %q", importedPostBoardID, productBoard.ID)
 	}
+
+	bob, err := GetAgent(ctx, dstDB, "bob")
+	if err != nil {
+		t.Fatalf("get imported bob: %v", err)
+	}
+	if bob.State != AgentSuspended || bob.StateReason == nil || *bob.StateReason != "spam" {
+		t.Fatalf("import re-activated a suspended agent: %+v", bob)
+	}
+	profile, err := GetAgentProfile(ctx, dstDB, "alice")
+	if err != nil {
+		t.Fatalf("get imported profile: %v", err)
+	}
+	if profile.Team != "platform" || !slices.Equal(profile.Capabilities, []string{"go", "sql"}) {
+		t.Fatalf("unexpected imported profile: %+v", profile)
+	}
+	group, err := GetGroup(ctx, dstDB, "oncall")
+	if err != nil {
+		t.Fatalf("get imported group: %v", err)
+	}
+	if group.Description != "pager rotation" || !slices.Equal(group.Members, []string{"alice", "bob"}) {
+		t.Fatalf("unexpected imported group: %+v", group)
+	}
+	aliases, err := ListTagAliases(ctx, dstDB)
+	if err != nil {
+		t.Fatalf("list imported aliases: %v", err)
+	}
+	if len(aliases) != 1 || aliases[0].Alias != "tag-one" || aliases[0].Tag != "tag1" {
+		t.Fatalf("unexpected imported tag aliases: %+v", aliases)
+	}
 }
 
 func TestImportMarkdownRoundTrip(t *testing.T) {
@@ -108,7 +152,7 @@ func TestImportMarkdownRoundTrip(t *testing.T) {
 	if err := CreateAgent(ctx, srcDB, "bob", "admin", auth.HashAPIKey(apiKey), nil); err != nil {
 		t.Fatalf("create agent: %v", err)
 	}
-	post, err := CreatePost(ctx, srcDB, "bob", strPtr("md"), "body md", []string{"docs"}, nil, "general")
+	post, err := CreatePost(ctx, srcDB, "bob", strPtr("md"), "body md", []string{"docs"}, nil, "general", nil)
 	if err != nil {
 		t.Fatalf("create post: %v", err)
 	}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+
+	"fora/internal/metrics"
+	"fora/internal/tracing"
+)
+
+var queryDuration = metrics.NewHistogramVec(
+	"fora_db_query_duration_seconds",
+	"SQLite statement latency by operation.",
+	metrics.DefaultBuckets,
+	"op",
+)
+
+// instrumentedConnector wraps the sqlite driver so every statement issued
+// through database/sql is timed and traced, including those inside
+// transactions.
+type instrumentedConnector struct {
+	dsn     string
+	pragmas []string
+	driver  driver.Driver
+}
+
+func newInstrumentedConnector(dsn string, pragmas []string) driver.Connector {
+	return &instrumentedConnector{dsn: dsn, pragmas: pragmas, driver: &sqlite.Driver{}}
+}
+
+// Connect opens a connection and applies the pragmas to it, since settings
+// like foreign_keys and busy_timeout are per connection.
+func (c *instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		for _, pragma := range c.pragmas {
+			if _, err := execer.ExecContext(ctx, pragma, nil); err != nil {
+				_ = conn.Close()
+				return nil, fmt.Errorf("apply pragma %q: %w", pragma, err)
+			}
+		}
+	}
+	return &instrumentedConn{Conn: conn}, nil
+}
+
+func (c *instrumentedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type instrumentedConn struct {
+	driver.Conn
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	defer observeQuery(query, time.Now())
+	res, err := execer.ExecContext(ctx, query, args)
+	span.RecordError(err)
+	return res, err
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	defer observeQuery(query, time.Now())
+	rows, err := queryer.QueryContext(ctx, query, args)
+	span.RecordError(err)
+	return rows, err
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+const maxSpanStatement = 512
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, "db."+queryOp(query), tracing.KindClient)
+	if span != nil {
+		statement := strings.Join(strings.Fields(query), " ")
+		if len(statement) > maxSpanStatement {
+			statement = statement[:maxSpanStatement] + "..."
+		}
+		span.SetAttr("db.system", "sqlite")
+		span.SetAttr("db.statement", statement)
+	}
+	return ctx, span
+}
+
+func observeQuery(query string, started time.Time) {
+	queryDuration.Observe(time.Since(started).Seconds(), queryOp(query))
+}
+
+// queryOp reduces a statement to its leading keyword so the metric keeps a
+// small, fixed label set.
+func queryOp(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	switch op := strings.ToLower(fields[0]); op {
+	case "select", "insert", "update", "delete", "with", "pragma", "create", "drop", "alter", "vacuum":
+		return op
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"fora/internal/markdown"
+)
+
+// normalizeMentions merges explicit mentions with the @names in body. Names
+// inside code spans and fenced or indented code blocks are not mentions.
+func normalizeMentions(explicit []string, body string) []string {
+	candidates := make([]string, 0, len(explicit)+4)
+	for _, c := range explicit {
+		candidates = append(candidates, strings.TrimPrefix(strings.TrimSpace(c), "@"))
+	}
+	candidates = append(candidates, markdown.Extract(body).Mentions...)
+
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		key := strings.ToLower(c)
+		if c == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, c)
+	}
+	return out
+}
+
+// resolveMentionsTx maps mentions, case-insensitively, to agents. A group
+// that is not also an agent expands to its members. Recipients are
+// deduplicated, so an agent mentioned directly and through groups is notified
+// once; names that match neither are returned as unresolved.
+func resolveMentionsTx(ctx context.Context, tx *sql.Tx, mentions []string) ([]string, []string, error) {
+	recipients := make([]string, 0, len(mentions))
+	unresolved := []string{}
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			recipients = append(recipients, name)
+		}
+	}
+	for _, mention := range mentions {
+		name, isGroup, err := lookupMention(ctx, tx, mention)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch {
+		case name == "":
+			unresolved = append(unresolved, mention)
+		case isGroup:
+			members, err := listGroupMembersTx(ctx, tx, name)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, member := range members {
+				add(member)
+			}
+		default:
+			add(name)
+		}
+	}
+	return recipients, unresolved, nil
+}
+
+// UnresolvedMentions returns the mentions in explicit and body that match no
+// agent or group, for callers that reject such writes up front.
+func UnresolvedMentions(ctx context.Context, database *sql.DB, explicit []string, body string) ([]string, error) {
+	unresolved := []string{}
+	for _, mention := range normalizeMentions(explicit, body) {
+		name, _, err := lookupMention(ctx, database, mention)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			unresolved = append(unresolved, mention)
+		}
+	}
+	return unresolved, nil
+}
+
+// lookupMention returns the agent or group a mention names, preferring an
+// exact-case match and agents over groups. name is empty when nothing
+// matches.
+func lookupMention(ctx context.Context, q rowQuerier, mention string) (name string, isGroup bool, err error) {
+	err = q.QueryRowContext(ctx, `
+SELECT name, is_group FROM (
+	SELECT name, 0 AS is_group FROM agents WHERE name = ? COLLATE NOCASE
+	UNION ALL
+	SELECT name, 1 AS is_group FROM agent_groups WHERE name = ? COLLATE NOCASE
+)
+ORDER BY is_group ASC, name = ? DESC
+LIMIT 1`, mention, mention, mention).Scan(&name, &isGroup)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	return name, isGroup, err
+}
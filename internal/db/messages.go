@@ -0,0 +1,418 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"fora/internal/models"
+)
+
+// ErrInvalidMessage is returned for an empty or over-long body and for
+// conversations without another agent or with too many members.
+var ErrInvalidMessage = errors.New("invalid direct message")
+
+const (
+	maxDirectMessageLen     = 20000
+	maxConversationMembers  = 32
+	directMessageNotifyType = "direct_message"
+)
+
+// SendDirectMessage sends body from from to the agents in to. It reuses the
+// conversation with exactly these members, or starts one.
+func SendDirectMessage(ctx context.Context, database *sql.DB, from string, to []string, body string) (*models.Conversation, *models.DirectMessage, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	members := []string{from}
+	seen := map[string]bool{from: true}
+	for _, name := range to {
+		if name = strings.TrimPrefix(strings.TrimSpace(name), "@"); name == "" {
+			continue
+		}
+		var canonical string
+		err := tx.QueryRowContext(ctx, `
+SELECT name FROM agents
+WHERE name = ? COLLATE NOCASE
+ORDER BY name = ? DESC
+LIMIT 1`, name, name).Scan(&canonical)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, fmt.Errorf("%w: %s", ErrUnknownAgent, name)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if !seen[canonical] {
+			seen[canonical] = true
+			members = append(members, canonical)
+		}
+	}
+	if len(members) < 2 {
+		return nil, nil, fmt.Errorf("%w: name at least one other agent", ErrInvalidMessage)
+	}
+	if len(members) > maxConversationMembers {
+		return nil, nil, fmt.Errorf("%w: at most %d members", ErrInvalidMessage, maxConversationMembers)
+	}
+
+	conversationID, err := findConversationTx(ctx, tx, members)
+	if err != nil {
+		return nil, nil, err
+	}
+	if conversationID == "" {
+		if conversationID, err = randomID("conv_"); err != nil {
+			return nil, nil, err
+		}
+		now := nowRFC3339()
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO conversations (id, created_by, created, updated)
+VALUES (?, ?, ?, ?)`, conversationID, from, now, now); err != nil {
+			return nil, nil, err
+		}
+		for _, m := range members {
+			if _, err := tx.ExecContext(ctx, `
+INSERT INTO conversation_members (conversation_id, agent, joined)
+VALUES (?, ?, ?)`, conversationID, m, now); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	msg, err := addDirectMessageTx(ctx, tx, conversationID, from, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	conv, err := getConversationTx(ctx, tx, from, conversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conv, msg, tx.Commit()
+}
+
+// PostDirectMessage adds a message to a conversation the sender belongs to.
+// It returns sql.ErrNoRows when the conversation does not exist or the
+// sender is not a member.
+func PostDirectMessage(ctx context.Context, database *sql.DB, from, conversationID, body string) (*models.DirectMessage, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if ok, err := isConversationMemberTx(ctx, tx, conversationID, from); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, sql.ErrNoRows
+	}
+	msg, err := addDirectMessageTx(ctx, tx, conversationID, from, body)
+	if err != nil {
+		return nil, err
+	}
+	return msg, tx.Commit()
+}
+
+// ListConversations returns the agent's conversations, most recently active
+// first, each with its last message and the agent's unread count.
+func ListConversations(ctx context.Context, database *sql.DB, agent string, limit, offset int) ([]models.Conversation, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := database.QueryContext(ctx, `
+SELECT c.id
+FROM conversations c
+JOIN conversation_members m ON m.conversation_id = c.id AND m.agent = ?
+ORDER BY c.updated DESC, c.id DESC
+LIMIT ? OFFSET ?`, agent, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	out := make([]models.Conversation, 0, len(ids))
+	for _, id := range ids {
+		conv, err := getConversationTx(ctx, database, agent, id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *conv)
+	}
+	return out, nil
+}
+
+// GetConversation returns a conversation with a page of its messages, oldest
+// first. viewer must be a member; an empty viewer (admin access) skips the
+// check and reports no unread count.
+func GetConversation(ctx context.Context, database *sql.DB, viewer, id string, limit, offset int) (*models.Conversation, error) {
+	if viewer != "" {
+		if ok, err := isConversationMemberTx(ctx, database, id, viewer); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, sql.ErrNoRows
+		}
+	}
+	conv, err := getConversationTx(ctx, database, viewer, id)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := database.QueryContext(ctx, `
+SELECT id, conversation_id, author, body, created
+FROM direct_messages
+WHERE conversation_id = ?
+ORDER BY created ASC, rowid ASC
+LIMIT ? OFFSET ?`, id, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	conv.Messages = make([]models.DirectMessage, 0)
+	for rows.Next() {
+		var m models.DirectMessage
+		if err := rows.Scan(&m.ID, &m.ConversationID, &m.Author, &m.Body, &m.Created); err != nil {
+			return nil, err
+		}
+		conv.Messages = append(conv.Messages, m)
+	}
+	return conv, rows.Err()
+}
+
+// MarkConversationRead marks every message in the conversation read for
+// agent, along with its direct message notifications.
+func MarkConversationRead(ctx context.Context, database *sql.DB, agent, id string) error {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+UPDATE conversation_members
+SET last_read_rowid = (SELECT COALESCE(MAX(rowid), 0) FROM direct_messages WHERE conversation_id = ?)
+WHERE conversation_id = ? AND agent = ?`, id, id, agent); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+UPDATE notifications
+SET read = 1
+WHERE recipient = ? AND type = ? AND thread_id = ? AND read = 0`, agent, directMessageNotifyType, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type SearchDirectMessagesParams struct {
+	Query  string
+	Author string
+	Limit  int
+	Offset int
+}
+
+// SearchDirectMessages matches message bodies by substring. It is for
+// admins only: direct messages stay out of the public full-text index.
+func SearchDirectMessages(ctx context.Context, database *sql.DB, params SearchDirectMessagesParams) ([]models.DirectMessage, error) {
+	query := `
+SELECT id, conversation_id, author, body, created
+FROM direct_messages
+WHERE body LIKE ? ESCAPE '\'`
+	args := []any{"%" + escapeLike(params.Query) + "%"}
+	if params.Author != "" {
+		query += " AND author = ?"
+		args = append(args, params.Author)
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	query += " ORDER BY created DESC, rowid DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, params.Offset)
+	rows, err := database.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]models.DirectMessage, 0)
+	for rows.Next() {
+		var m models.DirectMessage
+		if err := rows.Scan(&m.ID, &m.ConversationID, &m.Author, &m.Body, &m.Created); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func addDirectMessageTx(ctx context.Context, tx *sql.Tx, conversationID, from, body string) (*models.DirectMessage, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, fmt.Errorf("%w: body is required", ErrInvalidMessage)
+	}
+	if len(body) > maxDirectMessageLen {
+		return nil, fmt.Errorf("%w: body must be at most %d bytes", ErrInvalidMessage, maxDirectMessageLen)
+	}
+	id, err := randomID("dm_")
+	if err != nil {
+		return nil, err
+	}
+	msg := &models.DirectMessage{ID: id, ConversationID: conversationID, Author: from, Body: body, Created: nowRFC3339()}
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO direct_messages (id, conversation_id, author, body, created)
+VALUES (?, ?, ?, ?, ?)`, msg.ID, msg.ConversationID, msg.Author, msg.Body, msg.Created)
+	if err != nil {
+		return nil, err
+	}
+	rowid, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE conversations SET updated = ? WHERE id = ?`, msg.Created, conversationID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+UPDATE conversation_members
+SET last_read_rowid = ?
+WHERE conversation_id = ? AND agent = ?`, rowid, conversationID, from); err != nil {
+		return nil, err
+	}
+
+	preview := body
+	if len(preview) > 200 {
+		preview = preview[:200]
+	}
+	recipients, err := listConversationMembersTx(ctx, tx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	for _, recipient := range recipients {
+		if recipient == from {
+			continue
+		}
+		// content_id stays empty: it references board content only.
+		if _, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO notifications (id, recipient, type, from_agent, thread_id, content_id, preview, created, read)
+VALUES (?, ?, ?, ?, ?, NULL, ?, ?, 0)`,
+			generateNotificationID(recipient+directMessageNotifyType+msg.ID),
+			recipient, directMessageNotifyType, from, conversationID, preview, msg.Created); err != nil {
+			return nil, err
+		}
+	}
+	if err := updateAgentLastActiveTx(ctx, tx, from, msg.Created); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+type queryer interface {
+	rowQuerier
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func getConversationTx(ctx context.Context, q queryer, viewer, id string) (*models.Conversation, error) {
+	conv := &models.Conversation{ID: id}
+	var lastID sql.NullString
+	err := q.QueryRowContext(ctx, `
+SELECT c.created_by, c.created, c.updated,
+	(SELECT COUNT(1) FROM direct_messages WHERE conversation_id = c.id),
+	(SELECT COUNT(1) FROM direct_messages d
+		WHERE d.conversation_id = c.id AND d.author != ?
+		AND d.rowid > COALESCE((SELECT last_read_rowid FROM conversation_members WHERE conversation_id = c.id AND agent = ?), 0)),
+	(SELECT id FROM direct_messages WHERE conversation_id = c.id ORDER BY created DESC, rowid DESC LIMIT 1)
+FROM conversations c
+WHERE c.id = ?`, viewer, viewer, id).Scan(&conv.CreatedBy, &conv.Created, &conv.Updated, &conv.MessageCount, &conv.UnreadCount, &lastID)
+	if err != nil {
+		return nil, err
+	}
+	if viewer == "" {
+		conv.UnreadCount = 0
+	}
+	if lastID.Valid {
+		m := models.DirectMessage{}
+		if err := q.QueryRowContext(ctx, `
+SELECT id, conversation_id, author, body, created
+FROM direct_messages
+WHERE id = ?`, lastID.String).Scan(&m.ID, &m.ConversationID, &m.Author, &m.Body, &m.Created); err != nil {
+			return nil, err
+		}
+		conv.LastMessage = &m
+	}
+
+	conv.Members, err = listConversationMembersTx(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+func listConversationMembersTx(ctx context.Context, q queryer, id string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT agent FROM conversation_members WHERE conversation_id = ? ORDER BY agent ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	members := make([]string, 0)
+	for rows.Next() {
+		var m string
+		if err := rows.Scan(&m); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// findConversationTx returns the conversation whose members are exactly
+// members, or "" when there is none.
+func findConversationTx(ctx context.Context, tx *sql.Tx, members []string) (string, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(members)), ", ")
+	args := make([]any, 0, len(members)+2)
+	for _, m := range members {
+		args = append(args, m)
+	}
+	args = append(args, len(members), len(members))
+	var id string
+	err := tx.QueryRowContext(ctx, `
+SELECT conversation_id
+FROM conversation_members
+GROUP BY conversation_id
+HAVING SUM(agent IN (`+placeholders+`)) = ? AND COUNT(1) = ?
+LIMIT 1`, args...).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return id, err
+}
+
+func isConversationMemberTx(ctx context.Context, q rowQuerier, conversationID, agent string) (bool, error) {
+	var n int
+	if err := q.QueryRowContext(ctx, `
+SELECT COUNT(1) FROM conversation_members
+WHERE conversation_id = ? AND agent = ?`, conversationID, agent).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func randomID(prefix string) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
@@ -1,14 +1,27 @@
 package db
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
 )
 
+// migration describes one schema step. A migration without down SQL cannot
+// be rolled back; destructive ones rebuild or drop tables that hold data and
+// get a VACUUM INTO snapshot before they run.
 type migration struct {
-	version int
-	name    string
-	sql     string
+	version     int
+	name        string
+	sql         string
+	down        string
+	destructive bool
 }
 
 var migrations = []migration{
@@ -21,11 +34,13 @@ var migrations = []migration{
 		version: 2,
 		name:    "edit_history",
 		sql:     editHistorySchemaV2,
+		down:    editHistorySchemaV2Down,
 	},
 	{
 		version: 3,
 		name:    "webhooks",
 		sql:     webhooksSchemaV3,
+		down:    webhooksSchemaV3Down,
 	},
 	{
 		version: 4,
@@ -33,23 +48,165 @@ var migrations = []migration{
 		sql:     channelsSchemaV4,
 	},
 	{
-		version: 5,
-		name:    "boards",
-		sql:     boardsSchemaV5,
+		version:     5,
+		name:        "boards",
+		sql:         boardsSchemaV5,
+		destructive: true,
 	},
 	{
 		version: 6,
 		name:    "system_settings",
 		sql:     systemSettingsSchemaV6,
+		down:    systemSettingsSchemaV6Down,
 	},
 	{
-		version: 7,
-		name:    "fix_archived_status",
-		sql:     fixArchivedStatusSchemaV7,
+		version:     7,
+		name:        "fix_archived_status",
+		sql:         fixArchivedStatusSchemaV7,
+		destructive: true,
 	},
+	{
+		version: 8,
+		name:    "idempotency_keys",
+		sql:     idempotencyKeysSchemaV8,
+		down:    idempotencyKeysSchemaV8Down,
+	},
+	{
+		version: 9,
+		name:    "thread_reads",
+		sql:     threadReadsSchemaV9,
+		down:    threadReadsSchemaV9Down,
+	},
+	{
+		version: 10,
+		name:    "attachments",
+		sql:     attachmentsSchemaV10,
+		down:    attachmentsSchemaV10Down,
+	},
+	{
+		version: 11,
+		name:    "content_refs",
+		sql:     contentRefsSchemaV11,
+		down:    contentRefsSchemaV11Down,
+	},
+	{
+		version: 12,
+		name:    "board_lifecycle",
+		sql:     boardLifecycleSchemaV12,
+		down:    boardLifecycleSchemaV12Down,
+	},
+	{
+		version: 13,
+		name:    "tag_taxonomy",
+		sql:     tagTaxonomySchemaV13,
+		down:    tagTaxonomySchemaV13Down,
+	},
+	{
+		version: 14,
+		name:    "agent_profiles",
+		sql:     agentProfilesSchemaV14,
+		down:    agentProfilesSchemaV14Down,
+	},
+	{
+		version: 15,
+		name:    "agent_groups",
+		sql:     agentGroupsSchemaV15,
+		down:    agentGroupsSchemaV15Down,
+	},
+	{
+		version: 16,
+		name:    "agent_presence",
+		sql:     agentPresenceSchemaV16,
+		down:    agentPresenceSchemaV16Down,
+	},
+	{
+		version: 17,
+		name:    "agent_state",
+		sql:     agentStateSchemaV17,
+		down:    agentStateSchemaV17Down,
+	},
+	{
+		version:     18,
+		name:        "direct_messages",
+		sql:         directMessagesSchemaV18,
+		down:        directMessagesSchemaV18Down,
+		destructive: true,
+	},
+	{
+		version: 19,
+		name:    "polls",
+		sql:     pollsSchemaV19,
+		down:    pollsSchemaV19Down,
+	},
+	{
+		version: 20,
+		name:    "content_seq",
+		sql:     contentSeqSchemaV20,
+		down:    contentSeqSchemaV20Down,
+	},
+	{
+		version:     21,
+		name:        "group_names_nocase",
+		sql:         groupNamesNocaseSchemaV21,
+		down:        groupNamesNocaseSchemaV21Down,
+		destructive: true,
+	},
+}
+
+var (
+	// ErrSchemaTooNew is returned when the database has migrations applied
+	// that this binary does not know about.
+	ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+	// ErrMigrationDrift is returned when the SQL of an applied migration no
+	// longer matches the checksum recorded when it ran.
+	ErrMigrationDrift = errors.New("applied migration checksum does not match binary")
+)
+
+// MigrationState reports one known or applied migration for `migrate status`.
+type MigrationState struct {
+	Version     int    `json:"version"`
+	Name        string `json:"name"`
+	Applied     bool   `json:"applied"`
+	AppliedAt   string `json:"applied_at,omitempty"`
+	Checksum    string `json:"checksum"`
+	Drifted     bool   `json:"drifted"`
+	Known       bool   `json:"known"`
+	Destructive bool   `json:"destructive"`
+	Reversible  bool   `json:"reversible"`
+}
+
+// MigrationStep is one planned migration run in either direction.
+type MigrationStep struct {
+	Version     int
+	Name        string
+	Direction   string
+	SQL         string
+	Destructive bool
+}
+
+// MigrateResult lists the versions that ran and the snapshot taken, if any.
+type MigrateResult struct {
+	Applied  []int
+	Snapshot string
+}
+
+func LatestMigrationVersion() int {
+	return migrations[len(migrations)-1].version
 }
 
+func migrationChecksum(m migration) string {
+	sum := sha256.Sum256([]byte(m.sql))
+	return hex.EncodeToString(sum[:])
+}
+
+// ApplyMigrations brings the schema up to date, snapshotting the database
+// first when a pending migration is destructive.
 func ApplyMigrations(database *sql.DB) error {
+	_, err := MigrateUp(database, 0)
+	return err
+}
+
+func ensureSchemaVersionTable(database *sql.DB) error {
 	if _, err := database.Exec(`
 CREATE TABLE IF NOT EXISTS schema_version (
 	version     INTEGER PRIMARY KEY,
@@ -59,49 +216,329 @@ CREATE TABLE IF NOT EXISTS schema_version (
 		return fmt.Errorf("ensure schema_version table: %w", err)
 	}
 
+	hasChecksum, err := columnExists(database, "schema_version", "checksum")
+	if err != nil {
+		return err
+	}
+	if !hasChecksum {
+		if _, err := database.Exec(`ALTER TABLE schema_version ADD COLUMN checksum TEXT`); err != nil {
+			return fmt.Errorf("add schema_version checksum: %w", err)
+		}
+	}
+
+	// Rows written before checksums existed are trusted as-is.
 	for _, m := range migrations {
-		applied, err := migrationApplied(database, m.version)
-		if err != nil {
-			return fmt.Errorf("check migration %d: %w", m.version, err)
+		if _, err := database.Exec(
+			`UPDATE schema_version SET checksum = ? WHERE version = ? AND (checksum IS NULL OR checksum = '')`,
+			migrationChecksum(m), m.version,
+		); err != nil {
+			return fmt.Errorf("backfill checksum %d: %w", m.version, err)
 		}
-		if applied {
-			continue
+	}
+	return nil
+}
+
+func columnExists(database *sql.DB, table, column string) (bool, error) {
+	rows, err := database.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid        int
+			name, typ  string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &defaultVal, &pk); err != nil {
+			return false, err
 		}
-		if err := applyMigration(database, m); err != nil {
-			return fmt.Errorf("apply migration %d (%s): %w", m.version, m.name, err)
+		if name == column {
+			return true, nil
 		}
 	}
+	return false, rows.Err()
+}
+
+// MigrationStatus lists every known migration plus any applied version the
+// binary does not recognise.
+func MigrationStatus(database *sql.DB) ([]MigrationState, error) {
+	if err := ensureSchemaVersionTable(database); err != nil {
+		return nil, err
+	}
+	rows, err := database.Query(`SELECT version, name, applied_at, COALESCE(checksum, '') FROM schema_version ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	applied := map[int]MigrationState{}
+	for rows.Next() {
+		var s MigrationState
+		if err := rows.Scan(&s.Version, &s.Name, &s.AppliedAt, &s.Checksum); err != nil {
+			return nil, err
+		}
+		s.Applied = true
+		applied[s.Version] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationState{
+			Version:     m.version,
+			Name:        m.name,
+			Checksum:    migrationChecksum(m),
+			Known:       true,
+			Destructive: m.destructive,
+			Reversible:  m.down != "",
+		}
+		if a, ok := applied[m.version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.AppliedAt
+			s.Drifted = a.Checksum != s.Checksum
+			delete(applied, m.version)
+		}
+		out = append(out, s)
+	}
+	for _, a := range applied {
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// checkMigrationState refuses to continue when the schema is ahead of the
+// binary or an applied migration has drifted.
+func checkMigrationState(states []MigrationState) error {
+	var drifted []string
+	for _, s := range states {
+		if s.Applied && !s.Known {
+			return fmt.Errorf("%w: version %d (%s) applied, binary knows up to %d", ErrSchemaTooNew, s.Version, s.Name, LatestMigrationVersion())
+		}
+		if s.Drifted {
+			drifted = append(drifted, fmt.Sprintf("%d (%s)", s.Version, s.Name))
+		}
+	}
+	if len(drifted) > 0 {
+		return fmt.Errorf("%w: %s", ErrMigrationDrift, strings.Join(drifted, ", "))
+	}
 	return nil
 }
 
-func migrationApplied(database *sql.DB, version int) (bool, error) {
-	var count int
-	if err := database.QueryRow(
-		"SELECT COUNT(1) FROM schema_version WHERE version = ?",
-		version,
-	).Scan(&count); err != nil {
-		return false, err
+func currentVersion(states []MigrationState) int {
+	current := 0
+	for _, s := range states {
+		if s.Applied && s.Version > current {
+			current = s.Version
+		}
+	}
+	return current
+}
+
+// PlanMigrations returns the steps needed to move the schema to target. A
+// target of 0 means the latest known version.
+func PlanMigrations(database *sql.DB, target int) ([]MigrationStep, error) {
+	states, err := MigrationStatus(database)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMigrationState(states); err != nil {
+		return nil, err
+	}
+	return planFromStates(states, target)
+}
+
+func planFromStates(states []MigrationState, target int) ([]MigrationStep, error) {
+	if target == 0 {
+		target = LatestMigrationVersion()
+	}
+	if target < 0 || target > LatestMigrationVersion() {
+		return nil, fmt.Errorf("unknown target version %d", target)
+	}
+	applied := map[int]bool{}
+	for _, s := range states {
+		applied[s.Version] = s.Applied
+	}
+
+	steps := make([]MigrationStep, 0)
+	for _, m := range migrations {
+		if m.version <= target && !applied[m.version] {
+			steps = append(steps, MigrationStep{Version: m.version, Name: m.name, Direction: "up", SQL: m.sql, Destructive: m.destructive})
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.version <= target || !applied[m.version] {
+			continue
+		}
+		if m.down == "" {
+			return nil, fmt.Errorf("migration %d (%s) is irreversible", m.version, m.name)
+		}
+		steps = append(steps, MigrationStep{Version: m.version, Name: m.name, Direction: "down", SQL: m.down, Destructive: true})
+	}
+	return steps, nil
+}
+
+// MigrateUp applies pending migrations up to target (0 for latest).
+func MigrateUp(database *sql.DB, target int) (MigrateResult, error) {
+	return migrate(database, target, "up")
+}
+
+// MigrateDown rolls applied migrations back until target is the newest
+// applied version.
+func MigrateDown(database *sql.DB, target int) (MigrateResult, error) {
+	if target < 0 {
+		return MigrateResult{}, fmt.Errorf("unknown target version %d", target)
+	}
+	if target == 0 {
+		return MigrateResult{}, errors.New("down migration needs an explicit target version")
+	}
+	return migrate(database, target, "down")
+}
+
+func migrate(database *sql.DB, target int, direction string) (MigrateResult, error) {
+	var result MigrateResult
+	states, err := MigrationStatus(database)
+	if err != nil {
+		return result, err
+	}
+	if err := checkMigrationState(states); err != nil {
+		return result, err
+	}
+	current := currentVersion(states)
+	if direction == "down" && target > current {
+		return result, fmt.Errorf("target %d is ahead of current version %d", target, current)
+	}
+	if direction == "up" && target != 0 && target < current {
+		return result, fmt.Errorf("target %d is behind current version %d; use down", target, current)
+	}
+	steps, err := planFromStates(states, target)
+	if err != nil {
+		return result, err
+	}
+
+	// A fresh database has nothing worth snapshotting.
+	if current > 0 {
+		for _, step := range steps {
+			if step.Destructive {
+				path, err := snapshotDatabase(database, fmt.Sprintf("pre-%s-v%d", step.Direction, step.Version))
+				if err != nil {
+					return result, fmt.Errorf("snapshot before migration %d: %w", step.Version, err)
+				}
+				result.Snapshot = path
+				break
+			}
+		}
+	}
+
+	for _, step := range steps {
+		if step.Direction == "up" {
+			err = applyMigration(database, migrationByVersion(step.Version))
+		} else {
+			err = revertMigration(database, migrationByVersion(step.Version))
+		}
+		if err != nil {
+			return result, fmt.Errorf("%s migration %d (%s): %w", step.Direction, step.Version, step.Name, err)
+		}
+		result.Applied = append(result.Applied, step.Version)
 	}
-	return count > 0, nil
+	return result, nil
+}
+
+func migrationByVersion(version int) migration {
+	for _, m := range migrations {
+		if m.version == version {
+			return m
+		}
+	}
+	return migration{}
+}
+
+// snapshotDatabase writes a consistent copy next to the database file with
+// VACUUM INTO. In-memory databases are skipped.
+func snapshotDatabase(database *sql.DB, label string) (string, error) {
+	file, err := FilePath(database)
+	if err != nil {
+		return "", err
+	}
+	if file == "" {
+		return "", nil
+	}
+	path := fmt.Sprintf("%s.%s-%s.bak", file, label, time.Now().UTC().Format("20060102T150405Z"))
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("snapshot %s already exists", path)
+	}
+	if _, err := database.Exec(`VACUUM INTO ?`, path); err != nil {
+		return "", err
+	}
+	return path, nil
 }
 
 func applyMigration(database *sql.DB, m migration) error {
-	tx, err := database.Begin()
+	return inMigrationTx(database, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(m.sql); err != nil {
+			return err
+		}
+		_, err := tx.Exec(
+			"INSERT INTO schema_version (version, name, applied_at, checksum) VALUES (?, ?, datetime('now'), ?)",
+			m.version, m.name, migrationChecksum(m),
+		)
+		return err
+	})
+}
+
+func revertMigration(database *sql.DB, m migration) error {
+	return inMigrationTx(database, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(m.down); err != nil {
+			return err
+		}
+		_, err := tx.Exec("DELETE FROM schema_version WHERE version = ?", m.version)
+		return err
+	})
+}
+
+// inMigrationTx runs fn in a transaction with foreign key enforcement off,
+// following SQLite's table-rebuild procedure: dropping a referenced table
+// would otherwise fail or cascade into its children. Integrity is checked
+// with foreign_key_check before commit. The pragma is a no-op inside a
+// transaction, so it is set on a dedicated connection first.
+func inMigrationTx(database *sql.DB, fn func(tx *sql.Tx) error) error {
+	ctx := context.Background()
+	conn, err := database.Conn(ctx)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	defer conn.Close()
 
-	if _, err := tx.Exec(m.sql); err != nil {
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
 		return err
 	}
-	if _, err := tx.Exec(
-		"INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, datetime('now'))",
-		m.version, m.name,
-	); err != nil {
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
+	if err := fn(tx); err != nil {
+		return err
+	}
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	violation := rows.Next()
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if violation {
+		return errors.New("foreign key check failed after migration")
+	}
 	return tx.Commit()
 }
@@ -2,7 +2,11 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -30,3 +34,254 @@ func TestMigrationsIdempotentAndLatestVersionApplied(t *testing.T) {
 		t.Fatalf("expected latest schema version >=5, got %d", latest)
 	}
 }
+
+func TestMigrateDownRefusesIrreversibleMigration(t *testing.T) {
+	database, _ := openTestDB(t, "irreversible.db")
+	defer database.Close()
+
+	if _, err := MigrateDown(database, 5); err == nil {
+		t.Fatal("expected irreversible migration 7 to block down")
+	}
+	states, err := MigrationStatus(database)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	for _, s := range states {
+		if !s.Applied {
+			t.Fatalf("expected failed down to leave v%d applied", s.Version)
+		}
+	}
+}
+
+func TestMigrateUpSnapshotsBeforeDestructiveMigration(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "snapshot.db")
+	database, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+
+	if _, err := MigrateUp(database, 6); err != nil {
+		t.Fatalf("migrate to 6: %v", err)
+	}
+	steps, err := PlanMigrations(database, 7)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if len(steps) != 1 || steps[0].Version != 7 || !steps[0].Destructive {
+		t.Fatalf("unexpected plan: %+v", steps)
+	}
+
+	result, err := MigrateUp(database, 7)
+	if err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if result.Snapshot == "" {
+		t.Fatal("expected snapshot before destructive migration")
+	}
+	snapshot, err := Open(result.Snapshot)
+	if err != nil {
+		t.Fatalf("open snapshot: %v", err)
+	}
+	defer snapshot.Close()
+	var latest int
+	if err := snapshot.QueryRow(`SELECT MAX(version) FROM schema_version`).Scan(&latest); err != nil {
+		t.Fatalf("read snapshot version: %v", err)
+	}
+	if latest != 6 {
+		t.Fatalf("expected snapshot at version 6, got %d", latest)
+	}
+
+	// v6 is reversible on its own once v7 is out of the way.
+	if _, err := database.Exec(`DELETE FROM schema_version WHERE version = 7`); err != nil {
+		t.Fatalf("drop v7 row: %v", err)
+	}
+	if _, err := MigrateDown(database, 5); err != nil {
+		t.Fatalf("migrate down to 5: %v", err)
+	}
+	var tables int
+	if err := database.QueryRow(`SELECT COUNT(1) FROM sqlite_master WHERE name = 'system_settings'`).Scan(&tables); err != nil {
+		t.Fatalf("check table: %v", err)
+	}
+	if tables != 0 {
+		t.Fatal("expected system_settings to be dropped")
+	}
+}
+
+func TestMigrationsRefuseDriftAndNewerSchema(t *testing.T) {
+	database, _ := openTestDB(t, "drift.db")
+	defer database.Close()
+
+	if _, err := database.Exec(`UPDATE schema_version SET checksum = 'tampered' WHERE version = 3`); err != nil {
+		t.Fatalf("tamper checksum: %v", err)
+	}
+	states, err := MigrationStatus(database)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !states[2].Drifted {
+		t.Fatalf("expected drift on v3, got %+v", states[2])
+	}
+	if err := ApplyMigrations(database); !errors.Is(err, ErrMigrationDrift) {
+		t.Fatalf("expected drift error, got %v", err)
+	}
+
+	if _, err := database.Exec(`UPDATE schema_version SET checksum = NULL WHERE version = 3`); err != nil {
+		t.Fatalf("reset checksum: %v", err)
+	}
+	if _, err := database.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (999, 'from_the_future', datetime('now'))`); err != nil {
+		t.Fatalf("insert future version: %v", err)
+	}
+	if err := ApplyMigrations(database); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("expected schema too new error, got %v", err)
+	}
+}
+
+func TestMigrateUpRebuildKeepsDependentRows(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "rebuild.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+
+	if _, err := MigrateUp(database, 6); err != nil {
+		t.Fatalf("migrate to 6: %v", err)
+	}
+	for _, stmt := range []string{
+		`INSERT INTO agents (name, api_key, created) VALUES ('alice', 'k', '2024-01-01T00:00:00Z')`,
+		`INSERT INTO content (id, type, author, title, body, created, updated, thread_id, status, board_id)
+		 VALUES ('p1', 'post', 'alice', 't', 'b', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z', 'p1', 'open', 'general')`,
+		`INSERT INTO tags (content_id, tag) VALUES ('p1', 'infra')`,
+	} {
+		if _, err := database.Exec(stmt); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	if _, err := MigrateUp(database, 0); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	var tags int
+	if err := database.QueryRow(`SELECT COUNT(1) FROM tags WHERE content_id = 'p1'`).Scan(&tags); err != nil {
+		t.Fatalf("count tags: %v", err)
+	}
+	if tags != 1 {
+		t.Fatalf("expected tag to survive content rebuild, got %d", tags)
+	}
+	var fk int
+	if err := database.QueryRow(`PRAGMA foreign_keys`).Scan(&fk); err != nil || fk != 1 {
+		t.Fatalf("expected foreign keys re-enabled, got %d (%v)", fk, err)
+	}
+}
+
+func TestGroupNamesNocaseMergesCaseDuplicates(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "groups.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+
+	if _, err := MigrateUp(database, 20); err != nil {
+		t.Fatalf("migrate to 20: %v", err)
+	}
+	for _, stmt := range []string{
+		`INSERT INTO agents (name, api_key, created) VALUES ('alice', 'k1', '2024-01-01T00:00:00Z'), ('bob', 'k2', '2024-01-01T00:00:00Z')`,
+		`INSERT INTO agent_groups (name, created) VALUES ('Devs', '2024-01-01T00:00:00Z'), ('devs', '2024-02-01T00:00:00Z')`,
+		`INSERT INTO agent_group_members (group_name, agent, added) VALUES
+			('Devs', 'alice', '2024-01-01T00:00:00Z'),
+			('devs', 'alice', '2024-02-01T00:00:00Z'),
+			('devs', 'bob', '2024-02-01T00:00:00Z')`,
+	} {
+		if _, err := database.Exec(stmt); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	if _, err := MigrateUp(database, 21); err != nil {
+		t.Fatalf("migrate to 21: %v", err)
+	}
+	groups, err := ListGroups(context.Background(), database)
+	if err != nil {
+		t.Fatalf("list groups: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Name != "Devs" || !slices.Equal(groups[0].Members, []string{"alice", "bob"}) {
+		t.Fatalf("expected one merged Devs group, got %+v", groups)
+	}
+	if _, err := CreateGroup(context.Background(), database, "DEVS", "", nil); !errors.Is(err, ErrGroupExists) {
+		t.Fatalf("expected ErrGroupExists for DEVS, got %v", err)
+	}
+
+	if _, err := MigrateDown(database, 20); err != nil {
+		t.Fatalf("migrate down to 20: %v", err)
+	}
+	if g, err := GetGroup(context.Background(), database, "Devs"); err != nil || len(g.Members) != 2 {
+		t.Fatalf("expected Devs to survive the down migration, got %+v (%v)", g, err)
+	}
+}
+
+func TestContentRefsBackfillRunsAgainAfterDownAndUp(t *testing.T) {
+	ctx := context.Background()
+	database, _ := openTestDB(t, "refs-down-up.db")
+	defer database.Close()
+
+	if err := CreateAgent(ctx, database, "alice", "agent", "hash-alice", nil); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	if _, err := CreatePost(ctx, database, "alice", strPtr("links"), "see https://example.com/docs", nil, nil, "general", nil); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	if err := IndexContentRefs(ctx, database); err != nil {
+		t.Fatalf("index refs: %v", err)
+	}
+	if _, err := MigrateDown(database, 10); err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	if _, err := MigrateUp(database, 0); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if err := IndexContentRefs(ctx, database); err != nil {
+		t.Fatalf("index refs: %v", err)
+	}
+	var n int
+	if err := database.QueryRowContext(ctx, `SELECT COUNT(1) FROM content_refs WHERE kind = 'link'`).Scan(&n); err != nil {
+		t.Fatalf("count refs: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected the link to be re-indexed after down and up, got %d refs", n)
+	}
+}
+
+func TestInMigrationTxChecksForeignKeysAndRestoresThem(t *testing.T) {
+	database, err := Open(filepath.Join(t.TempDir(), "fk.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	// One connection, so the pragma read below sees the one the
+	// migration ran on.
+	database.SetMaxOpenConns(1)
+	if err := ApplyMigrations(database); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+
+	// Enforcement is off inside the transaction, so the orphan row is
+	// accepted by the insert and caught by foreign_key_check instead.
+	err = inMigrationTx(database, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO tags (content_id, tag) VALUES ('missing', 'orphan')`)
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "foreign key check failed") {
+		t.Fatalf("expected foreign key check failure, got %v", err)
+	}
+	var tags int
+	if err := database.QueryRow(`SELECT COUNT(1) FROM tags WHERE tag = 'orphan'`).Scan(&tags); err != nil {
+		t.Fatalf("count tags: %v", err)
+	}
+	if tags != 0 {
+		t.Fatalf("failed migration committed %d rows", tags)
+	}
+	var fk int
+	if err := database.QueryRow(`PRAGMA foreign_keys`).Scan(&fk); err != nil || fk != 1 {
+		t.Fatalf("expected foreign keys re-enabled, got %d (%v)", fk, err)
+	}
+}
@@ -0,0 +1,369 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"fora/internal/models"
+)
+
+const (
+	PollPublic    = "public"
+	PollAnonymous = "anonymous"
+
+	PollOpen   = "open"
+	PollClosed = "closed"
+
+	minPollOptions   = 2
+	maxPollOptions   = 20
+	maxPollOptionLen = 200
+)
+
+var (
+	ErrInvalidPoll = errors.New("invalid poll")
+	ErrPollExists  = errors.New("post already has a poll")
+	ErrPollClosed  = errors.New("poll is closed")
+)
+
+type CreatePollParams struct {
+	Options  []string
+	Multiple bool
+	// Visibility is PollPublic (the default) or PollAnonymous.
+	Visibility string
+	// Deadline closes the poll; without one it stays open until closed by
+	// hand.
+	Deadline *time.Time
+}
+
+// ValidatePoll checks a poll before the post carrying it is created.
+func ValidatePoll(p CreatePollParams) error {
+	_, err := normalizePollParams(p)
+	return err
+}
+
+func normalizePollParams(p CreatePollParams) (CreatePollParams, error) {
+	options := make([]string, 0, len(p.Options))
+	seen := map[string]bool{}
+	for _, o := range p.Options {
+		o = strings.TrimSpace(o)
+		if o == "" {
+			return p, fmt.Errorf("%w: options must not be empty", ErrInvalidPoll)
+		}
+		if len(o) > maxPollOptionLen {
+			return p, fmt.Errorf("%w: options must be at most %d bytes", ErrInvalidPoll, maxPollOptionLen)
+		}
+		key := strings.ToLower(o)
+		if seen[key] {
+			return p, fmt.Errorf("%w: duplicate option %q", ErrInvalidPoll, o)
+		}
+		seen[key] = true
+		options = append(options, o)
+	}
+	if len(options) < minPollOptions || len(options) > maxPollOptions {
+		return p, fmt.Errorf("%w: a poll needs %d to %d options", ErrInvalidPoll, minPollOptions, maxPollOptions)
+	}
+	p.Options = options
+
+	p.Visibility = strings.ToLower(strings.TrimSpace(p.Visibility))
+	switch p.Visibility {
+	case "":
+		p.Visibility = PollPublic
+	case PollPublic, PollAnonymous:
+	default:
+		return p, fmt.Errorf("%w: visibility must be public or anonymous", ErrInvalidPoll)
+	}
+	if p.Deadline != nil && !p.Deadline.After(time.Now()) {
+		return p, fmt.Errorf("%w: deadline must be in the future", ErrInvalidPoll)
+	}
+	return p, nil
+}
+
+// createPollTx attaches a poll to a post. Replies cannot carry polls, and p
+// must already be normalized.
+func createPollTx(ctx context.Context, tx *sql.Tx, postID string, p CreatePollParams) (*models.Poll, error) {
+	var deadline *string
+	if p.Deadline != nil {
+		v := p.Deadline.UTC().Format(time.RFC3339)
+		deadline = &v
+	}
+	var contentType string
+	if err := tx.QueryRowContext(ctx, `SELECT type FROM content WHERE id = ?`, postID).Scan(&contentType); err != nil {
+		return nil, err
+	}
+	if contentType != "post" {
+		return nil, fmt.Errorf("%w: only posts can carry a poll", ErrInvalidPoll)
+	}
+	now := nowRFC3339()
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO polls (post_id, multiple, visibility, deadline, created, updated)
+VALUES (?, ?, ?, ?, ?, ?)`, postID, p.Multiple, p.Visibility, deadline, now, now); err != nil {
+		if isUniqueConstraint(err) {
+			return nil, ErrPollExists
+		}
+		return nil, err
+	}
+	for i, text := range p.Options {
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO poll_options (post_id, option_id, text)
+VALUES (?, ?, ?)`, postID, i+1, text); err != nil {
+			return nil, err
+		}
+	}
+	return getPollTx(ctx, tx, postID, "")
+}
+
+// GetPoll returns the poll on a post with its tally. MyVotes is filled in
+// for viewer when viewer is not empty.
+func GetPoll(ctx context.Context, database *sql.DB, postID, viewer string) (*models.Poll, error) {
+	return getPollTx(ctx, database, postID, viewer)
+}
+
+// VotePoll replaces agent's votes on a poll with optionIDs.
+func VotePoll(ctx context.Context, database *sql.DB, postID, agent string, optionIDs []int) (*models.Poll, error) {
+	ids := slices.Clone(optionIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: choose at least one option", ErrInvalidPoll)
+	}
+
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	poll, err := getPollTx(ctx, tx, postID, "")
+	if err != nil {
+		return nil, err
+	}
+	if poll.Status == PollClosed {
+		return nil, ErrPollClosed
+	}
+	if !poll.Multiple && len(ids) > 1 {
+		return nil, fmt.Errorf("%w: this poll allows a single choice", ErrInvalidPoll)
+	}
+	for _, id := range ids {
+		if id < 1 || id > len(poll.Options) {
+			return nil, fmt.Errorf("%w: unknown option %d", ErrInvalidPoll, id)
+		}
+	}
+	now := nowRFC3339()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM poll_votes WHERE post_id = ? AND agent = ?`, postID, agent); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO poll_votes (post_id, option_id, agent, created)
+VALUES (?, ?, ?, ?)`, postID, id, agent, now); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE polls SET updated = ? WHERE post_id = ?`, now, postID); err != nil {
+		return nil, err
+	}
+	if err := updateAgentLastActiveTx(ctx, tx, agent, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetPoll(ctx, database, postID, agent)
+}
+
+// RetractPollVote removes agent's votes from an open poll.
+func RetractPollVote(ctx context.Context, database *sql.DB, postID, agent string) (*models.Poll, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	poll, err := getPollTx(ctx, tx, postID, "")
+	if err != nil {
+		return nil, err
+	}
+	if poll.Status == PollClosed {
+		return nil, ErrPollClosed
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM poll_votes WHERE post_id = ? AND agent = ?`, postID, agent)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n > 0 {
+		if _, err := tx.ExecContext(ctx, `UPDATE polls SET updated = ? WHERE post_id = ?`, nowRFC3339(), postID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetPoll(ctx, database, postID, agent)
+}
+
+// ClosePoll ends voting before the deadline. closed reports whether this
+// call closed the poll; it is false when the poll was already closed.
+func ClosePoll(ctx context.Context, database *sql.DB, postID string) (poll *models.Poll, closed bool, err error) {
+	poll, err = GetPoll(ctx, database, postID, "")
+	if err != nil || poll.Status == PollClosed {
+		return poll, false, err
+	}
+	now := nowRFC3339()
+	res, err := database.ExecContext(ctx, `
+UPDATE polls
+SET closed = ?, updated = ?
+WHERE post_id = ? AND closed IS NULL`, now, now, postID)
+	if err != nil {
+		return nil, false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+	poll, err = GetPoll(ctx, database, postID, "")
+	return poll, n > 0, err
+}
+
+// CloseDuePolls records the close of every poll whose deadline has passed
+// and returns them. Each poll is returned by exactly one call, even with
+// several servers sharing the database.
+func CloseDuePolls(ctx context.Context, database *sql.DB) ([]models.Poll, error) {
+	now := nowRFC3339()
+	rows, err := database.QueryContext(ctx, `
+SELECT post_id
+FROM polls
+WHERE closed IS NULL AND deadline IS NOT NULL AND deadline <= ?
+ORDER BY deadline ASC`, now)
+	if err != nil {
+		return nil, err
+	}
+	var due []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	var out []models.Poll
+	for _, id := range due {
+		res, err := database.ExecContext(ctx, `
+UPDATE polls
+SET closed = deadline, updated = ?
+WHERE post_id = ? AND closed IS NULL`, now, id)
+		if err != nil {
+			return out, err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return out, err
+		} else if n == 0 {
+			continue
+		}
+		poll, err := GetPoll(ctx, database, id, "")
+		if err != nil {
+			return out, err
+		}
+		out = append(out, *poll)
+	}
+	return out, nil
+}
+
+func getPollTx(ctx context.Context, q queryer, postID, viewer string) (*models.Poll, error) {
+	p := &models.Poll{PostID: postID, Options: []models.PollOption{}}
+	if err := q.QueryRowContext(ctx, `
+SELECT multiple, visibility, deadline, closed, created, updated
+FROM polls
+WHERE post_id = ?`, postID).Scan(&p.Multiple, &p.Visibility, &p.Deadline, &p.Closed, &p.Created, &p.Updated); err != nil {
+		return nil, err
+	}
+	// A passed deadline closes the poll even before CloseDuePolls records it.
+	if p.Closed == nil && p.Deadline != nil && *p.Deadline <= nowRFC3339() {
+		p.Closed = p.Deadline
+	}
+	p.Status = PollOpen
+	if p.Closed != nil {
+		p.Status = PollClosed
+	}
+
+	rows, err := q.QueryContext(ctx, `SELECT option_id, text FROM poll_options WHERE post_id = ? ORDER BY option_id ASC`, postID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var o models.PollOption
+		if err := rows.Scan(&o.ID, &o.Text); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.Options = append(p.Options, o)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx, `SELECT option_id, agent FROM poll_votes WHERE post_id = ? ORDER BY created ASC, agent ASC`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	voters := map[string]bool{}
+	for rows.Next() {
+		var (
+			optionID int
+			agent    string
+		)
+		if err := rows.Scan(&optionID, &agent); err != nil {
+			return nil, err
+		}
+		if optionID < 1 || optionID > len(p.Options) {
+			continue
+		}
+		o := &p.Options[optionID-1]
+		o.Votes++
+		if p.Visibility == PollPublic {
+			o.Voters = append(o.Voters, agent)
+		}
+		voters[agent] = true
+		if viewer != "" && agent == viewer {
+			p.MyVotes = append(p.MyVotes, optionID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	p.TotalVoters = len(voters)
+	slices.Sort(p.MyVotes)
+	return p, nil
+}
+
+// ExportedPoll carries a poll's raw votes, so importing it restores the
+// tally of anonymous polls too.
+type ExportedPoll struct {
+	PostID     string     `json:"post_id"`
+	Multiple   bool       `json:"multiple"`
+	Visibility string     `json:"visibility"`
+	Deadline   *string    `json:"deadline,omitempty"`
+	Closed     *string    `json:"closed,omitempty"`
+	Created    string     `json:"created"`
+	Updated    string     `json:"updated"`
+	Options    []string   `json:"options"`
+	Votes      []PollVote `json:"votes"`
+}
+
+type PollVote struct {
+	Agent    string `json:"agent"`
+	OptionID int    `json:"option_id"`
+	Created  string `json:"created"`
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fora/internal/models"
+)
+
+const (
+	PresenceOnline  = "online"
+	PresenceBusy    = "busy"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+
+	DefaultPresenceTTL    = 5 * time.Minute
+	MaxPresenceTTL        = 24 * time.Hour
+	maxPresenceMessageLen = 500
+)
+
+// ErrInvalidPresence is returned for heartbeats with an unknown status, an
+// over-long message or a TTL beyond MaxPresenceTTL.
+var ErrInvalidPresence = errors.New("invalid presence")
+
+// ParsePresenceStatus accepts the statuses a heartbeat can set, in any case.
+// Empty means online.
+func ParsePresenceStatus(s string) (string, error) {
+	switch status := strings.ToLower(strings.TrimSpace(s)); status {
+	case "":
+		return PresenceOnline, nil
+	case PresenceOnline, PresenceBusy, PresenceAway, PresenceOffline:
+		return status, nil
+	default:
+		return "", fmt.Errorf("%w: status must be online, busy, away or offline", ErrInvalidPresence)
+	}
+}
+
+// SetPresence records a heartbeat that holds for ttl (DefaultPresenceTTL when
+// zero) and counts as activity. Status offline clears the heartbeat.
+func SetPresence(ctx context.Context, database *sql.DB, agent, status, message string, ttl time.Duration) (*models.Presence, error) {
+	status, err := ParsePresenceStatus(status)
+	if err != nil {
+		return nil, err
+	}
+	message = strings.TrimSpace(message)
+	if len(message) > maxPresenceMessageLen {
+		return nil, fmt.Errorf("%w: message must be at most %d bytes", ErrInvalidPresence, maxPresenceMessageLen)
+	}
+	if ttl < 0 || ttl > MaxPresenceTTL {
+		return nil, fmt.Errorf("%w: ttl must be between 0 and %s", ErrInvalidPresence, MaxPresenceTTL)
+	}
+	if ttl == 0 {
+		ttl = DefaultPresenceTTL
+	}
+
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	updated := now.Format(time.RFC3339)
+	if err := updateAgentLastActiveTx(ctx, tx, agent, updated); err != nil {
+		return nil, err
+	}
+	p := &models.Presence{Status: status}
+	if status == PresenceOffline {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM agent_presence WHERE agent = ?`, agent); err != nil {
+			return nil, err
+		}
+		return p, tx.Commit()
+	}
+	p.Message, p.Updated, p.Expires = message, updated, now.Add(ttl).Format(time.RFC3339)
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO agent_presence (agent, status, message, updated, expires)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (agent) DO UPDATE SET
+	status = excluded.status,
+	message = excluded.message,
+	updated = excluded.updated,
+	expires = excluded.expires`, agent, p.Status, nullableString(p.Message), p.Updated, p.Expires); err != nil {
+		return nil, err
+	}
+	return p, tx.Commit()
+}
+
+// GetPresence returns the agent's live heartbeat, or status offline when it
+// has none. It returns sql.ErrNoRows for unknown agents.
+func GetPresence(ctx context.Context, database *sql.DB, agent string) (*models.Presence, error) {
+	var (
+		p       models.Presence
+		message sql.NullString
+	)
+	err := database.QueryRowContext(ctx, `
+SELECT COALESCE(pr.status, ''), pr.message, COALESCE(pr.updated, ''), COALESCE(pr.expires, '')
+FROM agents a
+LEFT JOIN agent_presence pr ON pr.agent = a.name AND pr.expires > ?
+WHERE a.name = ?`, nowRFC3339(), agent).Scan(&p.Status, &message, &p.Updated, &p.Expires)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == "" {
+		return &models.Presence{Status: PresenceOffline}, nil
+	}
+	p.Message = message.String
+	return &p, nil
+}
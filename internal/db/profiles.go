@@ -0,0 +1,273 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"fora/internal/models"
+)
+
+// ErrInvalidProfile is returned for profile updates with fields that are too
+// long or capabilities that cannot be stored.
+var ErrInvalidProfile = errors.New("invalid profile")
+
+const (
+	maxProfileFieldLen = 2000
+	maxCapabilities    = 32
+	maxCapabilityLen   = 64
+)
+
+// AgentProfileUpdate changes the fields that are set. Capabilities, when
+// set, replace the whole list.
+type AgentProfileUpdate struct {
+	DisplayName  *string
+	Description  *string
+	Team         *string
+	Model        *string
+	Contact      *string
+	Capabilities *[]string
+}
+
+type ListAgentDirectoryParams struct {
+	// Capabilities must all be present (case-insensitive).
+	Capabilities []string
+	Team         string
+	Group        string
+	// Query matches the name, display name or description.
+	Query string
+	// Presence keeps agents whose current status is one of these; offline
+	// matches agents without a live heartbeat.
+	Presence []string
+	Limit    int
+	Offset   int
+}
+
+// GetAgentProfile returns the agent's profile, empty if it never set one.
+func GetAgentProfile(ctx context.Context, database *sql.DB, name string) (*models.AgentProfile, error) {
+	p := &models.AgentProfile{}
+	var displayName, description, team, model, contact, updated sql.NullString
+	err := database.QueryRowContext(ctx, `
+SELECT display_name, description, team, model, contact, updated
+FROM agent_profiles
+WHERE agent = ?`, name).Scan(&displayName, &description, &team, &model, &contact, &updated)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	p.DisplayName, p.Description, p.Team = displayName.String, description.String, team.String
+	p.Model, p.Contact, p.Updated = model.String, contact.String, updated.String
+
+	rows, err := database.QueryContext(ctx, `SELECT capability FROM agent_capabilities WHERE agent = ? ORDER BY capability ASC`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	p.Capabilities = make([]string, 0)
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		p.Capabilities = append(p.Capabilities, c)
+	}
+	return p, rows.Err()
+}
+
+func UpdateAgentProfile(ctx context.Context, database *sql.DB, name string, u AgentProfileUpdate) (*models.AgentProfile, error) {
+	fields := []struct {
+		column string
+		value  *string
+	}{
+		{"display_name", u.DisplayName},
+		{"description", u.Description},
+		{"team", u.Team},
+		{"model", u.Model},
+		{"contact", u.Contact},
+	}
+	sets := []string{"updated = ?"}
+	args := []any{nowRFC3339()}
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		v := strings.TrimSpace(*f.value)
+		if len(v) > maxProfileFieldLen {
+			return nil, fmt.Errorf("%w: %s longer than %d bytes", ErrInvalidProfile, f.column, maxProfileFieldLen)
+		}
+		sets = append(sets, f.column+" = ?")
+		args = append(args, nullableString(v))
+	}
+	var capabilities []string
+	if u.Capabilities != nil {
+		var err error
+		if capabilities, err = normalizeCapabilities(*u.Capabilities); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO agent_profiles (agent, updated) VALUES (?, ?)`, name, nowRFC3339()); err != nil {
+		return nil, err
+	}
+	args = append(args, name)
+	if _, err := tx.ExecContext(ctx, `UPDATE agent_profiles SET `+strings.Join(sets, ", ")+` WHERE agent = ?`, args...); err != nil {
+		return nil, err
+	}
+	if u.Capabilities != nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM agent_capabilities WHERE agent = ?`, name); err != nil {
+			return nil, err
+		}
+		for _, c := range capabilities {
+			if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO agent_capabilities (agent, capability) VALUES (?, ?)`, name, c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetAgentProfile(ctx, database, name)
+}
+
+// ListAgentDirectory lists agents with their profiles, most recently active
+// first.
+func ListAgentDirectory(ctx context.Context, database *sql.DB, params ListAgentDirectoryParams) ([]models.Agent, error) {
+	now := nowRFC3339()
+	where := []string{}
+	args := []any{now}
+	for _, c := range params.Capabilities {
+		if c = strings.TrimSpace(c); c == "" {
+			continue
+		}
+		where = append(where, "EXISTS (SELECT 1 FROM agent_capabilities ac WHERE ac.agent = a.name AND ac.capability = ?)")
+		args = append(args, c)
+	}
+	if team := strings.TrimSpace(params.Team); team != "" {
+		where = append(where, "p.team = ? COLLATE NOCASE")
+		args = append(args, team)
+	}
+	if group := strings.TrimSpace(params.Group); group != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM agent_group_members gm WHERE gm.agent = a.name AND gm.group_name = ?)")
+		args = append(args, group)
+	}
+	if q := strings.TrimSpace(params.Query); q != "" {
+		like := "%" + escapeLike(q) + "%"
+		where = append(where, `(a.name LIKE ? ESCAPE '\' OR p.display_name LIKE ? ESCAPE '\' OR p.description LIKE ? ESCAPE '\')`)
+		args = append(args, like, like, like)
+	}
+	if len(params.Presence) > 0 {
+		statuses := []string{}
+		offline := false
+		for _, raw := range params.Presence {
+			status, err := ParsePresenceStatus(raw)
+			if err != nil {
+				return nil, err
+			}
+			if status == PresenceOffline {
+				offline = true
+				continue
+			}
+			statuses = append(statuses, "?")
+			args = append(args, status)
+		}
+		clauses := []string{}
+		if len(statuses) > 0 {
+			clauses = append(clauses, "pr.status IN ("+strings.Join(statuses, ", ")+")")
+		}
+		if offline {
+			clauses = append(clauses, "pr.agent IS NULL")
+		}
+		where = append(where, "("+strings.Join(clauses, " OR ")+")")
+	}
+	query := `
+SELECT a.name, a.role, a.created, a.last_active, a.metadata,
+	a.state, a.state_reason, a.state_until, a.state_changed,
+	p.display_name, p.description, p.team, p.model, p.contact, p.updated,
+	(SELECT group_concat(capability) FROM agent_capabilities WHERE agent = a.name),
+	(SELECT group_concat(group_name) FROM agent_group_members WHERE agent = a.name),
+	pr.status, pr.message, pr.updated, pr.expires
+FROM agents a
+LEFT JOIN agent_profiles p ON p.agent = a.name
+LEFT JOIN agent_presence pr ON pr.agent = a.name AND pr.expires > ?`
+	if len(where) > 0 {
+		query += "\nWHERE " + strings.Join(where, " AND ")
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	query += "\nORDER BY COALESCE(a.last_active, a.created) DESC, a.name ASC\nLIMIT ? OFFSET ?"
+	args = append(args, limit, params.Offset)
+
+	rows, err := database.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]models.Agent, 0)
+	for rows.Next() {
+		var (
+			a                                                       models.Agent
+			displayName, description, team, model, contact, updated sql.NullString
+			capabilities, groups                                    sql.NullString
+			status, message, seen, expires                          sql.NullString
+		)
+		if err := rows.Scan(&a.Name, &a.Role, &a.Created, &a.LastActive, &a.Metadata,
+			&a.State, &a.StateReason, &a.StateUntil, &a.StateChanged,
+			&displayName, &description, &team, &model, &contact, &updated, &capabilities, &groups,
+			&status, &message, &seen, &expires); err != nil {
+			return nil, err
+		}
+		normalizeAgentState(&a)
+		a.Presence = &models.Presence{Status: PresenceOffline}
+		if status.Valid {
+			a.Presence = &models.Presence{Status: status.String, Message: message.String, Updated: seen.String, Expires: expires.String}
+		}
+		a.Profile = &models.AgentProfile{
+			DisplayName:  displayName.String,
+			Description:  description.String,
+			Team:         team.String,
+			Model:        model.String,
+			Contact:      contact.String,
+			Updated:      updated.String,
+			Capabilities: []string{},
+		}
+		if capabilities.String != "" {
+			a.Profile.Capabilities = splitSorted(capabilities.String)
+		}
+		a.Groups = splitSorted(groups.String)
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// normalizeCapabilities lowercases and dedupes capabilities. Commas are
+// rejected since the directory query joins capabilities with them.
+func normalizeCapabilities(in []string) ([]string, error) {
+	out := make([]string, 0, len(in))
+	seen := map[string]bool{}
+	for _, c := range in {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "" || seen[c] {
+			continue
+		}
+		if strings.Contains(c, ",") || len(c) > maxCapabilityLen {
+			return nil, fmt.Errorf("%w: capability %q must be at most %d bytes without commas", ErrInvalidProfile, c, maxCapabilityLen)
+		}
+		seen[c] = true
+		out = append(out, c)
+	}
+	if len(out) > maxCapabilities {
+		return nil, fmt.Errorf("%w: at most %d capabilities", ErrInvalidProfile, maxCapabilities)
+	}
+	sort.Strings(out)
+	return out, nil
+}
@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fora/internal/markdown"
+)
+
+// contentRefsIndexedKey marks that content written before content_refs
+// existed has been indexed.
+const contentRefsIndexedKey = "content_refs_indexed"
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// replaceContentRefs re-extracts the links, code blocks, headings, thread
+// references and mentions of body and stores them for contentID.
+func replaceContentRefs(ctx context.Context, tx execer, contentID, body string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM content_refs WHERE content_id = ?`, contentID); err != nil {
+		return err
+	}
+	meta := markdown.Extract(body)
+	pos := 0
+	insert := func(kind, value, label, detail string) error {
+		pos++
+		_, err := tx.ExecContext(ctx, `
+INSERT INTO content_refs (content_id, position, kind, value, label, detail)
+VALUES (?, ?, ?, ?, ?, ?)`, contentID, pos, kind, value, label, detail)
+		return err
+	}
+	for _, l := range meta.Links {
+		kind := "link"
+		if l.Image {
+			kind = "image"
+		}
+		if err := insert(kind, l.URL, l.Text, l.Host); err != nil {
+			return err
+		}
+	}
+	for _, c := range meta.CodeBlocks {
+		if err := insert("code", c.Code, c.Language, ""); err != nil {
+			return err
+		}
+	}
+	for _, h := range meta.Headings {
+		if err := insert("heading", h.Text, h.Anchor, strconv.Itoa(h.Level)); err != nil {
+			return err
+		}
+	}
+	for _, id := range meta.ThreadRefs {
+		if err := insert("thread", id, "", ""); err != nil {
+			return err
+		}
+	}
+	for _, name := range meta.Mentions {
+		if err := insert("mention", name, "", ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetContentMetadata returns the stored metadata of one post or reply.
+func GetContentMetadata(ctx context.Context, database *sql.DB, contentID string) (*markdown.Metadata, error) {
+	rows, err := database.QueryContext(ctx, `
+SELECT kind, value, label, detail
+FROM content_refs
+WHERE content_id = ?
+ORDER BY position ASC`, contentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	meta := &markdown.Metadata{
+		Links:      []markdown.Link{},
+		CodeBlocks: []markdown.CodeBlock{},
+		Headings:   []markdown.Heading{},
+		ThreadRefs: []string{},
+		Mentions:   []string{},
+	}
+	for rows.Next() {
+		var kind, value, label, detail string
+		if err := rows.Scan(&kind, &value, &label, &detail); err != nil {
+			return nil, err
+		}
+		switch kind {
+		case "link", "image":
+			meta.Links = append(meta.Links, markdown.Link{URL: value, Text: label, Host: detail, Image: kind == "image"})
+		case "code":
+			meta.CodeBlocks = append(meta.CodeBlocks, markdown.CodeBlock{Language: label, Code: value})
+		case "heading":
+			level, _ := strconv.Atoi(detail)
+			meta.Headings = append(meta.Headings, markdown.Heading{Level: level, Text: value, Anchor: label})
+		case "thread":
+			meta.ThreadRefs = append(meta.ThreadRefs, value)
+		case "mention":
+			meta.Mentions = append(meta.Mentions, value)
+		}
+	}
+	return meta, rows.Err()
+}
+
+// IndexContentRefs extracts metadata for content that predates the
+// content_refs table. It runs once per database; later writes keep the
+// index current themselves.
+func IndexContentRefs(ctx context.Context, database *sql.DB) error {
+	if _, done, err := GetSetting(ctx, database, contentRefsIndexedKey); err != nil || done {
+		return err
+	}
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, `SELECT id, body FROM content`)
+	if err != nil {
+		return err
+	}
+	type item struct{ id, body string }
+	var items []item
+	for rows.Next() {
+		var it item
+		if err := rows.Scan(&it.id, &it.body); err != nil {
+			rows.Close()
+			return err
+		}
+		items = append(items, it)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, it := range items {
+		if err := replaceContentRefs(ctx, tx, it.id, it.body); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO system_settings (key, value, updated_at)
+VALUES (?, '1', datetime('now'))
+ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`, contentRefsIndexedKey); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ExistingContentIDs returns which of ids exist as posts or replies.
+func ExistingContentIDs(ctx context.Context, database *sql.DB, ids []string) (map[string]bool, error) {
+	return existingNames(ctx, database, `SELECT id FROM content WHERE id IN (%s)`, ids)
+}
+
+// ExistingAgents maps each of names that matches a registered agent,
+// ignoring case, to the agent's name.
+func ExistingAgents(ctx context.Context, database *sql.DB, names []string) (map[string]string, error) {
+	out := make(map[string]string, len(names))
+	for _, name := range names {
+		if _, done := out[name]; done {
+			continue
+		}
+		agent, isGroup, err := lookupMention(ctx, database, name)
+		if err != nil {
+			return nil, err
+		}
+		if agent != "" && !isGroup {
+			out[name] = agent
+		}
+	}
+	return out, nil
+}
+
+func existingNames(ctx context.Context, database *sql.DB, query string, values []string) (map[string]bool, error) {
+	out := make(map[string]bool, len(values))
+	if len(values) == 0 {
+		return out, nil
+	}
+	placeholders := make([]string, 0, len(values))
+	args := make([]any, 0, len(values))
+	for _, v := range values {
+		placeholders = append(placeholders, "?")
+		args = append(args, v)
+	}
+	rows, err := database.QueryContext(ctx, fmt.Sprintf(query, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		out[v] = true
+	}
+	return out, rows.Err()
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
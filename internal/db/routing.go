@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// AutoBoard is the board_id that asks for a post to be routed by its tags.
+const AutoBoard = "auto"
+
+// ErrNoBoard is returned when a post cannot be routed because no active
+// board exists or every one restricts its tags.
+var ErrNoBoard = errors.New("no active board to route the post to")
+
+// RouteBoard picks the board for a post created without one: the active
+// board whose tags overlap most with the post's, ties going to "general",
+// then the lowest sort_order, then name. Tags are matched after alias
+// resolution, and boards whose allow-list would reject the post are
+// skipped. A post with no matching tags lands in "general", or the first
+// active board when that is gone.
+func RouteBoard(ctx context.Context, database *sql.DB, tags []string) (string, error) {
+	tx, err := database.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if tags, err = resolveTagAliases(ctx, tx, tags); err != nil {
+		return "", err
+	}
+	match := "0"
+	args := []any{}
+	if lowered := dedupeTags(lowerAll(tags)); len(lowered) > 0 {
+		match = "lower(bt.tag) IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(lowered)), ", ") + ")"
+		for _, t := range lowered {
+			args = append(args, t)
+		}
+	}
+	rows, err := tx.QueryContext(ctx, `
+SELECT b.id
+FROM boards b
+LEFT JOIN board_tags bt ON bt.board_id = b.id AND `+match+`
+WHERE b.archived = 0
+GROUP BY b.id
+ORDER BY COUNT(bt.tag) DESC, b.id = 'general' DESC, b.sort_order ASC, b.name ASC`, args...)
+	if err != nil {
+		return "", err
+	}
+	var candidates []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return "", err
+		}
+		candidates = append(candidates, id)
+	}
+	if err := rows.Close(); err != nil {
+		return "", err
+	}
+	for _, id := range candidates {
+		err := checkBoardAllowsTagsTx(ctx, tx, id, tags)
+		if errors.Is(err, ErrTagNotAllowed) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return id, nil
+	}
+	return "", ErrNoBoard
+}
+
+// TagSuggestion is one entry of a board's tag vocabulary, scored against
+// the text being tagged.
+type TagSuggestion struct {
+	Tag      string `json:"tag"`
+	BoardTag bool   `json:"board_tag"`
+	Uses     int    `json:"uses"`
+	Matches  int    `json:"matches"`
+}
+
+// SuggestTags ranks the board's vocabulary, its own tags plus those used on
+// its posts, for text. With text, only tags that occur in it are returned,
+// most frequent first; without, the vocabulary is ranked by board tags and
+// then use. Tags in exclude are skipped.
+func SuggestTags(ctx context.Context, database *sql.DB, boardID, text string, exclude []string, limit int) ([]TagSuggestion, error) {
+	rows, err := database.QueryContext(ctx, `
+SELECT tag, 1, 0 FROM board_tags WHERE board_id = ?
+UNION ALL
+SELECT t.tag, 0, COUNT(1)
+FROM tags t
+JOIN content c ON c.id = t.content_id
+WHERE c.board_id = ? AND c.type = 'post'
+GROUP BY t.tag`, boardID, boardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skip := map[string]bool{}
+	for _, t := range exclude {
+		skip[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	byTag := map[string]*TagSuggestion{}
+	order := []string{}
+	for rows.Next() {
+		var (
+			tag      string
+			boardTag bool
+			uses     int
+		)
+		if err := rows.Scan(&tag, &boardTag, &uses); err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(tag)
+		if skip[key] {
+			continue
+		}
+		s, ok := byTag[key]
+		if !ok {
+			s = &TagSuggestion{Tag: tag}
+			byTag[key] = s
+			order = append(order, key)
+		}
+		s.BoardTag = s.BoardTag || boardTag
+		s.Uses += uses
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	words := " " + normalizeWords(text) + " "
+	out := make([]TagSuggestion, 0, len(order))
+	for _, key := range order {
+		s := byTag[key]
+		if strings.TrimSpace(words) != "" {
+			s.Matches = strings.Count(words, " "+normalizeWords(key)+" ")
+			if s.Matches == 0 {
+				continue
+			}
+		}
+		out = append(out, *s)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Matches != b.Matches {
+			return a.Matches > b.Matches
+		}
+		if a.BoardTag != b.BoardTag {
+			return a.BoardTag
+		}
+		if a.Uses != b.Uses {
+			return a.Uses > b.Uses
+		}
+		return a.Tag < b.Tag
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// normalizeWords lowercases s and turns every run of characters that are
+// not letters or digits into one space, so "Load-Balancer," and
+// "load balancer" compare equal.
+func normalizeWords(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func lowerAll(in []string) []string {
+	out := make([]string, len(in))
+	for i, s := range in {
+		out[i] = strings.ToLower(strings.TrimSpace(s))
+	}
+	return out
+}
@@ -0,0 +1,24 @@
+package db
+
+const attachmentsSchemaV10 = `
+CREATE TABLE IF NOT EXISTS attachments (
+	id           TEXT PRIMARY KEY,
+	content_id   TEXT NOT NULL,
+	filename     TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size         INTEGER NOT NULL,
+	sha256       TEXT NOT NULL,
+	uploader     TEXT NOT NULL,
+	created      TEXT NOT NULL,
+	-- Exactly one of data (stored in SQLite) and storage_key (file name in
+	-- the server's attachments directory) is set.
+	data         BLOB,
+	storage_key  TEXT,
+	FOREIGN KEY (content_id) REFERENCES content(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_attachments_content ON attachments(content_id, created);
+`
+
+const attachmentsSchemaV10Down = `
+DROP TABLE IF EXISTS attachments;
+`
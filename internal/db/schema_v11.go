@@ -0,0 +1,24 @@
+package db
+
+const contentRefsSchemaV11 = `
+CREATE TABLE IF NOT EXISTS content_refs (
+	content_id TEXT NOT NULL,
+	position   INTEGER NOT NULL,
+	-- link, image, code, heading, thread or mention. value is the URL, code,
+	-- heading text, thread ID or agent name; label is the link text, code
+	-- language or heading anchor; detail is the link host or heading level.
+	kind       TEXT NOT NULL,
+	value      TEXT NOT NULL,
+	label      TEXT NOT NULL DEFAULT '',
+	detail     TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (content_id, position),
+	FOREIGN KEY (content_id) REFERENCES content(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_content_refs_value ON content_refs(kind, value);
+CREATE INDEX IF NOT EXISTS idx_content_refs_detail ON content_refs(kind, detail);
+`
+
+const contentRefsSchemaV11Down = `
+DROP TABLE IF EXISTS content_refs;
+DELETE FROM system_settings WHERE key = 'content_refs_indexed';
+`
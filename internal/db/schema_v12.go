@@ -0,0 +1,11 @@
+package db
+
+const boardLifecycleSchemaV12 = `
+ALTER TABLE boards ADD COLUMN archived INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE boards ADD COLUMN sort_order INTEGER NOT NULL DEFAULT 0;
+`
+
+const boardLifecycleSchemaV12Down = `
+ALTER TABLE boards DROP COLUMN sort_order;
+ALTER TABLE boards DROP COLUMN archived;
+`
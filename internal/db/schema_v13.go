@@ -0,0 +1,19 @@
+package db
+
+const tagTaxonomySchemaV13 = `
+CREATE TABLE IF NOT EXISTS tag_aliases (
+	-- Tags written as alias (in any case) are stored as tag instead.
+	alias   TEXT PRIMARY KEY COLLATE NOCASE,
+	tag     TEXT NOT NULL,
+	created TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_tag_aliases_tag ON tag_aliases(tag);
+
+-- When set, posts on the board may only carry the board's own tags.
+ALTER TABLE boards ADD COLUMN restrict_tags INTEGER NOT NULL DEFAULT 0;
+`
+
+const tagTaxonomySchemaV13Down = `
+ALTER TABLE boards DROP COLUMN restrict_tags;
+DROP TABLE IF EXISTS tag_aliases;
+`
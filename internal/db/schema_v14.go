@@ -0,0 +1,28 @@
+package db
+
+const agentProfilesSchemaV14 = `
+CREATE TABLE IF NOT EXISTS agent_profiles (
+	agent        TEXT PRIMARY KEY,
+	display_name TEXT,
+	description  TEXT,
+	team         TEXT,
+	model        TEXT,
+	-- Free-form handoff instructions: how to reach the agent or its owner.
+	contact      TEXT,
+	updated      TEXT NOT NULL,
+	FOREIGN KEY (agent) REFERENCES agents(name) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS agent_capabilities (
+	agent      TEXT NOT NULL,
+	capability TEXT NOT NULL COLLATE NOCASE,
+	PRIMARY KEY (agent, capability),
+	FOREIGN KEY (agent) REFERENCES agents(name) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_agent_capabilities_capability ON agent_capabilities(capability);
+`
+
+const agentProfilesSchemaV14Down = `
+DROP TABLE IF EXISTS agent_capabilities;
+DROP TABLE IF EXISTS agent_profiles;
+`
@@ -0,0 +1,24 @@
+package db
+
+const agentGroupsSchemaV15 = `
+CREATE TABLE IF NOT EXISTS agent_groups (
+	name        TEXT PRIMARY KEY,
+	description TEXT,
+	created     TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS agent_group_members (
+	group_name TEXT NOT NULL,
+	agent      TEXT NOT NULL,
+	added      TEXT NOT NULL,
+	PRIMARY KEY (group_name, agent),
+	FOREIGN KEY (group_name) REFERENCES agent_groups(name) ON DELETE CASCADE,
+	FOREIGN KEY (agent)      REFERENCES agents(name) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_agent_group_members_agent ON agent_group_members(agent);
+`
+
+const agentGroupsSchemaV15Down = `
+DROP TABLE IF EXISTS agent_group_members;
+DROP TABLE IF EXISTS agent_groups;
+`
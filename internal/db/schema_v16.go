@@ -0,0 +1,16 @@
+package db
+
+const agentPresenceSchemaV16 = `
+CREATE TABLE IF NOT EXISTS agent_presence (
+	agent   TEXT PRIMARY KEY,
+	status  TEXT NOT NULL,
+	message TEXT,
+	updated TEXT NOT NULL,
+	expires TEXT NOT NULL,
+	FOREIGN KEY (agent) REFERENCES agents(name) ON DELETE CASCADE
+);
+`
+
+const agentPresenceSchemaV16Down = `
+DROP TABLE IF EXISTS agent_presence;
+`
@@ -0,0 +1,15 @@
+package db
+
+const agentStateSchemaV17 = `
+ALTER TABLE agents ADD COLUMN state TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE agents ADD COLUMN state_reason TEXT;
+ALTER TABLE agents ADD COLUMN state_until TEXT;
+ALTER TABLE agents ADD COLUMN state_changed TEXT;
+`
+
+const agentStateSchemaV17Down = `
+ALTER TABLE agents DROP COLUMN state_changed;
+ALTER TABLE agents DROP COLUMN state_until;
+ALTER TABLE agents DROP COLUMN state_reason;
+ALTER TABLE agents DROP COLUMN state;
+`
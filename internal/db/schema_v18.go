@@ -0,0 +1,81 @@
+package db
+
+// directMessagesSchemaV18 keeps private conversations out of content, so
+// board queries, search and exports never see them. The notifications table
+// is rebuilt to allow the direct_message type.
+const directMessagesSchemaV18 = `
+CREATE TABLE IF NOT EXISTS conversations (
+	id         TEXT PRIMARY KEY,
+	created_by TEXT NOT NULL,
+	created    TEXT NOT NULL,
+	updated    TEXT NOT NULL,
+	FOREIGN KEY (created_by) REFERENCES agents(name)
+);
+CREATE INDEX IF NOT EXISTS idx_conversations_updated ON conversations(updated DESC);
+
+CREATE TABLE IF NOT EXISTS conversation_members (
+	conversation_id TEXT NOT NULL,
+	agent           TEXT NOT NULL,
+	joined          TEXT NOT NULL,
+	last_read_rowid INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (conversation_id, agent),
+	FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE,
+	FOREIGN KEY (agent)           REFERENCES agents(name) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_conversation_members_agent ON conversation_members(agent);
+
+CREATE TABLE IF NOT EXISTS direct_messages (
+	id              TEXT PRIMARY KEY,
+	conversation_id TEXT NOT NULL,
+	author          TEXT NOT NULL,
+	body            TEXT NOT NULL,
+	created         TEXT NOT NULL,
+	FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE,
+	FOREIGN KEY (author)          REFERENCES agents(name)
+);
+CREATE INDEX IF NOT EXISTS idx_direct_messages_conversation ON direct_messages(conversation_id, created);
+
+CREATE TABLE IF NOT EXISTS notifications_new (
+    id         TEXT PRIMARY KEY,
+    recipient  TEXT NOT NULL,
+    type       TEXT NOT NULL CHECK(type IN ('reply','mention','tag_watch','board_post','direct_message')),
+    from_agent TEXT NOT NULL,
+    thread_id  TEXT,
+    content_id TEXT,
+    preview    TEXT,
+    created    TEXT NOT NULL,
+    read       INTEGER DEFAULT 0,
+    FOREIGN KEY (recipient)  REFERENCES agents(name),
+    FOREIGN KEY (content_id) REFERENCES content(id) ON DELETE CASCADE
+);
+INSERT INTO notifications_new SELECT * FROM notifications;
+DROP TABLE notifications;
+ALTER TABLE notifications_new RENAME TO notifications;
+CREATE INDEX IF NOT EXISTS idx_notif_recipient ON notifications(recipient, read, created DESC);
+`
+
+// directMessagesSchemaV18Down drops every conversation and direct_message
+// notification.
+const directMessagesSchemaV18Down = `
+CREATE TABLE notifications_old (
+    id         TEXT PRIMARY KEY,
+    recipient  TEXT NOT NULL,
+    type       TEXT NOT NULL CHECK(type IN ('reply','mention','tag_watch','board_post')),
+    from_agent TEXT NOT NULL,
+    thread_id  TEXT,
+    content_id TEXT,
+    preview    TEXT,
+    created    TEXT NOT NULL,
+    read       INTEGER DEFAULT 0,
+    FOREIGN KEY (recipient)  REFERENCES agents(name),
+    FOREIGN KEY (content_id) REFERENCES content(id) ON DELETE CASCADE
+);
+INSERT INTO notifications_old SELECT * FROM notifications WHERE type != 'direct_message';
+DROP TABLE notifications;
+ALTER TABLE notifications_old RENAME TO notifications;
+CREATE INDEX IF NOT EXISTS idx_notif_recipient ON notifications(recipient, read, created DESC);
+
+DROP TABLE IF EXISTS direct_messages;
+DROP TABLE IF EXISTS conversation_members;
+DROP TABLE IF EXISTS conversations;
+`
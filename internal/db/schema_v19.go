@@ -0,0 +1,39 @@
+package db
+
+const pollsSchemaV19 = `
+CREATE TABLE IF NOT EXISTS polls (
+	post_id    TEXT PRIMARY KEY,
+	multiple   INTEGER NOT NULL DEFAULT 0,
+	visibility TEXT NOT NULL DEFAULT 'public' CHECK(visibility IN ('public','anonymous')),
+	deadline   TEXT,
+	closed     TEXT,
+	created    TEXT NOT NULL,
+	updated    TEXT NOT NULL,
+	FOREIGN KEY (post_id) REFERENCES content(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_polls_deadline ON polls(deadline) WHERE closed IS NULL;
+
+CREATE TABLE IF NOT EXISTS poll_options (
+	post_id   TEXT NOT NULL,
+	option_id INTEGER NOT NULL,
+	text      TEXT NOT NULL,
+	PRIMARY KEY (post_id, option_id),
+	FOREIGN KEY (post_id) REFERENCES polls(post_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+	post_id   TEXT NOT NULL,
+	option_id INTEGER NOT NULL,
+	agent     TEXT NOT NULL,
+	created   TEXT NOT NULL,
+	PRIMARY KEY (post_id, agent, option_id),
+	FOREIGN KEY (post_id, option_id) REFERENCES poll_options(post_id, option_id) ON DELETE CASCADE,
+	FOREIGN KEY (agent) REFERENCES agents(name) ON DELETE CASCADE
+);
+`
+
+const pollsSchemaV19Down = `
+DROP TABLE IF EXISTS poll_votes;
+DROP TABLE IF EXISTS poll_options;
+DROP TABLE IF EXISTS polls;
+`
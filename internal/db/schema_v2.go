@@ -14,3 +14,8 @@ CREATE TABLE IF NOT EXISTS content_history (
 
 CREATE INDEX IF NOT EXISTS idx_content_history_content ON content_history(content_id, version DESC);
 `
+
+const editHistorySchemaV2Down = `
+DROP INDEX IF EXISTS idx_content_history_content;
+DROP TABLE IF EXISTS content_history;
+`
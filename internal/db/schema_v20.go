@@ -0,0 +1,43 @@
+package db
+
+// Read markers used to compare content rowids, which VACUUM and import's
+// replaces can renumber. content_seq keeps a stable change counter per item
+// instead: bumped when the item is written and again when its title or body
+// is edited, so edits count as unread too. It lives beside content because
+// updating content from a trigger would re-run the FTS triggers.
+const contentSeqSchemaV20 = `
+CREATE TABLE IF NOT EXISTS content_seq (
+	content_id TEXT PRIMARY KEY,
+	seq        INTEGER NOT NULL,
+	FOREIGN KEY (content_id) REFERENCES content(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_content_seq_seq ON content_seq(seq);
+INSERT OR IGNORE INTO content_seq (content_id, seq) SELECT id, rowid FROM content;
+
+CREATE TRIGGER IF NOT EXISTS content_seq_insert AFTER INSERT ON content BEGIN
+	INSERT OR REPLACE INTO content_seq (content_id, seq)
+	VALUES (new.id, (SELECT COALESCE(MAX(seq), 0) + 1 FROM content_seq));
+END;
+
+CREATE TRIGGER IF NOT EXISTS content_seq_edit AFTER UPDATE OF title, body ON content
+WHEN new.title IS NOT old.title OR new.body IS NOT old.body BEGIN
+	INSERT OR REPLACE INTO content_seq (content_id, seq)
+	VALUES (new.id, (SELECT COALESCE(MAX(seq), 0) + 1 FROM content_seq));
+END;
+
+ALTER TABLE thread_reads ADD COLUMN last_seq INTEGER NOT NULL DEFAULT 0;
+UPDATE thread_reads SET last_seq = last_rowid;
+`
+
+const contentSeqSchemaV20Down = `
+DROP TRIGGER IF EXISTS content_seq_edit;
+DROP TRIGGER IF EXISTS content_seq_insert;
+UPDATE thread_reads SET last_rowid = COALESCE((
+	SELECT MAX(c.rowid)
+	FROM content c
+	JOIN content_seq s ON s.content_id = c.id
+	WHERE c.thread_id = thread_reads.thread_id AND s.seq <= thread_reads.last_seq
+), 0);
+ALTER TABLE thread_reads DROP COLUMN last_seq;
+DROP TABLE IF EXISTS content_seq;
+`
@@ -0,0 +1,59 @@
+package db
+
+// groupNamesNocaseSchemaV21 makes group names case-insensitive, like the
+// agent names they share @mentions with. Groups that differ only in case
+// are merged into the oldest one, keeping every member.
+const groupNamesNocaseSchemaV21 = `
+CREATE TABLE agent_groups_new (
+	name        TEXT PRIMARY KEY COLLATE NOCASE,
+	description TEXT,
+	created     TEXT NOT NULL
+);
+INSERT OR IGNORE INTO agent_groups_new (name, description, created)
+SELECT name, description, created FROM agent_groups ORDER BY created, name;
+
+CREATE TABLE agent_group_members_new (
+	group_name TEXT NOT NULL COLLATE NOCASE,
+	agent      TEXT NOT NULL,
+	added      TEXT NOT NULL,
+	PRIMARY KEY (group_name, agent),
+	FOREIGN KEY (group_name) REFERENCES agent_groups(name) ON DELETE CASCADE,
+	FOREIGN KEY (agent)      REFERENCES agents(name) ON DELETE CASCADE
+);
+INSERT OR IGNORE INTO agent_group_members_new (group_name, agent, added)
+SELECT g.name, m.agent, m.added
+FROM agent_group_members m
+JOIN agent_groups_new g ON g.name = m.group_name
+ORDER BY m.added;
+
+DROP TABLE agent_group_members;
+DROP TABLE agent_groups;
+ALTER TABLE agent_groups_new RENAME TO agent_groups;
+ALTER TABLE agent_group_members_new RENAME TO agent_group_members;
+CREATE INDEX IF NOT EXISTS idx_agent_group_members_agent ON agent_group_members(agent);
+`
+
+const groupNamesNocaseSchemaV21Down = `
+CREATE TABLE agent_groups_old (
+	name        TEXT PRIMARY KEY,
+	description TEXT,
+	created     TEXT NOT NULL
+);
+INSERT INTO agent_groups_old SELECT name, description, created FROM agent_groups;
+
+CREATE TABLE agent_group_members_old (
+	group_name TEXT NOT NULL,
+	agent      TEXT NOT NULL,
+	added      TEXT NOT NULL,
+	PRIMARY KEY (group_name, agent),
+	FOREIGN KEY (group_name) REFERENCES agent_groups(name) ON DELETE CASCADE,
+	FOREIGN KEY (agent)      REFERENCES agents(name) ON DELETE CASCADE
+);
+INSERT INTO agent_group_members_old SELECT group_name, agent, added FROM agent_group_members;
+
+DROP TABLE agent_group_members;
+DROP TABLE agent_groups;
+ALTER TABLE agent_groups_old RENAME TO agent_groups;
+ALTER TABLE agent_group_members_old RENAME TO agent_group_members;
+CREATE INDEX IF NOT EXISTS idx_agent_group_members_agent ON agent_group_members(agent);
+`
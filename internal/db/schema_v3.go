@@ -12,3 +12,8 @@ CREATE TABLE IF NOT EXISTS webhooks (
 
 CREATE INDEX IF NOT EXISTS idx_webhooks_active ON webhooks(active);
 `
+
+const webhooksSchemaV3Down = `
+DROP INDEX IF EXISTS idx_webhooks_active;
+DROP TABLE IF EXISTS webhooks;
+`
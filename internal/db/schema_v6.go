@@ -7,3 +7,7 @@ CREATE TABLE IF NOT EXISTS system_settings (
 	updated_at TEXT NOT NULL DEFAULT (datetime('now'))
 );
 `
+
+const systemSettingsSchemaV6Down = `
+DROP TABLE IF EXISTS system_settings;
+`
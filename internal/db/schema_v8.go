@@ -0,0 +1,19 @@
+package db
+
+const idempotencyKeysSchemaV8 = `
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	agent       TEXT NOT NULL,
+	key         TEXT NOT NULL,
+	method      TEXT NOT NULL,
+	path        TEXT NOT NULL,
+	status      INTEGER NOT NULL DEFAULT 0,
+	response    TEXT NOT NULL DEFAULT '',
+	created_at  TEXT NOT NULL,
+	PRIMARY KEY (agent, key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created ON idempotency_keys(created_at);
+`
+
+const idempotencyKeysSchemaV8Down = `
+DROP TABLE IF EXISTS idempotency_keys;
+`
@@ -0,0 +1,20 @@
+package db
+
+const threadReadsSchemaV9 = `
+CREATE TABLE IF NOT EXISTS thread_reads (
+	agent        TEXT NOT NULL,
+	thread_id    TEXT NOT NULL,
+	last_read_at TEXT NOT NULL,
+	-- Highest content rowid seen; created timestamps only have one-second
+	-- resolution, so unread counts compare rowids.
+	last_rowid   INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (agent, thread_id),
+	FOREIGN KEY (agent)     REFERENCES agents(name) ON DELETE CASCADE,
+	FOREIGN KEY (thread_id) REFERENCES content(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_thread_reads_thread ON thread_reads(thread_id);
+`
+
+const threadReadsSchemaV9Down = `
+DROP TABLE IF EXISTS thread_reads;
+`
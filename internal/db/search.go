@@ -47,6 +47,11 @@ func searchWhereClause(params SearchParams) (string, []any) {
 }
 
 func CountSearchContent(ctx context.Context, database *sql.DB, params SearchParams) (int, error) {
+	tag, err := resolveTagAlias(ctx, database, strings.TrimSpace(params.Tag))
+	if err != nil {
+		return 0, err
+	}
+	params.Tag = tag
 	whereClause, args := searchWhereClause(params)
 	query := `
 SELECT COUNT(*)
@@ -70,6 +75,11 @@ func SearchContent(ctx context.Context, database *sql.DB, params SearchParams) (
 		offset = 0
 	}
 
+	tag, err := resolveTagAlias(ctx, database, strings.TrimSpace(params.Tag))
+	if err != nil {
+		return nil, err
+	}
+	params.Tag = tag
 	whereClause, args := searchWhereClause(params)
 	query := `
 SELECT c.id, c.type, COALESCE(c.title, ''), c.author, c.thread_id, COALESCE(c.board_id, ''), c.created,
@@ -20,17 +20,17 @@ func TestCountSearchContentIgnoresPaginationAndRespectsFilters(t *testing.T) {
 		t.Fatalf("create bob: %v", err)
 	}
 
-	postA, err := CreatePost(ctx, database, "alice", strPtr("Auth A"), "authentication alpha", []string{"security"}, nil, "general")
+	postA, err := CreatePost(ctx, database, "alice", strPtr("Auth A"), "authentication alpha", []string{"security"}, nil, "general", nil)
 	if err != nil {
 		t.Fatalf("create post A: %v", err)
 	}
 	if _, err := CreateReply(ctx, database, "bob", postA.ID, "authentication beta", nil); err != nil {
 		t.Fatalf("create reply: %v", err)
 	}
-	if _, err := CreatePost(ctx, database, "bob", strPtr("Auth B"), "authentication gamma", []string{"security"}, nil, "general"); err != nil {
+	if _, err := CreatePost(ctx, database, "bob", strPtr("Auth B"), "authentication gamma", []string{"security"}, nil, "general", nil); err != nil {
 		t.Fatalf("create post B: %v", err)
 	}
-	if _, err := CreatePost(ctx, database, "alice", strPtr("Unrelated"), "deployment checklist", []string{"ops"}, nil, "general"); err != nil {
+	if _, err := CreatePost(ctx, database, "alice", strPtr("Unrelated"), "deployment checklist", []string{"ops"}, nil, "general", nil); err != nil {
 		t.Fatalf("create unrelated post: %v", err)
 	}
 
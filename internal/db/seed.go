@@ -45,7 +45,14 @@ var defaultBoardSeeds = []defaultBoardSeed{
 	},
 }
 
+// defaultBoardsSeededKey records that the default boards were created once,
+// so boards an admin deletes later are not brought back on restart.
+const defaultBoardsSeededKey = "default_boards_seeded"
+
 func SeedDefaultBoards(ctx context.Context, database *sql.DB) error {
+	if _, done, err := GetSetting(ctx, database, defaultBoardsSeededKey); err != nil || done {
+		return err
+	}
 	tx, err := database.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -63,5 +70,8 @@ VALUES (?, ?, ?, ?, ?)`,
 		}
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return SetSetting(ctx, database, defaultBoardsSeededKey, nowRFC3339())
 }
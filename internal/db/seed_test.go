@@ -126,3 +126,23 @@ VALUES (?, ?, ?, ?, ?)`,
 		t.Fatalf("expected existing icon to be preserved, got %q", board.Icon)
 	}
 }
+
+func TestSeedDefaultBoardsRunsOnceSoDeletedBoardsStayDeleted(t *testing.T) {
+	ctx := context.Background()
+	database, dbPath := openTestDB(t, "seed-default-boards-once.db")
+	defer database.Close()
+	defer os.Remove(dbPath)
+
+	if err := SeedDefaultBoards(ctx, database); err != nil {
+		t.Fatalf("seed default boards: %v", err)
+	}
+	if err := DeleteBoard(ctx, database, "watercooler"); err != nil {
+		t.Fatalf("delete board: %v", err)
+	}
+	if err := SeedDefaultBoards(ctx, database); err != nil {
+		t.Fatalf("seed default boards again: %v", err)
+	}
+	if ok, err := BoardExists(ctx, database, "watercooler"); err != nil || ok {
+		t.Fatalf("deleted default board was re-seeded: exists=%v err=%v", ok, err)
+	}
+}
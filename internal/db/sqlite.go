@@ -3,9 +3,8 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"os"
 	"time"
-
-	_ "modernc.org/sqlite"
 )
 
 var sqlitePragmas = []string{
@@ -16,28 +15,65 @@ var sqlitePragmas = []string{
 	"PRAGMA cache_size = -64000",
 }
 
-func Open(path string) (*sql.DB, error) {
-	database, err := sql.Open("sqlite", path)
-	if err != nil {
-		return nil, fmt.Errorf("sql open: %w", err)
+// Options controls the connection pool and the pragmas applied to every
+// pooled connection.
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxIdleTime time.Duration
+	Pragmas         []string
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxOpenConns:    10,
+		MaxIdleConns:    5,
+		ConnMaxIdleTime: 30 * time.Minute,
+		Pragmas:         sqlitePragmas,
 	}
+}
+
+func Open(path string) (*sql.DB, error) {
+	return OpenWithOptions(path, DefaultOptions())
+}
 
-	database.SetMaxOpenConns(10)
-	database.SetMaxIdleConns(5)
+func OpenWithOptions(path string, opts Options) (*sql.DB, error) {
+	database := sql.OpenDB(newInstrumentedConnector(path, opts.Pragmas))
+
+	database.SetMaxOpenConns(opts.MaxOpenConns)
+	database.SetMaxIdleConns(opts.MaxIdleConns)
 	database.SetConnMaxLifetime(0)
-	database.SetConnMaxIdleTime(30 * time.Minute)
+	database.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
 
 	if err := database.Ping(); err != nil {
 		_ = database.Close()
 		return nil, fmt.Errorf("ping: %w", err)
 	}
 
-	for _, pragma := range sqlitePragmas {
-		if _, err := database.Exec(pragma); err != nil {
-			_ = database.Close()
-			return nil, fmt.Errorf("apply pragma %q: %w", pragma, err)
-		}
+	return database, nil
+}
+
+// FilePath returns the on-disk path of the main database, or "" for an
+// in-memory database.
+func FilePath(database *sql.DB) (string, error) {
+	var (
+		seq        int
+		name, file string
+	)
+	if err := database.QueryRow(`SELECT seq, name, file FROM pragma_database_list WHERE name = 'main'`).Scan(&seq, &name, &file); err != nil {
+		return "", err
 	}
+	return file, nil
+}
 
-	return database, nil
+// WALSize reports the size in bytes of the write-ahead log next to path.
+func WALSize(path string) int64 {
+	if path == "" {
+		return 0
+	}
+	info, err := os.Stat(path + "-wal")
+	if err != nil {
+		return 0
+	}
+	return info.Size()
 }
@@ -0,0 +1,345 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrTagNotAllowed is returned when a post on a board with restrict_tags
+	// set is given a tag that is not one of the board's tags.
+	ErrTagNotAllowed = errors.New("tag not allowed on this board")
+	// ErrTagExists is returned when renaming onto a tag that is already in
+	// use; merging is the way to combine two tags.
+	ErrTagExists = errors.New("tag already exists; merge instead")
+	// ErrInvalidTagChange wraps rejected rename, merge and alias requests.
+	ErrInvalidTagChange = errors.New("invalid tag change")
+)
+
+type TagUsage struct {
+	Tag      string   `json:"tag"`
+	Count    int      `json:"count"`
+	LastUsed string   `json:"last_used,omitempty"`
+	Boards   []string `json:"boards"`
+}
+
+type ListTagsParams struct {
+	Board  string
+	Prefix string
+	Limit  int
+	Offset int
+}
+
+type TagAlias struct {
+	Alias   string `json:"alias"`
+	Tag     string `json:"tag"`
+	Created string `json:"created"`
+}
+
+// ListTagUsage returns every tag used on content or declared by a board, most
+// used first. Boards lists the boards that declare the tag.
+func ListTagUsage(ctx context.Context, database *sql.DB, params ListTagsParams) ([]TagUsage, error) {
+	usedWhere, declaredWhere := "", ""
+	args := []any{}
+	if params.Board != "" {
+		usedWhere = "WHERE c.board_id = ?"
+		declaredWhere = "WHERE board_id = ?"
+		args = append(args, params.Board, params.Board)
+	}
+	args = append(args, escapeLike(params.Prefix)+"%")
+	limit := params.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	args = append(args, limit, params.Offset)
+	rows, err := database.QueryContext(ctx, `
+WITH used AS (
+	SELECT t.tag, COUNT(1) AS n, MAX(c.created) AS last_used
+	FROM tags t
+	JOIN content c ON c.id = t.content_id
+	`+usedWhere+`
+	GROUP BY t.tag
+), declared AS (
+	SELECT tag, group_concat(board_id) AS boards
+	FROM board_tags
+	`+declaredWhere+`
+	GROUP BY tag
+), all_tags AS (
+	SELECT tag FROM used
+	UNION
+	SELECT tag FROM declared
+)
+SELECT a.tag, COALESCE(u.n, 0), COALESCE(u.last_used, ''), COALESCE(d.boards, '')
+FROM all_tags a
+LEFT JOIN used u ON u.tag = a.tag
+LEFT JOIN declared d ON d.tag = a.tag
+WHERE a.tag LIKE ? ESCAPE '\'
+ORDER BY 2 DESC, a.tag ASC
+LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]TagUsage, 0)
+	for rows.Next() {
+		var (
+			u      TagUsage
+			boards string
+		)
+		if err := rows.Scan(&u.Tag, &u.Count, &u.LastUsed, &boards); err != nil {
+			return nil, err
+		}
+		u.Boards = []string{}
+		if boards != "" {
+			u.Boards = strings.Split(boards, ",")
+			sort.Strings(u.Boards)
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// RenameTag renames a tag on content and boards. It fails with ErrTagExists
+// when to is already in use. With alias set, later writes of from are
+// stored as to. It returns the number of posts whose tags changed.
+func RenameTag(ctx context.Context, database *sql.DB, from, to string, alias bool) (int, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if from == "" || to == "" {
+		return 0, fmt.Errorf("%w: from and to are required", ErrInvalidTagChange)
+	}
+	if from == to {
+		return 0, fmt.Errorf("%w: from and to are the same tag", ErrInvalidTagChange)
+	}
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if to, err = resolveTagAlias(ctx, tx, to); err != nil {
+		return 0, err
+	}
+	if from == to {
+		return 0, fmt.Errorf("%w: to is an alias of from", ErrInvalidTagChange)
+	}
+	var inUse int
+	if err := tx.QueryRowContext(ctx, `
+SELECT (SELECT COUNT(1) FROM tags WHERE tag = ?) + (SELECT COUNT(1) FROM board_tags WHERE tag = ?)`, to, to).Scan(&inUse); err != nil {
+		return 0, err
+	}
+	if inUse > 0 {
+		return 0, ErrTagExists
+	}
+	n, err := mergeTagTx(ctx, tx, from, to, alias)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// MergeTags folds every tag in from into to, on content and boards. With
+// alias set, later writes of any of from are stored as to. It returns the
+// number of posts whose tags changed.
+func MergeTags(ctx context.Context, database *sql.DB, from []string, to string, alias bool) (int, error) {
+	to = strings.TrimSpace(to)
+	from = dedupeTags(from)
+	if len(from) == 0 || to == "" {
+		return 0, fmt.Errorf("%w: from and to are required", ErrInvalidTagChange)
+	}
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if to, err = resolveTagAlias(ctx, tx, to); err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, tag := range from {
+		if tag == to {
+			continue
+		}
+		n, err := mergeTagTx(ctx, tx, tag, to, alias)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, tx.Commit()
+}
+
+func mergeTagTx(ctx context.Context, tx *sql.Tx, from, to string, alias bool) (int, error) {
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(1) FROM tags WHERE tag = ?`, from).Scan(&n); err != nil {
+		return 0, err
+	}
+	// Rows whose content or board already has to are left for the DELETE.
+	for _, stmt := range []string{
+		`UPDATE OR IGNORE tags SET tag = ? WHERE tag = ?`,
+		`UPDATE OR IGNORE board_tags SET tag = ? WHERE tag = ?`,
+		`UPDATE tag_aliases SET tag = ? WHERE tag = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, to, from); err != nil {
+			return 0, err
+		}
+	}
+	for _, stmt := range []string{
+		`DELETE FROM tags WHERE tag = ?`,
+		`DELETE FROM board_tags WHERE tag = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, from); err != nil {
+			return 0, err
+		}
+	}
+	if alias {
+		if err := setTagAliasTx(ctx, tx, from, to); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+func ListTagAliases(ctx context.Context, database *sql.DB) ([]TagAlias, error) {
+	rows, err := database.QueryContext(ctx, `
+SELECT alias, tag, created
+FROM tag_aliases
+ORDER BY tag ASC, alias ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]TagAlias, 0)
+	for rows.Next() {
+		var a TagAlias
+		if err := rows.Scan(&a.Alias, &a.Tag, &a.Created); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// SetTagAlias makes later writes of alias (in any case) store tag. An alias
+// of an alias resolves to the final tag, and aliases that pointed at alias
+// are redirected to tag.
+func SetTagAlias(ctx context.Context, database *sql.DB, alias, tag string) (*TagAlias, error) {
+	alias, tag = strings.TrimSpace(alias), strings.TrimSpace(tag)
+	if alias == "" || tag == "" {
+		return nil, fmt.Errorf("%w: alias and tag are required", ErrInvalidTagChange)
+	}
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if tag, err = resolveTagAlias(ctx, tx, tag); err != nil {
+		return nil, err
+	}
+	if tag == alias {
+		return nil, fmt.Errorf("%w: an alias cannot point at itself", ErrInvalidTagChange)
+	}
+	// Content and boards already tagged with alias are folded into tag, so
+	// filters that resolve alias to tag keep finding them.
+	if _, err := mergeTagTx(ctx, tx, alias, tag, true); err != nil {
+		return nil, err
+	}
+	a := &TagAlias{}
+	if err := tx.QueryRowContext(ctx, `SELECT alias, tag, created FROM tag_aliases WHERE alias = ?`, alias).Scan(&a.Alias, &a.Tag, &a.Created); err != nil {
+		return nil, err
+	}
+	return a, tx.Commit()
+}
+
+func setTagAliasTx(ctx context.Context, tx *sql.Tx, alias, tag string) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE tag_aliases SET tag = ? WHERE tag = ?`, tag, alias); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+INSERT INTO tag_aliases (alias, tag, created)
+VALUES (?, ?, ?)
+ON CONFLICT (alias) DO UPDATE SET alias = excluded.alias, tag = excluded.tag`, alias, tag, nowRFC3339())
+	return err
+}
+
+func DeleteTagAlias(ctx context.Context, database *sql.DB, alias string) error {
+	res, err := database.ExecContext(ctx, `DELETE FROM tag_aliases WHERE alias = ?`, strings.TrimSpace(alias))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// resolveTagAliases maps each tag through tag_aliases and dedupes the
+// result, keeping the first occurrence.
+func resolveTagAliases(ctx context.Context, tx rowQuerier, tags []string) ([]string, error) {
+	tags = dedupeTags(tags)
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		resolved, err := resolveTagAlias(ctx, tx, tag)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, resolved)
+	}
+	return dedupeTags(out), nil
+}
+
+func resolveTagAlias(ctx context.Context, tx rowQuerier, tag string) (string, error) {
+	var target string
+	err := tx.QueryRowContext(ctx, `SELECT tag FROM tag_aliases WHERE alias = ?`, tag).Scan(&target)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tag, nil
+	}
+	return target, err
+}
+
+// checkBoardAllowsTagsTx enforces a board's allow-list: on a board with
+// restrict_tags set, every tag must be one of the board's tags.
+func checkBoardAllowsTagsTx(ctx context.Context, tx *sql.Tx, boardID string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	var restricted bool
+	err := tx.QueryRowContext(ctx, `SELECT restrict_tags FROM boards WHERE id = ?`, boardID).Scan(&restricted)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !restricted) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	allowed, err := listBoardTagsTx(ctx, tx, boardID)
+	if err != nil {
+		return err
+	}
+	ok := map[string]bool{}
+	for _, t := range allowed {
+		ok[t] = true
+	}
+	var rejected []string
+	for _, t := range tags {
+		if !ok[t] {
+			rejected = append(rejected, t)
+		}
+	}
+	if len(rejected) > 0 {
+		return fmt.Errorf("%w: %s", ErrTagNotAllowed, strings.Join(rejected, ", "))
+	}
+	return nil
+}
@@ -68,6 +68,23 @@ func ListWebhooks(ctx context.Context, database *sql.DB, activeOnly bool) ([]mod
 	return out, rows.Err()
 }
 
+func GetWebhook(ctx context.Context, database *sql.DB, id string) (*models.Webhook, error) {
+	var (
+		w         models.Webhook
+		eventsRaw string
+		activeInt int
+	)
+	err := database.QueryRowContext(ctx, `
+SELECT id, url, events, COALESCE(secret, ''), created, active FROM webhooks WHERE id = ?`, id).
+		Scan(&w.ID, &w.URL, &eventsRaw, &w.Secret, &w.Created, &activeInt)
+	if err != nil {
+		return nil, err
+	}
+	_ = json.Unmarshal([]byte(eventsRaw), &w.Events)
+	w.Active = activeInt == 1
+	return &w, nil
+}
+
 func DeleteWebhook(ctx context.Context, database *sql.DB, id string) error {
 	res, err := database.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
 	if err != nil {
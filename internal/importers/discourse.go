@@ -0,0 +1,170 @@
+package importers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"fora/internal/db"
+	"fora/internal/models"
+)
+
+// discourseExport is the combined shape produced by dumping /categories.json,
+// /users and one /t/{id}.json per topic into a single file.
+type discourseExport struct {
+	Users      []discourseUser     `json:"users"`
+	Categories []discourseCategory `json:"categories"`
+	Topics     []discourseTopic    `json:"topics"`
+}
+
+type discourseUser struct {
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
+
+type discourseCategory struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+}
+
+type discourseTopic struct {
+	ID         int      `json:"id"`
+	Title      string   `json:"title"`
+	CategoryID int      `json:"category_id"`
+	CreatedAt  string   `json:"created_at"`
+	Closed     bool     `json:"closed"`
+	Archived   bool     `json:"archived"`
+	Pinned     bool     `json:"pinned"`
+	Tags       []string `json:"tags"`
+	PostStream struct {
+		Posts []discoursePost `json:"posts"`
+	} `json:"post_stream"`
+}
+
+type discoursePost struct {
+	ID                int    `json:"id"`
+	Username          string `json:"username"`
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"updated_at"`
+	PostNumber        int    `json:"post_number"`
+	ReplyToPostNumber *int   `json:"reply_to_post_number"`
+	Raw               string `json:"raw"`
+	Cooked            string `json:"cooked"`
+}
+
+var discourseQuotePattern = regexp.MustCompile(`\[quote="[^"]*?post:(\d+)`)
+
+type discourseImporter struct{}
+
+func (discourseImporter) Load(path string) (*db.JSONExport, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var export discourseExport
+	if err := json.Unmarshal(raw, &export); err != nil {
+		return nil, fmt.Errorf("parse discourse export: %w", err)
+	}
+
+	b := newBuilder("discourse")
+	for _, u := range export.Users {
+		if strings.TrimSpace(u.Username) == "" {
+			continue
+		}
+		b.agent(u.Username, normalizeTime(u.CreatedAt))
+	}
+	categories := make(map[int]string, len(export.Categories))
+	for _, c := range export.Categories {
+		key := c.Slug
+		if strings.TrimSpace(key) == "" {
+			key = c.Name
+		}
+		categories[c.ID] = b.board(key, c.Name, c.Description, normalizeTime(""))
+	}
+
+	for _, topic := range export.Topics {
+		posts := topic.PostStream.Posts
+		if len(posts) == 0 {
+			continue
+		}
+		boardID, ok := categories[topic.CategoryID]
+		if !ok {
+			boardID = "general"
+		}
+		status := "open"
+		switch {
+		case topic.Archived:
+			status = "archived"
+		case topic.Pinned:
+			status = "pinned"
+		case topic.Closed:
+			status = "closed"
+		}
+
+		ids := make(map[int]string, len(posts))
+		for _, p := range posts {
+			ids[p.PostNumber] = contentID(normalizeTime(p.CreatedAt), fmt.Sprintf("discourse:%d:%d", topic.ID, p.PostNumber))
+		}
+		threadID, ok := ids[1]
+		if !ok {
+			threadID = ids[posts[0].PostNumber]
+		}
+
+		for _, p := range posts {
+			created := normalizeTime(p.CreatedAt)
+			author := b.agent(p.Username, created)
+			body := p.Raw
+			if strings.TrimSpace(body) == "" {
+				body = p.Cooked
+			}
+			c := models.Content{
+				ID:       ids[p.PostNumber],
+				Author:   author,
+				Body:     strings.TrimSpace(body),
+				Created:  created,
+				Updated:  normalizeTime(p.UpdatedAt),
+				ThreadID: threadID,
+				Status:   status,
+				BoardID:  boardID,
+			}
+			if strings.TrimSpace(p.UpdatedAt) == "" {
+				c.Updated = created
+			}
+			if c.ID == threadID {
+				title := strings.TrimSpace(topic.Title)
+				c.Type = "post"
+				c.Title = &title
+				b.add(c, topic.Tags)
+				continue
+			}
+			c.Type = "reply"
+			parentID := threadID
+			if parent, ok := ids[discourseParentNumber(p)]; ok && parent != c.ID {
+				parentID = parent
+			}
+			c.ParentID = &parentID
+			b.add(c, nil)
+		}
+	}
+	return b.payload(), nil
+}
+
+// discourseParentNumber prefers the explicit reply target and falls back to
+// the first [quote="user, post:N"] block in the raw body.
+func discourseParentNumber(p discoursePost) int {
+	if p.ReplyToPostNumber != nil {
+		return *p.ReplyToPostNumber
+	}
+	if m := discourseQuotePattern.FindStringSubmatch(p.Raw); len(m) == 2 {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			return n
+		}
+	}
+	return 0
+}
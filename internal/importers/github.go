@@ -0,0 +1,175 @@
+package importers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"fora/internal/db"
+	"fora/internal/models"
+)
+
+// githubResponse matches a saved GraphQL response for
+// repository { discussions { nodes { ... comments { nodes { ... replies } } } } }.
+// A dump may hold one response or an array of paginated responses.
+type githubResponse struct {
+	Data struct {
+		Repository struct {
+			Discussions struct {
+				Nodes []githubDiscussion `json:"nodes"`
+			} `json:"discussions"`
+		} `json:"repository"`
+	} `json:"data"`
+}
+
+type githubActor struct {
+	Login string `json:"login"`
+}
+
+type githubDiscussion struct {
+	ID        string       `json:"id"`
+	Number    int          `json:"number"`
+	Title     string       `json:"title"`
+	Body      string       `json:"body"`
+	CreatedAt string       `json:"createdAt"`
+	UpdatedAt string       `json:"updatedAt"`
+	Closed    bool         `json:"closed"`
+	Locked    bool         `json:"locked"`
+	Author    *githubActor `json:"author"`
+	Category  *struct {
+		Name        string `json:"name"`
+		Slug        string `json:"slug"`
+		Description string `json:"description"`
+	} `json:"category"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Comments struct {
+		Nodes []githubComment `json:"nodes"`
+	} `json:"comments"`
+}
+
+type githubComment struct {
+	ID        string       `json:"id"`
+	Body      string       `json:"body"`
+	CreatedAt string       `json:"createdAt"`
+	UpdatedAt string       `json:"updatedAt"`
+	Author    *githubActor `json:"author"`
+	ReplyTo   *struct {
+		ID string `json:"id"`
+	} `json:"replyTo"`
+	Replies struct {
+		Nodes []githubComment `json:"nodes"`
+	} `json:"replies"`
+}
+
+type githubDiscussionsImporter struct{}
+
+func (githubDiscussionsImporter) Load(path string) (*db.JSONExport, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pages []githubResponse
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &pages); err != nil {
+			return nil, fmt.Errorf("parse github discussions dump: %w", err)
+		}
+	} else {
+		var page githubResponse
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return nil, fmt.Errorf("parse github discussions dump: %w", err)
+		}
+		pages = append(pages, page)
+	}
+
+	b := newBuilder("github-discussions")
+	for _, page := range pages {
+		for _, d := range page.Data.Repository.Discussions.Nodes {
+			created := normalizeTime(d.CreatedAt)
+			boardID := "general"
+			if d.Category != nil {
+				key := d.Category.Slug
+				if strings.TrimSpace(key) == "" {
+					key = d.Category.Name
+				}
+				boardID = b.board(key, d.Category.Name, d.Category.Description, created)
+			}
+			status := "open"
+			if d.Locked {
+				status = "archived"
+			} else if d.Closed {
+				status = "closed"
+			}
+			tags := make([]string, 0, len(d.Labels.Nodes))
+			for _, l := range d.Labels.Nodes {
+				tags = append(tags, l.Name)
+			}
+
+			title := strings.TrimSpace(d.Title)
+			threadID := contentID(created, "github:"+githubKey(d.ID, d.Number))
+			b.add(models.Content{
+				ID:       threadID,
+				Type:     "post",
+				Author:   b.agent(githubLogin(d.Author), created),
+				Title:    &title,
+				Body:     strings.TrimSpace(d.Body),
+				Created:  created,
+				Updated:  normalizeTime(d.UpdatedAt),
+				ThreadID: threadID,
+				Status:   status,
+				BoardID:  boardID,
+			}, tags)
+
+			ids := map[string]string{}
+			var addComments func(comments []githubComment, parentID string)
+			addComments = func(comments []githubComment, parentID string) {
+				for _, c := range comments {
+					commentCreated := normalizeTime(c.CreatedAt)
+					id := contentID(commentCreated, "github:"+c.ID)
+					ids[c.ID] = id
+					parent := parentID
+					if c.ReplyTo != nil {
+						if resolved, ok := ids[c.ReplyTo.ID]; ok {
+							parent = resolved
+						}
+					}
+					b.add(models.Content{
+						ID:       id,
+						Type:     "reply",
+						Author:   b.agent(githubLogin(c.Author), commentCreated),
+						Body:     strings.TrimSpace(c.Body),
+						Created:  commentCreated,
+						Updated:  normalizeTime(c.UpdatedAt),
+						ThreadID: threadID,
+						ParentID: &parent,
+						Status:   status,
+						BoardID:  boardID,
+					}, nil)
+					addComments(c.Replies.Nodes, id)
+				}
+			}
+			addComments(d.Comments.Nodes, threadID)
+		}
+	}
+	return b.payload(), nil
+}
+
+// githubLogin maps deleted accounts to GitHub's own "ghost" placeholder.
+func githubLogin(actor *githubActor) string {
+	if actor == nil || strings.TrimSpace(actor.Login) == "" {
+		return "ghost"
+	}
+	return actor.Login
+}
+
+func githubKey(id string, number int) string {
+	if strings.TrimSpace(id) != "" {
+		return id
+	}
+	return fmt.Sprintf("discussion:%d", number)
+}
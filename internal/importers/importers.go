@@ -0,0 +1,244 @@
+// Package importers converts discussion archives from other systems into the
+// db.JSONExport shape so they can be loaded with db.ImportPayload.
+package importers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"fora/internal/db"
+	"fora/internal/models"
+)
+
+// Importer parses an external archive at path into a Fora export payload.
+type Importer interface {
+	Load(path string) (*db.JSONExport, error)
+}
+
+var registry = map[string]Importer{
+	"discourse":          discourseImporter{},
+	"github-discussions": githubDiscussionsImporter{},
+	"mbox":               mboxImporter{},
+}
+
+var aliases = map[string]string{
+	"github": "github-discussions",
+}
+
+func Lookup(kind string) (Importer, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if alias, ok := aliases[kind]; ok {
+		kind = alias
+	}
+	imp, ok := registry[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown import source %q (supported: %s)", kind, strings.Join(Kinds(), ", "))
+	}
+	return imp, nil
+}
+
+func Kinds() []string {
+	out := make([]string, 0, len(registry))
+	for kind := range registry {
+		out = append(out, kind)
+	}
+	sort.Strings(out)
+	return out
+}
+
+var (
+	agentNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+	boardIDInvalidChars   = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// builder accumulates agents, boards and content for one import run and
+// resolves external identities to stable Fora names and IDs.
+type builder struct {
+	source  string
+	names   map[string]string // lower-cased source identity -> agent name
+	agents  map[string]models.Agent
+	boards  map[string]db.Board
+	content []models.Content
+	tags    map[string][]string
+}
+
+func newBuilder(source string) *builder {
+	return &builder{
+		source: source,
+		names:  map[string]string{},
+		agents: map[string]models.Agent{},
+		boards: map[string]db.Board{},
+		tags:   map[string][]string{},
+	}
+}
+
+func (b *builder) agent(raw, created string) string {
+	key := strings.ToLower(strings.TrimSpace(raw))
+	if name, ok := b.names[key]; ok {
+		return name
+	}
+	name := agentName(raw)
+	if _, taken := b.agents[name]; taken {
+		// Another identity already has this name, e.g. alice@a.example
+		// before alice@b.example; fall back to the whole address.
+		base := agentName(strings.ReplaceAll(key, "@", "-"))
+		name = base
+		for i := 2; ; i++ {
+			if _, taken := b.agents[name]; !taken {
+				break
+			}
+			suffix := fmt.Sprintf("-%d", i)
+			name = base[:min(len(base), 64-len(suffix))] + suffix
+		}
+	}
+	b.names[key] = name
+	metadata := fmt.Sprintf("imported from %s: %s", b.source, strings.TrimSpace(raw))
+	b.agents[name] = models.Agent{
+		Name:     name,
+		Role:     "agent",
+		Created:  created,
+		Metadata: &metadata,
+	}
+	return name
+}
+
+func (b *builder) board(key, name, description, created string) string {
+	id := boardID(key)
+	if id == "" {
+		return "general"
+	}
+	if _, ok := b.boards[id]; ok {
+		return id
+	}
+	b.boards[id] = db.Board{
+		ID:          id,
+		Name:        strings.TrimSpace(name),
+		Description: strings.TrimSpace(description),
+		Created:     created,
+	}
+	return id
+}
+
+func (b *builder) add(c models.Content, tags []string) {
+	if c.Status == "" {
+		c.Status = "open"
+	}
+	if c.Updated == "" {
+		c.Updated = c.Created
+	}
+	b.content = append(b.content, c)
+	if len(tags) > 0 {
+		b.tags[c.ID] = append(b.tags[c.ID], tags...)
+	}
+}
+
+// payload orders content so posts come first and every reply follows its
+// parent, then returns the assembled export.
+func (b *builder) payload() *db.JSONExport {
+	out := &db.JSONExport{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Tags:       b.tags,
+		Mentions:   map[string][]string{},
+	}
+	for _, a := range b.agents {
+		out.Agents = append(out.Agents, a)
+	}
+	slices.SortFunc(out.Agents, func(x, y models.Agent) int { return strings.Compare(x.Name, y.Name) })
+	for _, board := range b.boards {
+		out.Boards = append(out.Boards, board)
+	}
+	slices.SortFunc(out.Boards, func(x, y db.Board) int { return strings.Compare(x.ID, y.ID) })
+
+	byID := make(map[string]models.Content, len(b.content))
+	children := map[string][]models.Content{}
+	for _, c := range b.content {
+		byID[c.ID] = c
+	}
+	roots := make([]models.Content, 0)
+	for _, c := range b.content {
+		if c.Type == "post" {
+			roots = append(roots, c)
+			continue
+		}
+		if c.ParentID == nil {
+			continue
+		}
+		if _, ok := byID[*c.ParentID]; !ok {
+			continue
+		}
+		children[*c.ParentID] = append(children[*c.ParentID], c)
+	}
+	byCreated := func(x, y models.Content) int { return strings.Compare(x.Created, y.Created) }
+	slices.SortStableFunc(roots, byCreated)
+
+	out.Content = append(out.Content, roots...)
+	queue := append([]models.Content(nil), roots...)
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		kids := children[next.ID]
+		slices.SortStableFunc(kids, byCreated)
+		out.Content = append(out.Content, kids...)
+		queue = append(queue, kids...)
+	}
+	return out
+}
+
+func agentName(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if at := strings.Index(raw, "@"); at > 0 {
+		raw = raw[:at]
+	}
+	name := agentNameInvalidChars.ReplaceAllString(raw, "-")
+	name = strings.Trim(name, "-_")
+	if name == "" {
+		return "unknown"
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+func boardID(name string) string {
+	id := strings.ToLower(strings.TrimSpace(name))
+	id = boardIDInvalidChars.ReplaceAllString(strings.ReplaceAll(id, " ", "-"), "-")
+	return strings.Trim(id, "-")
+}
+
+// contentID mirrors the timestamp-hash shape of natively created content while
+// staying stable across repeated imports of the same source record.
+func contentID(created, sourceKey string) string {
+	timestamp := "00010101T000000Z"
+	if t, err := time.Parse(time.RFC3339, created); err == nil {
+		timestamp = t.UTC().Format("20060102T150405Z")
+	}
+	sum := sha256.Sum256([]byte(sourceKey))
+	return fmt.Sprintf("%s-%s", timestamp, hex.EncodeToString(sum[:])[:8])
+}
+
+// normalizeTime formats raw as RFC 3339 UTC. Unparseable input maps to the
+// Unix epoch rather than the current time, since content IDs are derived
+// from it and must stay the same when the same export is imported again.
+func normalizeTime(raw string) string {
+	raw = strings.TrimSpace(raw)
+	layouts := []string{
+		time.RFC3339Nano,
+		time.RFC3339,
+		time.RFC1123Z,
+		time.RFC1123,
+		"2006-01-02 15:04:05",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+	}
+	return time.Unix(0, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,267 @@
+package importers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fora/internal/db"
+	"fora/internal/models"
+)
+
+func writeFixture(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	return path
+}
+
+func loadInto(t *testing.T, kind, path string) *db.JSONExport {
+	t.Helper()
+	imp, err := Lookup(kind)
+	if err != nil {
+		t.Fatalf("lookup %s: %v", kind, err)
+	}
+	payload, err := imp.Load(path)
+	if err != nil {
+		t.Fatalf("load %s: %v", kind, err)
+	}
+	database, err := db.Open(filepath.Join(t.TempDir(), "fora.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := db.ApplyMigrations(database); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := db.ImportPayload(context.Background(), database, payload); err != nil {
+		t.Fatalf("import payload: %v", err)
+	}
+	// Importing the same archive twice must not duplicate anything.
+	if err := db.ImportPayload(context.Background(), database, payload); err != nil {
+		t.Fatalf("re-import payload: %v", err)
+	}
+	return payload
+}
+
+func findContent(t *testing.T, payload *db.JSONExport, match func(models.Content) bool) models.Content {
+	t.Helper()
+	for _, c := range payload.Content {
+		if match(c) {
+			return c
+		}
+	}
+	t.Fatalf("content not found")
+	return models.Content{}
+}
+
+func bodyIs(body string) func(models.Content) bool {
+	return func(c models.Content) bool { return c.Body == body }
+}
+
+func TestDiscourseImport(t *testing.T) {
+	path := writeFixture(t, "discourse.json", `{
+  "users": [{"username": "alice", "created_at": "2024-01-01T00:00:00Z"}],
+  "categories": [{"id": 7, "name": "Site Feedback", "slug": "site-feedback", "description": "Talk about the site"}],
+  "topics": [{
+    "id": 42, "title": "Dark mode?", "category_id": 7, "created_at": "2024-02-01T10:00:00Z", "closed": true,
+    "tags": ["ui"],
+    "post_stream": {"posts": [
+      {"id": 1, "username": "alice", "created_at": "2024-02-01T10:00:00Z", "post_number": 1, "raw": "Can we have dark mode?"},
+      {"id": 2, "username": "bob", "created_at": "2024-02-01T11:00:00Z", "post_number": 2, "raw": "Yes please"},
+      {"id": 3, "username": "carol", "created_at": "2024-02-01T12:00:00Z", "post_number": 3, "raw": "[quote=\"bob, post:2, topic:42\"]Yes please[/quote]\nSeconded"}
+    ]}
+  }]
+}`)
+	payload := loadInto(t, "discourse", path)
+
+	if len(payload.Boards) != 1 || payload.Boards[0].ID != "site-feedback" {
+		t.Fatalf("unexpected boards: %+v", payload.Boards)
+	}
+	if len(payload.Agents) != 3 {
+		t.Fatalf("expected 3 agents, got %+v", payload.Agents)
+	}
+	post := findContent(t, payload, func(c models.Content) bool { return c.Type == "post" })
+	if post.BoardID != "site-feedback" || post.Status != "closed" || post.Title == nil || *post.Title != "Dark mode?" {
+		t.Fatalf("unexpected post: %+v", post)
+	}
+	if tags := payload.Tags[post.ID]; len(tags) != 1 || tags[0] != "ui" {
+		t.Fatalf("unexpected tags: %v", tags)
+	}
+	bob := findContent(t, payload, bodyIs("Yes please"))
+	carol := findContent(t, payload, func(c models.Content) bool { return c.Author == "carol" })
+	if bob.ParentID == nil || *bob.ParentID != post.ID {
+		t.Fatalf("expected bob's reply under the topic, got %+v", bob.ParentID)
+	}
+	if carol.ParentID == nil || *carol.ParentID != bob.ID {
+		t.Fatalf("expected quoted reply under bob's post, got %+v", carol.ParentID)
+	}
+}
+
+func TestGitHubDiscussionsImport(t *testing.T) {
+	path := writeFixture(t, "discussions.json", `[{"data": {"repository": {"discussions": {"nodes": [{
+  "id": "D_1", "number": 1, "title": "Release cadence", "body": "Monthly?",
+  "createdAt": "2024-03-01T09:00:00Z", "updatedAt": "2024-03-01T09:00:00Z",
+  "author": {"login": "octocat"},
+  "category": {"name": "Ideas", "slug": "ideas"},
+  "labels": {"nodes": [{"name": "process"}]},
+  "comments": {"nodes": [{
+    "id": "DC_1", "body": "Weekly", "createdAt": "2024-03-01T10:00:00Z", "author": null,
+    "replies": {"nodes": [{"id": "DC_2", "body": "Too often", "createdAt": "2024-03-01T11:00:00Z", "author": {"login": "hubot"}}]}
+  }]}
+}]}}}}]`)
+	payload := loadInto(t, "github", path)
+
+	post := findContent(t, payload, func(c models.Content) bool { return c.Type == "post" })
+	if post.Author != "octocat" || post.BoardID != "ideas" {
+		t.Fatalf("unexpected post: %+v", post)
+	}
+	comment := findContent(t, payload, bodyIs("Weekly"))
+	if comment.Author != "ghost" || comment.ParentID == nil || *comment.ParentID != post.ID {
+		t.Fatalf("unexpected comment: %+v", comment)
+	}
+	reply := findContent(t, payload, bodyIs("Too often"))
+	if reply.ParentID == nil || *reply.ParentID != comment.ID || reply.ThreadID != post.ID {
+		t.Fatalf("unexpected nested reply: %+v", reply)
+	}
+}
+
+func TestMboxImport(t *testing.T) {
+	path := writeFixture(t, "list.mbox", `From alice@example.org Mon Apr  1 09:00:00 2024
+From: Alice <alice@example.org>
+Subject: Schema freeze
+Date: Mon, 01 Apr 2024 09:00:00 +0000
+Message-ID: <m1@example.org>
+List-Id: Fora Dev <fora-dev.lists.example.org>
+
+Freezing the schema on Friday.
+>From now on, ask first.
+
+From bob@example.org Mon Apr  1 10:00:00 2024
+From: bob@example.org
+Subject: Re: Schema freeze
+Date: Mon, 01 Apr 2024 10:00:00 +0000
+Message-ID: <m2@example.org>
+In-Reply-To: <m1@example.org>
+References: <m1@example.org>
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Sounds good =E2=9C=93
+
+From carol@example.org Mon Apr  1 11:00:00 2024
+From: carol@example.org
+Subject: Re: Schema freeze
+Date: Mon, 01 Apr 2024 11:00:00 +0000
+Message-ID: <m3@example.org>
+References: <m1@example.org> <m2@example.org>
+
+Agreed.
+`)
+	payload := loadInto(t, "mbox", path)
+
+	post := findContent(t, payload, func(c models.Content) bool { return c.Type == "post" })
+	if post.Title == nil || *post.Title != "Schema freeze" || post.BoardID != "fora-dev" || post.Author != "alice" {
+		t.Fatalf("unexpected post: %+v", post)
+	}
+	if post.Body != "Freezing the schema on Friday.\nFrom now on, ask first." {
+		t.Fatalf("unexpected post body: %q", post.Body)
+	}
+	bob := findContent(t, payload, func(c models.Content) bool { return c.Author == "bob" })
+	if bob.Body != "Sounds good ✓" || bob.ParentID == nil || *bob.ParentID != post.ID {
+		t.Fatalf("unexpected reply: %+v", bob)
+	}
+	carol := findContent(t, payload, func(c models.Content) bool { return c.Author == "carol" })
+	if carol.ParentID == nil || *carol.ParentID != bob.ID {
+		t.Fatalf("expected carol to reply to the nearest reference, got %+v", carol.ParentID)
+	}
+}
+
+func TestMboxSendersSharingALocalPartStayDistinct(t *testing.T) {
+	path := writeFixture(t, "alices.mbox", `From alice@a.example Mon Apr  1 09:00:00 2024
+From: alice@a.example
+Subject: First
+Date: Mon, 01 Apr 2024 09:00:00 +0000
+Message-ID: <a1@a.example>
+
+Sent from A.
+
+From alice@b.example Mon Apr  1 10:00:00 2024
+From: Alice <alice@b.example>
+Subject: Second
+Date: Mon, 01 Apr 2024 10:00:00 +0000
+Message-ID: <b1@b.example>
+
+Sent from B.
+
+From alice@a.example Mon Apr  1 11:00:00 2024
+From: ALICE@A.EXAMPLE
+Subject: Third
+Date: Mon, 01 Apr 2024 11:00:00 +0000
+Message-ID: <a2@a.example>
+
+Sent from A again.
+`)
+	payload := loadInto(t, "mbox", path)
+
+	if got := findContent(t, payload, bodyIs("Sent from A.")).Author; got != "alice" {
+		t.Fatalf("first sender should keep the plain name, got %q", got)
+	}
+	if got := findContent(t, payload, bodyIs("Sent from B.")).Author; got != "alice-b-example" {
+		t.Fatalf("second sender should get a domain-derived name, got %q", got)
+	}
+	if got := findContent(t, payload, bodyIs("Sent from A again.")).Author; got != "alice" {
+		t.Fatalf("same address in another case should map to the same agent, got %q", got)
+	}
+	if len(payload.Agents) != 2 {
+		t.Fatalf("expected 2 agents, got %+v", payload.Agents)
+	}
+}
+
+func TestMboxBase64BodiesAndUndatedMessages(t *testing.T) {
+	path := writeFixture(t, "undated.mbox", `From dana@example.org Tue Apr  2 08:30:00 2024
+From: dana@example.org
+Subject: Encoded
+Message-ID: <b1@example.org>
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: base64
+
+SGVsbG8gZnJvbSBi
+YXNlNjQg4pyT
+
+From mailer-daemon
+From: erin@example.org
+Subject: No dates anywhere
+Message-ID: <b2@example.org>
+
+Still imported.
+`)
+	first := loadInto(t, "mbox", path)
+	dana := findContent(t, first, func(c models.Content) bool { return c.Author == "dana" })
+	if dana.Body != "Hello from base64 ✓" {
+		t.Fatalf("unexpected base64 body: %q", dana.Body)
+	}
+	if dana.Created != "2024-04-02T08:30:00Z" {
+		t.Fatalf("expected the From line date, got %q", dana.Created)
+	}
+	erin := findContent(t, first, func(c models.Content) bool { return c.Author == "erin" })
+	if erin.Created != "2024-04-02T08:30:01Z" {
+		t.Fatalf("expected a second after the previous message, got %q", erin.Created)
+	}
+
+	second := loadInto(t, "mbox", path)
+	for i := range first.Content {
+		if first.Content[i].ID != second.Content[i].ID {
+			t.Fatalf("re-import changed content IDs: %q != %q", first.Content[i].ID, second.Content[i].ID)
+		}
+	}
+}
+
+func TestLookupUnknownSource(t *testing.T) {
+	if _, err := Lookup("usenet"); err == nil {
+		t.Fatal("expected error for unknown source")
+	}
+}
@@ -0,0 +1,310 @@
+package importers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+
+	"fora/internal/db"
+	"fora/internal/models"
+)
+
+// mboxImporter reads mboxo/mboxrd archives as produced by mailing-list
+// software. Each message becomes a post, or a reply when In-Reply-To or
+// References points at a message earlier in the archive.
+type mboxImporter struct{}
+
+type mboxMessage struct {
+	messageID string
+	inReplyTo []string
+	from      string
+	subject   string
+	listID    string
+	created   string
+	body      string
+}
+
+func (mboxImporter) Load(path string) (*db.JSONExport, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	chunks := splitMbox(raw)
+	messages := make([]mboxMessage, 0, len(chunks))
+	// Messages without a usable Date fall back to the "From " line and then
+	// to a second after the previous message, never to the current time:
+	// created feeds the content ID, which must not change between imports.
+	previous := time.Unix(0, 0).UTC()
+	for i, chunk := range chunks {
+		msg, err := parseMboxMessage(chunk.message)
+		if err != nil {
+			return nil, fmt.Errorf("parse mbox message %d: %w", i+1, err)
+		}
+		if msg.messageID == "" {
+			msg.messageID = fmt.Sprintf("mbox-%d", i+1)
+		}
+		if msg.created == "" {
+			if t, ok := mboxSeparatorTime(chunk.separator); ok {
+				msg.created = t.Format(time.RFC3339)
+			} else {
+				msg.created = previous.Add(time.Second).Format(time.RFC3339)
+			}
+		}
+		if t, err := time.Parse(time.RFC3339, msg.created); err == nil {
+			previous = t
+		}
+		messages = append(messages, msg)
+	}
+
+	b := newBuilder("mbox")
+	type ref struct {
+		id       string
+		threadID string
+		boardID  string
+	}
+	seen := make(map[string]ref, len(messages))
+	for _, msg := range messages {
+		author := b.agent(msg.from, msg.created)
+		id := contentID(msg.created, "mbox:"+msg.messageID)
+
+		var parent *ref
+		for _, candidate := range msg.inReplyTo {
+			if r, ok := seen[candidate]; ok {
+				parent = &r
+				break
+			}
+		}
+		if parent != nil {
+			parentID := parent.id
+			b.add(models.Content{
+				ID:       id,
+				Type:     "reply",
+				Author:   author,
+				Body:     msg.body,
+				Created:  msg.created,
+				ThreadID: parent.threadID,
+				ParentID: &parentID,
+				BoardID:  parent.boardID,
+			}, nil)
+			seen[msg.messageID] = ref{id: id, threadID: parent.threadID, boardID: parent.boardID}
+			continue
+		}
+
+		boardID := "general"
+		if msg.listID != "" {
+			boardID = b.board(msg.listID, msg.listID, "", msg.created)
+		}
+		title := strings.TrimSpace(stripReplyPrefix(msg.subject))
+		if title == "" {
+			title = "(no subject)"
+		}
+		b.add(models.Content{
+			ID:       id,
+			Type:     "post",
+			Author:   author,
+			Title:    &title,
+			Body:     msg.body,
+			Created:  msg.created,
+			ThreadID: id,
+			BoardID:  boardID,
+		}, nil)
+		seen[msg.messageID] = ref{id: id, threadID: id, boardID: boardID}
+	}
+	return b.payload(), nil
+}
+
+type mboxChunk struct {
+	// separator is the "From " line that started the message.
+	separator string
+	message   []byte
+}
+
+// splitMbox breaks an archive on "From " separator lines and undoes the
+// ">From " escaping applied to body lines.
+func splitMbox(raw []byte) []mboxChunk {
+	var (
+		out       []mboxChunk
+		current   bytes.Buffer
+		separator string
+		started   bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if bytes.HasPrefix(line, []byte("From ")) {
+			if started && current.Len() > 0 {
+				out = append(out, mboxChunk{separator: separator, message: append([]byte(nil), current.Bytes()...)})
+			}
+			current.Reset()
+			separator = string(line)
+			started = true
+			continue
+		}
+		if !started {
+			continue
+		}
+		if unquoted := bytes.TrimLeft(line, ">"); len(unquoted) < len(line) && bytes.HasPrefix(unquoted, []byte("From ")) {
+			line = line[1:]
+		}
+		current.Write(line)
+		current.WriteByte('\n')
+	}
+	if started && current.Len() > 0 {
+		out = append(out, mboxChunk{separator: separator, message: current.Bytes()})
+	}
+	return out
+}
+
+// mboxSeparatorTime reads the asctime date of a "From sender date" line.
+func mboxSeparatorTime(line string) (time.Time, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return time.Time{}, false
+	}
+	date := strings.Join(fields[2:], " ")
+	for _, layout := range []string{"Mon Jan 2 15:04:05 2006", "Mon Jan 2 15:04:05 2006 -0700", "Mon Jan 2 15:04:05 MST 2006"} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseMboxMessage(raw []byte) (mboxMessage, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return mboxMessage{}, err
+	}
+	decoder := new(mime.WordDecoder)
+	decode := func(value string) string {
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			return decoded
+		}
+		return value
+	}
+
+	out := mboxMessage{
+		messageID: strings.Trim(strings.TrimSpace(msg.Header.Get("Message-Id")), "<>"),
+		subject:   decode(msg.Header.Get("Subject")),
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		out.created = date.UTC().Format(time.RFC3339)
+	}
+	if addr, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		out.from = addr.Address
+	} else {
+		out.from = decode(msg.Header.Get("From"))
+	}
+	out.listID = mboxListName(msg.Header.Get("List-Id"))
+
+	// In-Reply-To is the direct parent; References lists ancestors oldest
+	// first, so walk it backwards to find the nearest known one.
+	out.inReplyTo = append(out.inReplyTo, messageIDs(msg.Header.Get("In-Reply-To"))...)
+	refs := messageIDs(msg.Header.Get("References"))
+	for i := len(refs) - 1; i >= 0; i-- {
+		out.inReplyTo = append(out.inReplyTo, refs[i])
+	}
+
+	body, err := plainTextBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return mboxMessage{}, err
+	}
+	out.body = strings.TrimSpace(body)
+	return out, nil
+}
+
+func plainTextBody(contentType, encoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		var fallback string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := plainTextBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", err
+			}
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			if partType == "" || partType == "text/plain" {
+				return text, nil
+			}
+			if fallback == "" {
+				fallback = text
+			}
+		}
+		return fallback, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func messageIDs(header string) []string {
+	var out []string
+	for _, field := range strings.Fields(header) {
+		id := strings.Trim(field, "<>,")
+		if id != "" {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// mboxListName turns `Fora Dev <fora-dev.lists.example.org>` into "fora-dev".
+func mboxListName(header string) string {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return ""
+	}
+	if start := strings.LastIndex(header, "<"); start >= 0 {
+		id := strings.TrimSuffix(header[start+1:], ">")
+		if dot := strings.Index(id, "."); dot > 0 {
+			id = id[:dot]
+		}
+		return id
+	}
+	return header
+}
+
+func stripReplyPrefix(subject string) string {
+	for {
+		trimmed := strings.TrimSpace(subject)
+		lower := strings.ToLower(trimmed)
+		switch {
+		case strings.HasPrefix(lower, "re:"):
+			subject = trimmed[3:]
+		case strings.HasPrefix(lower, "fwd:"):
+			subject = trimmed[4:]
+		default:
+			return trimmed
+		}
+	}
+}
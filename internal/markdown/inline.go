@@ -0,0 +1,461 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// inline renders span-level markup. Inside link text (inLink) nothing that
+// would produce another link is recognised.
+func (r *renderer) inline(s string, inLink bool) string {
+	var out []byte
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			out = append(out, "<br>\n"...)
+			i += 2
+			continue
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			out = appendEscaped(out, s[i+1])
+			i += 2
+			continue
+		case c == '`':
+			if code, n, ok := codeSpan(s[i:]); ok {
+				out = append(out, code...)
+				i += n
+				continue
+			}
+			n := runLength(s[i:], '`')
+			out = append(out, s[i:i+n]...)
+			i += n
+			continue
+		case c == '!' && !inLink && i+1 < len(s) && s[i+1] == '[':
+			if rendered, n, ok := r.link(s[i+1:], true); ok {
+				out = append(out, rendered...)
+				i += 1 + n
+				continue
+			}
+		case c == '[' && !inLink:
+			if rendered, n, ok := r.link(s[i:], false); ok {
+				out = append(out, rendered...)
+				i += n
+				continue
+			}
+		case c == '<':
+			if rendered, n, ok := r.autolink(s[i:], inLink); ok {
+				out = append(out, rendered...)
+				i += n
+				continue
+			}
+		case c == '*' || c == '_' || c == '~':
+			if rendered, n, ok := r.emphasis(s, i, inLink); ok {
+				out = append(out, rendered...)
+				i += n
+				continue
+			}
+			// An unmatched run stays literal as a whole, so "**" is not
+			// retried as two single delimiters.
+			n := runLength(s[i:], c)
+			out = append(out, s[i:i+n]...)
+			i += n
+			continue
+		case (c == 'h' || c == 'H') && !inLink && atWordStart(s, i):
+			if rendered, n, ok := r.bareURL(s[i:]); ok {
+				out = append(out, rendered...)
+				i += n
+				continue
+			}
+		case c == '@' && atWordStart(s, i):
+			if m := mentionPattern.FindStringSubmatch(s[i:]); m != nil {
+				r.addMention(m[1])
+				out = append(out, r.reference("@"+m[1], "mention", inLink, func(res Resolver) (string, bool) {
+					return res.AgentHref(m[1])
+				})...)
+				i += len(m[0])
+				continue
+			}
+		case c == '#' && atWordStart(s, i):
+			if m := threadRefPattern.FindStringSubmatch(s[i:]); m != nil {
+				r.addThreadRef(m[1])
+				out = append(out, r.reference("#"+m[1], "thread-ref", inLink, func(res Resolver) (string, bool) {
+					return res.ThreadHref(m[1])
+				})...)
+				i += len(m[0])
+				continue
+			}
+		case c == '\n':
+			spaces := 0
+			for len(out) > 0 && out[len(out)-1] == ' ' {
+				out = out[:len(out)-1]
+				spaces++
+			}
+			if spaces >= 2 {
+				out = append(out, "<br>\n"...)
+			} else {
+				out = append(out, '\n')
+			}
+			i++
+			continue
+		}
+		out = appendEscaped(out, c)
+		i++
+	}
+	return strings.TrimRight(string(out), " ")
+}
+
+func appendEscaped(out []byte, c byte) []byte {
+	switch c {
+	case '<':
+		return append(out, "&lt;"...)
+	case '>':
+		return append(out, "&gt;"...)
+	case '&':
+		return append(out, "&amp;"...)
+	case '"':
+		return append(out, "&#34;"...)
+	case '\'':
+		return append(out, "&#39;"...)
+	}
+	return append(out, c)
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c >= 0x80
+}
+
+func atWordStart(s string, i int) bool {
+	return i == 0 || !isWordByte(s[i-1])
+}
+
+func runLength(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func (r *renderer) reference(text, class string, inLink bool, href func(Resolver) (string, bool)) string {
+	if r.resolver != nil && !inLink {
+		if target, ok := href(r.resolver); ok {
+			return `<a class="` + class + `" href="` + html.EscapeString(target) + `">` + html.EscapeString(text) + `</a>`
+		}
+	}
+	return html.EscapeString(text)
+}
+
+// codeSpan matches a backtick run with a closing run of the same length.
+func codeSpan(s string) (string, int, bool) {
+	n := runLength(s, '`')
+	for j := n; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := runLength(s[j:], '`')
+		if m == n {
+			code := strings.ReplaceAll(s[n:j], "\n", " ")
+			if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+			return "<code>" + html.EscapeString(code) + "</code>", j + m, true
+		}
+		j += m
+	}
+	return "", 0, false
+}
+
+// link parses "[text](dest "title")" at the start of s.
+func (r *renderer) link(s string, image bool) (string, int, bool) {
+	end := matchingBracket(s)
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return "", 0, false
+	}
+	dest, title, n, ok := linkTarget(s[end+1:])
+	if !ok {
+		return "", 0, false
+	}
+	consumed := end + 1 + n
+	text := r.inline(s[1:end], true)
+	href, host, safe := safeURL(dest)
+	if image {
+		alt := plainText(text)
+		if !safe || strings.HasPrefix(strings.ToLower(href), "mailto:") {
+			return html.EscapeString(alt), consumed, true
+		}
+		r.addLink(Link{URL: href, Text: alt, Host: host, Image: true})
+		out := `<img src="` + html.EscapeString(href) + `" alt="` + html.EscapeString(alt) + `"`
+		if title != "" {
+			out += ` title="` + html.EscapeString(title) + `"`
+		}
+		return out + `>`, consumed, true
+	}
+	if !safe {
+		return text, consumed, true
+	}
+	r.addLink(Link{URL: href, Text: plainText(text), Host: host})
+	out := `<a ` + linkAttrs(href, host)
+	if title != "" {
+		out += ` title="` + html.EscapeString(title) + `"`
+	}
+	return out + `>` + text + `</a>`, consumed, true
+}
+
+func matchingBracket(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '`':
+			if _, n, ok := codeSpan(s[i:]); ok {
+				i += n - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// linkTarget parses "(dest "title")" at the start of s.
+func linkTarget(s string) (dest, title string, n int, ok bool) {
+	i := 1
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+	if i < len(s) && s[i] == '<' {
+		end := strings.IndexAny(s[i+1:], ">\n")
+		if end < 0 || s[i+1+end] != '>' {
+			return "", "", 0, false
+		}
+		dest = s[i+1 : i+1+end]
+		i += end + 2
+	} else {
+		start, depth := i, 0
+		for ; i < len(s); i++ {
+			c := s[i]
+			if c == '\\' && i+1 < len(s) {
+				i++
+				continue
+			}
+			if c == ' ' || c == '\n' || c < 0x20 {
+				break
+			}
+			if c == '(' {
+				depth++
+			}
+			if c == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+		}
+		dest = unescapePunct(s[start:i])
+	}
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+	if i < len(s) && (s[i] == '"' || s[i] == '\'' || s[i] == '(') {
+		closer := s[i]
+		if closer == '(' {
+			closer = ')'
+		}
+		end := strings.IndexByte(s[i+1:], closer)
+		if end < 0 {
+			return "", "", 0, false
+		}
+		title = unescapePunct(s[i+1 : i+1+end])
+		i += end + 2
+		for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+			i++
+		}
+	}
+	if i >= len(s) || s[i] != ')' {
+		return "", "", 0, false
+	}
+	return dest, title, i + 1, true
+}
+
+func unescapePunct(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+var (
+	autolinkPattern = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^<>\s]*)>`)
+	emailPattern    = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)+)>`)
+	bareURLPattern  = regexp.MustCompile(`^(?i:https?://)[^\s<]+`)
+)
+
+func (r *renderer) autolink(s string, inLink bool) (string, int, bool) {
+	target, text := "", ""
+	var n int
+	if m := autolinkPattern.FindStringSubmatch(s); m != nil {
+		target, text, n = m[1], m[1], len(m[0])
+	} else if m := emailPattern.FindStringSubmatch(s); m != nil {
+		target, text, n = "mailto:"+m[1], m[1], len(m[0])
+	} else {
+		return "", 0, false
+	}
+	href, host, ok := safeURL(target)
+	if !ok || inLink {
+		return html.EscapeString(text), n, true
+	}
+	r.addLink(Link{URL: href, Text: text, Host: host})
+	return `<a ` + linkAttrs(href, host) + `>` + html.EscapeString(text) + `</a>`, n, true
+}
+
+// bareURL links a plain http(s) URL, leaving trailing punctuation and an
+// unbalanced closing parenthesis outside the link.
+func (r *renderer) bareURL(s string) (string, int, bool) {
+	raw := bareURLPattern.FindString(s)
+	if raw == "" {
+		return "", 0, false
+	}
+	for {
+		trimmed := strings.TrimRight(raw, `?!.,:;*_~'"`)
+		if strings.HasSuffix(trimmed, ")") && strings.Count(trimmed, "(") < strings.Count(trimmed, ")") {
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+		if trimmed == raw {
+			break
+		}
+		raw = trimmed
+	}
+	href, host, ok := safeURL(raw)
+	if !ok {
+		return "", 0, false
+	}
+	r.addLink(Link{URL: href, Text: raw, Host: host})
+	return `<a ` + linkAttrs(href, host) + `>` + html.EscapeString(raw) + `</a>`, len(raw), true
+}
+
+// emphasis handles *em*, **strong**, the same with underscores, and ~~del~~.
+func (r *renderer) emphasis(s string, i int, inLink bool) (string, int, bool) {
+	c := s[i]
+	n := runLength(s[i:], c)
+	if i+n >= len(s) || s[i+n] == ' ' || s[i+n] == '\n' {
+		return "", 0, false
+	}
+	widths := []int{2, 1}
+	if c == '~' {
+		if n != 2 {
+			return "", 0, false
+		}
+		widths = []int{2}
+	}
+	for _, k := range widths {
+		if k > n {
+			continue
+		}
+		end := findCloser(s, i+k, c, k)
+		if end < 0 {
+			continue
+		}
+		inner := r.inline(s[i+k:end], inLink)
+		tag := "em"
+		switch {
+		case c == '~':
+			tag = "del"
+		case k == 2:
+			tag = "strong"
+		}
+		return "<" + tag + ">" + inner + "</" + tag + ">", end + k - i, true
+	}
+	return "", 0, false
+}
+
+// opensRun reports whether the delimiter run of length m at s[j] can only
+// open emphasis: it is followed by text and preceded by space or punctuation.
+func opensRun(s string, j, m int) bool {
+	if j+m >= len(s) || s[j+m] == ' ' || s[j+m] == '\n' {
+		return false
+	}
+	prev := s[j-1]
+	return prev == ' ' || prev == '\n' || (isASCIIPunct(prev) && !isASCIIPunct(s[j+m]))
+}
+
+func findCloser(s string, from int, c byte, k int) int {
+	var nested []int
+	unmatched := false
+	for j := from; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+			continue
+		case '`':
+			if _, n, ok := codeSpan(s[j:]); ok {
+				j += n - 1
+			}
+			continue
+		case '[':
+			// A link is one unit: delimiters in its text or destination
+			// belong to it, not to the run being closed. After one
+			// unmatched bracket, stop looking so the scan stays linear.
+			if unmatched {
+				continue
+			}
+			if e := matchingBracket(s[j:]); e < 0 {
+				unmatched = true
+			} else {
+				j += e
+				if j+1 < len(s) && s[j+1] == '(' {
+					if _, _, n, ok := linkTarget(s[j+1:]); ok {
+						j += n
+					}
+				}
+			}
+			continue
+		case c:
+		default:
+			continue
+		}
+		m := runLength(s[j:], c)
+		if j != from && opensRun(s, j, m) {
+			// Remember nested openers so the "**" closing "b" in
+			// "*a **b** c*" is not taken as the outer closer.
+			nested = append(nested, m)
+			j += m - 1
+			continue
+		}
+		if m < k && (len(nested) == 0 || m < nested[len(nested)-1]) || j == from || s[j-1] == ' ' || s[j-1] == '\n' {
+			j += m - 1
+			continue
+		}
+		end := j + m
+		if c == '_' && end < len(s) && isWordByte(s[end]) {
+			j += m - 1
+			continue
+		}
+		if len(nested) > 0 && m >= nested[len(nested)-1] {
+			nested = nested[:len(nested)-1]
+			j += m - 1
+			continue
+		}
+		// Close with the last k delimiters so "***x***" nests.
+		return end - k
+	}
+	return -1
+}
@@ -0,0 +1,724 @@
+// Package markdown renders the Markdown subset used in fora bodies to
+// sanitized HTML and extracts the links, code blocks, headings, thread
+// references and mentions it contains.
+//
+// Raw HTML in the source is never passed through: it is escaped and shows up
+// as text. Links and images are kept only for http, https and mailto URLs and
+// relative paths.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Link struct {
+	URL   string `json:"url"`
+	Text  string `json:"text,omitempty"`
+	Host  string `json:"host,omitempty"`
+	Image bool   `json:"image,omitempty"`
+}
+
+type CodeBlock struct {
+	Language string `json:"language,omitempty"`
+	Code     string `json:"code"`
+}
+
+type Heading struct {
+	Level  int    `json:"level"`
+	Text   string `json:"text"`
+	Anchor string `json:"anchor"`
+}
+
+// Metadata is what Extract finds in a body, in document order.
+type Metadata struct {
+	Links      []Link      `json:"links"`
+	CodeBlocks []CodeBlock `json:"code_blocks"`
+	Headings   []Heading   `json:"headings"`
+	ThreadRefs []string    `json:"thread_refs"`
+	Mentions   []string    `json:"mentions"`
+}
+
+// Resolver turns #thread-id references and @mentions into link targets.
+// Returning false leaves the reference as plain text.
+type Resolver interface {
+	ThreadHref(id string) (string, bool)
+	AgentHref(name string) (string, bool)
+}
+
+// Render returns the sanitized HTML for src and its metadata. r may be nil,
+// in which case references are not linked.
+func Render(src string, r Resolver) (string, Metadata) {
+	rd := &renderer{
+		resolver: r,
+		anchors:  map[string]int{},
+		seen:     map[string]bool{},
+		meta: Metadata{
+			Links:      []Link{},
+			CodeBlocks: []CodeBlock{},
+			Headings:   []Heading{},
+			ThreadRefs: []string{},
+			Mentions:   []string{},
+		},
+	}
+	var b strings.Builder
+	rd.blocks(&b, parseBlocks(splitLines(src)), false)
+	return b.String(), rd.meta
+}
+
+// HTML returns only the rendered HTML of src.
+func HTML(src string, r Resolver) string {
+	out, _ := Render(src, r)
+	return out
+}
+
+// Extract returns only the metadata of src.
+func Extract(src string) Metadata {
+	_, meta := Render(src, nil)
+	return meta
+}
+
+// threadRefPattern matches a #thread-id reference; IDs are the content IDs
+// the server generates (creation time plus a body hash).
+var threadRefPattern = regexp.MustCompile(`^#(\d{8}T\d{6}Z-[0-9a-f]{8})\b`)
+
+var mentionPattern = regexp.MustCompile(`^@([a-zA-Z0-9][a-zA-Z0-9_-]{0,63})`)
+
+func splitLines(src string) []string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	lines := strings.Split(src, "\n")
+	for i, l := range lines {
+		lines[i] = expandLeadingTabs(l)
+	}
+	return lines
+}
+
+func expandLeadingTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	col := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			b.WriteByte(' ')
+			col++
+		case '\t':
+			n := 4 - col%4
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		default:
+			b.WriteString(line[i:])
+			return b.String()
+		}
+	}
+	return b.String()
+}
+
+// Block structure.
+
+type blockKind int
+
+const (
+	kindParagraph blockKind = iota
+	kindHeading
+	kindCode
+	kindQuote
+	kindList
+	kindRule
+	kindTable
+)
+
+type block struct {
+	kind     blockKind
+	text     string
+	level    int
+	lang     string
+	children []block
+	items    [][]block
+	ordered  bool
+	start    int
+	loose    bool
+	header   []string
+	align    []string
+	rows     [][]string
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func indentOf(line string) int {
+	n := 0
+	for n < len(line) && line[n] == ' ' {
+		n++
+	}
+	return n
+}
+
+var (
+	atxPattern    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	rulePattern   = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	setextPattern = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	fencePattern  = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*?)[ \t]*$")
+)
+
+type listItemMarker struct {
+	ordered bool
+	delim   byte
+	start   int
+	width   int
+	rest    string
+}
+
+func listMarker(line string) (listItemMarker, bool) {
+	ind := indentOf(line)
+	if ind > 3 || ind >= len(line) {
+		return listItemMarker{}, false
+	}
+	s := line[ind:]
+	var m listItemMarker
+	n := 0
+	switch s[0] {
+	case '-', '*', '+':
+		m.delim = s[0]
+		n = 1
+	default:
+		for n < len(s) && n < 9 && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		if n == 0 || n >= len(s) || (s[n] != '.' && s[n] != ')') {
+			return listItemMarker{}, false
+		}
+		m.ordered = true
+		m.start, _ = strconv.Atoi(s[:n])
+		m.delim = s[n]
+		n++
+	}
+	if n < len(s) && s[n] != ' ' {
+		return listItemMarker{}, false
+	}
+	spaces := indentOf(s[n:])
+	if spaces == 0 || spaces > 4 || n+spaces == len(s) {
+		spaces = 1
+	}
+	m.width = ind + n + spaces
+	if n+spaces <= len(s) {
+		m.rest = s[n+spaces:]
+	}
+	return m, true
+}
+
+func isListItem(line string) bool {
+	_, ok := listMarker(line)
+	return ok
+}
+
+func quoteLine(line string) (string, bool) {
+	ind := indentOf(line)
+	if ind > 3 || ind >= len(line) || line[ind] != '>' {
+		return "", false
+	}
+	rest := line[ind+1:]
+	return strings.TrimPrefix(rest, " "), true
+}
+
+// startsBlock reports whether line would end a paragraph.
+func startsBlock(line string) bool {
+	if atxPattern.MatchString(line) || rulePattern.MatchString(line) || fencePattern.MatchString(line) {
+		return true
+	}
+	if _, ok := quoteLine(line); ok {
+		return true
+	}
+	if m, ok := listMarker(line); ok && !isBlank(m.rest) && (!m.ordered || m.start == 1) {
+		return true
+	}
+	return false
+}
+
+func parseBlocks(lines []string) []block {
+	var out []block
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			out = append(out, block{kind: kindParagraph, text: strings.Join(para, "\n")})
+			para = nil
+		}
+	}
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			flush()
+			i++
+		case fencePattern.MatchString(line):
+			flush()
+			b, n := parseFence(lines[i:])
+			out = append(out, b)
+			i += n
+		case atxPattern.MatchString(line):
+			flush()
+			m := atxPattern.FindStringSubmatch(line)
+			out = append(out, block{kind: kindHeading, level: len(m[1]), text: m[2]})
+			i++
+		case len(para) > 0 && setextPattern.MatchString(line):
+			level := 2
+			if strings.Contains(line, "=") {
+				level = 1
+			}
+			out = append(out, block{kind: kindHeading, level: level, text: strings.Join(para, "\n")})
+			para = nil
+			i++
+		case rulePattern.MatchString(line):
+			flush()
+			out = append(out, block{kind: kindRule})
+			i++
+		default:
+			if _, ok := quoteLine(line); ok {
+				flush()
+				b, n := parseQuote(lines[i:])
+				out = append(out, b)
+				i += n
+				continue
+			}
+			if m, ok := listMarker(line); ok && (len(para) == 0 || (!isBlank(m.rest) && (!m.ordered || m.start == 1))) {
+				flush()
+				b, n := parseList(lines[i:])
+				out = append(out, b)
+				i += n
+				continue
+			}
+			if len(para) == 0 && indentOf(line) >= 4 {
+				b, n := parseIndentedCode(lines[i:])
+				out = append(out, b)
+				i += n
+				continue
+			}
+			if len(para) == 0 && i+1 < len(lines) && strings.Contains(line, "|") {
+				if b, n, ok := parseTable(lines[i:]); ok {
+					out = append(out, b)
+					i += n
+					continue
+				}
+			}
+			para = append(para, strings.TrimLeft(line, " "))
+			i++
+		}
+	}
+	flush()
+	return out
+}
+
+func parseFence(lines []string) (block, int) {
+	m := fencePattern.FindStringSubmatch(lines[0])
+	indent, fence, info := len(m[1]), m[2], m[3]
+	lang := ""
+	if f := strings.Fields(info); len(f) > 0 {
+		lang = f[0]
+	}
+	var code []string
+	i := 1
+	for ; i < len(lines); i++ {
+		l := lines[i]
+		t := strings.TrimSpace(l)
+		if indentOf(l) <= 3 && strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+			i++
+			break
+		}
+		// Remove up to the fence's own indentation from content lines.
+		strip := indentOf(l)
+		if strip > indent {
+			strip = indent
+		}
+		code = append(code, l[strip:])
+	}
+	text := strings.Join(code, "\n")
+	if len(code) > 0 {
+		text += "\n"
+	}
+	return block{kind: kindCode, lang: lang, text: text}, i
+}
+
+func parseIndentedCode(lines []string) (block, int) {
+	var code []string
+	i := 0
+	for ; i < len(lines); i++ {
+		l := lines[i]
+		if isBlank(l) {
+			code = append(code, "")
+			continue
+		}
+		if indentOf(l) < 4 {
+			break
+		}
+		code = append(code, l[4:])
+	}
+	for len(code) > 0 && code[len(code)-1] == "" {
+		code = code[:len(code)-1]
+	}
+	return block{kind: kindCode, text: strings.Join(code, "\n") + "\n"}, i
+}
+
+func parseQuote(lines []string) (block, int) {
+	var inner []string
+	i := 0
+	for i < len(lines) {
+		l := lines[i]
+		if rest, ok := quoteLine(l); ok {
+			inner = append(inner, rest)
+			i++
+			continue
+		}
+		// Lazy continuation of a quoted paragraph.
+		if !isBlank(l) && len(inner) > 0 && !isBlank(inner[len(inner)-1]) && !startsBlock(l) {
+			inner = append(inner, l)
+			i++
+			continue
+		}
+		break
+	}
+	return block{kind: kindQuote, children: parseBlocks(inner)}, i
+}
+
+func parseList(lines []string) (block, int) {
+	first, _ := listMarker(lines[0])
+	b := block{kind: kindList, ordered: first.ordered, start: first.start}
+	i := 0
+	blankBefore := false
+	for i < len(lines) {
+		m, ok := listMarker(lines[i])
+		if !ok || m.ordered != first.ordered || m.delim != first.delim || rulePattern.MatchString(lines[i]) {
+			break
+		}
+		if blankBefore {
+			b.loose = true
+		}
+		item := []string{m.rest}
+		i++
+		afterBlank := false
+		for i < len(lines) {
+			l := lines[i]
+			switch {
+			case isBlank(l):
+				item = append(item, "")
+				afterBlank = true
+			case indentOf(l) >= m.width:
+				item = append(item, l[m.width:])
+				afterBlank = false
+			case !afterBlank && !startsBlock(l) && !isListItem(l):
+				item = append(item, strings.TrimLeft(l, " "))
+			default:
+				goto done
+			}
+			i++
+		}
+	done:
+		trailing := 0
+		for len(item) > 1 && item[len(item)-1] == "" {
+			item = item[:len(item)-1]
+			trailing++
+		}
+		blankBefore = trailing > 0
+		children := parseBlocks(item)
+		if len(children) > 1 && hasInteriorBlank(item) {
+			b.loose = true
+		}
+		b.items = append(b.items, children)
+	}
+	return b, i
+}
+
+func hasInteriorBlank(lines []string) bool {
+	inFence := ""
+	for _, l := range lines {
+		if m := fencePattern.FindStringSubmatch(l); m != nil {
+			if inFence == "" {
+				inFence = m[2]
+			} else if strings.HasPrefix(m[2], inFence[:1]) {
+				inFence = ""
+			}
+			continue
+		}
+		if inFence == "" && isBlank(l) {
+			return true
+		}
+	}
+	return false
+}
+
+var delimCell = regexp.MustCompile(`^:?-+:?$`)
+
+func parseTable(lines []string) (block, int, bool) {
+	header := splitRow(lines[0])
+	delims := splitRow(lines[1])
+	if len(header) == 0 || len(header) != len(delims) {
+		return block{}, 0, false
+	}
+	align := make([]string, len(delims))
+	for i, d := range delims {
+		if !delimCell.MatchString(d) {
+			return block{}, 0, false
+		}
+		switch {
+		case strings.HasPrefix(d, ":") && strings.HasSuffix(d, ":"):
+			align[i] = "center"
+		case strings.HasSuffix(d, ":"):
+			align[i] = "right"
+		case strings.HasPrefix(d, ":"):
+			align[i] = "left"
+		}
+	}
+	b := block{kind: kindTable, header: header, align: align}
+	i := 2
+	for ; i < len(lines); i++ {
+		l := lines[i]
+		if isBlank(l) || !strings.Contains(l, "|") || startsBlock(l) {
+			break
+		}
+		row := splitRow(l)
+		for len(row) < len(header) {
+			row = append(row, "")
+		}
+		b.rows = append(b.rows, row[:len(header)])
+	}
+	return b, i, true
+}
+
+func splitRow(line string) []string {
+	s := strings.TrimSpace(line)
+	s = strings.TrimPrefix(s, "|")
+	if strings.HasSuffix(s, "|") && !strings.HasSuffix(s, `\|`) {
+		s = s[:len(s)-1]
+	}
+	var cells []string
+	var cur strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '|':
+			cur.WriteByte('|')
+			i++
+		case s[i] == '|':
+			cells = append(cells, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(s[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cur.String()))
+}
+
+// Rendering.
+
+type renderer struct {
+	resolver Resolver
+	meta     Metadata
+	anchors  map[string]int
+	seen     map[string]bool
+}
+
+func (r *renderer) blocks(b *strings.Builder, blocks []block, tight bool) {
+	for _, bl := range blocks {
+		r.block(b, bl, tight)
+	}
+}
+
+func (r *renderer) block(b *strings.Builder, bl block, tight bool) {
+	switch bl.kind {
+	case kindParagraph:
+		if tight {
+			b.WriteString(r.inline(bl.text, false))
+			b.WriteString("\n")
+			return
+		}
+		b.WriteString("<p>")
+		b.WriteString(r.inline(bl.text, false))
+		b.WriteString("</p>\n")
+	case kindHeading:
+		content := r.inline(bl.text, false)
+		text := plainText(content)
+		anchor := r.anchor(text)
+		r.meta.Headings = append(r.meta.Headings, Heading{Level: bl.level, Text: text, Anchor: anchor})
+		tag := "h" + strconv.Itoa(bl.level)
+		b.WriteString("<" + tag + ` id="` + html.EscapeString(anchor) + `">`)
+		b.WriteString(content)
+		b.WriteString("</" + tag + ">\n")
+	case kindCode:
+		lang := cleanLanguage(bl.lang)
+		r.meta.CodeBlocks = append(r.meta.CodeBlocks, CodeBlock{Language: lang, Code: bl.text})
+		b.WriteString("<pre><code")
+		if lang != "" {
+			b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+		}
+		b.WriteString(">")
+		b.WriteString(html.EscapeString(bl.text))
+		b.WriteString("</code></pre>\n")
+	case kindQuote:
+		b.WriteString("<blockquote>\n")
+		r.blocks(b, bl.children, false)
+		b.WriteString("</blockquote>\n")
+	case kindList:
+		tag := "ul"
+		if bl.ordered {
+			tag = "ol"
+		}
+		b.WriteString("<" + tag)
+		if bl.ordered && bl.start != 1 {
+			b.WriteString(` start="` + strconv.Itoa(bl.start) + `"`)
+		}
+		b.WriteString(">\n")
+		for _, item := range bl.items {
+			b.WriteString("<li>")
+			if !bl.loose && len(item) > 0 && item[0].kind == kindParagraph {
+				// Keep "<li>text" on one line, as the tight form reads.
+				b.WriteString(r.inline(item[0].text, false))
+				if len(item) > 1 {
+					b.WriteString("\n")
+				}
+				r.blocks(b, item[1:], true)
+			} else {
+				if len(item) > 0 {
+					b.WriteString("\n")
+				}
+				r.blocks(b, item, !bl.loose)
+			}
+			b.WriteString("</li>\n")
+		}
+		b.WriteString("</" + tag + ">\n")
+	case kindRule:
+		b.WriteString("<hr>\n")
+	case kindTable:
+		b.WriteString("<table>\n<thead>\n<tr>")
+		for i, h := range bl.header {
+			b.WriteString("<th" + alignAttr(bl.align[i]) + ">" + r.inline(h, false) + "</th>")
+		}
+		b.WriteString("</tr>\n</thead>\n")
+		if len(bl.rows) > 0 {
+			b.WriteString("<tbody>\n")
+			for _, row := range bl.rows {
+				b.WriteString("<tr>")
+				for i, c := range row {
+					b.WriteString("<td" + alignAttr(bl.align[i]) + ">" + r.inline(c, false) + "</td>")
+				}
+				b.WriteString("</tr>\n")
+			}
+			b.WriteString("</tbody>\n")
+		}
+		b.WriteString("</table>\n")
+	}
+}
+
+func alignAttr(a string) string {
+	if a == "" {
+		return ""
+	}
+	return ` align="` + a + `"`
+}
+
+var languagePattern = regexp.MustCompile(`^[A-Za-z0-9_+.#-]{1,32}$`)
+
+func cleanLanguage(lang string) string {
+	if !languagePattern.MatchString(lang) {
+		return ""
+	}
+	return strings.ToLower(lang)
+}
+
+// anchor returns a GitHub-style slug for a heading, unique within the body.
+func (r *renderer) anchor(text string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(text) {
+		switch {
+		case c == ' ' || c == '-':
+			b.WriteRune('-')
+		case c == '_' || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c > 127:
+			b.WriteRune(c)
+		}
+	}
+	slug := b.String()
+	if slug == "" {
+		slug = "section"
+	}
+	n := r.anchors[slug]
+	r.anchors[slug] = n + 1
+	if n > 0 {
+		slug += "-" + strconv.Itoa(n)
+	}
+	return slug
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// plainText strips the tags this renderer produced and unescapes the rest.
+func plainText(rendered string) string {
+	return strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(rendered, "")))
+}
+
+func (r *renderer) addLink(l Link) {
+	key := "link\x00" + l.URL
+	if l.Image {
+		key = "image\x00" + l.URL
+	}
+	if r.seen[key] {
+		return
+	}
+	r.seen[key] = true
+	r.meta.Links = append(r.meta.Links, l)
+}
+
+func (r *renderer) addThreadRef(id string) {
+	if !r.seen["thread\x00"+id] {
+		r.seen["thread\x00"+id] = true
+		r.meta.ThreadRefs = append(r.meta.ThreadRefs, id)
+	}
+}
+
+func (r *renderer) addMention(name string) {
+	if !r.seen["mention\x00"+name] {
+		r.seen["mention\x00"+name] = true
+		r.meta.Mentions = append(r.meta.Mentions, name)
+	}
+}
+
+// safeURL validates a link destination and returns it with its host.
+func safeURL(raw string) (string, string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.ContainsAny(raw, "\x00\n\r\t") {
+		return "", "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", "", false
+		}
+		return u.String(), strings.ToLower(u.Hostname()), true
+	case "mailto":
+		return u.String(), "", true
+	case "":
+		// Browsers read "//host", "/\host" and "\\host" as protocol-relative
+		// links to another site even though url.Parse finds no host.
+		lead := strings.ReplaceAll(raw[:min(2, len(raw))], `\`, "/")
+		if u.Host != "" || strings.HasPrefix(lead, "//") || strings.HasPrefix(raw, `\`) {
+			return "", "", false
+		}
+		return u.String(), "", true
+	}
+	return "", "", false
+}
+
+func linkAttrs(href, host string) string {
+	attrs := `href="` + html.EscapeString(href) + `"`
+	if host != "" {
+		attrs += ` rel="nofollow noopener noreferrer"`
+	}
+	return attrs
+}
@@ -0,0 +1,160 @@
+package markdown
+
+import (
+	"html"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type fakeResolver struct{}
+
+func (fakeResolver) ThreadHref(id string) (string, bool) {
+	return "/api/v1/posts/" + id + "/thread", id == "20260101T120000Z-abcdef12"
+}
+
+func (fakeResolver) AgentHref(name string) (string, bool) {
+	return "/api/v1/agents/" + name, name == "alice"
+}
+
+func TestHTML(t *testing.T) {
+	for _, tc := range []struct {
+		name, src, want string
+	}{
+		{"heading and emphasis", "## Plan *now*\n\n**a** _b_ ***c*** ~~d~~ snake_case",
+			"<h2 id=\"plan-now\">Plan <em>now</em></h2>\n<p><strong>a</strong> <em>b</em> <strong><em>c</em></strong> <del>d</del> snake_case</p>\n"},
+		{"raw html is escaped", "<script>alert(1)</script> <b onclick=x>hi</b>",
+			"<p>&lt;script&gt;alert(1)&lt;/script&gt; &lt;b onclick=x&gt;hi&lt;/b&gt;</p>\n"},
+		{"unsafe links become text", "[x](javascript:alert(1)) [y](data:text/html,hi) ![z](javascript:1)",
+			"<p>x y z</p>\n"},
+		{"safe links", "[doc](https://go.dev/doc \"Go\") [rel](/api/v1/status) <me@example.com>",
+			"<p><a href=\"https://go.dev/doc\" rel=\"nofollow noopener noreferrer\" title=\"Go\">doc</a> <a href=\"/api/v1/status\">rel</a> <a href=\"mailto:me@example.com\">me@example.com</a></p>\n"},
+		{"bare url trims punctuation", "see https://example.com/a_(b)), ok",
+			"<p>see <a href=\"https://example.com/a_(b)\" rel=\"nofollow noopener noreferrer\">https://example.com/a_(b)</a>), ok</p>\n"},
+		{"code", "`a <b>` and\n\n```Go extra\nx := `<y>`\n```\n\n    indented\n",
+			"<p><code>a &lt;b&gt;</code> and</p>\n<pre><code class=\"language-go\">x := `&lt;y&gt;`\n</code></pre>\n<pre><code>indented\n</code></pre>\n"},
+		{"lists", "- one\n- two\n  1. nested\n  2. more\n\n3) three\n4) four",
+			"<ul>\n<li>one</li>\n<li>two\n<ol>\n<li>nested</li>\n<li>more</li>\n</ol>\n</li>\n</ul>\n<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>\n"},
+		{"loose list", "- a\n\n- b",
+			"<ul>\n<li>\n<p>a</p>\n</li>\n<li>\n<p>b</p>\n</li>\n</ul>\n"},
+		{"quote and rule", "> quoted\nlazy\n\n---",
+			"<blockquote>\n<p>quoted\nlazy</p>\n</blockquote>\n<hr>\n"},
+		{"table", "| a | b |\n|:-:|---|\n| `x\\|y` | 2 |",
+			"<table>\n<thead>\n<tr><th align=\"center\">a</th><th>b</th></tr>\n</thead>\n<tbody>\n<tr><td align=\"center\"><code>x|y</code></td><td>2</td></tr>\n</tbody>\n</table>\n"},
+		{"hard breaks", "a  \nb\\\nc", "<p>a<br>\nb<br>\nc</p>\n"},
+		{"references", "@alice @bob #20260101T120000Z-abcdef12 #20260101T120000Z-00000000 a@alice.dev",
+			"<p><a class=\"mention\" href=\"/api/v1/agents/alice\">@alice</a> @bob <a class=\"thread-ref\" href=\"/api/v1/posts/20260101T120000Z-abcdef12/thread\">#20260101T120000Z-abcdef12</a> #20260101T120000Z-00000000 a@alice.dev</p>\n"},
+		{"no links inside links", "[see @alice https://x.org](https://y.org)",
+			"<p><a href=\"https://y.org\" rel=\"nofollow noopener noreferrer\">see @alice https://x.org</a></p>\n"},
+		{"protocol-relative links become text", "[a](//evil.example) [b](/\\evil.example) [c](\\\\evil.example) ![d](//evil.example/x.png)",
+			"<p>a b c d</p>\n"},
+		{"nested emphasis with links", "*a **b** c* *x [**y**](javascript:alert(1))* *[**z**](https://z.example)*",
+			"<p><em>a <strong>b</strong> c</em> <em>x <strong>y</strong></em> <em><a href=\"https://z.example\" rel=\"nofollow noopener noreferrer\"><strong>z</strong></a></em></p>\n"},
+		{"entities in urls are not decoded", "[x](javascript&#58;alert(1)) [y](&#106;avascript:alert(1)) [z](JaVaScRiPt:alert(1))",
+			"<p><a href=\"javascript&amp;#58;alert(1)\">x</a> <a href=\"&amp;#106;avascript:alert(1)\">y</a> z</p>\n"},
+		{"autolinks", "<javascript:alert(1)> <//evil.example> <https://x.example/?a=1&b=\"2\">",
+			"<p>javascript:alert(1) &lt;//evil.example&gt; <a href=\"https://x.example/?a=1&amp;b=&#34;2&#34;\" rel=\"nofollow noopener noreferrer\">https://x.example/?a=1&amp;b=&#34;2&#34;</a></p>\n"},
+		{"html blocks are escaped", "<div onclick=\"x()\">\n<img src=x onerror=alert(1)>\n</div>",
+			"<p>&lt;div onclick=&#34;x()&#34;&gt;\n&lt;img src=x onerror=alert(1)&gt;\n&lt;/div&gt;</p>\n"},
+	} {
+		if got := HTML(tc.src, fakeResolver{}); got != tc.want {
+			t.Errorf("%s:\n got %q\nwant %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	src := strings.Join([]string{
+		"# Intro",
+		"See [the spec](https://Example.com/spec) and https://example.com/spec again.",
+		"![chart](https://img.example.org/c.png) by @alice, re #20260101T120000Z-abcdef12.",
+		"## Intro",
+		"```sql",
+		"SELECT 1; -- @notamention https://not.a.link",
+		"```",
+		"`@nor #20260101T120000Z-abcdef12 here`",
+	}, "\n")
+	got := Extract(src)
+	want := Metadata{
+		Links: []Link{
+			{URL: "https://Example.com/spec", Text: "the spec", Host: "example.com"},
+			{URL: "https://example.com/spec", Text: "https://example.com/spec", Host: "example.com"},
+			{URL: "https://img.example.org/c.png", Text: "chart", Host: "img.example.org", Image: true},
+		},
+		CodeBlocks: []CodeBlock{{Language: "sql", Code: "SELECT 1; -- @notamention https://not.a.link\n"}},
+		Headings:   []Heading{{Level: 1, Text: "Intro", Anchor: "intro"}, {Level: 2, Text: "Intro", Anchor: "intro-1"}},
+		ThreadRefs: []string{"20260101T120000Z-abcdef12"},
+		Mentions:   []string{"alice"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Extract:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+// renderedTag matches the tags the renderer emits; every attribute value
+// is quoted and escaped, so it never contains '"', '<' or '>'.
+var renderedTag = regexp.MustCompile(`<(/?)([a-z0-9]+)((?: [a-z]+="[^"<>]*")*)>`)
+
+var renderedAttr = regexp.MustCompile(` ([a-z]+)="([^"]*)"`)
+
+var allowedTags = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"em": true, "strong": true, "del": true, "code": true, "pre": true, "a": true, "img": true,
+	"ul": true, "ol": true, "li": true, "blockquote": true, "hr": true, "br": true,
+	"table": true, "thead": true, "tbody": true, "tr": true, "th": true, "td": true,
+}
+
+var allowedAttrs = map[string]bool{
+	"href": true, "src": true, "alt": true, "title": true, "rel": true,
+	"class": true, "id": true, "align": true, "start": true,
+}
+
+func FuzzRender(f *testing.F) {
+	for _, seed := range []string{
+		"## Plan *now*\n\n**a** _b_ ***c*** ~~d~~",
+		"<script>alert(1)</script> <b onclick=x>hi</b>",
+		"[x](javascript:alert(1)) ![z](javascript:1) [y](JaVaScRiPt:1)",
+		"[a](//evil.example) [b](/\\evil.example) [c](\\\\evil.example)",
+		"*a [**b**](javascript:x)* [x](javascript&#58;alert(1))",
+		"<javascript:alert(1)> <https://x.example/?a=1&b=\"2\"> https://example.com/a_(b))",
+		"<div onclick=\"x()\">\n<img src=x onerror=alert(1)>\n</div>",
+		"| a | b |\n|:-:|---|\n| `x\\|y` | 2 |",
+		"- one\n  1. [x](\"><script>)\n\n> q\n```\n<b>\n```",
+		"@alice #20260101T120000Z-abcdef12",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		out := HTML(src, fakeResolver{})
+		for _, m := range renderedTag.FindAllStringSubmatch(out, -1) {
+			if !allowedTags[m[2]] || (m[1] != "" && m[3] != "") {
+				t.Fatalf("unexpected tag %q in %q", m[0], out)
+			}
+			for _, a := range renderedAttr.FindAllStringSubmatch(m[3], -1) {
+				if !allowedAttrs[a[1]] {
+					t.Fatalf("unexpected attribute %q in %q", a[0], out)
+				}
+				if a[1] != "href" && a[1] != "src" {
+					continue
+				}
+				// Browsers drop whitespace and control bytes before
+				// reading the scheme, so check what they would see.
+				u := strings.ToLower(strings.Map(func(r rune) rune {
+					if r <= ' ' {
+						return -1
+					}
+					return r
+				}, html.UnescapeString(a[2])))
+				for _, bad := range []string{"javascript:", "vbscript:", "data:", "//", "/\\", "\\"} {
+					if strings.HasPrefix(u, bad) {
+						t.Fatalf("unsafe %s %q in %q", a[1], a[2], out)
+					}
+				}
+			}
+		}
+		if rest := renderedTag.ReplaceAllString(out, ""); strings.ContainsAny(rest, "<>") {
+			t.Fatalf("unescaped markup in %q (from %q)", out, src)
+		}
+	})
+}
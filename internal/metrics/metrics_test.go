@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritesPrometheusText(t *testing.T) {
+	counter := NewCounterVec("test_events_total", "Events seen.", "kind")
+	counter.Inc("a\"b")
+	counter.Add(2, "plain")
+	hist := NewHistogramVec("test_latency_seconds", "Latency.", []float64{0.1, 1}, "op")
+	hist.Observe(0.5, "read")
+	SetGaugeFunc("test_gauge", "A gauge.", func() float64 { return 42 })
+
+	var buf bytes.Buffer
+	defaultRegistry.WriteText(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE test_events_total counter",
+		`test_events_total{kind="a\"b"} 1`,
+		`test_events_total{kind="plain"} 2`,
+		`test_latency_seconds_bucket{op="read",le="0.1"} 0`,
+		`test_latency_seconds_bucket{op="read",le="1"} 1`,
+		`test_latency_seconds_bucket{op="read",le="+Inf"} 1`,
+		`test_latency_seconds_sum{op="read"} 0.5`,
+		"test_gauge 42",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
@@ -6,12 +6,42 @@ type Agent struct {
 	Created    string  `json:"created"`
 	LastActive *string `json:"last_active,omitempty"`
 	Metadata   *string `json:"metadata,omitempty"`
+	// State is active, suspended or deactivated. A suspension whose
+	// StateUntil has passed reads as active.
+	State        string        `json:"state"`
+	StateReason  *string       `json:"state_reason,omitempty"`
+	StateUntil   *string       `json:"state_until,omitempty"`
+	StateChanged *string       `json:"state_changed,omitempty"`
+	Profile      *AgentProfile `json:"profile,omitempty"`
+	Groups       []string      `json:"groups,omitempty"`
+	Presence     *Presence     `json:"presence,omitempty"`
+}
+
+// AgentProfile is the structured, self-maintained part of an agent's
+// directory entry.
+type AgentProfile struct {
+	DisplayName  string   `json:"display_name,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Team         string   `json:"team,omitempty"`
+	Capabilities []string `json:"capabilities"`
+	Model        string   `json:"model,omitempty"`
+	Contact      string   `json:"contact,omitempty"`
+	Updated      string   `json:"updated,omitempty"`
+}
+
+// Presence is an agent's last heartbeat. Status reads "offline" once the
+// heartbeat's TTL has passed or if the agent never sent one.
+type Presence struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Updated string `json:"updated,omitempty"`
+	Expires string `json:"expires,omitempty"`
 }
 
 type AgentStats struct {
-	AuthoredPosts         int     `json:"authored_posts"`
-	AuthoredReplies       int     `json:"authored_replies"`
-	UnreadNotifications   int     `json:"unread_notifications"`
-	RecentActivityAt      *string `json:"recent_activity_at,omitempty"`
-	RecentNotificationAt  *string `json:"recent_notification_at,omitempty"`
+	AuthoredPosts        int     `json:"authored_posts"`
+	AuthoredReplies      int     `json:"authored_replies"`
+	UnreadNotifications  int     `json:"unread_notifications"`
+	RecentActivityAt     *string `json:"recent_activity_at,omitempty"`
+	RecentNotificationAt *string `json:"recent_notification_at,omitempty"`
 }
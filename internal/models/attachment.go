@@ -0,0 +1,13 @@
+package models
+
+type Attachment struct {
+	ID          string `json:"id"`
+	ContentID   string `json:"content_id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	Uploader    string `json:"uploader"`
+	Created     string `json:"created"`
+	URL         string `json:"url"`
+}
@@ -1,11 +1,13 @@
 package models
 
 type Content struct {
-	ID       string   `json:"id"`
-	Type     string   `json:"type"`
-	Author   string   `json:"author"`
-	Title    *string  `json:"title,omitempty"`
-	Body     string   `json:"body"`
+	ID     string  `json:"id"`
+	Type   string  `json:"type"`
+	Author string  `json:"author"`
+	Title  *string `json:"title,omitempty"`
+	Body   string  `json:"body"`
+	// BodyHTML is the sanitized rendering of Body, set on ?render=html.
+	BodyHTML string   `json:"body_html,omitempty"`
 	Created  string   `json:"created"`
 	Updated  string   `json:"updated"`
 	ThreadID string   `json:"thread_id"`
@@ -13,6 +15,15 @@ type Content struct {
 	Status   string   `json:"status"`
 	BoardID  string   `json:"board_id"`
 	Tags     []string `json:"tags,omitempty"`
+	// Attachments is only filled in when content is loaded as part of a
+	// thread.
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// Poll is filled in alongside Attachments, and on the post returned
+	// when a poll is created with it.
+	Poll *Poll `json:"poll,omitempty"`
+	// UnresolvedMentions lists the @names in a newly created post or reply
+	// that matched no agent or group; nobody was notified for them.
+	UnresolvedMentions []string `json:"unresolved_mentions,omitempty"`
 }
 
 type ThreadListItem struct {
@@ -21,4 +32,5 @@ type ThreadListItem struct {
 	LastActivity     string   `json:"last_activity"`
 	Participants     []string `json:"participants"`
 	ParticipantCount int      `json:"participant_count"`
+	UnreadCount      int      `json:"unread_count"`
 }
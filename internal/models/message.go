@@ -0,0 +1,23 @@
+package models
+
+// Conversation is a private exchange between two or more agents, kept apart
+// from board content.
+type Conversation struct {
+	ID           string          `json:"id"`
+	Members      []string        `json:"members"`
+	CreatedBy    string          `json:"created_by"`
+	Created      string          `json:"created"`
+	Updated      string          `json:"updated"`
+	MessageCount int             `json:"message_count"`
+	UnreadCount  int             `json:"unread_count"`
+	LastMessage  *DirectMessage  `json:"last_message,omitempty"`
+	Messages     []DirectMessage `json:"messages,omitempty"`
+}
+
+type DirectMessage struct {
+	ID             string `json:"id"`
+	ConversationID string `json:"conversation_id"`
+	Author         string `json:"author"`
+	Body           string `json:"body"`
+	Created        string `json:"created"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// Poll is attached to a post. Voters are listed per option only when
+// Visibility is "public".
+type Poll struct {
+	PostID      string       `json:"post_id"`
+	Multiple    bool         `json:"multiple"`
+	Visibility  string       `json:"visibility"`
+	Deadline    *string      `json:"deadline,omitempty"`
+	Status      string       `json:"status"`
+	Closed      *string      `json:"closed,omitempty"`
+	Created     string       `json:"created"`
+	Updated     string       `json:"updated"`
+	Options     []PollOption `json:"options"`
+	TotalVoters int          `json:"total_voters"`
+	// MyVotes lists the option IDs the requesting agent chose.
+	MyVotes []int `json:"my_votes,omitempty"`
+}
+
+type PollOption struct {
+	ID     int      `json:"id"`
+	Text   string   `json:"text"`
+	Votes  int      `json:"votes"`
+	Voters []string `json:"voters,omitempty"`
+}
@@ -1,17 +1,20 @@
 package models
 
 type ThreadNode struct {
-	ID       string       `json:"id"`
-	Type     string       `json:"type"`
-	Author   string       `json:"author"`
-	Title    *string      `json:"title,omitempty"`
-	Body     string       `json:"body"`
-	Created  string       `json:"created"`
-	Updated  string       `json:"updated"`
-	ThreadID string       `json:"thread_id"`
-	ParentID *string      `json:"parent_id,omitempty"`
-	Status   string       `json:"status"`
-	BoardID  string       `json:"board_id"`
-	Tags     []string     `json:"tags,omitempty"`
-	Replies  []ThreadNode `json:"replies"`
+	ID          string       `json:"id"`
+	Type        string       `json:"type"`
+	Author      string       `json:"author"`
+	Title       *string      `json:"title,omitempty"`
+	Body        string       `json:"body"`
+	BodyHTML    string       `json:"body_html,omitempty"`
+	Created     string       `json:"created"`
+	Updated     string       `json:"updated"`
+	ThreadID    string       `json:"thread_id"`
+	ParentID    *string      `json:"parent_id,omitempty"`
+	Status      string       `json:"status"`
+	BoardID     string       `json:"board_id"`
+	Tags        []string     `json:"tags,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Poll        *Poll        `json:"poll,omitempty"`
+	Replies     []ThreadNode `json:"replies"`
 }
@@ -57,3 +57,16 @@ func (l *Limiter) Allow(key string, limit int, window time.Duration, now time.Ti
 	result.ResetAt = history[0].Add(window)
 	return result
 }
+
+// Refund gives back a request recorded by Allow at the given time.
+func (l *Limiter) Refund(key string, at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	history := l.buckets[key]
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Equal(at) {
+			l.buckets[key] = append(history[:i], history[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,326 @@
+// Package serverconfig loads fora-server settings from defaults, an optional
+// YAML file and FORA_* environment variables, in that order of precedence.
+package serverconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Listen      string      `yaml:"listen"`
+	AdminKeyOut string      `yaml:"admin_key_out"`
+	TLS         TLS         `yaml:"tls"`
+	HTTP        HTTP        `yaml:"http"`
+	DB          DB          `yaml:"db"`
+	RateLimits  RateLimits  `yaml:"rate_limits"`
+	Features    Features    `yaml:"features"`
+	Attachments Attachments `yaml:"attachments"`
+	Tracing     Tracing     `yaml:"tracing"`
+}
+
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+type HTTP struct {
+	ReadTimeout  Duration `yaml:"read_timeout"`
+	WriteTimeout Duration `yaml:"write_timeout"`
+	IdleTimeout  Duration `yaml:"idle_timeout"`
+	CORSOrigins  []string `yaml:"cors_origins"`
+}
+
+type DB struct {
+	Path            string   `yaml:"path"`
+	MaxOpenConns    int      `yaml:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns"`
+	ConnMaxIdleTime Duration `yaml:"conn_max_idle_time"`
+	JournalMode     string   `yaml:"journal_mode"`
+	Synchronous     string   `yaml:"synchronous"`
+	BusyTimeoutMS   int      `yaml:"busy_timeout_ms"`
+	CacheSizeKB     int      `yaml:"cache_size_kb"`
+}
+
+type RateLimits struct {
+	PostsPerHour    int `yaml:"posts_per_hour"`
+	RepliesPerHour  int `yaml:"replies_per_hour"`
+	WritesPerDay    int `yaml:"writes_per_day"`
+	ReadsPerMinute  int `yaml:"reads_per_minute"`
+	SearchPerMinute int `yaml:"search_per_minute"`
+}
+
+type Features struct {
+	MCP       bool `yaml:"mcp"`
+	Metrics   bool `yaml:"metrics"`
+	AccessLog bool `yaml:"access_log"`
+	// SeedBoards creates the default boards the first time a database is
+	// opened.
+	SeedBoards bool `yaml:"seed_boards"`
+}
+
+type Attachments struct {
+	Dir      string `yaml:"dir"`
+	MaxBytes int    `yaml:"max_bytes"`
+}
+
+type Tracing struct {
+	Exporter string `yaml:"exporter"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+// Duration reads and prints as a Go duration string such as "30s".
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d Duration) MarshalYAML() (any, error) { return d.String(), nil }
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := time.ParseDuration(strings.TrimSpace(node.Value))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", node.Value, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func Default() Config {
+	return Config{
+		Listen: ":8080",
+		HTTP: HTTP{
+			ReadTimeout:  Duration(10 * time.Second),
+			WriteTimeout: 0,
+			IdleTimeout:  Duration(60 * time.Second),
+			CORSOrigins:  []string{"*"},
+		},
+		DB: DB{
+			Path:            "./fora.db",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxIdleTime: Duration(30 * time.Minute),
+			JournalMode:     "WAL",
+			Synchronous:     "NORMAL",
+			BusyTimeoutMS:   5000,
+			CacheSizeKB:     64000,
+		},
+		RateLimits: RateLimits{
+			PostsPerHour:    20,
+			RepliesPerHour:  60,
+			WritesPerDay:    500,
+			ReadsPerMinute:  600,
+			SearchPerMinute: 60,
+		},
+		Features: Features{
+			MCP:        true,
+			Metrics:    true,
+			SeedBoards: true,
+		},
+		Attachments: Attachments{
+			MaxBytes: 10 << 20,
+		},
+		Tracing: Tracing{
+			Endpoint: "http://localhost:4318",
+		},
+	}
+}
+
+// Load returns defaults overlaid with the YAML file at path (if non-empty)
+// and then with environment variables from lookup.
+func Load(path string, lookup func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+	if strings.TrimSpace(path) != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("read config: %w", err)
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return cfg, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem(), "FORA", lookup); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+func (c Config) Validate() error {
+	if strings.TrimSpace(c.Listen) == "" {
+		return errors.New("listen address is required")
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return errors.New("tls.cert_file and tls.key_file must be set together")
+	}
+	if !oneOf(c.DB.JournalMode, "WAL", "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "OFF") {
+		return fmt.Errorf("db.journal_mode %q is not supported", c.DB.JournalMode)
+	}
+	if !oneOf(c.DB.Synchronous, "OFF", "NORMAL", "FULL", "EXTRA") {
+		return fmt.Errorf("db.synchronous %q is not supported", c.DB.Synchronous)
+	}
+	if c.DB.MaxOpenConns < 1 {
+		return errors.New("db.max_open_conns must be at least 1")
+	}
+	// Both are spliced into pragmas; a negative cache size would render as
+	// "--5", which SQLite reads as a comment.
+	if c.DB.BusyTimeoutMS < 0 {
+		return errors.New("db.busy_timeout_ms must not be negative")
+	}
+	if c.DB.CacheSizeKB < 0 {
+		return errors.New("db.cache_size_kb must not be negative")
+	}
+	for name, v := range map[string]int{
+		"posts_per_hour":    c.RateLimits.PostsPerHour,
+		"replies_per_hour":  c.RateLimits.RepliesPerHour,
+		"writes_per_day":    c.RateLimits.WritesPerDay,
+		"reads_per_minute":  c.RateLimits.ReadsPerMinute,
+		"search_per_minute": c.RateLimits.SearchPerMinute,
+	} {
+		if v < 1 {
+			return fmt.Errorf("rate_limits.%s must be at least 1", name)
+		}
+	}
+	if c.Attachments.MaxBytes < 1 {
+		return errors.New("attachments.max_bytes must be at least 1")
+	}
+	return nil
+}
+
+func oneOf(v string, allowed ...string) bool {
+	for _, a := range allowed {
+		if strings.EqualFold(v, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnixSocket reports the socket path when Listen uses the unix: scheme.
+func (c Config) UnixSocket() (string, bool) {
+	if path, ok := strings.CutPrefix(c.Listen, "unix:"); ok {
+		return path, true
+	}
+	return "", false
+}
+
+// Pragmas renders the DB settings as statements run on every connection.
+// foreign_keys is always on; the schema depends on it.
+func (c Config) Pragmas() []string {
+	return []string{
+		"PRAGMA journal_mode = " + c.DB.JournalMode,
+		"PRAGMA synchronous = " + c.DB.Synchronous,
+		"PRAGMA foreign_keys = ON",
+		"PRAGMA busy_timeout = " + strconv.Itoa(c.DB.BusyTimeoutMS),
+		"PRAGMA cache_size = -" + strconv.Itoa(c.DB.CacheSizeKB),
+	}
+}
+
+// YAML renders the config in the same shape the file accepts.
+func (c Config) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EnvVars lists every supported environment variable, e.g. FORA_DB_PATH.
+func EnvVars() []string {
+	var out []string
+	collectEnvNames(reflect.TypeOf(Config{}), "FORA", &out)
+	return out
+}
+
+func collectEnvNames(t reflect.Type, prefix string, out *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := prefix + "_" + strings.ToUpper(f.Tag.Get("yaml"))
+		if f.Type.Kind() == reflect.Struct {
+			collectEnvNames(f.Type, name, out)
+			continue
+		}
+		*out = append(*out, name)
+	}
+}
+
+// applyEnv walks the struct and overrides each leaf from FORA_<PATH> where
+// PATH is the upper-cased yaml keys joined with underscores.
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		field := v.Field(i)
+		name := prefix + "_" + strings.ToUpper(f.Tag.Get("yaml"))
+		if f.Type.Kind() == reflect.Struct {
+			if err := applyEnv(field, name, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+		raw, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setField(field, raw); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	if field.Type() == reflect.TypeOf(Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		parts := []string{}
+		for _, p := range strings.Split(raw, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				parts = append(parts, p)
+			}
+		}
+		field.Set(reflect.ValueOf(parts))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Kind())
+	}
+	return nil
+}
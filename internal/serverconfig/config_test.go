@@ -0,0 +1,114 @@
+package serverconfig
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fora.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func envMap(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func TestLoadLayersFileThenEnv(t *testing.T) {
+	path := writeConfig(t, `
+listen: 127.0.0.1:9000
+http:
+  read_timeout: 3s
+  cors_origins: [https://a.example.com]
+db:
+  max_open_conns: 4
+  journal_mode: DELETE
+rate_limits:
+  posts_per_hour: 7
+`)
+	cfg, err := Load(path, envMap(map[string]string{
+		"FORA_DB_MAX_OPEN_CONNS": "2",
+		"FORA_HTTP_CORS_ORIGINS": "https://b.example.com, https://c.example.com",
+		"FORA_FEATURES_MCP":      "false",
+		"FORA_HTTP_IDLE_TIMEOUT": "90s",
+	}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Listen != "127.0.0.1:9000" || cfg.RateLimits.PostsPerHour != 7 || cfg.DB.JournalMode != "DELETE" {
+		t.Fatalf("file values not applied: %+v", cfg)
+	}
+	if time.Duration(cfg.HTTP.ReadTimeout) != 3*time.Second || time.Duration(cfg.HTTP.IdleTimeout) != 90*time.Second {
+		t.Fatalf("unexpected timeouts: read=%s idle=%s", cfg.HTTP.ReadTimeout, cfg.HTTP.IdleTimeout)
+	}
+	if cfg.DB.MaxOpenConns != 2 || cfg.Features.MCP {
+		t.Fatalf("env overrides not applied: %+v", cfg)
+	}
+	if len(cfg.HTTP.CORSOrigins) != 2 || cfg.HTTP.CORSOrigins[1] != "https://c.example.com" {
+		t.Fatalf("unexpected cors origins: %v", cfg.HTTP.CORSOrigins)
+	}
+	// Untouched settings keep their defaults.
+	if cfg.RateLimits.RepliesPerHour != Default().RateLimits.RepliesPerHour || !cfg.Features.Metrics {
+		t.Fatalf("defaults lost: %+v", cfg)
+	}
+}
+
+func TestLoadRejectsInvalidConfig(t *testing.T) {
+	for name, tc := range map[string]struct {
+		file string
+		env  map[string]string
+		want string
+	}{
+		"unknown key":     {file: "listn: :80\n", want: "listn"},
+		"bad duration":    {file: "http:\n  read_timeout: soon\n", want: "invalid duration"},
+		"bad pragma":      {env: map[string]string{"FORA_DB_JOURNAL_MODE": "WAL; DROP TABLE agents"}, want: "journal_mode"},
+		"half tls":        {env: map[string]string{"FORA_TLS_CERT_FILE": "cert.pem"}, want: "tls"},
+		"zero rate limit": {env: map[string]string{"FORA_RATE_LIMITS_READS_PER_MINUTE": "0"}, want: "reads_per_minute"},
+		"bad env int":     {env: map[string]string{"FORA_DB_MAX_IDLE_CONNS": "many"}, want: "FORA_DB_MAX_IDLE_CONNS"},
+		"negative cache":  {file: "db:\n  cache_size_kb: -5\n", want: "cache_size_kb"},
+		"negative busy":   {env: map[string]string{"FORA_DB_BUSY_TIMEOUT_MS": "-1"}, want: "busy_timeout_ms"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := ""
+			if tc.file != "" {
+				path = writeConfig(t, tc.file)
+			}
+			_, err := Load(path, envMap(tc.env))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestUnixSocketAndPragmas(t *testing.T) {
+	cfg := Default()
+	if _, ok := cfg.UnixSocket(); ok {
+		t.Fatal("default listen should be tcp")
+	}
+	cfg.Listen = "unix:/run/fora.sock"
+	if path, ok := cfg.UnixSocket(); !ok || path != "/run/fora.sock" {
+		t.Fatalf("unexpected socket %q %v", path, ok)
+	}
+	pragmas := strings.Join(cfg.Pragmas(), "\n")
+	for _, want := range []string{"journal_mode = WAL", "foreign_keys = ON", "busy_timeout = 5000", "cache_size = -64000"} {
+		if !strings.Contains(pragmas, want) {
+			t.Fatalf("pragmas missing %q:\n%s", want, pragmas)
+		}
+	}
+	vars := EnvVars()
+	if !slices.Contains(vars, "FORA_DB_MAX_OPEN_CONNS") || !slices.Contains(vars, "FORA_TLS_KEY_FILE") {
+		t.Fatalf("unexpected env var list: %v", vars)
+	}
+}
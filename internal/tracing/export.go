@@ -0,0 +1,310 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config selects an exporter. Exporter is "", "none", "stdout" or "otlp".
+type Config struct {
+	Exporter    string
+	Endpoint    string // OTLP/HTTP base URL, e.g. http://localhost:4318
+	ServiceName string
+	Writer      io.Writer // destination for the stdout exporter
+}
+
+type exporter interface {
+	export(ctx context.Context, spans []*Span) error
+}
+
+type tracer struct {
+	service  string
+	exporter exporter
+	mu       sync.RWMutex
+	closed   bool
+	queue    chan *Span
+	done     chan struct{}
+	flushReq chan chan struct{}
+}
+
+const (
+	queueSize     = 2048
+	batchSize     = 256
+	flushInterval = 2 * time.Second
+)
+
+// Setup installs the global tracer and returns a shutdown function that
+// flushes pending spans. With no exporter configured it is a no-op.
+func Setup(cfg Config) (func(context.Context) error, error) {
+	var exp exporter
+	switch strings.ToLower(strings.TrimSpace(cfg.Exporter)) {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		if cfg.Writer == nil {
+			return nil, errors.New("stdout exporter needs a writer")
+		}
+		exp = &writerExporter{w: cfg.Writer}
+	case "otlp":
+		endpoint := strings.TrimRight(strings.TrimSpace(cfg.Endpoint), "/")
+		if endpoint == "" {
+			endpoint = "http://localhost:4318"
+		}
+		if !strings.HasSuffix(endpoint, "/v1/traces") {
+			endpoint += "/v1/traces"
+		}
+		exp = &otlpExporter{endpoint: endpoint, client: &http.Client{Timeout: 10 * time.Second}}
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q (use stdout or otlp)", cfg.Exporter)
+	}
+
+	service := cfg.ServiceName
+	if service == "" {
+		service = "fora-server"
+	}
+	t := &tracer{
+		service:  service,
+		exporter: exp,
+		queue:    make(chan *Span, queueSize),
+		done:     make(chan struct{}),
+		flushReq: make(chan chan struct{}),
+	}
+	go t.run()
+
+	globalMu.Lock()
+	global = t
+	globalMu.Unlock()
+
+	return func(ctx context.Context) error {
+		globalMu.Lock()
+		if global == t {
+			global = nil
+		}
+		globalMu.Unlock()
+		t.mu.Lock()
+		if !t.closed {
+			t.closed = true
+			close(t.queue)
+		}
+		t.mu.Unlock()
+		select {
+		case <-t.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, nil
+}
+
+// Flush blocks until spans ended so far have been handed to the exporter.
+func Flush() {
+	t := active()
+	if t == nil {
+		return
+	}
+	ack := make(chan struct{})
+	select {
+	case t.flushReq <- ack:
+		<-ack
+	case <-t.done:
+	}
+}
+
+func (t *tracer) export(s *Span) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return
+	}
+	// Drop rather than block request handling when the exporter falls behind.
+	select {
+	case t.queue <- s:
+	default:
+	}
+}
+
+func (t *tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_ = t.exporter.export(ctx, batch)
+		cancel()
+		batch = make([]*Span, 0, batchSize)
+	}
+	for {
+		select {
+		case s, ok := <-t.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case ack := <-t.flushReq:
+			for drained := false; !drained; {
+				select {
+				case s, ok := <-t.queue:
+					if !ok {
+						drained = true
+						break
+					}
+					batch = append(batch, s)
+				default:
+					drained = true
+				}
+			}
+			flush()
+			close(ack)
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// otlpSpan is the OTLP/JSON span encoding.
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func encodeSpan(s *Span) otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := otlpSpan{
+		TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+		Name:              s.name,
+		Kind:              int(s.kind),
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		Status:            otlpStatus{Code: 1},
+	}
+	if s.parentID != [8]byte{} {
+		out.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	keys := make([]string, 0, len(s.attrs))
+	for k := range s.attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		out.Attributes = append(out.Attributes, otlpKeyValue{Key: k, Value: otlpValue(s.attrs[k])})
+	}
+	if s.errMsg != "" {
+		out.Status = otlpStatus{Code: 2, Message: s.errMsg}
+	}
+	return out
+}
+
+func otlpValue(v any) map[string]any {
+	switch x := v.(type) {
+	case bool:
+		return map[string]any{"boolValue": x}
+	case int:
+		return map[string]any{"intValue": strconv.Itoa(x)}
+	case int64:
+		return map[string]any{"intValue": strconv.FormatInt(x, 10)}
+	case float64:
+		return map[string]any{"doubleValue": x}
+	case string:
+		return map[string]any{"stringValue": x}
+	default:
+		return map[string]any{"stringValue": fmt.Sprint(x)}
+	}
+}
+
+type otlpExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (e *otlpExporter) export(ctx context.Context, spans []*Span) error {
+	service := spans[0].tracer.service
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		encoded = append(encoded, encodeSpan(s))
+	}
+	body, err := json.Marshal(map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []otlpKeyValue{{Key: "service.name", Value: otlpValue(service)}},
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "fora"},
+				"spans": encoded,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp export: http %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// writerExporter prints one OTLP-shaped JSON span per line.
+type writerExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (e *writerExporter) export(_ context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	enc := json.NewEncoder(e.w)
+	for _, s := range spans {
+		if err := enc.Encode(encodeSpan(s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+// Package tracing is a small OpenTelemetry-compatible tracer. Spans carry W3C
+// trace context and are exported as OTLP/HTTP JSON or as JSON lines on a
+// writer. When no exporter is configured every call is a cheap no-op, but
+// incoming traceparent headers are still carried through for propagation.
+// Sampling follows the incoming parent; new traces are always sampled.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SpanKind int
+
+const (
+	KindInternal SpanKind = iota + 1
+	KindServer
+	KindClient
+)
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent renders the W3C traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// ParseTraceparent accepts version 00 traceparent values.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	var sc SpanContext
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+// Span is one timed operation. A nil *Span is valid and does nothing.
+type Span struct {
+	tracer   *tracer
+	name     string
+	kind     SpanKind
+	sc       SpanContext
+	parentID [8]byte
+	start    time.Time
+	end      time.Time
+	mu       sync.Mutex
+	attrs    map[string]any
+	errMsg   string
+	ended    bool
+}
+
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.name = name
+	s.mu.Unlock()
+}
+
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attrs[key] = value
+	s.mu.Unlock()
+}
+
+// RecordError marks the span as failed. A nil error is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.errMsg = err.Error()
+	s.mu.Unlock()
+}
+
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	// Unsampled spans still propagate their context but are never exported.
+	if s.sc.Sampled {
+		s.tracer.export(s)
+	}
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+type ctxKey int
+
+const (
+	spanKey ctxKey = iota
+	remoteKey
+)
+
+// SpanFromContext returns the active local span, if any.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey).(*Span)
+	return s
+}
+
+// SpanContextFromContext returns the active span context, local or remote.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.sc
+	}
+	sc, _ := ctx.Value(remoteKey).(SpanContext)
+	return sc
+}
+
+// Extract stores a valid incoming traceparent on ctx as the remote parent.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := ParseTraceparent(header.Get("traceparent"))
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey, sc)
+}
+
+// Inject writes the active span context, if any, as a traceparent header.
+func Inject(ctx context.Context, header http.Header) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		header.Set("traceparent", sc.Traceparent())
+	}
+}
+
+// Detach returns a background context that keeps ctx's trace position, for
+// work that outlives the request.
+func Detach(ctx context.Context) context.Context {
+	out := context.Background()
+	if s := SpanFromContext(ctx); s != nil {
+		out = context.WithValue(out, spanKey, s)
+	}
+	if sc, ok := ctx.Value(remoteKey).(SpanContext); ok {
+		out = context.WithValue(out, remoteKey, sc)
+	}
+	return out
+}
+
+// Start begins a span as a child of the active span in ctx. It returns ctx
+// unchanged and a nil span when tracing is disabled.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	t := active()
+	if t == nil {
+		return ctx, nil
+	}
+	parent := SpanContextFromContext(ctx)
+	s := &Span{
+		tracer: t,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		attrs:  map[string]any{},
+	}
+	// Follow the parent's sampling decision: a caller that chose not to
+	// sample keeps the whole trace unsampled here too. New traces are always
+	// sampled.
+	if parent.IsValid() {
+		s.sc.TraceID = parent.TraceID
+		s.parentID = parent.SpanID
+		s.sc.Sampled = parent.Sampled
+	} else {
+		_, _ = rand.Read(s.sc.TraceID[:])
+		s.sc.Sampled = true
+	}
+	_, _ = rand.Read(s.sc.SpanID[:])
+	return context.WithValue(ctx, spanKey, s), s
+}
+
+var (
+	globalMu sync.RWMutex
+	global   *tracer
+)
+
+func active() *tracer {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return global
+}
+
+// Enabled reports whether spans are being recorded.
+func Enabled() bool {
+	return active() != nil
+}
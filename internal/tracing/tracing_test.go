@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	const in = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceparent(in)
+	if !ok || !sc.Sampled {
+		t.Fatalf("parse %q failed: %+v", in, sc)
+	}
+	if got := sc.Traceparent(); got != in {
+		t.Fatalf("round trip = %q, want %q", got, in)
+	}
+	for _, bad := range []string{"", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "00-xyz-00f067aa0ba902b7-01"} {
+		if _, ok := ParseTraceparent(bad); ok {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestDisabledTracingPropagatesIncomingParent(t *testing.T) {
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, span := Start(Extract(context.Background(), header), "noop", KindServer)
+	if span != nil {
+		t.Fatal("expected nil span with tracing disabled")
+	}
+	span.SetAttr("ignored", true)
+	span.End()
+
+	out := http.Header{}
+	Inject(Detach(ctx), out)
+	if got := out.Get("traceparent"); got != header.Get("traceparent") {
+		t.Fatalf("expected passthrough traceparent, got %q", got)
+	}
+}
+
+func TestStdoutExporterWritesChildSpans(t *testing.T) {
+	var buf bytes.Buffer
+	shutdown, err := Setup(Config{Exporter: "stdout", Writer: &buf})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, parent := Start(Extract(context.Background(), header), "GET /x", KindServer)
+	_, child := Start(ctx, "db.select", KindClient)
+	child.SetAttr("db.statement", "SELECT 1")
+	child.End()
+	parent.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 spans, got %d: %s", len(lines), buf.String())
+	}
+	var spans []otlpSpan
+	for _, line := range lines {
+		var s otlpSpan
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			t.Fatalf("decode span: %v", err)
+		}
+		spans = append(spans, s)
+	}
+	if spans[0].Name != "db.select" || spans[0].ParentSpanID != spans[1].SpanID {
+		t.Fatalf("child span not linked to parent: %+v", spans)
+	}
+	if spans[1].TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spans[1].ParentSpanID != "00f067aa0ba902b7" {
+		t.Fatalf("server span did not continue remote trace: %+v", spans[1])
+	}
+}
+
+func TestUnsampledParentIsHonoured(t *testing.T) {
+	var buf bytes.Buffer
+	shutdown, err := Setup(Config{Exporter: "stdout", Writer: &buf})
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	ctx, parent := Start(Extract(context.Background(), header), "GET /x", KindServer)
+	childCtx, child := Start(ctx, "db.select", KindClient)
+	if parent.SpanContext().Sampled || child.SpanContext().Sampled {
+		t.Fatal("spans under an unsampled parent must stay unsampled")
+	}
+	out := http.Header{}
+	Inject(childCtx, out)
+	if got := out.Get("traceparent"); !strings.HasPrefix(got, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || !strings.HasSuffix(got, "-00") {
+		t.Fatalf("expected the unsampled trace to propagate, got %q", got)
+	}
+	child.End()
+	parent.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("unsampled spans were exported: %s", buf.String())
+	}
+}